@@ -1,5 +1,7 @@
 package v1
 
+import "slices"
+
 const (
 	PodModelLabel = "model"
 	// PodHashLabel is a label key used to store the hash of the Pod spec
@@ -15,9 +17,272 @@ const (
 	ModelPodIPAnnotation   = "model-pod-ip"
 	ModelPodPortAnnotation = "model-pod-port"
 
+	// PodPortAnnotationName is the name of the annotation that specifies the port
+	// that a model Pod that was not created by KubeAI (i.e. a Pod of a scale target)
+	// serves the model on.
+	PodPortAnnotationName = "port"
+	// PodModelPortsAnnotationName is the name of the annotation that specifies ports by
+	// model name, i.e. "model-a=8000,model-b=8001". Allows Pods that are created from
+	// a shared template to serve different models on different ports.
+	// Takes precedence over PodPortAnnotationName.
+	PodModelPortsAnnotationName = "model-ports"
+	// PodModelsAnnotationName is the name of the annotation that lists additional
+	// models that a Pod serves, i.e. "model-a,model-b". Allows multiple model servers
+	// to be packed into a single Pod (combined with PodModelPortsAnnotationName).
+	// The Pod still needs the PodModelLabel (for any of the models).
+	PodModelsAnnotationName = "models"
+	// PodEndpointWeightAnnotationName is the name of the annotation that specifies the
+	// relative capacity of a model Pod, i.e. "2" for a Pod that serves twice as many
+	// concurrent requests as a Pod without the annotation. The LeastLoad strategy
+	// routes requests to the Pod with the fewest in-flight requests per weight.
+	// Must be a positive integer, defaults to 1.
+	PodEndpointWeightAnnotationName = "endpoint-weight"
+
 	ModelCacheEvictionFinalizer = "kubeai.org/cache-eviction"
+
+	// AnnotationDomain is the domain of the annotations that are read from Models.
+	AnnotationDomain = "kubeai.org"
+	// LegacyAnnotationDomain is the domain that was used for annotations before
+	// the project was renamed. It is still accepted when reading annotations.
+	LegacyAnnotationDomain = "lingo.substratus.ai"
+
+	// ModelScaleTargetAnnotationName is the name of the annotation used to delegate scaling
+	// of a Model to other objects that implement the scale subresource.
+	// The value is of the form "<apiVersion>/<kind>/<name>" (i.e. "apps/v1/Deployment/my-deployment")
+	// and refers to an object in the same namespace as the Model. Multiple comma-separated
+	// references with optional weights (i.e. "apps/v1/Deployment/a=2,apps/v1/Deployment/b=1")
+	// cause replicas to be distributed across the objects in proportion to their weights.
+	ModelScaleTargetAnnotationName = "scale-target"
+	ModelScaleTargetAnnotation     = AnnotationDomain + "/" + ModelScaleTargetAnnotationName
+
+	// ModelScaleTargetSelectorAnnotationName is the name of the annotation that selects the
+	// object of the ModelScaleTargetAnnotation by a label selector (i.e. "app=my-model-server")
+	// instead of by name. The ModelScaleTargetAnnotation is then of the form "<apiVersion>/<kind>"
+	// and the newest matching object is scaled, which keeps scaling working for objects with
+	// generated names (i.e. a Deployment per revision).
+	ModelScaleTargetSelectorAnnotationName = "scale-target-selector"
+	ModelScaleTargetSelectorAnnotation     = AnnotationDomain + "/" + ModelScaleTargetSelectorAnnotationName
+
+	// ModelScaleTargetStrategyAnnotationName is the name of the annotation that specifies how
+	// the objects of the ModelScaleTargetAnnotation are scaled: ScaleTargetStrategySubresource
+	// (default) or ScaleTargetStrategyReplicas for objects that do not implement the scale
+	// subresource.
+	ModelScaleTargetStrategyAnnotationName = "scale-target-strategy"
+	ModelScaleTargetStrategyAnnotation     = AnnotationDomain + "/" + ModelScaleTargetStrategyAnnotationName
+
+	// ScaleTargetStrategySubresource scales objects via their scale subresource.
+	ScaleTargetStrategySubresource = "scale"
+	// ScaleTargetStrategyReplicas scales objects by patching their .spec.replicas field.
+	ScaleTargetStrategyReplicas = "replicas"
+
+	// ModelScaleUpdateStrategyAnnotationName is the name of the annotation that specifies
+	// how the scale subresource of the Model itself is written: ScaleUpdateStrategyUpdate
+	// or ScaleUpdateStrategyPatch (which does not conflict with concurrent writers).
+	// Defaults to the system-wide setting (modelAutoscaling.useScalePatch).
+	ModelScaleUpdateStrategyAnnotationName = "scale-update-strategy"
+	ModelScaleUpdateStrategyAnnotation     = AnnotationDomain + "/" + ModelScaleUpdateStrategyAnnotationName
+
+	// ScaleUpdateStrategyUpdate replaces the scale subresource.
+	ScaleUpdateStrategyUpdate = "update"
+	// ScaleUpdateStrategyPatch merge patches the replicas of the scale subresource.
+	ScaleUpdateStrategyPatch = "patch"
+
+	// ModelScalingBackendAnnotationName is the name of the annotation that specifies what
+	// applies the scaling decisions of KubeAI to a Model: ScalingBackendKubeAI (default) or
+	// ScalingBackendKEDA.
+	ModelScalingBackendAnnotationName = "scaling-backend"
+	ModelScalingBackendAnnotation     = AnnotationDomain + "/" + ModelScalingBackendAnnotationName
+
+	// ScalingBackendKubeAI writes the replicas to the scale target of the Model.
+	ScalingBackendKubeAI = "kubeai"
+	// ScalingBackendKEDA only records the replicas that KubeAI decided on. They are
+	// served as the "desiredReplicas" metric of the KEDA external scaler, and a KEDA
+	// ScaledObject scales the Model.
+	ScalingBackendKEDA = "keda"
+
+	// ModelDesiredReplicasAnnotationName is the name of the annotation that KubeAI records
+	// the replicas that it decided on in for Models that are scaled by ScalingBackendKEDA.
+	// Every KubeAI instance serves the value as the "desiredReplicas" metric of the KEDA
+	// external scaler. Written by KubeAI.
+	ModelDesiredReplicasAnnotationName = "desired-replicas"
+	ModelDesiredReplicasAnnotation     = AnnotationDomain + "/" + ModelDesiredReplicasAnnotationName
+
+	// ModelMinReplicasFromHPAAnnotationName is the name of the annotation that can be set
+	// to "true" to read the min replicas of a Model from the HorizontalPodAutoscaler of its
+	// scale target when the Model's minReplicas is 0 (see ReplicaBoundsFromHPA in the system
+	// config). Without it, a minReplicas of 0 scales the Model to zero, since the min replicas
+	// of an HPA default to 1.
+	ModelMinReplicasFromHPAAnnotationName = "min-replicas-from-hpa"
+	ModelMinReplicasFromHPAAnnotation     = AnnotationDomain + "/" + ModelMinReplicasFromHPAAnnotationName
+
+	// ModelScaledToZeroReasonAnnotationName is the name of the annotation that KubeAI records
+	// why it scaled a Model to zero replicas in (i.e. "Idle"), so that all KubeAI instances
+	// report the same reason. Set to "" once KubeAI scales the Model up or observes it with
+	// replicas, so that Models that are scaled to zero without KubeAI can be told apart.
+	ModelScaledToZeroReasonAnnotationName = "scaled-to-zero-reason"
+	ModelScaledToZeroReasonAnnotation     = AnnotationDomain + "/" + ModelScaledToZeroReasonAnnotationName
+
+	// ModelPodHashAnnotation is the annotation that KubeAI records the PodHashLabel of
+	// the Pods that it currently creates for a Model in, so that the Pods of the latest
+	// generation can be told apart during a rollout. Written by KubeAI.
+	ModelPodHashAnnotation = AnnotationDomain + "/" + PodHashLabel
+
+	// ModelManagedAnnotationName is the name of the annotation that can be set to "false"
+	// to stop KubeAI from managing the Pods and replicas of a Model (i.e. during debugging).
+	// Requests are still routed to the existing Pods of the Model.
+	ModelManagedAnnotationName = "managed"
+	ModelManagedAnnotation     = AnnotationDomain + "/" + ModelManagedAnnotationName
+
+	// ModelProtocolAnnotationName is the name of the annotation that specifies the protocol
+	// that the Pods of a Model are served over (ModelProtocolHTTP or ModelProtocolGRPC).
+	// Defaults to ModelProtocolHTTP.
+	ModelProtocolAnnotationName = "protocol"
+	ModelProtocolAnnotation     = AnnotationDomain + "/" + ModelProtocolAnnotationName
+
+	// ModelMaxQueueWaitAnnotationName is the name of the annotation that specifies the maximum
+	// time that a request for a Model waits for an available endpoint (i.e. "30s") before it
+	// is rejected. Overrides the system-wide default. "0s" disables the limit.
+	ModelMaxQueueWaitAnnotationName = "max-queue-wait"
+	ModelMaxQueueWaitAnnotation     = AnnotationDomain + "/" + ModelMaxQueueWaitAnnotationName
+
+	// ModelRequestTimeoutAnnotationName is the name of the annotation that specifies the maximum
+	// number of seconds that a request for a Model is proxied to a backend (i.e. "600") before it
+	// is cancelled with a 504 status code. Cancelled requests no longer count as active requests,
+	// so a stuck backend does not keep the Model from being scaled down. "0" disables the limit
+	// (default).
+	ModelRequestTimeoutAnnotationName = "request-timeout-seconds"
+	ModelRequestTimeoutAnnotation     = AnnotationDomain + "/" + ModelRequestTimeoutAnnotationName
+
+	// ModelResponseCacheTTLAnnotationName is the name of the annotation that enables the
+	// response cache of a Model: the responses to deterministic requests (temperature 0,
+	// not streamed) are cached for the given duration (i.e. "10m") and served by KubeAI
+	// without proxying the request, so cache hits do not activate or scale the Model.
+	ModelResponseCacheTTLAnnotationName = "response-cache-ttl"
+	ModelResponseCacheTTLAnnotation     = AnnotationDomain + "/" + ModelResponseCacheTTLAnnotationName
+	// ModelResponseCacheMaxEntriesAnnotationName is the name of the annotation that limits
+	// the number of responses that are cached for a Model (i.e. "500"). The least recently
+	// used responses are evicted first. Defaults to 1000.
+	ModelResponseCacheMaxEntriesAnnotationName = "response-cache-max-entries"
+	ModelResponseCacheMaxEntriesAnnotation     = AnnotationDomain + "/" + ModelResponseCacheMaxEntriesAnnotationName
+
+	// ModelAdaptiveTargetLatencyAnnotationName is the name of the annotation that enables the
+	// adaptive concurrency target of a Model: the target requests per replica are adjusted
+	// over time so that the p95 latency of requests approaches the given latency (i.e. "2s").
+	ModelAdaptiveTargetLatencyAnnotationName = "adaptive-target-latency"
+	ModelAdaptiveTargetLatencyAnnotation     = AnnotationDomain + "/" + ModelAdaptiveTargetLatencyAnnotationName
+	// ModelAdaptiveTargetRequestsAnnotationName is the name of the annotation that bounds the
+	// target requests per replica of the adaptive concurrency target (i.e. "10-50").
+	// Required when the ModelAdaptiveTargetLatencyAnnotation is set.
+	ModelAdaptiveTargetRequestsAnnotationName = "adaptive-target-requests"
+	ModelAdaptiveTargetRequestsAnnotation     = AnnotationDomain + "/" + ModelAdaptiveTargetRequestsAnnotationName
+	// ModelCordonedAnnotationName is the name of the annotation that can be set to
+	// "true" to stop routing new requests to a Model (i.e. for node maintenance).
+	// Requests are routed to failover Models instead. The Model is not scaled down
+	// while it is cordoned.
+	ModelCordonedAnnotationName = "cordoned"
+	ModelCordonedAnnotation     = AnnotationDomain + "/" + ModelCordonedAnnotationName
+	// ModelVersionAnnotationName is the name of the annotation that makes a Model a
+	// version of another model, in the form "<model>:<version>". Requests for the
+	// model that pin the version (see the X-Model-Version header) are routed to
+	// the annotated Model.
+	ModelVersionAnnotationName = "model-version"
+	ModelVersionAnnotation     = AnnotationDomain + "/" + ModelVersionAnnotationName
+	// ModelCapabilitiesAnnotationName is the name of the annotation that declares the
+	// capabilities of a Model as a comma-separated list of tags (i.e. "chat,7b").
+	// Requests that ask for capabilities (see the X-Model-Capability header) are
+	// routed to a Model with all of the tags, preferring Models with ready replicas.
+	ModelCapabilitiesAnnotationName = "capabilities"
+	ModelCapabilitiesAnnotation     = AnnotationDomain + "/" + ModelCapabilitiesAnnotationName
+	// ModelPriorityAnnotationName is the name of the annotation that specifies the
+	// priority of a Model (an integer, 0 when unset) for the replica budget: when the
+	// budget is exhausted, Models with a lower priority are scaled down to make room
+	// for scale ups of Models with a higher priority.
+	ModelPriorityAnnotationName = "priority"
+	ModelPriorityAnnotation     = AnnotationDomain + "/" + ModelPriorityAnnotationName
+	// ModelWarmPoolAnnotationName is the name of the annotation that specifies the
+	// warm pool (see WarmPoolLabel) that Pods are claimed from when the Model is
+	// scaled up from zero replicas. Requests are routed to the claimed Pods until
+	// Pods of the Model are ready.
+	ModelWarmPoolAnnotationName = "warm-pool"
+	ModelWarmPoolAnnotation     = AnnotationDomain + "/" + ModelWarmPoolAnnotationName
+	// ModelWarmupScheduleAnnotationName is the name of the annotation that raises the
+	// min replicas of a Model during time windows (in UTC), i.e.
+	// "Mon-Fri 08:00-18:00=2, Sat-Sun 10:00-16:00=1". The highest min replicas of the
+	// windows that apply is used. Outside of all windows, the min replicas of the
+	// Model spec apply.
+	ModelWarmupScheduleAnnotationName = "warmup-schedule"
+	ModelWarmupScheduleAnnotation     = AnnotationDomain + "/" + ModelWarmupScheduleAnnotationName
+
+	// WarmPoolLabel is the label that adds a Pod to the warm pool of the given name.
+	// Warm pool Pods are not created by KubeAI (i.e. they belong to a Deployment) and
+	// run a model server that can serve any of the Models that use the pool.
+	WarmPoolLabel = "kubeai.org/warm-pool"
+	// WarmPoolModelLabel is the label that is set on a warm pool Pod while it is
+	// claimed by the given model.
+	WarmPoolModelLabel = "kubeai.org/warm-pool-model"
+	// ModelReplicaCostAnnotationName is the name of the annotation that specifies the
+	// relative cost of one replica of a Model (a positive integer, 1 when unset), i.e.
+	// the number of GPUs of its Pods. Replicas count towards the replica budget with
+	// their cost, so that Models with large footprints use up more of the budget.
+	ModelReplicaCostAnnotationName = "replica-cost"
+	ModelReplicaCostAnnotation     = AnnotationDomain + "/" + ModelReplicaCostAnnotationName
+
+	// ModelProtocolHTTP is the protocol of servers that accept HTTP/1.1 requests.
+	ModelProtocolHTTP = "http"
+	// ModelProtocolGRPC is the protocol of gRPC servers (HTTP/2 without TLS).
+	ModelProtocolGRPC = "grpc"
+
+	// ModelSaturatedAnnotationName is the name of the annotation that KubeAI sets to "true"
+	// while a Model is running at its max replicas and the autoscaler would scale beyond
+	// it, so that all KubeAI instances apply backpressure and fail over. Removed once
+	// the Model is no longer saturated. Written by KubeAI.
+	ModelSaturatedAnnotationName = "saturated"
+	ModelSaturatedAnnotation     = AnnotationDomain + "/" + ModelSaturatedAnnotationName
 )
 
+// AnnotationDomains returns the given domains followed by AnnotationDomain and
+// LegacyAnnotationDomain, without duplicates.
+func AnnotationDomains(additional []string) []string {
+	var all []string
+	for _, d := range append(slices.Clone(additional), AnnotationDomain, LegacyAnnotationDomain) {
+		if d != "" && !slices.Contains(all, d) {
+			all = append(all, d)
+		}
+	}
+	return all
+}
+
+// GetModelAnnotation returns the value of the annotation with the given name
+// from the first of the domains that the Model has it set in.
+func GetModelAnnotation(m *Model, domains []string, name string) (key, value string, ok bool) {
+	return GetDomainAnnotation(m.GetAnnotations(), domains, name)
+}
+
+// GetDomainAnnotation returns the value of the annotation with the given name
+// from the first of the domains that it is set in.
+func GetDomainAnnotation(ann map[string]string, domains []string, name string) (key, value string, ok bool) {
+	for _, domain := range domains {
+		key = domain + "/" + name
+		if value, ok = ann[key]; ok {
+			return key, value, true
+		}
+	}
+	return "", "", false
+}
+
+// IsModelManaged returns false if the Model has the managed annotation set to "false"
+// in any of the given domains.
+func IsModelManaged(m *Model, domains []string) bool {
+	_, value, ok := GetModelAnnotation(m, domains, ModelManagedAnnotationName)
+	return !ok || value != "false"
+}
+
+// IsModelCordoned returns true if the Model was cordoned using the ModelCordonedAnnotation.
+func IsModelCordoned(m *Model, domains []string) bool {
+	_, value, ok := GetModelAnnotation(m, domains, ModelCordonedAnnotationName)
+	return ok && value == "true"
+}
+
 func PVCModelAnnotation(modelName string) string {
 	return "models.kubeai.org/" + modelName
 }
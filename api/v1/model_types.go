@@ -27,6 +27,9 @@ import (
 // +kubebuilder:validation:XValidation:rule="!self.url.startsWith(\"oss://\") || has(self.cacheProfile)", message="urls of format \"oss://...\" only supported when using a cacheProfile"
 // +kubebuilder:validation:XValidation:rule="!has(self.maxReplicas) || self.minReplicas <= self.maxReplicas", message="minReplicas should be less than or equal to maxReplicas."
 // +kubebuilder:validation:XValidation:rule="!has(self.adapters) || self.engine == \"VLLM\"", message="adapters only supported with VLLM engine."
+// +kubebuilder:validation:XValidation:rule="!has(self.idleMinReplicas) || self.idleMinReplicas <= self.minReplicas || self.minReplicas == 0", message="idleMinReplicas should be less than or equal to minReplicas, unless minReplicas is 0."
+// +kubebuilder:validation:XValidation:rule="!has(self.idleMinReplicas) || !has(self.maxReplicas) || self.idleMinReplicas <= self.maxReplicas", message="idleMinReplicas should be less than or equal to maxReplicas."
+// +kubebuilder:validation:XValidation:rule="!has(self.scaleFromZeroRequests) || self.scaleFromZeroRequests == 1 || has(self.scaleFromZeroDelaySeconds)", message="scaleFromZeroRequests requires scaleFromZeroDelaySeconds."
 type ModelSpec struct {
 	// URL of the model to be served.
 	// Currently the following formats are supported:
@@ -92,11 +95,45 @@ type ModelSpec struct {
 	// +kubebuilder:validation:Optional
 	MinReplicas int32 `json:"minReplicas"`
 
+	// IdleMinReplicas is the minimum number of Pod replicas that the model can scale down to
+	// when it is idle (the autoscaler observes no active requests for the ScaleDownDelay).
+	// MinReplicas applies while the model is receiving requests.
+	// When MinReplicas is 0, IdleMinReplicas can be higher to keep standby replicas
+	// instead of scaling to zero.
+	// Defaults to MinReplicas when not set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Optional
+	IdleMinReplicas *int32 `json:"idleMinReplicas,omitempty"`
+
 	// MaxReplicas is the maximum number of Pod replicas that the model can scale up to.
 	// Empty value means no limit.
 	// +kubebuilder:validation:Minimum=1
 	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
 
+	// ScaleFromZeroReplicas is the number of Pod replicas that the model will be scaled
+	// to when a request is received while the model is scaled to zero.
+	// Useful for models that are known to receive bursts of traffic.
+	// Bounded by MaxReplicas. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	ScaleFromZeroReplicas *int32 `json:"scaleFromZeroReplicas,omitempty"`
+
+	// ScaleFromZeroDelaySeconds requires ScaleFromZeroRequests (default 2) requests to be
+	// received within this window before a model is scaled up from zero replicas.
+	// Avoids cold starts caused by single requests from health checkers or warmup scripts.
+	// Requests that do not trigger a scale up will wait for the model to be scaled up.
+	// Disabled when unset or 0 (the first request triggers a scale up).
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Optional
+	ScaleFromZeroDelaySeconds *int64 `json:"scaleFromZeroDelaySeconds,omitempty"`
+
+	// ScaleFromZeroRequests is the number of requests that need to be received within
+	// ScaleFromZeroDelaySeconds before a model is scaled up from zero replicas.
+	// Defaults to 2 when ScaleFromZeroDelaySeconds is positive, otherwise 1.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	ScaleFromZeroRequests *int32 `json:"scaleFromZeroRequests,omitempty"`
+
 	// AutoscalingDisabled will stop the controller from managing the replicas
 	// for the Model. When disabled, metrics will not be collected on server Pods.
 	AutoscalingDisabled bool `json:"autoscalingDisabled,omitempty"`
@@ -107,11 +144,55 @@ type ModelSpec struct {
 	// +kubebuilder:default=100
 	TargetRequests *int32 `json:"targetRequests"`
 
+	// StreamingRequestWeight is the number of active requests that a streaming
+	// request (with `"stream": true` in the body) counts as when autoscaling.
+	// Streaming sessions typically hold a model server for much longer than
+	// one-shot requests. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	StreamingRequestWeight *int32 `json:"streamingRequestWeight,omitempty"`
+
+	// TargetLatencyMilliseconds switches the autoscaler from TargetRequests to scaling
+	// the model to keep the p95 latency of requests near this target. The number of
+	// replicas is adjusted in proportion to the observed p95 latency over the
+	// autoscaling time window. TargetRequests is used while no requests are observed.
+	// Disabled when unset.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	TargetLatencyMilliseconds *int64 `json:"targetLatencyMilliseconds,omitempty"`
+
 	// ScaleDownDelay is the minimum time before a deployment is scaled down after
 	// the autoscaling algorithm determines that it should be scaled down.
 	// +kubebuilder:default=30
 	ScaleDownDelaySeconds *int64 `json:"scaleDownDelaySeconds"`
 
+	// ScaleDownStabilizationWindowSeconds is the time window over which the
+	// autoscaler considers its previous recommendations when scaling down.
+	// The highest recommendation within the window is used, so a brief lull
+	// in traffic does not start a scale down (similar to the HPA's
+	// behavior.scaleDown.stabilizationWindowSeconds).
+	// Disabled when unset.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Optional
+	ScaleDownStabilizationWindowSeconds *int64 `json:"scaleDownStabilizationWindowSeconds,omitempty"`
+
+	// ScaleDownHalfLifeSeconds raises the minimum number of replicas to the
+	// recent peak of the desired replicas. The raised minimum decays towards
+	// MinReplicas, halving its distance to MinReplicas every half-life, so the
+	// model steps down gradually after a burst of requests.
+	// Disabled when unset.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	ScaleDownHalfLifeSeconds *int64 `json:"scaleDownHalfLifeSeconds,omitempty"`
+
+	// ScaleUpCooldownSeconds is the time after any scale up of the model during
+	// which it is not scaled down, so that a brief dip in traffic right after a
+	// burst does not undo the scale up. The ScaleDownDelay keeps counting
+	// during the cooldown. Disabled when unset.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Optional
+	ScaleUpCooldownSeconds *int64 `json:"scaleUpCooldownSeconds,omitempty"`
+
 	// Owner of the model. Used solely to populate the owner field in the
 	// OpenAI /v1/models endpoint.
 	// DEPRECATED.
@@ -122,6 +203,12 @@ type ModelSpec struct {
 	// If not specified, a default is used based on the engine and request.
 	// +kubebuilder:default={}
 	LoadBalancing LoadBalancing `json:"loadBalancing,omitempty"`
+
+	// FailoverModels are the names of Models (in order of preference) that serve
+	// the same model and that requests are routed to while this Model is saturated
+	// or can not get the capacity to serve requests.
+	// +kubebuilder:validation:Optional
+	FailoverModels []string `json:"failoverModels,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=TextGeneration;TextEmbedding;SpeechToText
@@ -158,6 +245,13 @@ type LoadBalancing struct {
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:default={}
 	PrefixHash PrefixHash `json:"prefixHash,omitempty"`
+	// MaxConcurrentRequestsPerReplica is the maximum number of requests that will be
+	// sent to a single model server Pod at the same time. Excess requests are queued
+	// until a request completes or more Pods become available.
+	// Unlimited when set to 0 (default).
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	MaxConcurrentRequestsPerReplica int32 `json:"maxConcurrentRequestsPerReplica,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=LeastLoad;PrefixHash
@@ -193,8 +287,24 @@ type PrefixHash struct {
 type ModelStatus struct {
 	Replicas ModelStatusReplicas `json:"replicas,omitempty"`
 	Cache    *ModelStatusCache   `json:"cache,omitempty"`
+	// Conditions describe the state of the Model (i.e. ModelConditionNeverReady).
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+const (
+	// ModelConditionNeverReady is True when none of the replicas of the Model became ready
+	// within the configured ready timeout, which is usually a sign of a misconfigured
+	// model server. It is False once any replica of the Model has been ready.
+	ModelConditionNeverReady = "NeverReady"
+	// ModelConditionUnscalable is True when the scale target of the Model (see
+	// ModelScaleTargetAnnotation) exists but does not implement the scale
+	// subresource. The Model is not scaled until its scale target changes.
+	ModelConditionUnscalable = "Unscalable"
+)
+
 type ModelStatusReplicas struct {
 	All   int32 `json:"all"`
 	Ready int32 `json:"ready"`
@@ -229,6 +339,44 @@ type ModelList struct {
 	Items           []Model `json:"items"`
 }
 
+// DefaultTargetRequests is the number of target requests per replica that is
+// used when TargetRequests is not set to a positive value.
+const DefaultTargetRequests = 100
+
+// GetTargetRequests returns the number of active requests that a single replica
+// of the Model is expected to handle. Falls back to DefaultTargetRequests if
+// TargetRequests is not set to a positive value (the API server defaults and
+// validates the field, but objects may have been created before it existed).
+func (s *ModelSpec) GetTargetRequests() int32 {
+	if s.TargetRequests == nil || *s.TargetRequests < 1 {
+		return DefaultTargetRequests
+	}
+	return *s.TargetRequests
+}
+
+// GetStreamingRequestWeight returns the number of active requests that a
+// streaming request counts as (see StreamingRequestWeight).
+func (s *ModelSpec) GetStreamingRequestWeight() int32 {
+	if s.StreamingRequestWeight == nil || *s.StreamingRequestWeight < 1 {
+		return 1
+	}
+	return *s.StreamingRequestWeight
+}
+
+// GetScaleFromZeroRequests returns the number of requests that need to be received
+// within ScaleFromZeroDelaySeconds before the Model is scaled up from zero replicas.
+func (s *ModelSpec) GetScaleFromZeroRequests() int32 {
+	switch {
+	case s.ScaleFromZeroDelaySeconds == nil || *s.ScaleFromZeroDelaySeconds <= 0:
+		// No two requests can be received within an empty window.
+		return 1
+	case s.ScaleFromZeroRequests != nil:
+		return *s.ScaleFromZeroRequests
+	default:
+		return 2
+	}
+}
+
 func init() {
 	SchemeBuilder.Register(&Model{}, &ModelList{})
 }
@@ -21,6 +21,7 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -144,22 +145,72 @@ func (in *ModelSpec) DeepCopyInto(out *ModelSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.IdleMinReplicas != nil {
+		in, out := &in.IdleMinReplicas, &out.IdleMinReplicas
+		*out = new(int32)
+		**out = **in
+	}
 	if in.MaxReplicas != nil {
 		in, out := &in.MaxReplicas, &out.MaxReplicas
 		*out = new(int32)
 		**out = **in
 	}
+	if in.ScaleFromZeroReplicas != nil {
+		in, out := &in.ScaleFromZeroReplicas, &out.ScaleFromZeroReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScaleFromZeroDelaySeconds != nil {
+		in, out := &in.ScaleFromZeroDelaySeconds, &out.ScaleFromZeroDelaySeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ScaleFromZeroRequests != nil {
+		in, out := &in.ScaleFromZeroRequests, &out.ScaleFromZeroRequests
+		*out = new(int32)
+		**out = **in
+	}
 	if in.TargetRequests != nil {
 		in, out := &in.TargetRequests, &out.TargetRequests
 		*out = new(int32)
 		**out = **in
 	}
+	if in.StreamingRequestWeight != nil {
+		in, out := &in.StreamingRequestWeight, &out.StreamingRequestWeight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetLatencyMilliseconds != nil {
+		in, out := &in.TargetLatencyMilliseconds, &out.TargetLatencyMilliseconds
+		*out = new(int64)
+		**out = **in
+	}
 	if in.ScaleDownDelaySeconds != nil {
 		in, out := &in.ScaleDownDelaySeconds, &out.ScaleDownDelaySeconds
 		*out = new(int64)
 		**out = **in
 	}
+	if in.ScaleDownStabilizationWindowSeconds != nil {
+		in, out := &in.ScaleDownStabilizationWindowSeconds, &out.ScaleDownStabilizationWindowSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ScaleDownHalfLifeSeconds != nil {
+		in, out := &in.ScaleDownHalfLifeSeconds, &out.ScaleDownHalfLifeSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ScaleUpCooldownSeconds != nil {
+		in, out := &in.ScaleUpCooldownSeconds, &out.ScaleUpCooldownSeconds
+		*out = new(int64)
+		**out = **in
+	}
 	out.LoadBalancing = in.LoadBalancing
+	if in.FailoverModels != nil {
+		in, out := &in.FailoverModels, &out.FailoverModels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelSpec.
@@ -181,6 +232,13 @@ func (in *ModelStatus) DeepCopyInto(out *ModelStatus) {
 		*out = new(ModelStatusCache)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModelStatus.
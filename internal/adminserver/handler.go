@@ -0,0 +1,569 @@
+package adminserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/loadbalancer"
+	"github.com/substratusai/kubeai/internal/modelautoscaler"
+	"github.com/substratusai/kubeai/internal/modelclient"
+	"github.com/substratusai/kubeai/internal/modelcontroller"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Handler serves administrative endpoints that are used to inspect and
+// operate the running system. These endpoints should not be exposed publicly.
+type Handler struct {
+	ModelClient     *modelclient.ModelClient
+	ModelReconciler *modelcontroller.ModelReconciler
+	LoadBalancer    *loadbalancer.LoadBalancer
+	Autoscaler      *modelautoscaler.Autoscaler
+	// Namespace is the namespace that Models are managed in.
+	Namespace string
+	http.Handler
+}
+
+func NewHandler(
+	modelClient *modelclient.ModelClient,
+	modelReconciler *modelcontroller.ModelReconciler,
+	loadBalancer *loadbalancer.LoadBalancer,
+	autoscaler *modelautoscaler.Autoscaler,
+	namespace string,
+) *Handler {
+	h := &Handler{
+		ModelClient:     modelClient,
+		ModelReconciler: modelReconciler,
+		LoadBalancer:    loadBalancer,
+		Autoscaler:      autoscaler,
+		Namespace:       namespace,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/models/{model}/scaler", h.getModelScaler)
+	mux.HandleFunc("GET /admin/models/{model}/status", h.getModelStatus)
+	mux.HandleFunc("GET /admin/models/{model}/scaler-config", h.getModelScalerConfig)
+	mux.HandleFunc("POST /admin/models/{model}/scale", h.forceModelScale)
+	mux.HandleFunc("POST /admin/models/{model}/cordon", h.cordonModel)
+	mux.HandleFunc("POST /admin/models/{model}/uncordon", h.uncordonModel)
+	mux.HandleFunc("POST /admin/models/{model}/reconcile", h.reconcileModel)
+	mux.HandleFunc("GET /admin/models/idle", h.getIdleModels)
+	mux.HandleFunc("GET /admin/scale-events", h.streamScaleEvents)
+	mux.HandleFunc("GET /admin/scaler-states", h.streamScalerStates)
+	mux.HandleFunc("GET /admin/autoscaling", h.getAutoscaling)
+	mux.HandleFunc("POST /admin/autoscaling/pause", h.pauseAutoscaling)
+	mux.HandleFunc("POST /admin/autoscaling/resume", h.resumeAutoscaling)
+	mux.HandleFunc("POST /admin/autoscaling/freeze-scale-down", h.freezeScaleDown)
+	mux.HandleFunc("POST /admin/reconcile", h.reconcileAll)
+	mux.HandleFunc("GET /admin/routing", h.getRouting)
+	mux.HandleFunc("POST /admin/routing/fallback-model", h.setFallbackModel)
+
+	h.Handler = mux
+
+	return h
+}
+
+func (h *Handler) getModelScaler(w http.ResponseWriter, r *http.Request) {
+	model := r.PathValue("model")
+	snapshot, ok := h.ModelClient.ScalerSnapshot(model)
+	if !ok {
+		sendErrorResponse(w, http.StatusNotFound, "no scaler state found for model: %q", model)
+		return
+	}
+	sendJSONResponse(w, snapshot)
+}
+
+// getModelScalerConfig returns the autoscaling configuration that is in effect
+// for a model (Model fields with defaults, annotations, and system settings).
+func (h *Handler) getModelScalerConfig(w http.ResponseWriter, r *http.Request) {
+	model := r.PathValue("model")
+	cfg, err := h.Autoscaler.ScalerConfig(r.Context(), model)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to get scaler config: %v", err)
+		return
+	}
+	if cfg == nil {
+		sendErrorResponse(w, http.StatusNotFound, "model not found: %q", model)
+		return
+	}
+	sendJSONResponse(w, cfg)
+}
+
+// forceModelScale pins the replicas of a model for a duration.
+// Query parameters: "replicas" (required) and "duration" (defaults to 10m).
+func (h *Handler) forceModelScale(w http.ResponseWriter, r *http.Request) {
+	model := r.PathValue("model")
+	replicas, err := strconv.ParseInt(r.URL.Query().Get("replicas"), 10, 32)
+	if err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, "invalid replicas: %v", err)
+		return
+	}
+	duration := defaultForceScaleDuration
+	if v := r.URL.Query().Get("duration"); v != "" {
+		duration, err = time.ParseDuration(v)
+		if err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, "invalid duration: %v", err)
+			return
+		}
+	}
+
+	if err := h.ModelClient.ForceScale(r.Context(), model, int32(replicas), duration); err != nil {
+		var scaleErr *modelclient.ScaleError
+		switch {
+		case errors.Is(err, modelclient.ErrScaleNotFound):
+			sendErrorResponse(w, http.StatusNotFound, "model not found: %q", model)
+		case errors.As(err, &scaleErr):
+			sendErrorResponse(w, http.StatusInternalServerError, "failed to scale model: %v", err)
+		default:
+			sendErrorResponse(w, http.StatusBadRequest, "%v", err)
+		}
+		return
+	}
+
+	snapshot, _ := h.ModelClient.ScalerSnapshot(model)
+	sendJSONResponse(w, snapshot)
+}
+
+// streamScaleEvents streams the scale events of this instance as newline-delimited
+// JSON until the client disconnects.
+func (h *Handler) streamScaleEvents(w http.ResponseWriter, r *http.Request) {
+	events, unsubscribe := h.ModelClient.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-events:
+			if err := enc.Encode(e); err != nil {
+				log.Printf("ERROR: streaming scale events: %v", err)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+type modelScalerState struct {
+	Model string `json:"model"`
+	// Deleted is true if the Model no longer exists.
+	Deleted       bool  `json:"deleted,omitempty"`
+	Replicas      int32 `json:"replicas"`
+	ReadyReplicas int32 `json:"readyReplicas"`
+	// Endpoints are the addresses that requests for the model are routed to.
+	Endpoints []string                   `json:"endpoints"`
+	Scaler    modelclient.ScalerSnapshot `json:"scaler"`
+}
+
+// streamScalerStates streams the scaling state of models as newline-delimited
+// JSON until the client disconnects: the state of every Model when the stream
+// is opened, followed by the state of a model whenever it changes. Changes are
+// coalesced while the client is not keeping up, so a slow client receives the
+// latest state of each changed model and never blocks scaling.
+func (h *Handler) streamScalerStates(w http.ResponseWriter, r *http.Request) {
+	// Watch before listing so that no change is missed.
+	watch, stop := h.ModelClient.WatchStates()
+	defer stop()
+
+	models, err := h.ModelClient.ListAllModels(r.Context())
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to list models: %v", err)
+		return
+	}
+	initial := make([]string, 0, len(models))
+	for _, m := range models {
+		initial = append(initial, m.Name)
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	enc := json.NewEncoder(w)
+	send := func(models []string) bool {
+		for _, model := range models {
+			state, err := h.modelScalerState(r.Context(), model)
+			if err != nil {
+				log.Printf("ERROR: getting scaler state of model %s: %v", model, err)
+				continue
+			}
+			if err := enc.Encode(state); err != nil {
+				log.Printf("ERROR: streaming scaler states: %v", err)
+				return false
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+
+	if !send(initial) {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-watch.Ready():
+			if !send(watch.Changed()) {
+				return
+			}
+		}
+	}
+}
+
+func (h *Handler) modelScalerState(ctx context.Context, model string) (modelScalerState, error) {
+	state := modelScalerState{Model: model}
+	m, err := h.ModelClient.LookupModel(ctx, model, "", nil)
+	if err != nil {
+		return state, err
+	}
+	if m == nil {
+		state.Deleted = true
+	} else {
+		if m.Spec.Replicas != nil {
+			state.Replicas = *m.Spec.Replicas
+		}
+		state.ReadyReplicas = m.Status.Replicas.Ready
+	}
+	state.Endpoints = h.LoadBalancer.GetAllAddresses(model)
+	state.Scaler, _ = h.ModelClient.ScalerSnapshot(model)
+	state.Scaler.Model = model
+	// Resolved from the current replicas rather than the last scale operation
+	// of this instance.
+	state.Scaler.ScaledToZeroReason, err = h.ModelClient.ScaledToZeroReason(ctx, model)
+	if err != nil {
+		return state, err
+	}
+	return state, nil
+}
+
+// defaultForceScaleDuration is the pin duration used when the "duration" query parameter is not set.
+const defaultForceScaleDuration = 10 * time.Minute
+
+// defaultScaleDownFreezeDuration is the freeze duration used when the "duration" query parameter is not set.
+const defaultScaleDownFreezeDuration = 30 * time.Minute
+
+type modelStatus struct {
+	Model  string `json:"model"`
+	Status string `json:"status"`
+	// ScaledToZero is true if the model has no replicas. The next request
+	// for the model will cause a cold start.
+	ScaledToZero bool `json:"scaledToZero"`
+	// ScaledToZeroReason describes why the model has no replicas (see the
+	// modelclient.ScaledToZero* reasons). Empty if unknown.
+	ScaledToZeroReason string `json:"scaledToZeroReason,omitempty"`
+	// ScalingUp is true if the model has replicas but none of them are ready.
+	ScalingUp bool `json:"scalingUp"`
+	// Cordoned is true if new requests are not routed to the model.
+	Cordoned bool `json:"cordoned"`
+	// AverageColdStartSeconds is the average duration of recent cold starts.
+	// Omitted when no cold starts have been observed.
+	AverageColdStartSeconds float64 `json:"averageColdStartSeconds,omitempty"`
+	// P90ColdStartSeconds is the 90th percentile duration of recent cold starts.
+	// Omitted when no cold starts have been observed.
+	P90ColdStartSeconds float64 `json:"p90ColdStartSeconds,omitempty"`
+	// EstimatedReadyTime is the estimated time that a cold start (in progress,
+	// or triggered by the next request) will complete at. Omitted when the model
+	// is ready or no cold starts have been observed.
+	EstimatedReadyTime *time.Time `json:"estimatedReadyTime,omitempty"`
+}
+
+func (h *Handler) getModelStatus(w http.ResponseWriter, r *http.Request) {
+	model := r.PathValue("model")
+	status, err := h.ModelClient.ModelStatus(r.Context(), model)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to get model status: %v", err)
+		return
+	}
+	if status == modelclient.ModelStatusUnknown {
+		sendErrorResponse(w, http.StatusNotFound, "model not found: %q", model)
+		return
+	}
+
+	cordoned, err := h.ModelClient.IsCordoned(r.Context(), model)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to get model status: %v", err)
+		return
+	}
+
+	resp := modelStatus{
+		Model:        model,
+		Status:       status.String(),
+		ScaledToZero: status == modelclient.ModelStatusScaledToZero,
+		ScalingUp:    status == modelclient.ModelStatusScalingUp,
+		Cordoned:     cordoned,
+	}
+	if resp.ScaledToZero {
+		resp.ScaledToZeroReason, err = h.ModelClient.ScaledToZeroReason(r.Context(), model)
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, "failed to get model status: %v", err)
+			return
+		}
+	}
+	coldStart := h.ModelClient.ColdStartSnapshot(model)
+	if coldStart.Observed > 0 {
+		resp.AverageColdStartSeconds = coldStart.AverageDuration.Seconds()
+		resp.P90ColdStartSeconds = coldStart.P90Duration.Seconds()
+		if status != modelclient.ModelStatusReady {
+			now := time.Now()
+			began := now
+			if resp.ScalingUp && !coldStart.Began.IsZero() {
+				began = coldStart.Began
+			}
+			readyTime := began.Add(coldStart.AverageDuration)
+			if readyTime.Before(now) {
+				// The cold start is taking longer than usual.
+				readyTime = now
+			}
+			resp.EstimatedReadyTime = &readyTime
+		}
+	}
+	sendJSONResponse(w, resp)
+}
+
+type modelCordon struct {
+	Model    string `json:"model"`
+	Cordoned bool   `json:"cordoned"`
+}
+
+// cordonModel stops routing new requests to the model.
+func (h *Handler) cordonModel(w http.ResponseWriter, r *http.Request) {
+	h.setModelCordoned(w, r, h.ModelClient.Cordon, true)
+}
+
+// uncordonModel resumes routing requests to the model.
+func (h *Handler) uncordonModel(w http.ResponseWriter, r *http.Request) {
+	h.setModelCordoned(w, r, h.ModelClient.Uncordon, false)
+}
+
+func (h *Handler) setModelCordoned(w http.ResponseWriter, r *http.Request, set func(ctx context.Context, model string) error, cordoned bool) {
+	model := r.PathValue("model")
+	if err := set(r.Context(), model); err != nil {
+		if apierrors.IsNotFound(err) {
+			sendErrorResponse(w, http.StatusNotFound, "model not found: %q", model)
+		} else {
+			sendErrorResponse(w, http.StatusInternalServerError, "failed to update model: %v", err)
+		}
+		return
+	}
+	sendJSONResponse(w, modelCordon{Model: model, Cordoned: cordoned})
+}
+
+type idleModels struct {
+	Since  string   `json:"since"`
+	Models []string `json:"models"`
+}
+
+// defaultIdleSince is the idle duration used when the "since" query parameter is not set.
+const defaultIdleSince = time.Hour
+
+func (h *Handler) getIdleModels(w http.ResponseWriter, r *http.Request) {
+	since := defaultIdleSince
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		since, err = time.ParseDuration(v)
+		if err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, "invalid since duration: %v", err)
+			return
+		}
+	}
+
+	models, err := h.ModelClient.IdleModels(r.Context(), since)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to find idle models: %v", err)
+		return
+	}
+	if models == nil {
+		models = []string{}
+	}
+	sendJSONResponse(w, idleModels{Since: since.String(), Models: models})
+}
+
+type autoscalingStatus struct {
+	Paused bool `json:"paused"`
+	// ScaleDownFrozenUntil is the time until which scale downs are frozen.
+	// Omitted if scale downs are not frozen.
+	ScaleDownFrozenUntil *time.Time `json:"scaleDownFrozenUntil,omitempty"`
+}
+
+func (h *Handler) autoscalingStatus(ctx context.Context) autoscalingStatus {
+	status := autoscalingStatus{Paused: h.ModelClient.IsAutoscalingPaused(ctx)}
+	if until := h.ModelClient.ScaleDownFrozenUntil(ctx); !until.IsZero() {
+		status.ScaleDownFrozenUntil = &until
+	}
+	return status
+}
+
+func (h *Handler) getAutoscaling(w http.ResponseWriter, r *http.Request) {
+	sendJSONResponse(w, h.autoscalingStatus(r.Context()))
+}
+
+func (h *Handler) pauseAutoscaling(w http.ResponseWriter, r *http.Request) {
+	if err := h.ModelClient.PauseAutoscaling(r.Context()); err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to pause autoscaling: %v", err)
+		return
+	}
+	sendJSONResponse(w, h.autoscalingStatus(r.Context()))
+}
+
+func (h *Handler) resumeAutoscaling(w http.ResponseWriter, r *http.Request) {
+	if err := h.ModelClient.ResumeAutoscaling(r.Context()); err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to apply deferred scale operations: %v", err)
+		return
+	}
+	sendJSONResponse(w, h.autoscalingStatus(r.Context()))
+}
+
+// freezeScaleDown prevents models from being scaled down for a duration.
+// Query parameters: "duration" (defaults to 30m, "0s" ends the freeze).
+func (h *Handler) freezeScaleDown(w http.ResponseWriter, r *http.Request) {
+	duration := defaultScaleDownFreezeDuration
+	if v := r.URL.Query().Get("duration"); v != "" {
+		var err error
+		duration, err = time.ParseDuration(v)
+		if err != nil || duration < 0 {
+			sendErrorResponse(w, http.StatusBadRequest, "invalid duration: %q", v)
+			return
+		}
+	}
+	if _, err := h.ModelClient.FreezeScaleDown(r.Context(), duration); err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to freeze scale downs: %v", err)
+		return
+	}
+	sendJSONResponse(w, h.autoscalingStatus(r.Context()))
+}
+
+type reconcileSummary struct {
+	// Models is the number of Models that exist.
+	Models int `json:"models"`
+	// ModelsEnqueued is the number of Models that were enqueued for reconciliation.
+	// Only the leader reconciles Models, so this will be 0 on other instances.
+	ModelsEnqueued int `json:"modelsEnqueued"`
+	// EndpointGroupsRefreshed is the number of models whose routing endpoints were re-read.
+	EndpointGroupsRefreshed int `json:"endpointGroupsRefreshed"`
+}
+
+func (h *Handler) reconcileAll(w http.ResponseWriter, r *http.Request) {
+	models, err := h.ModelClient.ListAllModels(r.Context())
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to list models: %v", err)
+		return
+	}
+
+	summary := reconcileSummary{
+		Models:         len(models),
+		ModelsEnqueued: h.ModelReconciler.EnqueueReconcile(models),
+	}
+	summary.EndpointGroupsRefreshed, err = h.LoadBalancer.ReconcileAll(r.Context(), h.Namespace)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to refresh endpoints: %v", err)
+		return
+	}
+
+	log.Printf("Reconcile requested via admin endpoint: %+v", summary)
+	sendJSONResponse(w, summary)
+}
+
+// reconcileModel triggers a reconcile of a single model and refreshes its endpoints,
+// i.e. after changing annotations that are only read when the Model is reconciled.
+func (h *Handler) reconcileModel(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("model")
+	m, err := h.ModelClient.LookupModel(r.Context(), name, "", nil)
+	if err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to get model: %v", err)
+		return
+	}
+	if m == nil {
+		sendErrorResponse(w, http.StatusNotFound, "model not found: %q", name)
+		return
+	}
+
+	summary := reconcileSummary{
+		Models:         1,
+		ModelsEnqueued: h.ModelReconciler.EnqueueReconcile([]kubeaiv1.Model{*m}),
+	}
+	if err := h.LoadBalancer.ReconcileModel(r.Context(), h.Namespace, m.Name); err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to refresh endpoints: %v", err)
+		return
+	}
+	summary.EndpointGroupsRefreshed = 1
+
+	log.Printf("Reconcile of model %q requested via admin endpoint: %+v", m.Name, summary)
+	sendJSONResponse(w, summary)
+}
+
+type routingStatus struct {
+	// FallbackModel is the Model that requests for unknown models are routed
+	// to. Omitted if the fallback is disabled.
+	FallbackModel string `json:"fallbackModel,omitempty"`
+}
+
+func (h *Handler) getRouting(w http.ResponseWriter, r *http.Request) {
+	sendJSONResponse(w, routingStatus{FallbackModel: h.ModelClient.FallbackModel()})
+}
+
+// setFallbackModel changes the fallback model of this KubeAI instance until it
+// is restarted. Query parameters: "model" (empty disables the fallback).
+func (h *Handler) setFallbackModel(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("model")
+	if name != "" {
+		m, err := h.ModelClient.LookupModel(r.Context(), name, "", nil)
+		if err != nil {
+			sendErrorResponse(w, http.StatusInternalServerError, "failed to get model: %v", err)
+			return
+		}
+		if m == nil {
+			sendErrorResponse(w, http.StatusNotFound, "model not found: %q", name)
+			return
+		}
+	}
+	h.ModelClient.SetFallbackModel(name)
+	log.Printf("Fallback model set to %q via admin endpoint", name)
+	sendJSONResponse(w, routingStatus{FallbackModel: name})
+}
+
+func sendJSONResponse(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		sendErrorResponse(w, http.StatusInternalServerError, "failed to encode response: %v", err)
+	}
+}
+
+func sendErrorResponse(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("sending error response: %v: %v", status, msg)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if status >= 500 {
+		// Don't leak internal error messages to the client.
+		msg = http.StatusText(status)
+	}
+
+	if err := json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{
+		Error: msg,
+	}); err != nil {
+		log.Printf("error encoding error response: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package adminserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/config"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelautoscaler"
+	"github.com/substratusai/kubeai/internal/modelclient"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	"github.com/substratusai/kubeai/internal/modelcontroller"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	testingclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+)
+
+const testNamespace = "default"
+
+func TestHandler(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-model", Namespace: testNamespace},
+		Spec: kubeaiv1.ModelSpec{
+			Replicas:    ptr.To[int32](1),
+			MaxReplicas: ptr.To[int32](3),
+		},
+	}
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "autoscaler-state"}
+	k8sClient := modelclienttest.NewClient(t, m, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name}})
+	mc := modelclient.NewModelClient(k8sClient, testNamespace, modelclient.Options{})
+	a, err := modelautoscaler.New(ctx, k8sClient, nil, mc, nil, config.ModelAutoscaling{}, 0, stateRef, nil, testingclock.NewFakeClock(time.Now()))
+	require.NoError(t, err)
+	h := NewHandler(mc, &modelcontroller.ModelReconciler{}, nil, a, testNamespace)
+
+	cases := []struct {
+		method, path string
+		expStatus    int
+		expBody      map[string]any
+	}{
+		{method: http.MethodGet, path: "/admin/unknown", expStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/admin/models/my-model/scale", expStatus: http.StatusMethodNotAllowed},
+		{method: http.MethodPost, path: "/admin/models/my-model/status", expStatus: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: "/admin/models/missing/scaler", expStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/admin/models/missing/status", expStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/admin/models/missing/scaler-config", expStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/admin/models/my-model/scaler-config", expStatus: http.StatusOK, expBody: map[string]any{"model": "my-model"}},
+		{method: http.MethodGet, path: "/admin/models/my-model/status", expStatus: http.StatusOK, expBody: map[string]any{"model": "my-model", "cordoned": false}},
+
+		{method: http.MethodPost, path: "/admin/models/my-model/scale", expStatus: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/admin/models/my-model/scale?replicas=2&duration=invalid", expStatus: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/admin/models/my-model/scale?replicas=-1", expStatus: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/admin/models/missing/scale?replicas=2", expStatus: http.StatusNotFound},
+		{method: http.MethodPost, path: "/admin/models/my-model/scale?replicas=2", expStatus: http.StatusOK},
+		{method: http.MethodGet, path: "/admin/models/my-model/scaler", expStatus: http.StatusOK},
+
+		{method: http.MethodPost, path: "/admin/models/missing/cordon", expStatus: http.StatusNotFound},
+		{method: http.MethodPost, path: "/admin/models/my-model/cordon", expStatus: http.StatusOK, expBody: map[string]any{"model": "my-model", "cordoned": true}},
+		{method: http.MethodGet, path: "/admin/models/my-model/status", expStatus: http.StatusOK, expBody: map[string]any{"cordoned": true}},
+		{method: http.MethodPost, path: "/admin/models/my-model/uncordon", expStatus: http.StatusOK, expBody: map[string]any{"model": "my-model", "cordoned": false}},
+
+		{method: http.MethodGet, path: "/admin/models/idle?since=invalid", expStatus: http.StatusBadRequest},
+		{method: http.MethodGet, path: "/admin/models/idle", expStatus: http.StatusOK, expBody: map[string]any{"since": "1h0m0s"}},
+
+		{method: http.MethodPost, path: "/admin/autoscaling/pause", expStatus: http.StatusOK, expBody: map[string]any{"paused": true}},
+		{method: http.MethodGet, path: "/admin/autoscaling", expStatus: http.StatusOK, expBody: map[string]any{"paused": true}},
+		{method: http.MethodPost, path: "/admin/autoscaling/resume", expStatus: http.StatusOK, expBody: map[string]any{"paused": false}},
+		{method: http.MethodPost, path: "/admin/autoscaling/freeze-scale-down?duration=-1m", expStatus: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/admin/autoscaling/freeze-scale-down?duration=0s", expStatus: http.StatusOK},
+		{method: http.MethodGet, path: "/admin/autoscaling/pause", expStatus: http.StatusMethodNotAllowed},
+
+		{method: http.MethodPost, path: "/admin/models/missing/reconcile", expStatus: http.StatusNotFound},
+
+		{method: http.MethodGet, path: "/admin/routing", expStatus: http.StatusOK, expBody: map[string]any{"fallbackModel": nil}},
+		{method: http.MethodPost, path: "/admin/routing/fallback-model?model=missing", expStatus: http.StatusNotFound},
+		{method: http.MethodPost, path: "/admin/routing/fallback-model?model=my-model", expStatus: http.StatusOK, expBody: map[string]any{"fallbackModel": "my-model"}},
+		{method: http.MethodGet, path: "/admin/routing", expStatus: http.StatusOK, expBody: map[string]any{"fallbackModel": "my-model"}},
+		{method: http.MethodPost, path: "/admin/routing/fallback-model", expStatus: http.StatusOK, expBody: map[string]any{"fallbackModel": nil}},
+	}
+	for _, c := range cases {
+		t.Run(c.method+" "+c.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+			require.Equal(t, c.expStatus, w.Code, w.Body.String())
+			if w.Code == http.StatusMethodNotAllowed || c.path == "/admin/unknown" {
+				return
+			}
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			if c.expStatus != http.StatusOK {
+				require.NotEmpty(t, body["error"])
+			}
+			for k, v := range c.expBody {
+				require.Equal(t, v, body[k], k)
+			}
+		})
+	}
+
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "the forced scale should be applied")
+	require.Empty(t, mc.FallbackModel(), "the fallback model should be disabled again")
+}
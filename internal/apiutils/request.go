@@ -8,6 +8,7 @@ import (
 	"mime"
 	"mime/multipart"
 	"net/http"
+	"strings"
 
 	"context"
 
@@ -20,9 +21,27 @@ var (
 	ErrModelNotFound = fmt.Errorf("model not found")
 )
 
+// ModelVersionHeader is the header that pins the version of the requested model
+// (see v1.ModelVersionAnnotation).
+const ModelVersionHeader = "X-Model-Version"
+
+// ModelCapabilityHeader is the header that requests a Model by its capabilities
+// (see v1.ModelCapabilitiesAnnotation) instead of by name. Multiple capabilities
+// can be requested as a comma-separated list or by repeating the header.
+const ModelCapabilityHeader = "X-Model-Capability"
+
+// GRPCModelHeader is the metadata key that gRPC requests specify the model in,
+// as their protobuf bodies are passed through without being parsed.
+const GRPCModelHeader = "Kubeai-Model"
+
 type Request struct {
 	Body        []byte
 	bodyPayload map[string]interface{}
+	// bodyParsed is false if the model was read from a header
+	// and the body was passed through without being parsed.
+	bodyParsed bool
+	// bodyJSON is true if the body is a JSON object with a "model" field.
+	bodyJSON bool
 
 	Selectors []string
 
@@ -34,24 +53,110 @@ type Request struct {
 
 	Model   string
 	Adapter string
+	// ResolvedModel is the Model that the request is routed to (see Model),
+	// as it was resolved when the request was parsed.
+	ResolvedModel *v1.Model
+
+	// Version is the version of the model that was requested with the
+	// ModelVersionHeader. Requests are routed to the Model that serves the
+	// version, or to the requested model if the version is unknown.
+	Version string
+	// Versioned is true if the request is routed to the Model of the Version.
+	Versioned bool
+
+	// Capabilities are the capabilities that were requested with the
+	// ModelCapabilityHeader. Requests are routed to the best available Model
+	// with all of the capabilities, or to the requested model if none has them.
+	Capabilities []string
+	// Capable is true if the request is routed to a Model of the Capabilities.
+	Capable bool
+
+	// GRPC is true if the request is a gRPC call (see GRPCModelHeader).
+	GRPC bool
+
+	// Fallback is true if the requested model was not found
+	// and the request is being routed to the fallback model.
+	Fallback bool
 
 	LoadBalancing v1.LoadBalancing
 
+	// AutoscalingDisabled is true if requests will not scale the model up from zero.
+	AutoscalingDisabled bool
+
+	// FailoverModels are the Models that the request can be routed to
+	// when the Model is unavailable (see Failover).
+	FailoverModels []string
+
 	Prefix string
 
+	// Stream is true if the body requests a streaming response.
+	// Always false when the body was not parsed.
+	Stream bool
+	// Deterministic is true if the body requests a temperature of 0, so that
+	// identical requests receive identical responses.
+	// Always false when the body was not parsed.
+	Deterministic bool
+	// Weight is the number of active requests that the request counts as
+	// when autoscaling (see v1.ModelSpec.StreamingRequestWeight).
+	Weight int64
+
 	ContentLength int64
 }
 
 type ModelClient interface {
-	LookupModel(ctx context.Context, model, adapter string, selectors []string) (*v1.Model, error)
+	ResolveModel(ctx context.Context, model, adapter string, selectors []string) (*v1.Model, bool, error)
+	ResolveVersioned(ctx context.Context, model, version string) (string, bool, error)
+	ResolveCapable(ctx context.Context, capabilities, selectors []string) (string, bool, error)
 }
 
-func ParseRequest(ctx context.Context, client ModelClient, body io.Reader, path string, headers http.Header) (*Request, error) {
+// ParseRequest reads the requested model from the body of the request.
+// If modelHeader is not empty and the request has that header, the model is read
+// from the header instead and the body is passed through without being parsed
+// (unless the model needs the body for load balancing).
+// gRPC requests always specify the model in the header, or in the
+// GRPCModelHeader metadata, and their body is never parsed.
+func ParseRequest(ctx context.Context, client ModelClient, body io.Reader, path string, headers http.Header, modelHeader string) (*Request, error) {
 	r := &Request{
 		ID: uuid.New().String(),
 	}
 
 	r.Selectors = headers.Values("X-Label-Selector")
+	r.Version = headers.Get(ModelVersionHeader)
+	r.Capabilities = parseCapabilityHeader(headers.Values(ModelCapabilityHeader))
+	if isGRPC(headers.Get("Content-Type")) {
+		r.GRPC = true
+		model := headers.Get(GRPCModelHeader)
+		if modelHeader != "" && headers.Get(modelHeader) != "" {
+			model = headers.Get(modelHeader)
+		}
+		if model == "" {
+			return nil, fmt.Errorf("%w: missing %q metadata", ErrBadRequest, strings.ToLower(GRPCModelHeader))
+		}
+		if err := r.readRawBody(body); err != nil {
+			return nil, fmt.Errorf("%w: reading body: %w", ErrBadRequest, err)
+		}
+		r.RequestedModel = model
+		r.Model, r.Adapter = SplitModelAdapter(model)
+		if err := r.lookupModel(ctx, client, path); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
+	if modelHeader != "" {
+		if model := headers.Get(modelHeader); model != "" {
+			if err := r.readRawBody(body); err != nil {
+				return nil, fmt.Errorf("%w: reading body: %w", ErrBadRequest, err)
+			}
+			r.RequestedModel = model
+			r.Model, r.Adapter = SplitModelAdapter(model)
+			if err := r.lookupModel(ctx, client, path); err != nil {
+				return nil, err
+			}
+			return r, nil
+		}
+	}
+	r.bodyParsed = true
 
 	// Parse media type (with params - which are used for multipart form data)
 	var (
@@ -149,6 +254,23 @@ func (r *Request) readyMultiPartBody(body io.Reader, mediaParams map[string]stri
 	return nil
 }
 
+// isGRPC returns true if the content type is that of a gRPC request
+// (i.e. "application/grpc" or "application/grpc+proto").
+func isGRPC(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/grpc" || strings.HasPrefix(mediaType, "application/grpc+"))
+}
+
+func (r *Request) readRawBody(body io.Reader) error {
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	r.Body = b
+	r.ContentLength = int64(len(r.Body))
+	return nil
+}
+
 func (r *Request) readJSONBody(body io.Reader) error {
 	var payload map[string]interface{}
 	if err := json.NewDecoder(body).Decode(&payload); err != nil {
@@ -156,18 +278,24 @@ func (r *Request) readJSONBody(body io.Reader) error {
 	}
 
 	modelInf, ok := payload["model"]
-	if !ok {
+	if !ok && len(r.Capabilities) == 0 {
+		// The model is optional if it is resolved by its capabilities.
 		return fmt.Errorf("missing 'model' field")
 	}
 	r.bodyPayload = payload
 
-	modelStr, ok := modelInf.(string)
-	if !ok {
+	modelStr, isString := modelInf.(string)
+	if ok && !isString {
 		return fmt.Errorf("field 'model' should be a string")
 	}
 
 	r.RequestedModel = modelStr
 	r.Model, r.Adapter = SplitModelAdapter(modelStr)
+	r.bodyJSON = true
+	r.Stream, _ = payload["stream"].(bool)
+	if temperature, ok := payload["temperature"].(float64); ok && temperature == 0 {
+		r.Deterministic = true
+	}
 
 	if r.Adapter != "" {
 		// vLLM expects the adapter to be in the model field.
@@ -185,15 +313,48 @@ func (r *Request) readJSONBody(body io.Reader) error {
 }
 
 func (r *Request) lookupModel(ctx context.Context, client ModelClient, path string) error {
-	model, err := client.LookupModel(ctx, r.Model, r.Adapter, r.Selectors)
+	model, err := r.lookupCapableModel(ctx, client)
 	if err != nil {
-		return fmt.Errorf("lookup model: %w", err)
+		return err
+	}
+	if model == nil {
+		model, err = r.lookupVersionedModel(ctx, client)
+		if err != nil {
+			return err
+		}
+	}
+	if model == nil && r.Model == "" && len(r.Capabilities) > 0 {
+		// Only capabilities were requested.
+		return fmt.Errorf("%w: no model with capabilities %q", ErrModelNotFound, r.Capabilities)
+	}
+	var fallback bool
+	if model == nil {
+		model, fallback, err = client.ResolveModel(ctx, r.Model, r.Adapter, r.Selectors)
+		if err != nil {
+			return fmt.Errorf("lookup model: %w", err)
+		}
 	}
 	if model == nil {
 		return fmt.Errorf("%w: %q", ErrModelNotFound, r.RequestedModel)
 	}
+	if fallback {
+		// The request body is passed through unmodified so that the fallback
+		// model can see which model was requested.
+		r.Model, r.Adapter, r.Fallback = model.Name, "", true
+	}
 
+	r.ResolvedModel = model
 	r.LoadBalancing = model.Spec.LoadBalancing
+	r.AutoscalingDisabled = model.Spec.AutoscalingDisabled
+	r.FailoverModels = model.Spec.FailoverModels
+	r.setWeight(model)
+
+	if r.LoadBalancing.Strategy == v1.PrefixHashStrategy && !r.bodyParsed && !r.GRPC {
+		// The prefix is read from the body.
+		if err := json.Unmarshal(r.Body, &r.bodyPayload); err != nil {
+			return fmt.Errorf("%w: decoding body: %w", ErrBadRequest, err)
+		}
+	}
 
 	if r.LoadBalancing.Strategy == v1.PrefixHashStrategy && r.bodyPayload != nil {
 		defer func() {
@@ -218,6 +379,124 @@ func (r *Request) lookupModel(ctx context.Context, client ModelClient, path stri
 	return nil
 }
 
+// lookupVersionedModel returns the Model that serves the requested version of the
+// model. Returns nil if no version was requested or the version is unknown (the
+// request is then routed to the unversioned model).
+func (r *Request) lookupVersionedModel(ctx context.Context, client ModelClient) (*v1.Model, error) {
+	if r.Version == "" {
+		return nil, nil
+	}
+	name, ok, err := client.ResolveVersioned(ctx, r.Model, r.Version)
+	if err != nil {
+		return nil, fmt.Errorf("lookup model version: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	model, _, err := client.ResolveModel(ctx, name, r.Adapter, r.Selectors)
+	if err != nil {
+		return nil, fmt.Errorf("lookup model version: %w", err)
+	}
+	if model == nil || model.Name != name {
+		// The Model of the version does not match the request (i.e. the adapter).
+		return nil, nil
+	}
+	if err := r.rewriteModel(model.Name); err != nil {
+		return nil, err
+	}
+	r.Model, r.Versioned = model.Name, true
+	return model, nil
+}
+
+// lookupCapableModel returns the best available Model with the requested
+// capabilities. Returns nil if no capabilities were requested or no Model has
+// them (the request is then routed to the requested model).
+func (r *Request) lookupCapableModel(ctx context.Context, client ModelClient) (*v1.Model, error) {
+	if len(r.Capabilities) == 0 {
+		return nil, nil
+	}
+	name, ok, err := client.ResolveCapable(ctx, r.Capabilities, r.Selectors)
+	if err != nil {
+		return nil, fmt.Errorf("lookup model capabilities: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	model, _, err := client.ResolveModel(ctx, name, r.Adapter, r.Selectors)
+	if err != nil {
+		return nil, fmt.Errorf("lookup model capabilities: %w", err)
+	}
+	if model == nil || model.Name != name {
+		// The Model does not match the request (i.e. the adapter or selectors).
+		return nil, nil
+	}
+	if err := r.rewriteModel(model.Name); err != nil {
+		return nil, err
+	}
+	r.Model, r.Capable = model.Name, true
+	return model, nil
+}
+
+// parseCapabilityHeader returns the capabilities of the values of the
+// ModelCapabilityHeader.
+func parseCapabilityHeader(values []string) []string {
+	var capabilities []string
+	for _, v := range values {
+		for _, capability := range strings.Split(v, ",") {
+			if capability = strings.TrimSpace(capability); capability != "" {
+				capabilities = append(capabilities, capability)
+			}
+		}
+	}
+	return capabilities
+}
+
+// Failover routes the request to the given failover Model instead of the
+// resolved Model. The "model" field of the body is rewritten (unless it names
+// an adapter) so that the model server of the failover Model accepts the request.
+// The prefix of the request is kept.
+func (r *Request) Failover(model *v1.Model) error {
+	if !r.Fallback {
+		if err := r.rewriteModel(model.Name); err != nil {
+			return err
+		}
+	}
+
+	r.Model = model.Name
+	r.ResolvedModel = model
+	r.LoadBalancing = model.Spec.LoadBalancing
+	r.AutoscalingDisabled = model.Spec.AutoscalingDisabled
+	r.setWeight(model)
+	return nil
+}
+
+// rewriteModel rewrites the "model" field of a JSON body (unless it names an
+// adapter) so that the model server of the given Model accepts the request.
+func (r *Request) rewriteModel(name string) error {
+	if !r.bodyJSON || r.Adapter != "" {
+		return nil
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(r.Body, &payload); err != nil {
+		return fmt.Errorf("decoding body: %w", err)
+	}
+	payload["model"] = name
+	rewritten, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("remarshalling body: %w", err)
+	}
+	r.Body = rewritten
+	r.ContentLength = int64(len(r.Body))
+	return nil
+}
+
+func (r *Request) setWeight(model *v1.Model) {
+	r.Weight = 1
+	if r.Stream {
+		r.Weight = int64(model.Spec.GetStreamingRequestWeight())
+	}
+}
+
 func getPrefixForCompletionRequest(body map[string]interface{}, n int) (string, error) {
 	// Example request body:
 	// {
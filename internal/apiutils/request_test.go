@@ -10,6 +10,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 	v1 "github.com/substratusai/kubeai/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 )
 
 func Test_getPrefixForCompletionRequest(t *testing.T) {
@@ -115,13 +117,18 @@ func Test_firstNChars(t *testing.T) {
 
 func TestParseRequest(t *testing.T) {
 	cases := []struct {
-		name             string
-		body             string
-		path             string
-		headers          http.Header
-		expModel         string
-		expAdapter       string
-		expPrefix        string
+		name        string
+		body        string
+		path        string
+		headers     http.Header
+		modelHeader string
+		expModel    string
+		expAdapter  string
+		expPrefix   string
+		// expBody is checked if set.
+		expBody string
+		// expWeight defaults to 1.
+		expWeight        int64
 		expErrorContains []string
 	}{
 		{
@@ -134,6 +141,17 @@ func TestParseRequest(t *testing.T) {
 			body:     `{"model": "test-model"}`,
 			expModel: "test-model",
 		},
+		{
+			name:      "streaming",
+			body:      `{"model": "test-model", "stream": true}`,
+			expModel:  "test-model",
+			expWeight: 3,
+		},
+		{
+			name:     "not streaming",
+			body:     `{"model": "test-model", "stream": false}`,
+			expModel: "test-model",
+		},
 		{
 			name:       "model and adapter",
 			body:       `{"model": "test-model_test-adapter"}`,
@@ -169,6 +187,98 @@ func TestParseRequest(t *testing.T) {
 			expPrefix: "test-prefi", // "test-prefix" (max 10) --> "test-prefi"
 
 		},
+		{
+			name:        "model header",
+			body:        `{"prompt": "test-prefix"}`,
+			path:        "/v1/completions",
+			headers:     http.Header{"X-Model": []string{"test-model_test-adapter"}},
+			modelHeader: "X-Model",
+			expModel:    "test-model",
+			expAdapter:  "test-adapter",
+			expPrefix:   "test-prefi",
+		},
+		{
+			name:        "model header not set",
+			body:        `{"model": "test-model"}`,
+			modelHeader: "X-Model",
+			expModel:    "test-model",
+		},
+		{
+			name:      "model version",
+			body:      `{"model": "test-model", "prompt": "test-prefix"}`,
+			path:      "/v1/completions",
+			headers:   http.Header{"X-Model-Version": []string{"v2"}},
+			expModel:  "test-model-v2",
+			expBody:   `{"model":"test-model-v2","prompt":"test-prefix"}`,
+			expPrefix: "test-prefi",
+		},
+		{
+			name:     "unknown model version",
+			body:     `{"model": "test-model"}`,
+			headers:  http.Header{"X-Model-Version": []string{"v3"}},
+			expModel: "test-model",
+		},
+		{
+			name:      "model capabilities",
+			body:      `{"model": "any", "prompt": "test-prefix"}`,
+			path:      "/v1/completions",
+			headers:   http.Header{"X-Model-Capability": []string{"chat, 7b"}},
+			expModel:  "test-chat-7b",
+			expBody:   `{"model":"test-chat-7b","prompt":"test-prefix"}`,
+			expPrefix: "test-prefi",
+		},
+		{
+			name:     "repeated model capability headers",
+			body:     `{"model": "any"}`,
+			headers:  http.Header{"X-Model-Capability": []string{"chat", "7b"}},
+			expModel: "test-chat-7b",
+		},
+		{
+			name:     "unknown model capabilities",
+			body:     `{"model": "test-model"}`,
+			headers:  http.Header{"X-Model-Capability": []string{"vision"}},
+			expModel: "test-model",
+		},
+		{
+			name:     "model capabilities without model",
+			body:     `{"prompt": "test-prefix"}`,
+			headers:  http.Header{"X-Model-Capability": []string{"chat, 7b"}},
+			expModel: "test-chat-7b",
+			expBody:  `{"model":"test-chat-7b","prompt":"test-prefix"}`,
+		},
+		{
+			name:             "unknown model capabilities without model",
+			body:             `{"prompt": "test-prefix"}`,
+			headers:          http.Header{"X-Model-Capability": []string{"vision"}},
+			expErrorContains: []string{"model not found", "vision"},
+		},
+		{
+			name:        "grpc",
+			body:        "\x00\x00\x00\x00\x02\x08\x01",
+			headers:     http.Header{"Content-Type": []string{"application/grpc+proto"}, "Kubeai-Model": []string{"test-model_test-adapter"}},
+			expModel:    "test-model",
+			expAdapter:  "test-adapter",
+			modelHeader: "X-Model",
+		},
+		{
+			name:        "grpc model header",
+			body:        "\x00\x00\x00\x00\x00",
+			headers:     http.Header{"Content-Type": []string{"application/grpc"}, "Kubeai-Model": []string{"other-model"}, "X-Model": []string{"test-model"}},
+			modelHeader: "X-Model",
+			expModel:    "test-model",
+		},
+		{
+			name:             "grpc missing model",
+			body:             `{"model": "test-model"}`,
+			headers:          http.Header{"Content-Type": []string{"application/grpc"}},
+			expErrorContains: []string{"bad request", "kubeai-model"},
+		},
+		{
+			name:             "model header disabled",
+			body:             `{}`,
+			headers:          http.Header{"X-Model": []string{"test-model"}},
+			expErrorContains: []string{"bad request"},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -176,7 +286,7 @@ func TestParseRequest(t *testing.T) {
 
 			mockClient := &mockModelClient{prefixCharLen: 10}
 
-			req, err := ParseRequest(ctx, mockClient, bytes.NewReader([]byte(c.body)), c.path, c.headers)
+			req, err := ParseRequest(ctx, mockClient, bytes.NewReader([]byte(c.body)), c.path, c.headers, c.modelHeader)
 			if c.expErrorContains != nil {
 				for _, ec := range c.expErrorContains {
 					require.ErrorContains(t, err, ec)
@@ -189,6 +299,14 @@ func TestParseRequest(t *testing.T) {
 			require.Equal(t, c.expModel, req.Model)
 			require.Equal(t, c.expAdapter, req.Adapter)
 			require.Equal(t, c.expPrefix, req.Prefix)
+			if c.expBody != "" {
+				require.JSONEq(t, c.expBody, string(req.Body))
+			}
+			expWeight := c.expWeight
+			if expWeight == 0 {
+				expWeight = 1
+			}
+			require.Equal(t, expWeight, req.Weight)
 		})
 	}
 
@@ -198,9 +316,11 @@ type mockModelClient struct {
 	prefixCharLen int
 }
 
-func (m *mockModelClient) LookupModel(ctx context.Context, model, adapter string, selectors []string) (*v1.Model, error) {
+func (m *mockModelClient) ResolveModel(ctx context.Context, model, adapter string, selectors []string) (*v1.Model, bool, error) {
 	return &v1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: model},
 		Spec: v1.ModelSpec{
+			StreamingRequestWeight: ptr.To[int32](3),
 			LoadBalancing: v1.LoadBalancing{
 				Strategy: v1.PrefixHashStrategy,
 				PrefixHash: v1.PrefixHash{
@@ -209,5 +329,19 @@ func (m *mockModelClient) LookupModel(ctx context.Context, model, adapter string
 				},
 			},
 		},
-	}, nil
+	}, false, nil
+}
+
+func (m *mockModelClient) ResolveVersioned(ctx context.Context, model, version string) (string, bool, error) {
+	if model == "test-model" && version == "v2" {
+		return "test-model-v2", true, nil
+	}
+	return "", false, nil
+}
+
+func (m *mockModelClient) ResolveCapable(ctx context.Context, capabilities, selectors []string) (string, bool, error) {
+	if len(capabilities) == 2 && capabilities[0] == "chat" && capabilities[1] == "7b" {
+		return "test-chat-7b", true, nil
+	}
+	return "", false, nil
 }
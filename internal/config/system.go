@@ -31,14 +31,48 @@ type System struct {
 	// Defaults to ":8081"
 	HealthAddress string `json:"healthAddress" validate:"required"`
 
+	// ExternalScalerAddr is the address that the KEDA external scaler gRPC API binds to.
+	// The external scaler is disabled when empty (default).
+	ExternalScalerAddr string `json:"externalScalerAddr"`
+
+	// AdminAddr is the address that the admin API (i.e. /admin/models/<model>/scale)
+	// binds to. The admin API is not authenticated, so it should be bound to a
+	// loopback address (i.e. "127.0.0.1:8082") and accessed with port forwarding.
+	// The admin API is disabled when empty (default).
+	AdminAddr string `json:"adminAddr"`
+
+	// WebhookAddr is the address that the validating admission webhook (which
+	// rejects Models, Deployments and Pods with invalid KubeAI annotations) binds
+	// to, i.e. ":9443". The webhook is served over TLS with the certificate in
+	// WebhookCertDir and is disabled when empty (default).
+	WebhookAddr string `json:"webhookAddr"`
+
+	// WebhookCertDir is the directory containing the tls.crt and tls.key of the
+	// webhook server. Defaults to the controller-runtime default when empty.
+	WebhookCertDir string `json:"webhookCertDir"`
+
 	ModelAutoscaling ModelAutoscaling `json:"modelAutoscaling" validate:"required"`
 
 	ModelServerPods ModelServerPods `json:"modelServerPods,omitempty"`
 
 	ModelRollouts ModelRollouts `json:"modelRollouts"`
 
+	ModelRouting ModelRouting `json:"modelRouting"`
+
 	LeaderElection LeaderElection `json:"leaderElection"`
 
+	// AnnotationDomains are additional domains that Model annotations
+	// (i.e. "<domain>/scale-target") are read from. The "kubeai.org" and
+	// "lingo.substratus.ai" domains are always accepted.
+	AnnotationDomains []string `json:"annotationDomains,omitempty"`
+
+	// ModelSelector is a label selector (i.e. "team=ml-platform") that restricts
+	// the Models that KubeAI manages (Pods and replicas). Models that do not match
+	// are ignored, even if they are annotated with "kubeai.org/managed: true".
+	// The managed annotation can still disable management of matching Models.
+	// All Models are managed when empty (default).
+	ModelSelector string `json:"modelSelector"`
+
 	// AllowPodAddressOverride will allow the pod address to be overridden by the Model objects. Useful for development purposes.
 	AllowPodAddressOverride bool `json:"allowPodAddressOverride"`
 
@@ -114,16 +148,142 @@ type LeaderElection struct {
 type ModelRollouts struct {
 	// Surge is the number of additional Pods to create when rolling out an update.
 	Surge int32 `json:"surge"`
+	// ReadyTimeout is the time after which a Model that has Pods but never had a
+	// ready replica is reported with the NeverReady condition and a warning Event.
+	// Disabled when 0 (default).
+	ReadyTimeout Duration `json:"readyTimeout"`
+	// ConvergingRequeueInterval is the interval at which a Model is reconciled
+	// again while not all of its desired replicas are ready (in addition to
+	// reconciles that are triggered by Pod events).
+	// Disabled when 0 (default).
+	ConvergingRequeueInterval Duration `json:"convergingRequeueInterval"`
+}
+
+type ModelRouting struct {
+	// IdleTTL is the amount of time after which the routing state for a model
+	// is evicted if the model has no ready endpoints and has not received any requests.
+	// The routing state is re-created when the model is reconciled or requested again.
+	// Eviction is disabled when unset (default).
+	IdleTTL Duration `json:"idleTTL"`
+	// RejectWhenSaturated causes requests to be rejected with a 429 status code
+	// when a model is running at its max replicas and the autoscaler would
+	// scale beyond it. Requests are queued when disabled (default).
+	RejectWhenSaturated bool `json:"rejectWhenSaturated"`
+	// FallbackModel is the name of a Model that requests are routed to
+	// when the requested model does not exist. Disabled when empty (default).
+	FallbackModel string `json:"fallbackModel"`
+	// ModelHeader is the name of a request header (i.e. "X-Model") that clients
+	// can specify the model in. Requests with the header are routed without
+	// parsing the request body. Disabled when empty (default).
+	ModelHeader string `json:"modelHeader"`
+	// MaxQueueWait is the maximum time that a request waits for an available
+	// endpoint of a model (i.e. while the model scales up from zero). Requests
+	// that wait longer are rejected with a 503 status code. Can be overridden
+	// per model with the kubeai.org/max-queue-wait annotation.
+	// Disabled when 0 (default).
+	MaxQueueWait Duration `json:"maxQueueWait"`
+	// PodSelector is a label selector (i.e. "platform.example.com/kubeai=true")
+	// that restricts the model Pods that requests are routed to. Pods that do
+	// not match are not reconciled, which reduces load in namespaces with
+	// many Pods. Applies to Pods created by KubeAI as well.
+	// All model Pods are used when empty (default).
+	PodSelector string `json:"podSelector"`
+	// CurrentGenerationOnly restricts routing to the ready Pods of the latest
+	// generation of a model (by the "pod-hash" label of Pods created by KubeAI
+	// or the "pod-template-hash" label of Deployment Pods) during a rollout.
+	// Pods of older generations are only routed to until a Pod of the latest
+	// generation is ready. Disabled when false (default).
+	CurrentGenerationOnly bool `json:"currentGenerationOnly"`
+	// PodModelsAnnotationName is the name of the Pod annotation (under the
+	// annotation domains) that lists the additional models that a Pod serves,
+	// i.e. "served-models" for "kubeai.org/served-models".
+	// Defaults to "models" when empty.
+	PodModelsAnnotationName string `json:"podModelsAnnotationName"`
 }
 
 type ModelAutoscaling struct {
-	// Interval is the time between each autoscaling check.
+	// Interval is the time between each autoscaling check. Every Model is
+	// re-evaluated on each check, also when no requests were received.
 	// Defaults to 10 seconds.
 	Interval Duration `json:"interval" validate:"required"`
 	// TimeWindow that the autoscaling algorithm will consider when
 	// calculating the average number of requests.
 	// Defaults to 10 minutes.
 	TimeWindow Duration `json:"timeWindow" validate:"required"`
+	// ScaleDownJitter is the maximum random delay that is added on top of
+	// a Model's ScaleDownDelay. Spreads out scale-downs of Models that
+	// become idle at the same time.
+	// Defaults to 0 (no jitter).
+	ScaleDownJitter Duration `json:"scaleDownJitter"`
+	// ScaleDebounceInterval is the minimum time between writes to the replicas
+	// of a Model. Scale operations within the interval are coalesced and the
+	// latest value is written once the interval has passed. Reduces writes
+	// during request bursts.
+	// Disabled when 0 (default).
+	ScaleDebounceInterval Duration `json:"scaleDebounceInterval"`
+	// ScaleToZeroDrainDelay is the time after which the requests for a Model are
+	// checked again before it is scaled to zero. The Model is kept at one replica
+	// if any requests were received in the meantime (or are queued), so that
+	// requests are not stranded by a scale down that was decided right before
+	// they were received.
+	// Disabled when 0 (default). Scale downs to zero are always refused while
+	// requests are queued.
+	ScaleToZeroDrainDelay Duration `json:"scaleToZeroDrainDelay"`
+	// WarmupGrace is the time over which the capacity of a newly ready replica
+	// ramps in when scaling on latency (see Model .spec.targetLatencyMilliseconds).
+	// Avoids overshooting while model servers warm up after reporting readiness.
+	// Disabled when 0 (default).
+	WarmupGrace Duration `json:"warmupGrace"`
+	// UnschedulableTimeout is the time after which a Pod that can not be scheduled
+	// is treated as a sign that the cluster can not provide more capacity for a Model.
+	// When this happens, the autoscaler backs off to the replicas that could be
+	// scheduled and stops scaling the Model up beyond them, except for one replica
+	// that is tried once per UnschedulableTimeout, until more Pods are scheduled.
+	// Requests for the Model are rejected while it has no ready replicas.
+	// Disabled when 0 (default).
+	UnschedulableTimeout Duration `json:"unschedulableTimeout"`
+	// ScaleUpTolerance and ScaleDownTolerance add hysteresis to the rounding of
+	// the desired replicas that are calculated from the active requests (as a
+	// fraction of a replica). A Model is only scaled up once the load exceeds
+	// the current replicas by more than ScaleUpTolerance, and only scaled down
+	// to the replicas that leave at least ScaleDownTolerance of headroom.
+	// Reduces oscillation when the load is close to a multiple of the target
+	// requests. Disabled when 0 (default).
+	ScaleUpTolerance   float64 `json:"scaleUpTolerance" validate:"gte=0,lt=1"`
+	ScaleDownTolerance float64 `json:"scaleDownTolerance" validate:"gte=0,lt=1"`
+	// MaxTotalReplicas is the replica budget across all Models: scale ups are
+	// clamped so that the total replicas of all managed Models do not exceed
+	// it. Only Models with a lower priority are scaled down to make room
+	// (see kubeaiv1.ModelPriorityAnnotation) and force scales are not limited.
+	// Disabled when 0 (default).
+	MaxTotalReplicas int32 `json:"maxTotalReplicas" validate:"gte=0"`
+	// AuditLog is the destination that every change of the replicas of a Model
+	// is recorded to (timestamp, actor, model, and replicas): a file path that
+	// JSON lines are appended to, or an http(s) URL that each change is POSTed
+	// to as JSON in the background. Disabled when empty (default).
+	AuditLog string `json:"auditLog,omitempty"`
+	// UseScalePatch writes the replicas of Models with a patch of the scale
+	// subresource instead of an update. Patches do not conflict with
+	// concurrent writes to the Model. Can be overridden per Model with the
+	// kubeaiv1.ModelScaleUpdateStrategyAnnotation. Disabled by default.
+	UseScalePatch bool `json:"useScalePatch"`
+	// RespectPodDisruptionBudgets prevents scale downs below the minAvailable
+	// of the PodDisruptionBudgets that select the Pods of a Model (absolute
+	// values only, percentages and maxUnavailable do not imply a minimum).
+	// Requires permissions to list PodDisruptionBudgets. Disabled by default.
+	RespectPodDisruptionBudgets bool `json:"respectPodDisruptionBudgets"`
+	// ReplicaBoundsFromHPA reads the replica bounds of a Model from the
+	// HorizontalPodAutoscaler that targets its scale target (i.e. a Deployment)
+	// when the Model does not set them: maxReplicas when unset, and minReplicas
+	// when 0 for Models with the kubeai.org/min-replicas-from-hpa: "true"
+	// annotation. Bounds on the Model take precedence. Requires permissions to
+	// list HorizontalPodAutoscalers. Disabled by default.
+	ReplicaBoundsFromHPA bool `json:"replicaBoundsFromHPA"`
+	// ObserveOnly calculates the desired replicas of Models and reports them
+	// in metrics without ever changing the replicas of Models (i.e. to evaluate
+	// the autoscaler alongside an existing one). Force scales are still
+	// applied. Disabled by default.
+	ObserveOnly bool `json:"observeOnly"`
 	// StateConfigMapName is the name of the ConfigMap that will be used
 	// to store the state of the autoscaler. This ConfigMap ensures that
 	// the autoscaler can recover from crashes and restarts without losing
@@ -139,6 +299,12 @@ func (a *ModelAutoscaling) RequiredConsecutiveScaleDowns(scaleDownDelaySeconds i
 	return int(math.Ceil(float64(time.Duration(scaleDownDelaySeconds)*time.Second) / float64(a.Interval.Duration)))
 }
 
+// MaxScaleDownJitterCount returns the maximum number of additional consecutive
+// scale down operations that may be required as a result of the ScaleDownJitter.
+func (a *ModelAutoscaling) MaxScaleDownJitterCount() int {
+	return int(math.Ceil(float64(a.ScaleDownJitter.Duration) / float64(a.Interval.Duration)))
+}
+
 // AverageWindowCount returns the number of intervals that will be considered when
 // calculating the average value.
 func (a *ModelAutoscaling) AverageWindowCount() int {
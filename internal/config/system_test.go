@@ -15,6 +15,7 @@ func TestAutoscalingConfig(t *testing.T) {
 		scaleDownDelaySeconds                 int64
 		expectedRequiredConsecutiveScaleDowns int
 		expectedAverageWindowCount            int
+		expectedMaxScaleDownJitterCount       int
 	}{
 		{
 			name: "default",
@@ -47,11 +48,24 @@ func TestAutoscalingConfig(t *testing.T) {
 			expectedRequiredConsecutiveScaleDowns: 2,
 			expectedAverageWindowCount:            3,
 		},
+		{
+			name: "with-jitter",
+			cfg: config.ModelAutoscaling{
+				Interval:        config.Duration{Duration: 10 * time.Second},
+				TimeWindow:      config.Duration{Duration: 10 * time.Minute},
+				ScaleDownJitter: config.Duration{Duration: 25 * time.Second},
+			},
+			scaleDownDelaySeconds:                 30,
+			expectedRequiredConsecutiveScaleDowns: 3,
+			expectedAverageWindowCount:            60,
+			expectedMaxScaleDownJitterCount:       3,
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			require.Equal(t, c.expectedRequiredConsecutiveScaleDowns, c.cfg.RequiredConsecutiveScaleDowns(c.scaleDownDelaySeconds))
+			require.Equal(t, c.expectedMaxScaleDownJitterCount, c.cfg.MaxScaleDownJitterCount())
 		})
 	}
 }
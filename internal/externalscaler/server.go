@@ -0,0 +1,175 @@
+// Package externalscaler implements the KEDA external scaler gRPC API
+// (https://keda.sh/docs/latest/concepts/external-scalers/) so that KEDA
+// ScaledObjects can be driven by the active request counts that KubeAI tracks,
+// or by the replicas that KubeAI decided on (see v1.ScalingBackendKEDA).
+//
+// The generated code in this package is based on externalscaler.proto.
+package externalscaler
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/substratusai/kubeai/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MetadataModel is the ScaledObject trigger metadata key that specifies the Model.
+	MetadataModel = "model"
+	// MetadataMetric is the ScaledObject trigger metadata key that specifies the
+	// metric that is served: MetricActiveRequests (default) or MetricDesiredReplicas.
+	MetadataMetric = "metric"
+
+	// MetricActiveRequests is the total number of active requests for the Model,
+	// with the TargetRequests of the Model as the target.
+	MetricActiveRequests = "activeRequests"
+	// MetricDesiredReplicas is the number of replicas that KubeAI decided on for a
+	// Model that is scaled by KEDA (see v1.ScalingBackendKEDA), with a target of 1
+	// so that KEDA scales to exactly that number of replicas.
+	MetricDesiredReplicas = "desiredReplicas"
+)
+
+type ModelClient interface {
+	LookupModel(ctx context.Context, model, adapter string, selectors []string) (*v1.Model, error)
+	// DesiredReplicas returns the replicas that KubeAI decided on for the Model.
+	DesiredReplicas(model *v1.Model) int32
+}
+
+type MetricsSource interface {
+	// ActiveRequests returns the total number of active requests for a model
+	// across all KubeAI instances.
+	ActiveRequests(ctx context.Context, model string) (int64, error)
+}
+
+// Server serves the KEDA external scaler API.
+type Server struct {
+	UnimplementedExternalScalerServer
+
+	modelClient    ModelClient
+	metrics        MetricsSource
+	streamInterval time.Duration
+}
+
+func NewServer(modelClient ModelClient, metrics MetricsSource, streamInterval time.Duration) *Server {
+	return &Server{
+		modelClient:    modelClient,
+		metrics:        metrics,
+		streamInterval: streamInterval,
+	}
+}
+
+func (s *Server) IsActive(ctx context.Context, ref *ScaledObjectRef) (*IsActiveResponse, error) {
+	model, metric, err := s.lookupModel(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	value, err := s.metricValue(ctx, model, metric)
+	if err != nil {
+		return nil, err
+	}
+	return &IsActiveResponse{Result: value > 0}, nil
+}
+
+func (s *Server) StreamIsActive(ref *ScaledObjectRef, stream ExternalScaler_StreamIsActiveServer) error {
+	ticker := time.NewTicker(s.streamInterval)
+	defer ticker.Stop()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			resp, err := s.IsActive(ctx, ref)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetMetricSpec returns the Model's TargetRequests as the target value of the
+// active requests, so that KEDA calculates the desired replicas the same way
+// that the KubeAI autoscaler does: ceil(activeRequests / targetRequests).
+// The desired replicas have a target value of 1.
+func (s *Server) GetMetricSpec(ctx context.Context, ref *ScaledObjectRef) (*GetMetricSpecResponse, error) {
+	model, metric, err := s.lookupModel(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	target := int64(1)
+	if metric == MetricActiveRequests {
+		target = int64(model.Spec.GetTargetRequests())
+	}
+	return &GetMetricSpecResponse{
+		MetricSpecs: []*MetricSpec{{
+			MetricName:      metric,
+			TargetSize:      target,
+			TargetSizeFloat: float64(target),
+		}},
+	}, nil
+}
+
+func (s *Server) GetMetrics(ctx context.Context, req *GetMetricsRequest) (*GetMetricsResponse, error) {
+	model, metric, err := s.lookupModel(ctx, req.ScaledObjectRef)
+	if err != nil {
+		return nil, err
+	}
+	value, err := s.metricValue(ctx, model, metric)
+	if err != nil {
+		return nil, err
+	}
+	return &GetMetricsResponse{
+		MetricValues: []*MetricValue{{
+			MetricName:       metric,
+			MetricValue:      value,
+			MetricValueFloat: float64(value),
+		}},
+	}, nil
+}
+
+// lookupModel returns the Model and the metric of the ScaledObject.
+func (s *Server) lookupModel(ctx context.Context, ref *ScaledObjectRef) (*v1.Model, string, error) {
+	metadata := ref.GetScalerMetadata()
+	name := metadata[MetadataModel]
+	if name == "" {
+		return nil, "", status.Errorf(codes.InvalidArgument, "missing %q in scaler metadata", MetadataModel)
+	}
+	metric := metadata[MetadataMetric]
+	switch metric {
+	case "":
+		metric = MetricActiveRequests
+	case MetricActiveRequests, MetricDesiredReplicas:
+	default:
+		return nil, "", status.Errorf(codes.InvalidArgument, "invalid %q in scaler metadata: %q, expected %q or %q",
+			MetadataMetric, metric, MetricActiveRequests, MetricDesiredReplicas)
+	}
+	model, err := s.modelClient.LookupModel(ctx, name, "", nil)
+	if err != nil {
+		return nil, "", status.Errorf(codes.Internal, "looking up model: %v", err)
+	}
+	if model == nil {
+		return nil, "", status.Errorf(codes.NotFound, "model not found: %q", name)
+	}
+	return model, metric, nil
+}
+
+func (s *Server) metricValue(ctx context.Context, model *v1.Model, metric string) (int64, error) {
+	if metric == MetricDesiredReplicas {
+		return int64(s.modelClient.DesiredReplicas(model)), nil
+	}
+	return s.activeRequests(ctx, model)
+}
+
+func (s *Server) activeRequests(ctx context.Context, model *v1.Model) (int64, error) {
+	active, err := s.metrics.ActiveRequests(ctx, model.Name)
+	if err != nil {
+		return 0, status.Errorf(codes.Unavailable, "getting active requests: %v", err)
+	}
+	return active, nil
+}
@@ -0,0 +1,119 @@
+package externalscaler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "github.com/substratusai/kubeai/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer(t *testing.T) {
+	models := &testModelClient{models: map[string]*v1.Model{
+		"my-model": {
+			ObjectMeta: metav1.ObjectMeta{Name: "my-model"},
+			Spec:       v1.ModelSpec{TargetRequests: ptr.To[int32](5)},
+		},
+		"unset-target": {
+			ObjectMeta: metav1.ObjectMeta{Name: "unset-target"},
+		},
+	}}
+	metrics := &testMetricsSource{active: map[string]int64{"my-model": 12}}
+	s := NewServer(models, metrics, time.Second)
+	ctx := context.Background()
+
+	ref := &ScaledObjectRef{ScalerMetadata: map[string]string{MetadataModel: "my-model"}}
+
+	spec, err := s.GetMetricSpec(ctx, ref)
+	require.NoError(t, err)
+	require.Len(t, spec.MetricSpecs, 1)
+	require.Equal(t, int64(5), spec.MetricSpecs[0].TargetSize)
+
+	spec, err = s.GetMetricSpec(ctx, &ScaledObjectRef{ScalerMetadata: map[string]string{MetadataModel: "unset-target"}})
+	require.NoError(t, err)
+	require.Equal(t, int64(v1.DefaultTargetRequests), spec.MetricSpecs[0].TargetSize)
+
+	m, err := s.GetMetrics(ctx, &GetMetricsRequest{ScaledObjectRef: ref})
+	require.NoError(t, err)
+	require.Len(t, m.MetricValues, 1)
+	require.Equal(t, int64(12), m.MetricValues[0].MetricValue)
+
+	active, err := s.IsActive(ctx, ref)
+	require.NoError(t, err)
+	require.True(t, active.Result)
+
+	metrics.active["my-model"] = 0
+	active, err = s.IsActive(ctx, ref)
+	require.NoError(t, err)
+	require.False(t, active.Result)
+
+	_, err = s.IsActive(ctx, &ScaledObjectRef{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.IsActive(ctx, &ScaledObjectRef{ScalerMetadata: map[string]string{MetadataModel: "does-not-exist"}})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.IsActive(ctx, &ScaledObjectRef{ScalerMetadata: map[string]string{MetadataModel: "my-model", MetadataMetric: "unknown"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServerDesiredReplicas(t *testing.T) {
+	models := &testModelClient{
+		models: map[string]*v1.Model{
+			"my-model": {
+				ObjectMeta: metav1.ObjectMeta{Name: "my-model"},
+				Spec:       v1.ModelSpec{TargetRequests: ptr.To[int32](5)},
+			},
+		},
+		desired: map[string]int32{"my-model": 3},
+	}
+	s := NewServer(models, &testMetricsSource{}, time.Second)
+	ctx := context.Background()
+
+	ref := &ScaledObjectRef{ScalerMetadata: map[string]string{MetadataModel: "my-model", MetadataMetric: MetricDesiredReplicas}}
+
+	spec, err := s.GetMetricSpec(ctx, ref)
+	require.NoError(t, err)
+	require.Equal(t, MetricDesiredReplicas, spec.MetricSpecs[0].MetricName)
+	require.Equal(t, int64(1), spec.MetricSpecs[0].TargetSize)
+
+	m, err := s.GetMetrics(ctx, &GetMetricsRequest{ScaledObjectRef: ref})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), m.MetricValues[0].MetricValue)
+
+	active, err := s.IsActive(ctx, ref)
+	require.NoError(t, err)
+	require.True(t, active.Result)
+
+	models.desired["my-model"] = 0
+	active, err = s.IsActive(ctx, ref)
+	require.NoError(t, err)
+	require.False(t, active.Result)
+}
+
+type testModelClient struct {
+	models  map[string]*v1.Model
+	desired map[string]int32
+}
+
+func (c *testModelClient) LookupModel(ctx context.Context, model, adapter string, selectors []string) (*v1.Model, error) {
+	return c.models[model], nil
+}
+
+func (c *testModelClient) DesiredReplicas(model *v1.Model) int32 {
+	return c.desired[model.Name]
+}
+
+type testMetricsSource struct {
+	active map[string]int64
+}
+
+func (s *testMetricsSource) ActiveRequests(ctx context.Context, model string) (int64, error) {
+	return s.active[model], nil
+}
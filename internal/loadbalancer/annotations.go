@@ -0,0 +1,136 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	v1 "github.com/substratusai/kubeai/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ValidatePodAnnotations returns the problems with the routing annotations of the
+// Pod (see v1.PodModelsAnnotationName, v1.PodModelPortsAnnotationName and
+// v1.PodEndpointWeightAnnotationName). Typos
+// in these annotations otherwise only surface as requests that are not routed.
+// Also used by the validating admission webhook to reject Pods and Deployments
+// with invalid annotations (see the webhooks package).
+func (r *LoadBalancer) ValidatePodAnnotations(pod corev1.Pod) []string {
+	name := r.PodModelsAnnotationName
+	if name == "" {
+		name = v1.PodModelsAnnotationName
+	}
+
+	var problems []string
+	ann := pod.GetAnnotations()
+	if key, value, ok := v1.GetDomainAnnotation(ann, r.AnnotationDomains, name); ok {
+		var models []string
+		for _, model := range strings.Split(value, ",") {
+			model = strings.TrimSpace(model)
+			switch {
+			case model == "":
+			case slices.Contains(models, model):
+				problems = append(problems, fmt.Sprintf("%s annotation lists model %q more than once", key, model))
+			default:
+				models = append(models, model)
+			}
+		}
+		if len(models) == 0 {
+			problems = append(problems, fmt.Sprintf("%s annotation does not list any models", key))
+		}
+	}
+
+	if key, value, ok := v1.GetDomainAnnotation(ann, r.AnnotationDomains, v1.PodModelPortsAnnotationName); ok {
+		served := r.getPodModels(pod)
+		// Warm pool Pods serve the models that claim them.
+		_, warm := pod.Labels[v1.WarmPoolLabel]
+		var models []string
+		for _, entry := range strings.Split(value, ",") {
+			model, port, found := strings.Cut(strings.TrimSpace(entry), "=")
+			if !found || model == "" {
+				problems = append(problems, fmt.Sprintf("%s annotation entry %q: expected <model>=<port>", key, entry))
+				continue
+			}
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				problems = append(problems, fmt.Sprintf("%s annotation entry %q: invalid port %q", key, entry, port))
+			}
+			if slices.Contains(models, model) {
+				problems = append(problems, fmt.Sprintf("%s annotation lists model %q more than once", key, model))
+			}
+			if !warm && !slices.Contains(served, model) {
+				problems = append(problems, fmt.Sprintf("%s annotation lists model %q that the Pod does not serve", key, model))
+			}
+			models = append(models, model)
+		}
+	}
+
+	if key, value, ok := v1.GetDomainAnnotation(ann, r.AnnotationDomains, v1.PodEndpointWeightAnnotationName); ok {
+		if weight, err := strconv.ParseInt(value, 10, 64); err != nil || weight < 1 {
+			problems = append(problems, fmt.Sprintf("%s annotation %q: expected a positive integer", key, value))
+		}
+	}
+
+	return problems
+}
+
+// annotationWarning are the problems with the routing annotations of a Pod that
+// were last reported by warnInvalidAnnotations.
+type annotationWarning struct {
+	uid      types.UID
+	problems string
+	// evented is true once Events were emitted for the problems.
+	evented bool
+}
+
+// warnInvalidAnnotations logs and emits Events for the problems with the
+// routing annotations of the Pod (see ValidatePodAnnotations). Pods are
+// reconciled on every change, so problems are only reported again once they
+// change. Events are only emitted by the leader (see IsLeader), since every
+// instance reconciles all Pods.
+// Pods with invalid annotations are routed to as far as their annotations can be
+// parsed. They are only rejected if the validating admission webhook is enabled.
+func (r *LoadBalancer) warnInvalidAnnotations(pod *corev1.Pod) {
+	problems := r.ValidatePodAnnotations(*pod)
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}.String()
+	leader := r.IsLeader == nil || r.IsLeader.Load()
+
+	r.warnedAnnotationsMtx.Lock()
+	prev, ok := r.warnedAnnotations[key]
+	if len(problems) == 0 {
+		delete(r.warnedAnnotations, key)
+		r.warnedAnnotationsMtx.Unlock()
+		return
+	}
+	w := annotationWarning{uid: pod.UID, problems: strings.Join(problems, "\n")}
+	changed := !ok || prev.uid != w.uid || prev.problems != w.problems
+	w.evented = !changed && prev.evented
+	emit := r.Recorder != nil && leader && !w.evented
+	if emit {
+		w.evented = true
+	}
+	if r.warnedAnnotations == nil {
+		r.warnedAnnotations = map[string]annotationWarning{}
+	}
+	r.warnedAnnotations[key] = w
+	r.warnedAnnotationsMtx.Unlock()
+
+	for _, problem := range problems {
+		if changed {
+			log.Printf("WARNING: pod %s: %s", key, problem)
+		}
+		if emit {
+			r.Recorder.Event(pod, corev1.EventTypeWarning, "InvalidAnnotation", problem)
+		}
+	}
+}
+
+// forgetAnnotationWarnings removes the reported problems of a deleted Pod (see
+// warnInvalidAnnotations).
+func (r *LoadBalancer) forgetAnnotationWarnings(pod types.NamespacedName) {
+	r.warnedAnnotationsMtx.Lock()
+	delete(r.warnedAnnotations, pod.String())
+	r.warnedAnnotationsMtx.Unlock()
+}
@@ -0,0 +1,117 @@
+package loadbalancer
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "github.com/substratusai/kubeai/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestValidatePodAnnotations(t *testing.T) {
+	cases := map[string]struct {
+		labels      map[string]string
+		annotations map[string]string
+		expProblems []string
+	}{
+		"valid": {
+			annotations: map[string]string{
+				"kubeai.org/models":          "model-a, model-b",
+				"kubeai.org/model-ports":     "model-a=8000,model-b=8001",
+				"kubeai.org/endpoint-weight": "2",
+			},
+		},
+		"no annotations": {},
+		"empty models": {
+			annotations: map[string]string{"kubeai.org/models": " , "},
+			expProblems: []string{`kubeai.org/models annotation does not list any models`},
+		},
+		"duplicate models": {
+			annotations: map[string]string{"kubeai.org/models": "model-a,model-b,model-a"},
+			expProblems: []string{`kubeai.org/models annotation lists model "model-a" more than once`},
+		},
+		"invalid model ports": {
+			annotations: map[string]string{
+				"kubeai.org/model-ports": "model-a=http,model-a=8001,=8002,model-typo=8003",
+			},
+			expProblems: []string{
+				`kubeai.org/model-ports annotation entry "model-a=http": invalid port "http"`,
+				`kubeai.org/model-ports annotation lists model "model-a" more than once`,
+				`kubeai.org/model-ports annotation entry "=8002": expected <model>=<port>`,
+				`kubeai.org/model-ports annotation lists model "model-typo" that the Pod does not serve`,
+			},
+		},
+		"invalid endpoint weight": {
+			annotations: map[string]string{"kubeai.org/endpoint-weight": "0"},
+			expProblems: []string{`kubeai.org/endpoint-weight annotation "0": expected a positive integer`},
+		},
+		"warm pool pod": {
+			labels:      map[string]string{v1.WarmPoolLabel: "gpu"},
+			annotations: map[string]string{"kubeai.org/model-ports": "model-b=8000"},
+		},
+	}
+	r := &LoadBalancer{AnnotationDomains: v1.AnnotationDomains(nil)}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			labels := map[string]string{v1.PodModelLabel: "model-a"}
+			for k, v := range c.labels {
+				labels[k] = v
+			}
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: labels, Annotations: c.annotations}}
+			require.Equal(t, c.expProblems, r.ValidatePodAnnotations(pod))
+		})
+	}
+}
+
+func TestWarnInvalidAnnotations(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	isLeader := &atomic.Bool{}
+	r := &LoadBalancer{AnnotationDomains: v1.AnnotationDomains(nil), Recorder: recorder, IsLeader: isLeader}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "default",
+		Name:        "my-pod",
+		UID:         "uid-1",
+		Labels:      map[string]string{v1.PodModelLabel: "model-a"},
+		Annotations: map[string]string{"kubeai.org/model-ports": "model-a=0"},
+	}}
+	events := func() int {
+		n := len(recorder.Events)
+		for range n {
+			<-recorder.Events
+		}
+		return n
+	}
+
+	// Only the leader emits Events.
+	r.warnInvalidAnnotations(pod)
+	require.Equal(t, 0, events())
+	isLeader.Store(true)
+	r.warnInvalidAnnotations(pod)
+	require.Equal(t, 1, events())
+
+	// Repeated reconciles do not emit Events again.
+	r.warnInvalidAnnotations(pod)
+	require.Equal(t, 0, events())
+
+	// Changed problems and recreated Pods are reported again.
+	pod.Annotations["kubeai.org/model-ports"] = "model-a=x"
+	r.warnInvalidAnnotations(pod)
+	require.Equal(t, 1, events())
+	pod.UID = "uid-2"
+	r.warnInvalidAnnotations(pod)
+	require.Equal(t, 1, events())
+
+	// Problems are reported again once they were fixed or the Pod was deleted.
+	r.forgetAnnotationWarnings(types.NamespacedName{Namespace: "default", Name: "my-pod"})
+	r.warnInvalidAnnotations(pod)
+	require.Equal(t, 1, events())
+	delete(pod.Annotations, "kubeai.org/model-ports")
+	r.warnInvalidAnnotations(pod)
+	pod.Annotations["kubeai.org/model-ports"] = "model-a=x"
+	r.warnInvalidAnnotations(pod)
+	require.Equal(t, 1, events())
+}
@@ -1,9 +1,11 @@
 package loadbalancer
 
+// getAddrLeastLoad returns the endpoint with the fewest in-flight requests
+// relative to its weight (see v1.PodEndpointWeightAnnotationName).
 func (g *group) getAddrLeastLoad(adapter string) (endpoint, bool) {
 	var bestEp endpoint
 	var found bool
-	var minInFlight int
+	var minInFlight, minWeight int64
 	for _, ep := range g.endpoints {
 		if adapter != "" {
 			// Skip endpoints that don't have the requested adapter.
@@ -11,11 +13,12 @@ func (g *group) getAddrLeastLoad(adapter string) (endpoint, bool) {
 				continue
 			}
 		}
-		inFlight := int(ep.inFlight.Load())
-		if !found || inFlight < minInFlight {
+		inFlight, weight := ep.inFlight.Load(), ep.getWeight()
+		// inFlight/weight < minInFlight/minWeight
+		if !found || inFlight*minWeight < minInFlight*weight {
 			bestEp = ep
 			found = true
-			minInFlight = inFlight
+			minInFlight, minWeight = inFlight, weight
 		}
 	}
 
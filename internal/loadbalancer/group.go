@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	v1 "github.com/substratusai/kubeai/api/v1"
 	"github.com/substratusai/kubeai/internal/apiutils"
+	"github.com/substratusai/kubeai/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
 func newEndpointGroup() *group {
@@ -19,6 +23,7 @@ func newEndpointGroup() *group {
 		chwblSortedHashes: []uint64{},
 		bcast:             make(chan struct{}),
 	}
+	g.markResolved(time.Now())
 	return g
 }
 
@@ -38,6 +43,18 @@ type group struct {
 
 	bmtx  sync.RWMutex
 	bcast chan struct{} // closed when there's a broadcast
+
+	// waiting is the number of requests that are waiting for endpoints.
+	waiting atomic.Int64
+
+	// lastResolved is the last time (in unix nanoseconds) the group was used
+	// to route a request (or when the group was created).
+	lastResolved atomic.Int64
+}
+
+// markResolved records that the group was used to route a request at the given time.
+func (g *group) markResolved(t time.Time) {
+	g.lastResolved.Store(t.UnixNano())
 }
 
 type endpoint struct {
@@ -46,6 +63,17 @@ type endpoint struct {
 	inFlight *atomic.Int64
 
 	adapters map[string]struct{}
+
+	// weight is the relative capacity of the endpoint for the LeastLoad
+	// strategy (see v1.PodEndpointWeightAnnotationName). 0 is treated as 1.
+	weight int64
+}
+
+func (ep endpoint) getWeight() int64 {
+	if ep.weight < 1 {
+		return 1
+	}
+	return ep.weight
 }
 
 // getBestAddr returns the best "IP:Port". It blocks until there are available endpoints
@@ -55,14 +83,25 @@ func (g *group) getBestAddr(ctx context.Context, req *apiutils.Request, awaitCha
 	// await endpoints exists
 	for awaitChangeEndpoints || len(g.endpoints) == 0 {
 		g.mtx.RUnlock()
+		g.waiting.Add(1)
 		select {
 		case <-g.awaitEndpoints():
 		case <-ctx.Done():
+			g.waiting.Add(-1)
 			return "", func() {}, ctx.Err()
 		}
+		g.waiting.Add(-1)
 		g.mtx.RLock()
 	}
 
+	limit := int64(req.LoadBalancing.MaxConcurrentRequestsPerReplica)
+	var capacityFreed chan struct{}
+	if limit > 0 {
+		// Obtain the broadcast channel before inspecting in-flight counts
+		// so that requests completing in the meantime are not missed.
+		capacityFreed = g.awaitEndpoints()
+	}
+
 	var ep endpoint
 	var found bool
 	switch req.LoadBalancing.Strategy {
@@ -79,14 +118,66 @@ func (g *group) getBestAddr(ctx context.Context, req *apiutils.Request, awaitCha
 		return g.getBestAddr(ctx, req, true)
 	}
 
-	g.addInFlight(ep.inFlight, 1)
+	if limit <= 0 {
+		g.addInFlight(ep.inFlight, 1)
+		doneActive := trackEndpointActive(ctx, req.Model, ep.address)
+		decFunc := func() {
+			g.addInFlight(ep.inFlight, -1)
+			doneActive()
+		}
+		g.mtx.RUnlock()
+		return ep.address, decFunc, nil
+	}
+
+	admitted := g.tryAddInFlight(ep.inFlight, limit)
+	if !admitted && req.LoadBalancing.Strategy != v1.LeastLoadStrategy {
+		// The preferred endpoint is at capacity, try the least loaded endpoint instead.
+		if ep, found = g.getAddrLeastLoad(req.Adapter); found {
+			admitted = g.tryAddInFlight(ep.inFlight, limit)
+		}
+	}
+	g.mtx.RUnlock()
+
+	if !admitted {
+		// All endpoints are at capacity, queue until a request completes
+		// or new endpoints are added.
+		metrics.InferenceRequestsConcurrencyLimited.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+			metrics.AttrRequestModel.String(req.Model),
+		)))
+		g.waiting.Add(1)
+		select {
+		case <-capacityFreed:
+		case <-ctx.Done():
+			g.waiting.Add(-1)
+			return "", func() {}, ctx.Err()
+		}
+		g.waiting.Add(-1)
+		return g.getBestAddr(ctx, req, false)
+	}
+
+	doneActive := trackEndpointActive(ctx, req.Model, ep.address)
 	decFunc := func() {
 		g.addInFlight(ep.inFlight, -1)
+		doneActive()
+		// Notify requests that are queued waiting for capacity.
+		g.broadcastEndpoints()
 	}
-	g.mtx.RUnlock()
 	return ep.address, decFunc, nil
 }
 
+// trackEndpointActive increments the active requests metric of the endpoint
+// and returns the func that decrements it once the request completes.
+func trackEndpointActive(ctx context.Context, model, address string) func() {
+	attrs := metric.WithAttributeSet(attribute.NewSet(
+		metrics.AttrRequestModel.String(model),
+		metrics.AttrEndpoint.String(address),
+	))
+	metrics.InferenceRequestsEndpointActive.Add(ctx, 1, attrs)
+	return func() {
+		metrics.InferenceRequestsEndpointActive.Add(ctx, -1, attrs)
+	}
+}
+
 func (g *group) awaitEndpoints() chan struct{} {
 	g.bmtx.RLock()
 	defer g.bmtx.RUnlock()
@@ -105,19 +196,37 @@ func (g *group) getAllAddrs() []string {
 	return hosts
 }
 
-func (g *group) reconcileEndpoints(observed map[string]endpoint) {
+// isIdle returns true if the group has no endpoints, no in-flight or waiting
+// requests, and has not been resolved since the given time.
+// The caller must hold LoadBalancer.endpointsMtx.
+func (g *group) isIdle(notResolvedSince time.Time) bool {
+	g.mtx.RLock()
+	defer g.mtx.RUnlock()
+	return len(g.endpoints) == 0 &&
+		g.totalInFlight.Load() == 0 &&
+		g.waiting.Load() == 0 &&
+		g.lastResolved.Load() < notResolvedSince.UnixNano()
+}
+
+// reconcileEndpoints replaces the endpoints of the group with the observed ones.
+// Returns true if endpoints were added or removed.
+func (g *group) reconcileEndpoints(observed map[string]endpoint) bool {
+	var changed bool
 	g.mtx.Lock()
 	for name, observedEp := range observed {
 		if currentEp, ok := g.endpoints[name]; ok {
 			currentEp.adapters = observedEp.adapters
+			currentEp.weight = observedEp.weight
 			g.endpoints[name] = currentEp
 		} else {
 			g.endpoints[name] = endpoint{
 				inFlight: &atomic.Int64{},
 				address:  observedEp.address,
 				adapters: observedEp.adapters,
+				weight:   observedEp.weight,
 			}
 			g.chwblAddEndpoint(name)
+			changed = true
 		}
 	}
 	for name, ep := range g.endpoints {
@@ -125,6 +234,7 @@ func (g *group) reconcileEndpoints(observed map[string]endpoint) {
 			g.totalInFlight.Add(-ep.inFlight.Load())
 			g.chwblRemoveEndpoint(name)
 			delete(g.endpoints, name)
+			changed = true
 		}
 	}
 	g.mtx.Unlock()
@@ -133,6 +243,7 @@ func (g *group) reconcileEndpoints(observed map[string]endpoint) {
 	if len(observed) > 0 {
 		g.broadcastEndpoints()
 	}
+	return changed
 }
 
 func (g *group) broadcastEndpoints() {
@@ -143,6 +254,21 @@ func (g *group) broadcastEndpoints() {
 	g.bcast = make(chan struct{})
 }
 
+// tryAddInFlight increments the in-flight count of the endpoint
+// if it is below the limit. Returns true if the count was incremented.
+func (g *group) tryAddInFlight(endpointInFlight *atomic.Int64, limit int64) bool {
+	for {
+		current := endpointInFlight.Load()
+		if current >= limit {
+			return false
+		}
+		if endpointInFlight.CompareAndSwap(current, current+1) {
+			g.totalInFlight.Add(1)
+			return true
+		}
+	}
+}
+
 func (g *group) addInFlight(endpointInFlight *atomic.Int64, add int64) int64 {
 	g.totalInFlight.Add(add)
 	return endpointInFlight.Add(add)
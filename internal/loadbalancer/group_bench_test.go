@@ -5,9 +5,11 @@ import (
 	"testing"
 
 	"github.com/substratusai/kubeai/internal/apiutils"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
 )
 
 func BenchmarkEndpointGroup(b *testing.B) {
+	metricstest.Init(b)
 	e := newEndpointGroup()
 	e.reconcileEndpoints(map[string]endpoint{"pod1": {address: "10.0.0.1:8000"}})
 	b.ResetTimer()
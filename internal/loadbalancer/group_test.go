@@ -5,16 +5,19 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "github.com/substratusai/kubeai/api/v1"
 	"github.com/substratusai/kubeai/internal/apiutils"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
 
 	"k8s.io/apimachinery/pkg/util/rand"
 )
 
 func TestConcurrentAccess(t *testing.T) {
+	metricstest.Init(t)
 	const (
 		myModel = "myModel"
 		myAddr  = "10.0.0.1:8000"
@@ -77,6 +80,7 @@ func TestConcurrentAccess(t *testing.T) {
 }
 
 func TestBlockAndWaitForEndpoints(t *testing.T) {
+	metricstest.Init(t)
 	var completed atomic.Int32
 	var startWg, doneWg sync.WaitGroup
 	startTogether := func(n int, f func()) {
@@ -109,6 +113,7 @@ func TestBlockAndWaitForEndpoints(t *testing.T) {
 }
 
 func TestAbortOnCtxCancel(t *testing.T) {
+	metricstest.Init(t)
 	ctx, cancel := context.WithCancel(context.Background())
 
 	var startWg, doneWg sync.WaitGroup
@@ -127,3 +132,73 @@ func TestAbortOnCtxCancel(t *testing.T) {
 
 	doneWg.Wait()
 }
+
+func TestMaxConcurrentRequestsPerReplica(t *testing.T) {
+	metricstest.Init(t)
+	const myAddr = "10.0.0.1:8000"
+
+	group := newEndpointGroup()
+	group.reconcileEndpoints(map[string]endpoint{"pod1": {address: myAddr}})
+
+	req := &apiutils.Request{
+		Model: "my-model",
+		LoadBalancing: v1.LoadBalancing{
+			Strategy:                        v1.LeastLoadStrategy,
+			MaxConcurrentRequestsPerReplica: 2,
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var dones []func()
+	for i := 0; i < 2; i++ {
+		addr, done, err := group.getBestAddr(ctx, req, false)
+		require.NoError(t, err)
+		require.Equal(t, myAddr, addr)
+		dones = append(dones, done)
+	}
+
+	queued := make(chan error)
+	go func() {
+		_, done, err := group.getBestAddr(ctx, req, false)
+		done()
+		queued <- err
+	}()
+	require.Eventually(t, func() bool { return group.waiting.Load() == 1 }, time.Second, time.Millisecond,
+		"request over the limit should be queued")
+
+	dones[0]()
+	require.NoError(t, <-queued, "queued request should be admitted when capacity frees up")
+	dones[1]()
+	require.Equal(t, int64(0), group.totalInFlight.Load())
+
+	metricstest.RequireConcurrencyLimitedMetric(t, metricstest.Collect(t), "my-model", 1)
+}
+
+func TestLeastLoadWeighted(t *testing.T) {
+	metricstest.Init(t)
+	group := newEndpointGroup()
+	group.reconcileEndpoints(map[string]endpoint{
+		"pod1": {address: "10.0.0.1:8000"},
+		"pod2": {address: "10.0.0.2:8000", weight: 2},
+	})
+
+	req := &apiutils.Request{
+		Model:         "my-model",
+		LoadBalancing: v1.LoadBalancing{Strategy: v1.LeastLoadStrategy},
+	}
+	counts := map[string]int{}
+	var dones []func()
+	for i := 0; i < 6; i++ {
+		addr, done, err := group.getBestAddr(context.Background(), req, false)
+		require.NoError(t, err)
+		counts[addr]++
+		dones = append(dones, done)
+	}
+	require.Equal(t, map[string]int{"10.0.0.1:8000": 2, "10.0.0.2:8000": 4}, counts)
+
+	for _, done := range dones {
+		done()
+	}
+	require.Equal(t, int64(0), group.totalInFlight.Load())
+}
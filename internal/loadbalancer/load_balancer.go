@@ -2,27 +2,51 @@ package loadbalancer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	v1 "github.com/substratusai/kubeai/api/v1"
 	"github.com/substratusai/kubeai/internal/apiutils"
 	"github.com/substratusai/kubeai/internal/k8sutils"
+	"github.com/substratusai/kubeai/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func New(mgr ctrl.Manager) (*LoadBalancer, error) {
+// New returns a LoadBalancer that is reconciled by the given manager. Pod annotations
+// are read from the given annotationDomains (see v1.AnnotationDomains). Only model
+// Pods that match the given podSelector are routed to (all when nil).
+func New(mgr ctrl.Manager, annotationDomains []string, podSelector labels.Selector) (*LoadBalancer, error) {
 	r := &LoadBalancer{}
 	r.Client = mgr.GetClient()
+	r.AnnotationDomains = annotationDomains
+	r.PodSelector = podSelector
 	r.groups = map[string]*group{}
 	r.ExcludePods = map[string]struct{}{}
+	r.Recorder = mgr.GetEventRecorderFor("kubeai-loadbalancer")
 	if err := r.SetupWithManager(mgr); err != nil {
 		return nil, err
 	}
@@ -32,7 +56,9 @@ func New(mgr ctrl.Manager) (*LoadBalancer, error) {
 type LoadBalancer struct {
 	client.Client
 
-	endpointsMtx sync.Mutex
+	// endpointsMtx guards the groups map. Groups are looked up with a read lock
+	// so that concurrent requests for existing models do not contend.
+	endpointsMtx sync.RWMutex
 	// map[<model-name>]endpointGroup
 	groups map[string]*group
 
@@ -40,30 +66,120 @@ type LoadBalancer struct {
 	selfIPs    []string
 
 	ExcludePods map[string]struct{}
+
+	// AnnotationDomains are the domains that Pod port annotations are read from
+	// (in order of precedence).
+	AnnotationDomains []string
+
+	// Recorder is used to emit Events when conflicts are detected.
+	// Optional.
+	Recorder record.EventRecorder
+
+	// PodSelector restricts the model Pods that are routed to (and reconciled).
+	// Optional, all model Pods are used when nil.
+	PodSelector labels.Selector
+
+	// PodModelsAnnotationName is the name of the domain annotation that lists the
+	// additional models that a Pod serves. Defaults to v1.PodModelsAnnotationName.
+	// Pods are indexed by it (see podModelsIndex), so it has to be set before the
+	// cache of the manager is started.
+	PodModelsAnnotationName string
+
+	// IsLeader restricts the Events for invalid Pod annotations to the leader
+	// (see warnInvalidAnnotations). Optional, all instances emit them when nil.
+	IsLeader *atomic.Bool
+
+	// warnedAnnotationsMtx guards warnedAnnotations.
+	warnedAnnotationsMtx sync.Mutex
+	// map[<namespace>/<pod-name>]annotationWarning
+	warnedAnnotations map[string]annotationWarning
+
+	// CurrentGenerationOnly restricts routing to the Pods of the latest
+	// generation of a model during a rollout (see podGeneration), as long as
+	// any of them are ready.
+	CurrentGenerationOnly bool
+
+	// OnEndpointsChange is called with the name of a model after endpoints
+	// were added to or removed from it. Optional.
+	OnEndpointsChange func(model string)
+
+	// synced is true once the endpoints of all models were populated by Sync.
+	synced atomic.Bool
+	// syncedCh is closed once synced is true (see syncedChan).
+	syncedOnce sync.Once
+	syncedCh   chan struct{}
 }
 
+const (
+	selfLabelKey = "app.kubernetes.io/name"
+	selfLabelVal = "kubeai"
+)
+
 func (r *LoadBalancer) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, podModelsIndex, r.indexPodModels); err != nil {
+		return fmt.Errorf("indexing pod models: %w", err)
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{NeedLeaderElection: ptr.To(false)}).
-		For(&corev1.Pod{}).
+		// Avoid reconciling Pods that are not relevant for routing.
+		For(&corev1.Pod{}, builder.WithPredicates(r.podPredicate())).
 		Complete(r)
 }
 
+// isModelPod returns true if Pods with the given labels serve models:
+// Pods with the v1.PodModelLabel and warm pool Pods (see v1.WarmPoolLabel).
+func isModelPod(podLabels map[string]string) bool {
+	_, model := podLabels[v1.PodModelLabel]
+	_, warm := podLabels[v1.WarmPoolLabel]
+	return model || warm
+}
+
+// podPredicate filters the events of Pods that are not relevant for routing.
+// Updates pass if either the old or the new Pod is relevant, so that a Pod
+// whose labels no longer match is removed from the endpoints.
+func (r *LoadBalancer) podPredicate() predicate.Funcs {
+	relevant := func(obj client.Object) bool {
+		labels := obj.GetLabels()
+		if labels[selfLabelKey] == selfLabelVal {
+			return true
+		}
+		return isModelPod(labels) && r.matchesPodSelector(labels)
+	}
+	funcs := predicate.NewPredicateFuncs(relevant)
+	funcs.UpdateFunc = func(e event.UpdateEvent) bool {
+		return relevant(e.ObjectOld) || relevant(e.ObjectNew)
+	}
+	return funcs
+}
+
+// matchesPodSelector returns true if Pods with the given labels can be routed to.
+func (r *LoadBalancer) matchesPodSelector(podLabels map[string]string) bool {
+	return r.PodSelector == nil || r.PodSelector.Matches(labels.Set(podLabels))
+}
+
+// podModelsIndex is the name of the field index of model Pods by the models
+// that they serve (see getPodModels).
+const podModelsIndex = "kubeai.podModels"
+
+// indexPodModels is the client.IndexerFunc of the podModelsIndex.
+func (r *LoadBalancer) indexPodModels(obj client.Object) []string {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || !isModelPod(pod.Labels) {
+		return nil
+	}
+	return r.getPodModels(*pod)
+}
+
 func (r *LoadBalancer) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var pod corev1.Pod
 	if err := r.Get(ctx, req.NamespacedName, &pod); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.forgetAnnotationWarnings(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
 	labels := pod.GetLabels()
-	if labels == nil {
-		return ctrl.Result{}, nil
-	}
-
-	const (
-		selfLabelKey = "app.kubernetes.io/name"
-		selfLabelVal = "kubeai"
-	)
 	if labels[selfLabelKey] == selfLabelVal {
 		var podList corev1.PodList
 		if err := r.List(ctx, &podList, client.InNamespace(pod.Namespace), client.MatchingLabels{selfLabelKey: selfLabelVal}); err != nil {
@@ -81,30 +197,157 @@ func (r *LoadBalancer) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Re
 		return ctrl.Result{}, nil
 	}
 
-	modelName, ok := labels[v1.PodModelLabel]
-	if !ok {
+	if !isModelPod(labels) || !r.matchesPodSelector(labels) {
+		// The labels of the Pod might have changed since it was routed to.
+		for _, modelName := range r.modelsWithEndpoint(pod.Namespace + "/" + pod.Name) {
+			if err := r.reconcileModelEndpoints(ctx, pod.Namespace, modelName); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
 		return ctrl.Result{}, nil
 	}
+	r.warnInvalidAnnotations(&pod)
+
+	models := r.getPodModels(pod)
+	if _, ok := labels[v1.WarmPoolLabel]; ok {
+		// The model that released the Pod is no longer in its labels.
+		for _, modelName := range r.modelsWithEndpoint(pod.Namespace + "/" + pod.Name) {
+			if !slices.Contains(models, modelName) {
+				models = append(models, modelName)
+			}
+		}
+	}
+	for _, modelName := range models {
+		if err := r.reconcileModelEndpoints(ctx, pod.Namespace, modelName); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// ReconcileAll refreshes the endpoints of every Model in the given namespace, of
+// every model that has Pods, and of every model that has endpoints (i.e. whose
+// Model was deleted), so that the endpoints of models without Pods are removed.
+// It returns the number of models whose endpoints were refreshed.
+func (r *LoadBalancer) ReconcileAll(ctx context.Context, namespace string) (int, error) {
+	pods, err := r.listModelPods(ctx, namespace)
+	if err != nil {
+		return 0, fmt.Errorf("listing model pods: %w", err)
+	}
+	var modelList v1.ModelList
+	if err := r.List(ctx, &modelList, client.InNamespace(namespace)); err != nil {
+		return 0, fmt.Errorf("listing models: %w", err)
+	}
 
+	models := map[string]struct{}{}
+	for _, m := range modelList.Items {
+		models[m.Name] = struct{}{}
+	}
+	r.endpointsMtx.RLock()
+	for modelName := range r.groups {
+		models[modelName] = struct{}{}
+	}
+	r.endpointsMtx.RUnlock()
+	for _, pod := range pods {
+		if !r.matchesPodSelector(pod.Labels) {
+			continue
+		}
+		for _, modelName := range r.getPodModels(pod) {
+			models[modelName] = struct{}{}
+		}
+	}
+	for modelName := range models {
+		if err := r.reconcileModelEndpoints(ctx, namespace, modelName); err != nil {
+			return 0, fmt.Errorf("model %q: %w", modelName, err)
+		}
+	}
+
+	return len(models), nil
+}
+
+// ReconcileModel refreshes the endpoints of the given model in the given namespace.
+func (r *LoadBalancer) ReconcileModel(ctx context.Context, namespace, model string) error {
+	return r.reconcileModelEndpoints(ctx, namespace, model)
+}
+
+// Sync populates the endpoints of every model in the given namespace once the
+// given cache is synced, instead of waiting for the Pods to be reconciled one by
+// one. Requests that are received before Sync returns might wait for endpoints
+// that already exist (see Ready).
+func (r *LoadBalancer) Sync(ctx context.Context, c cache.Cache, namespace string) error {
+	if !c.WaitForCacheSync(ctx) {
+		return errors.New("cache did not sync")
+	}
+	n, err := r.ReconcileAll(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	log.Printf("populated the endpoints of %d models", n)
+	if r.synced.CompareAndSwap(false, true) {
+		close(r.syncedChan())
+	}
+	return nil
+}
+
+// WaitForInitialSync blocks until Sync completes (i.e. before consuming
+// requests from queues) or the context is done.
+func (r *LoadBalancer) WaitForInitialSync(ctx context.Context) error {
+	if r.synced.Load() {
+		return nil
+	}
+	select {
+	case <-r.syncedChan():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *LoadBalancer) syncedChan() chan struct{} {
+	r.syncedOnce.Do(func() { r.syncedCh = make(chan struct{}) })
+	return r.syncedCh
+}
+
+// Ready is a healthz.Checker that fails until Sync completes.
+func (r *LoadBalancer) Ready(_ *http.Request) error {
+	if !r.synced.Load() {
+		return errors.New("model endpoints are not populated yet")
+	}
+	return nil
+}
+
+func (r *LoadBalancer) reconcileModelEndpoints(ctx context.Context, namespace, modelName string) error {
+	// Pods that serve multiple models are only labeled for one of them,
+	// so they are looked up by all of the models that they serve.
 	var podList corev1.PodList
-	if err := r.List(ctx, &podList, client.InNamespace(pod.Namespace), client.MatchingLabels{v1.PodModelLabel: modelName}); err != nil {
-		return ctrl.Result{}, fmt.Errorf("listing matching pods: %w", err)
+	if err := r.List(ctx, &podList, client.InNamespace(namespace), client.MatchingFields{podModelsIndex: modelName}); err != nil {
+		return fmt.Errorf("listing matching pods: %w", err)
 	}
 
 	observedEndpoints := map[string]endpoint{}
+	endpointGenerations := map[string]string{}
+	var modelPods []corev1.Pod
 	for _, pod := range podList.Items {
+		if !r.matchesPodSelector(pod.Labels) {
+			continue
+		}
 		if _, exclude := r.ExcludePods[pod.Name]; exclude {
 			continue
 		}
+		modelPods = append(modelPods, pod)
 		if !k8sutils.PodIsReady(&pod) {
 			continue
 		}
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "Model" && owner.Name != modelName {
+			// Keep routing stable by only using the Pods that belong to the Model.
+			r.reportModelConflict(ctx, &pod, modelName, owner.Name)
+			continue
+		}
 
-		// The Model controller should always set the port annotation in the Pods it creates
-		// to communicate the port that the given backend listens on.
-		port := getPodAnnotation(pod, v1.ModelPodPortAnnotation)
-		if port == "" {
-			log.Printf("ERROR: No port annotation %q found for pod %s, skipping", v1.ModelPodPortAnnotation, pod.Name)
+		port, err := r.getPodPort(pod, modelName)
+		if err != nil {
+			log.Printf("ERROR: %v, skipping pod %s", err, pod.Name)
 			continue
 		}
 
@@ -119,15 +362,168 @@ func (r *LoadBalancer) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Re
 			continue
 		}
 
-		observedEndpoints[pod.Namespace+"/"+pod.Name] = endpoint{
+		key := pod.Namespace + "/" + pod.Name
+		observedEndpoints[key] = endpoint{
 			address:  ip + ":" + port,
 			adapters: getEndpointAdapters(pod),
+			weight:   r.getPodWeight(pod),
+		}
+		endpointGenerations[key] = podGeneration(pod)
+	}
+
+	if r.CurrentGenerationOnly {
+		current, err := r.currentGenerations(ctx, namespace, modelName, modelPods)
+		if err != nil {
+			return fmt.Errorf("resolving current generation: %w", err)
 		}
+		observedEndpoints = filterGeneration(observedEndpoints, endpointGenerations, current)
 	}
 
-	r.getEndpoints(modelName).reconcileEndpoints(observedEndpoints)
+	if r.getEndpoints(modelName).reconcileEndpoints(observedEndpoints) && r.OnEndpointsChange != nil {
+		r.OnEndpointsChange(modelName)
+	}
 
-	return ctrl.Result{}, nil
+	return nil
+}
+
+// listModelPods returns the Pods in the given namespace that serve models (see isModelPod).
+func (r *LoadBalancer) listModelPods(ctx context.Context, namespace string) ([]corev1.Pod, error) {
+	var modelPods, warmPods corev1.PodList
+	if err := r.List(ctx, &modelPods, client.InNamespace(namespace), client.HasLabels{v1.PodModelLabel}); err != nil {
+		return nil, err
+	}
+	if err := r.List(ctx, &warmPods, client.InNamespace(namespace), client.HasLabels{v1.WarmPoolLabel}); err != nil {
+		return nil, err
+	}
+	pods := modelPods.Items
+	for _, pod := range warmPods.Items {
+		if _, ok := pod.Labels[v1.PodModelLabel]; !ok {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// modelsWithEndpoint returns the models that have an endpoint for the Pod with the given key.
+func (r *LoadBalancer) modelsWithEndpoint(key string) []string {
+	r.endpointsMtx.RLock()
+	defer r.endpointsMtx.RUnlock()
+
+	var models []string
+	for model, g := range r.groups {
+		g.mtx.RLock()
+		_, ok := g.endpoints[key]
+		g.mtx.RUnlock()
+		if ok {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// podGeneration returns the hash of the Pod template that the Pod was created from:
+// the v1.PodHashLabel for Pods that are created by KubeAI and the pod-template-hash
+// label for Pods of Deployments.
+func podGeneration(pod corev1.Pod) string {
+	if hash, ok := pod.Labels[v1.PodHashLabel]; ok {
+		return hash
+	}
+	return pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+}
+
+// deploymentRevisionAnnotation is the annotation that the Deployment controller
+// records the revision of a ReplicaSet in. The ReplicaSet with the highest
+// revision of a Deployment has its latest Pod template (also after a rollback).
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// currentGenerations returns the generations (see podGeneration) of the latest
+// Pod templates of the given Pods of the model:
+//   - The v1.ModelPodHashAnnotation of the Model for Pods that are created by KubeAI.
+//   - The pod-template-hash of the ReplicaSet with the latest revision of each
+//     Deployment for Pods of Deployments.
+//
+// The age of the Pods is not taken into account, as the Pods of previous
+// generations are recreated (i.e. after an eviction) until they are scaled down.
+func (r *LoadBalancer) currentGenerations(ctx context.Context, namespace, modelName string, pods []corev1.Pod) (map[string]struct{}, error) {
+	current := map[string]struct{}{}
+	var createdByKubeAI bool
+	replicaSets := map[string]struct{}{}
+	for _, pod := range pods {
+		if _, ok := pod.Labels[v1.PodHashLabel]; ok {
+			createdByKubeAI = true
+		} else if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "ReplicaSet" {
+			replicaSets[owner.Name] = struct{}{}
+		}
+	}
+
+	if createdByKubeAI {
+		model := &v1.Model{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: modelName}, model); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("getting model: %w", err)
+		}
+		if hash := model.Annotations[v1.ModelPodHashAnnotation]; hash != "" {
+			current[hash] = struct{}{}
+		}
+	}
+
+	// map[<deployment-name>]<latest-revision>
+	revisions := map[string]int64{}
+	latest := map[string]string{}
+	for name := range replicaSets {
+		rs := &appsv1.ReplicaSet{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, rs); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("getting replica set: %w", err)
+		}
+		owner := metav1.GetControllerOf(rs)
+		if owner == nil || owner.Kind != "Deployment" {
+			continue
+		}
+		revision, err := strconv.ParseInt(rs.Annotations[deploymentRevisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		if latestRevision, ok := revisions[owner.Name]; !ok || revision > latestRevision {
+			revisions[owner.Name] = revision
+			latest[owner.Name] = rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+		}
+	}
+	for _, hash := range latest {
+		current[hash] = struct{}{}
+	}
+	return current, nil
+}
+
+// filterGeneration returns the endpoints of the given generations. All endpoints
+// are returned if none of them are of the given generations (i.e. if the current
+// generations are unknown), so that requests are still served while the Pods of
+// a new generation are starting.
+func filterGeneration(endpoints map[string]endpoint, generations map[string]string, current map[string]struct{}) map[string]endpoint {
+	filtered := map[string]endpoint{}
+	for key, ep := range endpoints {
+		if _, ok := current[generations[key]]; ok {
+			filtered[key] = ep
+		}
+	}
+	if len(filtered) == 0 {
+		return endpoints
+	}
+	return filtered
+}
+
+// reportModelConflict records that a Pod is labeled for one model but owned by another.
+func (r *LoadBalancer) reportModelConflict(ctx context.Context, pod *corev1.Pod, modelName, ownerName string) {
+	log.Printf("WARNING: Pod %s/%s is labeled for model %q but is owned by Model %q, excluding it from routing",
+		pod.Namespace, pod.Name, modelName, ownerName)
+	metrics.ModelRoutingConflicts.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		metrics.AttrRequestModel.String(modelName),
+	)))
+	if r.Recorder != nil {
+		r.Recorder.Eventf(pod, corev1.EventTypeWarning, "ModelConflict",
+			"Pod is labeled for model %q but is owned by Model %q, excluding it from routing", modelName, ownerName)
+	}
 }
 
 func getEndpointAdapters(pod corev1.Pod) map[string]struct{} {
@@ -142,6 +538,73 @@ func getEndpointAdapters(pod corev1.Pod) map[string]struct{} {
 	return adapters
 }
 
+// getPodWeight returns the weight of the Pod for the LeastLoad strategy (see
+// v1.PodEndpointWeightAnnotationName). Invalid weights default to 1 (see
+// ValidatePodAnnotations).
+func (r *LoadBalancer) getPodWeight(pod corev1.Pod) int64 {
+	if _, value, ok := v1.GetDomainAnnotation(pod.GetAnnotations(), r.AnnotationDomains, v1.PodEndpointWeightAnnotationName); ok {
+		if weight, err := strconv.ParseInt(value, 10, 64); err == nil && weight > 0 {
+			return weight
+		}
+	}
+	return 1
+}
+
+// getPodModels returns the models that the Pod serves: the model of the
+// v1.PodModelLabel followed by the models listed in the domain annotation
+// PodModelsAnnotationName (if any) and the model that claimed the Pod from
+// its warm pool (see v1.WarmPoolModelLabel).
+func (r *LoadBalancer) getPodModels(pod corev1.Pod) []string {
+	name := r.PodModelsAnnotationName
+	if name == "" {
+		name = v1.PodModelsAnnotationName
+	}
+	var models []string
+	if model, ok := pod.Labels[v1.PodModelLabel]; ok {
+		models = append(models, model)
+	}
+	if _, value, ok := v1.GetDomainAnnotation(pod.GetAnnotations(), r.AnnotationDomains, name); ok {
+		for _, model := range strings.Split(value, ",") {
+			if model = strings.TrimSpace(model); model != "" && !slices.Contains(models, model) {
+				models = append(models, model)
+			}
+		}
+	}
+	if model, ok := pod.Labels[v1.WarmPoolModelLabel]; ok && !slices.Contains(models, model) {
+		models = append(models, model)
+	}
+	return models
+}
+
+// getPodPort returns the port that the Pod serves the given model on.
+func (r *LoadBalancer) getPodPort(pod corev1.Pod, modelName string) (string, error) {
+	// The Model controller should always set the port annotation in the Pods it creates
+	// to communicate the port that the given backend listens on.
+	if port := getPodAnnotation(pod, v1.ModelPodPortAnnotation); port != "" {
+		return port, nil
+	}
+
+	// Pods that are created by other controllers (i.e. scale targets) can specify ports
+	// with domain annotations.
+	ann := pod.GetAnnotations()
+	if key, value, ok := v1.GetDomainAnnotation(ann, r.AnnotationDomains, v1.PodModelPortsAnnotationName); ok {
+		for _, entry := range strings.Split(value, ",") {
+			model, port, found := strings.Cut(strings.TrimSpace(entry), "=")
+			if !found {
+				return "", fmt.Errorf("invalid %s annotation entry %q: expected <model>=<port>", key, entry)
+			}
+			if model == modelName {
+				return port, nil
+			}
+		}
+	}
+	if _, port, ok := v1.GetDomainAnnotation(ann, r.AnnotationDomains, v1.PodPortAnnotationName); ok {
+		return port, nil
+	}
+
+	return "", fmt.Errorf("no port annotation %q found", v1.ModelPodPortAnnotation)
+}
+
 func getPodAnnotation(pod corev1.Pod, key string) string {
 	if ann := pod.GetAnnotations(); ann != nil {
 		return ann[key]
@@ -153,16 +616,90 @@ func getPodAnnotation(pod corev1.Pod, key string) string {
 // If the group does not exist, it is created.
 // This assumes that the existance of the model is already checked.
 func (r *LoadBalancer) getEndpoints(model string) *group {
+	r.endpointsMtx.RLock()
+	g, ok := r.groups[model]
+	r.endpointsMtx.RUnlock()
+	if ok {
+		return g
+	}
+
 	r.endpointsMtx.Lock()
+	g = r.getEndpointsLocked(model)
+	r.endpointsMtx.Unlock()
+	return g
+}
+
+// resolveEndpoints is the same as getEndpoints but also records that
+// the group was resolved in order to route a request.
+func (r *LoadBalancer) resolveEndpoints(model string) *group {
+	now := time.Now()
+
+	// The group is marked as resolved while holding the lock so that it
+	// can not be evicted before the request is waiting on it.
+	r.endpointsMtx.RLock()
+	g, ok := r.groups[model]
+	if ok {
+		g.markResolved(now)
+	}
+	r.endpointsMtx.RUnlock()
+	if ok {
+		return g
+	}
+
+	r.endpointsMtx.Lock()
+	g = r.getEndpointsLocked(model)
+	g.markResolved(now)
+	r.endpointsMtx.Unlock()
+	return g
+}
+
+// getEndpointsLocked must be called while holding the endpointsMtx write lock.
+func (r *LoadBalancer) getEndpointsLocked(model string) *group {
 	g, ok := r.groups[model]
 	if !ok {
 		g = newEndpointGroup()
 		r.groups[model] = g
 	}
-	r.endpointsMtx.Unlock()
 	return g
 }
 
+// EvictIdle removes the endpoint groups of models that have no endpoints,
+// no active requests, and have not been resolved within the given TTL.
+// Evicted groups are re-created on the next reconcile or request for the model.
+// Returns the number of evicted groups.
+func (r *LoadBalancer) EvictIdle(ttl time.Duration) int {
+	notResolvedSince := time.Now().Add(-ttl)
+
+	r.endpointsMtx.Lock()
+	defer r.endpointsMtx.Unlock()
+
+	var evicted int
+	for model, g := range r.groups {
+		if g.isIdle(notResolvedSince) {
+			delete(r.groups, model)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// StartIdleEviction periodically evicts idle endpoint groups (see EvictIdle).
+// It blocks until the context is cancelled.
+func (r *LoadBalancer) StartIdleEviction(ctx context.Context, ttl time.Duration) {
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n := r.EvictIdle(ttl); n > 0 {
+				log.Printf("Evicted %d idle model endpoint groups", n)
+			}
+		}
+	}
+}
+
 func (r *LoadBalancer) GetSelfIPs() []string {
 	r.selfIPsMtx.RLock()
 	defer r.selfIPsMtx.RUnlock()
@@ -173,7 +710,15 @@ func (r *LoadBalancer) GetSelfIPs() []string {
 // becomes available or the context times out. It returns a function that should be called when the
 // request is complete to decrement the in-flight count.
 func (r *LoadBalancer) AwaitBestAddress(ctx context.Context, req *apiutils.Request) (string, func(), error) {
-	return r.getEndpoints(req.Model).getBestAddr(ctx, req, false)
+	modelAttrs := metric.WithAttributeSet(attribute.NewSet(
+		metrics.AttrRequestModel.String(req.Model),
+	))
+	metrics.InferenceRequestsQueued.Add(ctx, 1, modelAttrs)
+	start := time.Now()
+	addr, decFunc, err := r.resolveEndpoints(req.Model).getBestAddr(ctx, req, false)
+	metrics.InferenceRequestsQueueDuration.Record(ctx, time.Since(start).Seconds(), modelAttrs)
+	metrics.InferenceRequestsQueued.Add(ctx, -1, modelAttrs)
+	return addr, decFunc, err
 }
 
 // GetAllHosts retrieves the list of all hosts for a given model.
@@ -0,0 +1,36 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+// BenchmarkResolveEndpoints measures looking up the endpoint groups of many models
+// from concurrent requests while their endpoints are being reconciled.
+func BenchmarkResolveEndpoints(b *testing.B) {
+	const models = 1000
+
+	r := &LoadBalancer{groups: map[string]*group{}}
+	names := make([]string, models)
+	for i := range names {
+		names[i] = fmt.Sprintf("model-%d", i)
+		r.getEndpoints(names[i]).reconcileEndpoints(map[string]endpoint{"pod1": {address: "10.0.0.1:8000"}})
+	}
+
+	var next atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			i := next.Add(1)
+			name := names[i%models]
+			if i%10 == 0 {
+				// Reconcile
+				r.getEndpoints(name).reconcileEndpoints(map[string]endpoint{"pod1": {address: "10.0.0.1:8000"}})
+			} else {
+				// Request
+				r.resolveEndpoints(name)
+			}
+		}
+	})
+}
@@ -10,8 +10,34 @@ import (
 	v1 "github.com/substratusai/kubeai/api/v1"
 	"github.com/substratusai/kubeai/internal/apiutils"
 	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
+// testScheme registers the Model types, as the load balancer reads Models.
+var testScheme = func() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	if err := v1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	return scheme
+}()
+
 func TestAwaitBestHostBehavior(t *testing.T) {
 	const (
 		myModel              = "my-model"
@@ -421,3 +447,508 @@ func TestLoadBalancingStrategies(t *testing.T) {
 		})
 	}
 }
+
+func TestEvictIdle(t *testing.T) {
+	metricstest.Init(t)
+
+	const (
+		idleModel   = "idle-model"
+		activeModel = "active-model"
+		readyModel  = "ready-model"
+	)
+
+	manager := &LoadBalancer{
+		groups: map[string]*group{},
+	}
+
+	manager.getEndpoints(idleModel).markResolved(time.Now().Add(-time.Hour))
+	manager.getEndpoints(activeModel).markResolved(time.Now())
+	readyGroup := manager.getEndpoints(readyModel)
+	readyGroup.reconcileEndpoints(map[string]endpoint{"pod1": {address: "10.0.0.1:8000"}})
+	readyGroup.markResolved(time.Now().Add(-time.Hour))
+
+	require.Equal(t, 1, manager.EvictIdle(time.Minute))
+	require.NotContains(t, manager.groups, idleModel)
+	require.Contains(t, manager.groups, activeModel)
+	require.Contains(t, manager.groups, readyModel, "groups with endpoints should not be evicted")
+
+	// Groups with waiting requests should not be evicted.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	waitErr := make(chan error)
+	go func() {
+		_, _, err := manager.AwaitBestAddress(ctx, &apiutils.Request{
+			Model:         idleModel,
+			LoadBalancing: v1.LoadBalancing{Strategy: v1.LeastLoadStrategy},
+		})
+		waitErr <- err
+	}()
+	require.Eventually(t, func() bool {
+		manager.endpointsMtx.Lock()
+		defer manager.endpointsMtx.Unlock()
+		g, ok := manager.groups[idleModel]
+		return ok && g.waiting.Load() == 1
+	}, time.Second, time.Millisecond)
+	manager.endpointsMtx.Lock()
+	manager.groups[idleModel].markResolved(time.Now().Add(-time.Hour))
+	manager.endpointsMtx.Unlock()
+	require.Equal(t, 0, manager.EvictIdle(time.Minute))
+	require.Contains(t, manager.groups, idleModel)
+
+	cancel()
+	require.ErrorIs(t, <-waitErr, context.Canceled)
+}
+
+func TestReconcileAll(t *testing.T) {
+	const namespace = "default"
+
+	readyPod := func(name, model, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      map[string]string{v1.PodModelLabel: model},
+				Annotations: map[string]string{v1.ModelPodPortAnnotation: "8000"},
+			},
+			Status: corev1.PodStatus{
+				PodIP:      ip,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+
+	manager := &LoadBalancer{
+		groups: map[string]*group{},
+	}
+	manager.Client = newTestClient(manager,
+		readyPod("pod1", "model-a", "10.0.0.1"),
+		readyPod("pod2", "model-a", "10.0.0.2"),
+		readyPod("pod3", "model-b", "10.0.0.3"),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: namespace}},
+	)
+
+	n, err := manager.ReconcileAll(context.Background(), namespace)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.ElementsMatch(t, []string{"10.0.0.1:8000", "10.0.0.2:8000"}, manager.GetAllAddresses("model-a"))
+	require.ElementsMatch(t, []string{"10.0.0.3:8000"}, manager.GetAllAddresses("model-b"))
+}
+
+func TestReconcileModelConflict(t *testing.T) {
+	metricstest.Init(t)
+	const namespace = "default"
+
+	ownedPod := func(name, model, owner, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      map[string]string{v1.PodModelLabel: model},
+				Annotations: map[string]string{v1.ModelPodPortAnnotation: "8000"},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: v1.GroupVersion.String(),
+					Kind:       "Model",
+					Name:       owner,
+					UID:        types.UID("uid-" + owner),
+					Controller: ptr.To(true),
+				}},
+			},
+			Status: corev1.PodStatus{
+				PodIP:      ip,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	manager := &LoadBalancer{
+		groups:   map[string]*group{},
+		Recorder: recorder,
+	}
+	manager.Client = newTestClient(manager,
+		ownedPod("model-a-pod", "model-a", "model-a", "10.0.0.1"),
+		ownedPod("model-b-pod", "model-a", "model-b", "10.0.0.2"),
+	)
+
+	_, err := manager.ReconcileAll(context.Background(), namespace)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:8000"}, manager.GetAllAddresses("model-a"),
+		"Pods owned by a different Model should be excluded from routing")
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, "ModelConflict")
+}
+
+func TestReconcilePodPorts(t *testing.T) {
+	const namespace = "default"
+
+	readyPod := func(name, model, ip string, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      map[string]string{v1.PodModelLabel: model},
+				Annotations: annotations,
+			},
+			Status: corev1.PodStatus{
+				PodIP:      ip,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+
+	sharedPorts := map[string]string{
+		"lingo.substratus.ai/model-ports": "model-a=8000, model-b=8001",
+		"kubeai.org/port":                 "9000",
+	}
+	manager := &LoadBalancer{
+		groups:            map[string]*group{},
+		AnnotationDomains: v1.AnnotationDomains(nil),
+	}
+	manager.Client = newTestClient(manager,
+		readyPod("pod1", "model-a", "10.0.0.1", sharedPorts),
+		readyPod("pod2", "model-b", "10.0.0.2", sharedPorts),
+		readyPod("pod3", "model-c", "10.0.0.3", sharedPorts),
+		readyPod("pod4", "model-c", "10.0.0.4", map[string]string{v1.ModelPodPortAnnotation: "7000", "kubeai.org/port": "9000"}),
+		readyPod("pod5", "model-c", "10.0.0.5", nil),
+		readyPod("pod6", "model-d", "10.0.0.6", map[string]string{"kubeai.org/model-ports": "invalid"}),
+		readyPod("pod7", "model-e", "10.0.0.7", map[string]string{
+			"kubeai.org/models":      "model-e, model-f",
+			"kubeai.org/model-ports": "model-e=8000,model-f=8001",
+		}),
+	)
+
+	_, err := manager.ReconcileAll(context.Background(), namespace)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"10.0.0.1:8000"}, manager.GetAllAddresses("model-a"))
+	require.ElementsMatch(t, []string{"10.0.0.2:8001"}, manager.GetAllAddresses("model-b"))
+	require.ElementsMatch(t, []string{"10.0.0.3:9000", "10.0.0.4:7000"}, manager.GetAllAddresses("model-c"),
+		"Pods without a port annotation should be skipped")
+	require.Empty(t, manager.GetAllAddresses("model-d"))
+	require.ElementsMatch(t, []string{"10.0.0.7:8000"}, manager.GetAllAddresses("model-e"))
+	require.ElementsMatch(t, []string{"10.0.0.7:8001"}, manager.GetAllAddresses("model-f"),
+		"Pods should serve the additional models that they are annotated with")
+}
+
+func TestReconcilePodModelsAnnotationName(t *testing.T) {
+	const namespace = "default"
+
+	manager := &LoadBalancer{
+		groups:                  map[string]*group{},
+		AnnotationDomains:       v1.AnnotationDomains(nil),
+		PodModelsAnnotationName: "served-models",
+	}
+	manager.Client = newTestClient(manager, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod1",
+			Namespace: namespace,
+			Labels:    map[string]string{v1.PodModelLabel: "model-a"},
+			Annotations: map[string]string{
+				"kubeai.org/served-models": "model-a,model-b",
+				"kubeai.org/models":        "model-c",
+				"kubeai.org/port":          "8000",
+			},
+		},
+		Status: corev1.PodStatus{
+			PodIP:      "10.0.0.1",
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	})
+
+	_, err := manager.ReconcileAll(context.Background(), namespace)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:8000"}, manager.GetAllAddresses("model-b"),
+		"models should be read from the configured annotation")
+	require.Empty(t, manager.GetAllAddresses("model-c"), "the default annotation should be ignored")
+}
+
+func TestReconcilePodSelector(t *testing.T) {
+	const namespace = "default"
+
+	readyPod := func(name, ip string, labels map[string]string) *corev1.Pod {
+		labels[v1.PodModelLabel] = "model-a"
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      labels,
+				Annotations: map[string]string{v1.ModelPodPortAnnotation: "8000"},
+			},
+			Status: corev1.PodStatus{
+				PodIP:      ip,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+
+	selector, err := labels.Parse("platform.example.com/kubeai=true")
+	require.NoError(t, err)
+	manager := &LoadBalancer{
+		groups:      map[string]*group{},
+		PodSelector: selector,
+	}
+	manager.Client = newTestClient(manager,
+		readyPod("selected", "10.0.0.1", map[string]string{"platform.example.com/kubeai": "true"}),
+		readyPod("not-selected", "10.0.0.2", map[string]string{}),
+	)
+
+	_, err = manager.ReconcileAll(context.Background(), namespace)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:8000"}, manager.GetAllAddresses("model-a"),
+		"Pods that do not match the selector should be excluded from routing")
+
+	// A Pod that stops matching is removed from routing.
+	ctx := context.Background()
+	pod := &corev1.Pod{}
+	require.NoError(t, manager.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "selected"}, pod))
+	delete(pod.Labels, "platform.example.com/kubeai")
+	require.NoError(t, manager.Update(ctx, pod))
+	_, err = manager.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "selected"}})
+	require.NoError(t, err)
+	require.Empty(t, manager.GetAllAddresses("model-a"))
+}
+
+func TestPodPredicate(t *testing.T) {
+	selector, err := labels.Parse("platform.example.com/kubeai=true")
+	require.NoError(t, err)
+	pred := (&LoadBalancer{PodSelector: selector}).podPredicate()
+
+	pod := func(podLabels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", Labels: podLabels}}
+	}
+	selected := pod(map[string]string{v1.PodModelLabel: "model-a", "platform.example.com/kubeai": "true"})
+	unselected := pod(map[string]string{v1.PodModelLabel: "model-a"})
+	self := pod(map[string]string{selfLabelKey: selfLabelVal})
+	other := pod(map[string]string{"app": "other"})
+
+	require.True(t, pred.Create(event.CreateEvent{Object: selected}))
+	require.True(t, pred.Create(event.CreateEvent{Object: self}))
+	require.False(t, pred.Create(event.CreateEvent{Object: unselected}))
+	require.False(t, pred.Create(event.CreateEvent{Object: other}))
+	require.True(t, pred.Delete(event.DeleteEvent{Object: selected}))
+
+	// Pods that stop or start matching are reconciled.
+	require.True(t, pred.Update(event.UpdateEvent{ObjectOld: selected, ObjectNew: unselected}))
+	require.True(t, pred.Update(event.UpdateEvent{ObjectOld: unselected, ObjectNew: selected}))
+	require.False(t, pred.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: unselected}))
+}
+
+func TestReconcileWarmPool(t *testing.T) {
+	const namespace = "default"
+
+	warmPod := func(name, ip string, labels map[string]string) *corev1.Pod {
+		labels[v1.WarmPoolLabel] = "gpu"
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      labels,
+				Annotations: map[string]string{"kubeai.org/port": "8000"},
+			},
+			Status: corev1.PodStatus{
+				PodIP:      ip,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	}
+
+	manager := &LoadBalancer{
+		groups:            map[string]*group{},
+		AnnotationDomains: v1.AnnotationDomains(nil),
+	}
+	k8sClient := newTestClient(manager,
+		warmPod("claimed", "10.0.0.1", map[string]string{v1.WarmPoolModelLabel: "model-a"}),
+		warmPod("unclaimed", "10.0.0.2", map[string]string{}),
+	)
+	manager.Client = k8sClient
+	ctx := context.Background()
+
+	n, err := manager.ReconcileAll(ctx, namespace)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []string{"10.0.0.1:8000"}, manager.GetAllAddresses("model-a"),
+		"claimed warm pool Pods should be routed to")
+
+	// Released.
+	var pod corev1.Pod
+	require.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "claimed"}, &pod))
+	delete(pod.Labels, v1.WarmPoolModelLabel)
+	require.NoError(t, k8sClient.Update(ctx, &pod))
+	_, err = manager.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "claimed"}})
+	require.NoError(t, err)
+	require.Empty(t, manager.GetAllAddresses("model-a"))
+}
+
+func TestReconcileCurrentGenerationOnly(t *testing.T) {
+	const namespace = "default"
+	ctx := context.Background()
+
+	pod := func(name, ip string, labels map[string]string, created time.Time, ready bool) *corev1.Pod {
+		labels[v1.PodModelLabel] = "model-a"
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				Labels:            labels,
+				Annotations:       map[string]string{v1.ModelPodPortAnnotation: "8000"},
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Status: corev1.PodStatus{PodIP: ip},
+		}
+		if ready {
+			p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		}
+		return p
+	}
+	setReady := func(t *testing.T, k8sClient client.Client, p *corev1.Pod) {
+		t.Helper()
+		p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		require.NoError(t, k8sClient.Status().Update(ctx, p))
+	}
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("kubeai pods", func(t *testing.T) {
+		model := &v1.Model{ObjectMeta: metav1.ObjectMeta{
+			Name:        "model-a",
+			Namespace:   namespace,
+			Annotations: map[string]string{v1.ModelPodHashAnnotation: "new-hash"},
+		}}
+		newPod := pod("new", "10.0.0.3", map[string]string{v1.PodHashLabel: "new-hash"}, now.Add(-time.Minute), false)
+		manager := &LoadBalancer{
+			groups:                map[string]*group{},
+			CurrentGenerationOnly: true,
+		}
+		k8sClient := newTestClient(manager,
+			model,
+			pod("old1", "10.0.0.1", map[string]string{v1.PodHashLabel: "old-hash"}, now.Add(-time.Hour), true),
+			// Pods of a previous generation can be newer than the Pods of the latest generation.
+			pod("old2", "10.0.0.2", map[string]string{v1.PodHashLabel: "old-hash"}, now, true),
+			newPod,
+		)
+		manager.Client = k8sClient
+
+		_, err := manager.ReconcileAll(ctx, namespace)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"10.0.0.1:8000", "10.0.0.2:8000"}, manager.GetAllAddresses("model-a"),
+			"Pods of the previous generation should be routed to until a Pod of the latest generation is ready")
+
+		setReady(t, k8sClient, newPod)
+		_, err = manager.ReconcileAll(ctx, namespace)
+		require.NoError(t, err)
+		require.Equal(t, []string{"10.0.0.3:8000"}, manager.GetAllAddresses("model-a"),
+			"only the Pods of the latest generation should be routed to")
+	})
+
+	t.Run("deployment pods", func(t *testing.T) {
+		deployment := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "model-a", UID: "deployment", Controller: ptr.To(true)}
+		replicaSet := func(hash, revision string) *appsv1.ReplicaSet {
+			return &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+				Name:            "model-a-" + hash,
+				Namespace:       namespace,
+				Labels:          map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash},
+				Annotations:     map[string]string{deploymentRevisionAnnotation: revision},
+				OwnerReferences: []metav1.OwnerReference{deployment},
+			}}
+		}
+		deploymentPod := func(name, ip, hash string, created time.Time, ready bool) *corev1.Pod {
+			p := pod(name, ip, map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: hash}, created, ready)
+			p.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "model-a-" + hash, UID: types.UID(hash), Controller: ptr.To(true)}}
+			return p
+		}
+		newPod := deploymentPod("new", "10.0.0.3", "bbb", now.Add(-time.Minute), false)
+		manager := &LoadBalancer{
+			groups:                map[string]*group{},
+			CurrentGenerationOnly: true,
+		}
+		k8sClient := newTestClient(manager,
+			// The latest revision after a rollback to "aaa".
+			replicaSet("aaa", "3"),
+			replicaSet("bbb", "4"),
+			deploymentPod("old1", "10.0.0.1", "aaa", now.Add(-time.Hour), true),
+			// Recreated by the ReplicaSet of the previous generation.
+			deploymentPod("old2", "10.0.0.2", "aaa", now, true),
+			newPod,
+		)
+		manager.Client = k8sClient
+
+		_, err := manager.ReconcileAll(ctx, namespace)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"10.0.0.1:8000", "10.0.0.2:8000"}, manager.GetAllAddresses("model-a"))
+
+		setReady(t, k8sClient, newPod)
+		_, err = manager.ReconcileAll(ctx, namespace)
+		require.NoError(t, err)
+		require.Equal(t, []string{"10.0.0.3:8000"}, manager.GetAllAddresses("model-a"),
+			"only the Pods of the ReplicaSet with the latest revision should be routed to")
+	})
+}
+
+func TestSync(t *testing.T) {
+	const namespace = "default"
+
+	manager := &LoadBalancer{
+		groups: map[string]*group{},
+	}
+	manager.Client = newTestClient(manager, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pod1",
+			Namespace:   namespace,
+			Labels:      map[string]string{v1.PodModelLabel: "model-a"},
+			Annotations: map[string]string{v1.ModelPodPortAnnotation: "8000"},
+		},
+		Status: corev1.PodStatus{
+			PodIP:      "10.0.0.1",
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	})
+	require.Error(t, manager.Ready(nil), "should not be ready before the endpoints are populated")
+
+	require.Error(t, manager.Sync(context.Background(), &testCache{synced: false}, namespace))
+	require.Error(t, manager.Ready(nil))
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, manager.WaitForInitialSync(canceled), context.Canceled)
+
+	require.NoError(t, manager.Sync(context.Background(), &testCache{synced: true}, namespace))
+	require.NoError(t, manager.Ready(nil))
+	require.NoError(t, manager.WaitForInitialSync(canceled), "should not block once synced")
+	require.NoError(t, manager.Sync(context.Background(), &testCache{synced: true}, namespace), "should be safe to call again")
+	require.Equal(t, []string{"10.0.0.1:8000"}, manager.GetAllAddresses("model-a"))
+}
+
+type testCache struct {
+	cache.Cache
+	synced bool
+}
+
+func (c *testCache) WaitForCacheSync(ctx context.Context) bool {
+	return c.synced
+}
+
+// newTestClient returns a fake client with the given objects and the
+// podModelsIndex of the given LoadBalancer.
+func newTestClient(r *LoadBalancer, objs ...client.Object) client.WithWatch {
+	return fake.NewClientBuilder().WithScheme(testScheme).WithObjects(objs...).
+		WithIndex(&corev1.Pod{}, podModelsIndex, r.indexPodModels).Build()
+}
+
+func TestReconcileAllWithoutPods(t *testing.T) {
+	const namespace = "default"
+
+	manager := &LoadBalancer{
+		groups: map[string]*group{},
+	}
+	manager.Client = newTestClient(manager,
+		&v1.Model{ObjectMeta: metav1.ObjectMeta{Name: "model-a", Namespace: namespace}},
+		&v1.Model{ObjectMeta: metav1.ObjectMeta{Name: "model-b", Namespace: namespace}},
+	)
+	// Stale endpoints of Models whose Pods were removed.
+	manager.getEndpoints("model-a").reconcileEndpoints(map[string]endpoint{"default/pod1": {address: "10.0.0.1:8000"}})
+	manager.getEndpoints("deleted").reconcileEndpoints(map[string]endpoint{"default/pod2": {address: "10.0.0.2:8000"}})
+
+	n, err := manager.ReconcileAll(context.Background(), namespace)
+	require.NoError(t, err)
+	require.Equal(t, 3, n, "all Models and models with endpoints should be refreshed")
+	require.Empty(t, manager.GetAllAddresses("model-a"))
+	require.Empty(t, manager.GetAllAddresses("deleted"))
+}
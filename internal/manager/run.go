@@ -2,13 +2,16 @@ package manager
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
 
 	"sigs.k8s.io/yaml"
@@ -17,9 +20,15 @@ import (
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
 	"k8s.io/utils/ptr"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -31,8 +40,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/adminserver"
+	"github.com/substratusai/kubeai/internal/externalscaler"
 	"github.com/substratusai/kubeai/internal/leader"
 	"github.com/substratusai/kubeai/internal/loadbalancer"
 	"github.com/substratusai/kubeai/internal/messenger"
@@ -42,6 +54,7 @@ import (
 	"github.com/substratusai/kubeai/internal/modelproxy"
 	"github.com/substratusai/kubeai/internal/openaiserver"
 	"github.com/substratusai/kubeai/internal/vllmclient"
+	"github.com/substratusai/kubeai/internal/webhooks"
 
 	// Pulling in these packages will register the gocloud implementations.
 	_ "gocloud.dev/pubsub/awssnssqs"
@@ -106,24 +119,32 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 		Log.Info("loaded config", "config", string(cfgYaml))
 	}
 
-	// if the enable-http2 flag is false (the default), http/2 should be disabled
-	// due to its vulnerabilities. More specifically, disabling http/2 will
-	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
-	// Rapid Reset CVEs. For more information see:
+	// The webhook server disables http/2 due to its vulnerabilities. More
+	// specifically, disabling http/2 will prevent from being vulnerable to the
+	// HTTP/2 Stream Cancellation and Rapid Reset CVEs. For more information see:
 	// - https://github.com/advisories/GHSA-qppj-fm5r-hxr3
 	// - https://github.com/advisories/GHSA-4374-p667-p6c8
-	//disableHTTP2 := func(c *tls.Config) {
-	//	Log.Info("disabling http/2")
-	//	c.NextProtos = []string{"http/1.1"}
-	//}
-
-	//if !enableHTTP2 {
-	//	tlsOpts = append(tlsOpts, disableHTTP2)
-	//}
+	disableHTTP2 := func(c *tls.Config) {
+		c.NextProtos = []string{"http/1.1"}
+	}
 
-	//webhookServer := webhook.NewServer(webhook.Options{
-	//	TLSOpts: tlsOpts,
-	//})
+	var webhookServer webhook.Server
+	if cfg.WebhookAddr != "" {
+		host, portStr, err := net.SplitHostPort(cfg.WebhookAddr)
+		if err != nil {
+			return fmt.Errorf("parsing webhook address: %w", err)
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return fmt.Errorf("parsing webhook port: %w", err)
+		}
+		webhookServer = webhook.NewServer(webhook.Options{
+			Host:    host,
+			Port:    port,
+			CertDir: cfg.WebhookCertDir,
+			TLSOpts: []func(*tls.Config){disableHTTP2},
+		})
+	}
 
 	// Metrics endpoint is enabled in 'config/default/kustomization.yaml'. The Metrics options configure the server.
 	// More info:
@@ -138,9 +159,9 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 	}
 
 	mgr, err := ctrl.NewManager(k8sCfg, ctrl.Options{
-		Scheme:  Scheme,
-		Metrics: metricsServerOptions,
-		//WebhookServer:          webhookServer,
+		Scheme:                 Scheme,
+		Metrics:                metricsServerOptions,
+		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: cfg.HealthAddress,
 		// TODO: Consolidate controller and autoscaler leader election.
 		LeaderElection:          true,
@@ -204,10 +225,68 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 		cfg.LeaderElection.RetryPeriod.Duration,
 	)
 
-	loadBalancer, err := loadbalancer.New(mgr)
+	var podSelector labels.Selector
+	if cfg.ModelRouting.PodSelector != "" {
+		podSelector, err = labels.Parse(cfg.ModelRouting.PodSelector)
+		if err != nil {
+			return fmt.Errorf("parsing model routing pod selector: %w", err)
+		}
+	}
+	var modelSelector labels.Selector
+	if cfg.ModelSelector != "" {
+		modelSelector, err = labels.Parse(cfg.ModelSelector)
+		if err != nil {
+			return fmt.Errorf("parsing model selector: %w", err)
+		}
+	}
+	loadBalancer, err := loadbalancer.New(mgr, kubeaiv1.AnnotationDomains(cfg.AnnotationDomains), podSelector)
 	if err != nil {
 		return fmt.Errorf("unable to setup model resolver: %w", err)
 	}
+	loadBalancer.CurrentGenerationOnly = cfg.ModelRouting.CurrentGenerationOnly
+	loadBalancer.PodModelsAnnotationName = cfg.ModelRouting.PodModelsAnnotationName
+	loadBalancer.IsLeader = leaderElection.IsLeader
+
+	var auditSink modelclient.AuditSink
+	if cfg.ModelAutoscaling.AuditLog != "" {
+		auditSink, err = modelclient.NewAuditSink(cfg.ModelAutoscaling.AuditLog)
+		if err != nil {
+			return fmt.Errorf("unable to setup scale audit log: %w", err)
+		}
+	}
+
+	stateConfigMapRef := types.NamespacedName{Name: cfg.ModelAutoscaling.StateConfigMapName, Namespace: namespace}
+	modelClient := modelclient.NewModelClient(mgr.GetClient(), namespace, modelclient.Options{
+		FallbackModel:               cfg.ModelRouting.FallbackModel,
+		AnnotationDomains:           cfg.AnnotationDomains,
+		ModelSelector:               modelSelector,
+		UnschedulableTimeout:        cfg.ModelAutoscaling.UnschedulableTimeout.Duration,
+		ScaleDebounceInterval:       cfg.ModelAutoscaling.ScaleDebounceInterval.Duration,
+		MaxQueueWait:                cfg.ModelRouting.MaxQueueWait.Duration,
+		AuditSink:                   auditSink,
+		MaxTotalReplicas:            cfg.ModelAutoscaling.MaxTotalReplicas,
+		UseScalePatch:               cfg.ModelAutoscaling.UseScalePatch,
+		RespectPodDisruptionBudgets: cfg.ModelAutoscaling.RespectPodDisruptionBudgets,
+		ReplicaBoundsFromHPA:        cfg.ModelAutoscaling.ReplicaBoundsFromHPA,
+		ObserveOnly:                 cfg.ModelAutoscaling.ObserveOnly,
+		StateConfigMap:              stateConfigMapRef,
+		InstanceName:                hostname,
+	})
+	if err := modelClient.IndexCapabilities(ctx, mgr.GetFieldIndexer()); err != nil {
+		return fmt.Errorf("unable to index model capabilities: %w", err)
+	}
+	if err := modelClient.WatchSharedState(ctx, mgr.GetCache()); err != nil {
+		return fmt.Errorf("unable to watch the state ConfigMap: %w", err)
+	}
+	// Endpoint changes are included in the state watches of the model client
+	// (i.e. the admin scaler state stream).
+	loadBalancer.OnEndpointsChange = modelClient.NotifyStateChange
+
+	if cfg.WebhookAddr != "" {
+		if err := webhooks.Setup(mgr, loadBalancer, modelClient); err != nil {
+			return fmt.Errorf("unable to setup validating webhooks: %w", err)
+		}
+	}
 
 	modelReconciler := &modelcontroller.ModelReconciler{
 		Client:                  mgr.GetClient(),
@@ -223,6 +302,9 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 		ModelServerPods:         cfg.ModelServerPods,
 		ModelLoaders:            cfg.ModelLoading,
 		ModelRollouts:           cfg.ModelRollouts,
+		AnnotationDomains:       kubeaiv1.AnnotationDomains(cfg.AnnotationDomains),
+		ModelSelector:           modelSelector,
+		Scaler:                  modelClient,
 		VLLMClient: &vllmclient.Client{
 			HTTPClient: &http.Client{Timeout: 10 * time.Second},
 		},
@@ -235,12 +317,10 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		return fmt.Errorf("unable to set up health check: %w", err)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := mgr.AddReadyzCheck("readyz", loadBalancer.Ready); err != nil {
 		return fmt.Errorf("unable to set up ready check: %w", err)
 	}
 
-	modelClient := modelclient.NewModelClient(mgr.GetClient(), namespace)
-
 	metricsPort, err := parsePortFromAddr(cfg.MetricsAddr)
 	if err != nil {
 		return fmt.Errorf("unable to parse metrics port: %w", err)
@@ -254,21 +334,42 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 		loadBalancer,
 		cfg.ModelAutoscaling,
 		metricsPort,
-		types.NamespacedName{Name: cfg.ModelAutoscaling.StateConfigMapName, Namespace: namespace},
+		stateConfigMapRef,
 		cfg.FixedSelfMetricAddrs,
+		clock.RealClock{},
 	)
 	if err != nil {
 		return fmt.Errorf("unable to create model autoscaler: %w", err)
 	}
+	modelAutoscaler.Recorder = mgr.GetEventRecorderFor("kubeai-autoscaler")
 
-	modelProxy := modelproxy.NewHandler(modelClient, loadBalancer, 3, nil)
+	modelProxy := modelproxy.NewHandler(modelClient, loadBalancer, 3, nil, cfg.ModelRouting.RejectWhenSaturated, cfg.ModelRouting.ModelHeader)
+	// Cached responses of deleted Models would otherwise only be evicted once
+	// the response cache is full.
+	modelInformer, err := mgr.GetCache().GetInformer(ctx, &kubeaiv1.Model{})
+	if err != nil {
+		return fmt.Errorf("unable to get Model informer: %w", err)
+	}
+	if _, err := modelInformer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if m, ok := obj.(*kubeaiv1.Model); ok {
+				modelProxy.ForgetModel(m.Name)
+			}
+		},
+	}); err != nil {
+		return fmt.Errorf("unable to watch deleted Models: %w", err)
+	}
 	openaiHandler := openaiserver.NewHandler(mgr.GetClient(), modelProxy)
 	mux := http.NewServeMux()
 	mux.Handle("/openai/", openaiHandler)
 	apiServer := &http.Server{
 		BaseContext: func(_ net.Listener) context.Context { return ctx },
 		Addr:        ":8000",
-		Handler:     mux,
+		// gRPC clients connect over HTTP/2 without TLS.
+		Handler: h2c.NewHandler(modelProxy.WithGRPC(mux), &http2.Server{}),
 	}
 
 	metricsMux := http.NewServeMux()
@@ -278,6 +379,24 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 	}
 	metricsMux.Handle("/metrics", promhttp.Handler())
 
+	// The admin API can modify Models, so it is not served on the metrics
+	// port (which is exposed by the Service) and is disabled by default.
+	var adminServer *http.Server
+	if cfg.AdminAddr != "" {
+		adminServer = &http.Server{
+			BaseContext: func(_ net.Listener) context.Context { return ctx },
+			Addr:        cfg.AdminAddr,
+			Handler:     adminserver.NewHandler(modelClient, modelReconciler, loadBalancer, modelAutoscaler, namespace),
+		}
+	}
+
+	var externalScalerServer *grpc.Server
+	if cfg.ExternalScalerAddr != "" {
+		externalScalerServer = grpc.NewServer()
+		externalscaler.RegisterExternalScalerServer(externalScalerServer,
+			externalscaler.NewServer(modelClient, modelAutoscaler, cfg.ModelAutoscaling.Interval.Duration))
+	}
+
 	httpClient := &http.Client{}
 
 	var msgrs []*messenger.Messenger
@@ -309,12 +428,78 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 		modelAutoscaler.Start(ctx)
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer func() {
+			Log.Info("model client stopped")
+			wg.Done()
+		}()
+		<-ctx.Done()
+		// Ensure that no replicas are written after shutdown begins.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), modelClientShutdownTimeout)
+		defer cancel()
+		if err := modelClient.Shutdown(shutdownCtx); err != nil {
+			Log.Error(err, "timed out waiting for in-flight scale operations")
+		}
+		if auditSink != nil {
+			if err := auditSink.Close(); err != nil {
+				Log.Error(err, "unable to close scale audit log")
+			}
+		}
+	}()
+
+	if ttl := cfg.ModelRouting.IdleTTL.Duration; ttl > 0 {
+		wg.Add(1)
+		go func() {
+			defer func() {
+				Log.Info("idle routing eviction stopped")
+				wg.Done()
+			}()
+			loadBalancer.StartIdleEviction(ctx, ttl)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer func() {
+			Log.Info("autoscaling pause signal handler stopped")
+			wg.Done()
+		}()
+		// SIGUSR1 toggles pausing of autoscaling (useful during maintenance windows).
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGUSR1)
+		defer signal.Stop(sigs)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigs:
+				if modelClient.IsAutoscalingPaused(ctx) {
+					if err := modelClient.ResumeAutoscaling(ctx); err != nil {
+						Log.Error(err, "applying deferred scale operations")
+					}
+				} else if err := modelClient.PauseAutoscaling(ctx); err != nil {
+					Log.Error(err, "pausing autoscaling")
+				}
+			}
+		}
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer func() {
 			Log.Info("api server stopped")
 			wg.Done()
 		}()
+		// Populate the endpoints of all models before serving requests so that
+		// requests for models with ready Pods do not wait after a restart.
+		if err := loadBalancer.Sync(ctx, mgr.GetCache(), namespace); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			Log.Error(err, "populating model endpoints")
+			os.Exit(1)
+		}
 		Log.Info("starting api server", "addr", apiServer.Addr)
 		if err := apiServer.ListenAndServe(); err != nil {
 			if errors.Is(err, http.ErrServerClosed) {
@@ -341,6 +526,43 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 			}
 		}
 	}()
+	if adminServer != nil {
+		wg.Add(1)
+		go func() {
+			defer func() {
+				Log.Info("admin server stopped")
+				wg.Done()
+			}()
+			Log.Info("starting admin server", "addr", adminServer.Addr)
+			if err := adminServer.ListenAndServe(); err != nil {
+				if errors.Is(err, http.ErrServerClosed) {
+					Log.Info("admin server closed")
+				} else {
+					Log.Error(err, "error serving admin server")
+					os.Exit(1)
+				}
+			}
+		}()
+	}
+	if externalScalerServer != nil {
+		wg.Add(1)
+		go func() {
+			defer func() {
+				Log.Info("external scaler server stopped")
+				wg.Done()
+			}()
+			Log.Info("starting external scaler server", "addr", cfg.ExternalScalerAddr)
+			lis, err := net.Listen("tcp", cfg.ExternalScalerAddr)
+			if err != nil {
+				Log.Error(err, "error listening for external scaler server")
+				os.Exit(1)
+			}
+			if err := externalScalerServer.Serve(lis); err != nil {
+				Log.Error(err, "error serving external scaler server")
+				os.Exit(1)
+			}
+		}()
+	}
 	wg.Add(1)
 	go func() {
 		defer func() {
@@ -365,6 +587,11 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 				Log.Info("messenger stopped", "index", i)
 				wg.Done()
 			}()
+			// Messages are only consumed once the endpoints of all models are known,
+			// like requests to the api server.
+			if err := loadBalancer.WaitForInitialSync(ctx); err != nil {
+				return
+			}
 			Log.Info("Starting messenger", "index", i)
 			err := msgrs[i].Start(ctx)
 			if err != nil {
@@ -393,6 +620,12 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 		}
 		apiServer.Shutdown(context.Background())
 		metricsServer.Shutdown(context.Background())
+		if adminServer != nil {
+			adminServer.Shutdown(context.Background())
+		}
+		if externalScalerServer != nil {
+			externalScalerServer.GracefulStop()
+		}
 	}()
 
 	Log.Info("run launched all goroutines")
@@ -402,6 +635,9 @@ func Run(ctx context.Context, k8sCfg *rest.Config, cfg config.System) error {
 	return nil
 }
 
+// modelClientShutdownTimeout is the maximum time to wait for in-flight scale operations on shutdown.
+const modelClientShutdownTimeout = 10 * time.Second
+
 // parsePortFromAddr takes a string like ":8080" and returns 8080.
 func parsePortFromAddr(addr string) (int, error) {
 	if addr == "" {
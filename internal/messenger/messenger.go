@@ -71,7 +71,9 @@ func NewMessenger(
 }
 
 type ModelClient interface {
-	LookupModel(ctx context.Context, model, adapter string, selectors []string) (*v1.Model, error)
+	ResolveModel(ctx context.Context, model, adapter string, selectors []string) (*v1.Model, bool, error)
+	ResolveVersioned(ctx context.Context, model, version string) (string, bool, error)
+	ResolveCapable(ctx context.Context, capabilities, selectors []string) (string, bool, error)
 	ScaleAtLeastOneReplica(ctx context.Context, model string) error
 }
 
@@ -211,9 +213,13 @@ func (m *Messenger) handleRequest(ctx context.Context, msg *pubsub.Message) {
 	))
 	metrics.InferenceRequestsActive.Add(ctx, 1, metricAttrs)
 	defer metrics.InferenceRequestsActive.Add(ctx, -1, metricAttrs)
+	metrics.InferenceRequestsLoad.Add(ctx, mr.Weight, metricAttrs)
+	defer metrics.InferenceRequestsLoad.Add(ctx, -mr.Weight, metricAttrs)
 
 	// Ensure the backend is scaled to at least one Pod.
-	m.modelClient.ScaleAtLeastOneReplica(ctx, mr.Model)
+	if err := m.modelClient.ScaleAtLeastOneReplica(ctx, mr.Model); err != nil {
+		log.Printf("Failed to scale model %q for message %s: %v", mr.Model, msg.LoggableID, err)
+	}
 
 	log.Printf("Awaiting host for message %s", msg.LoggableID)
 
@@ -273,7 +279,7 @@ func (m *Messenger) parseMsgRequest(ctx context.Context, msg *pubsub.Message) (*
 	req.metadata = payload.Metadata
 	req.path = path
 
-	apiR, err := apiutils.ParseRequest(ctx, m.modelClient, bytes.NewReader(payload.Body), path, http.Header{})
+	apiR, err := apiutils.ParseRequest(ctx, m.modelClient, bytes.NewReader(payload.Body), path, http.Header{}, "")
 	if err != nil {
 		return req, err
 	}
@@ -16,14 +16,70 @@ const (
 var (
 	InferenceRequestsActiveMetricName               = "kubeai.inference.requests.active"
 	InferenceRequestsActive                         metric.Int64UpDownCounter
+	InferenceRequestsLoadMetricName                 = "kubeai.inference.requests.load"
+	InferenceRequestsLoad                           metric.Int64UpDownCounter
 	InferenceRequestsHashLookupIterationsMetricName = "kubeai.inference.requests.hash.lookup.iterations"
 	InferenceRequestsHashLookupIterations           metric.Int64Histogram
+	InferenceRequestsQueueDurationMetricName        = "kubeai.inference.requests.queue.duration"
+	InferenceRequestsQueueDuration                  metric.Float64Histogram
+	InferenceRequestsQueuedMetricName               = "kubeai.inference.requests.queued"
+	InferenceRequestsQueued                         metric.Int64UpDownCounter
+	InferenceRequestsQueueTimeoutsMetricName        = "kubeai.inference.requests.queue.timeouts"
+	InferenceRequestsQueueTimeouts                  metric.Int64Counter
+	InferenceRequestsCacheHitsMetricName            = "kubeai.inference.requests.cache.hits"
+	InferenceRequestsCacheHits                      metric.Int64Counter
+	InferenceRequestsConcurrencyLimitedMetricName   = "kubeai.inference.requests.concurrency.limited"
+	InferenceRequestsConcurrencyLimited             metric.Int64Counter
+	InferenceStreamsActiveMetricName                = "kubeai.inference.streams.active"
+	InferenceStreamsActive                          metric.Int64UpDownCounter
+)
+
+// Metrics used to observe requests by model:
+var (
+	InferenceRequestsMetricName         = "kubeai.inference.requests"
+	InferenceRequests                   metric.Int64Counter
+	InferenceRequestsDurationMetricName = "kubeai.inference.requests.duration"
+	InferenceRequestsDuration           metric.Float64Histogram
+)
+
+// Metrics used to observe autoscaling decisions:
+var (
+	ModelLastActivityTimestampMetricName = "kubeai.model.last.activity.timestamp"
+	ModelLastActivityTimestamp           metric.Float64Gauge
+	AutoscalingPausedMetricName          = "kubeai.autoscaling.paused"
+	AutoscalingPaused                    metric.Int64Gauge
+	ModelColdStartDurationMetricName     = "kubeai.model.coldstart.duration"
+	ModelColdStartDuration               metric.Float64Histogram
+	ModelScaleNoopsMetricName            = "kubeai.model.scale.noops"
+	ModelScaleNoops                      metric.Int64Counter
+	ModelReplicasTotalMetricName         = "kubeai.model.replicas.total"
+	ModelReplicasTotal                   metric.Int64Gauge
+	ModelReplicaBudgetMetricName         = "kubeai.model.replica.budget"
+	ModelReplicaBudget                   metric.Int64Gauge
+	ModelReplicasLagMetricName           = "kubeai.model.replicas.lag"
+	ModelReplicasLag                     metric.Int64Gauge
+	ModelReplicasDesiredMetricName       = "kubeai.model.replicas.desired"
+	ModelReplicasDesired                 metric.Int64Gauge
+	ModelRequestsAverageMetricName       = "kubeai.model.requests.average"
+	ModelRequestsAverage                 metric.Float64Gauge
+	ModelUnscalableMetricName            = "kubeai.model.unscalable"
+	ModelUnscalable                      metric.Int64Gauge
+)
+
+// Metrics used to observe routing:
+var (
+	ModelRoutingConflictsMetricName           = "kubeai.model.routing.conflicts"
+	ModelRoutingConflicts                     metric.Int64Counter
+	InferenceRequestsEndpointActiveMetricName = "kubeai.inference.requests.endpoint.active"
+	InferenceRequestsEndpointActive           metric.Int64UpDownCounter
 )
 
 // Attributes:
 var (
-	AttrRequestModel = attribute.Key("request.model")
-	AttrRequestType  = attribute.Key("request.type")
+	AttrRequestModel       = attribute.Key("request.model")
+	AttrRequestType        = attribute.Key("request.type")
+	AttrResponseStatusCode = attribute.Key("response.status_code")
+	AttrEndpoint           = attribute.Key("endpoint")
 )
 
 // Attribute values:
@@ -41,6 +97,12 @@ func Init(meter metric.Meter) error {
 	if err != nil {
 		return fmt.Errorf("%s: %w", InferenceRequestsActiveMetricName, err)
 	}
+	InferenceRequestsLoad, err = meter.Int64UpDownCounter(InferenceRequestsLoadMetricName,
+		metric.WithDescription("The number of active requests by model, weighted by the request type (see .spec.streamingRequestWeight)"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", InferenceRequestsLoadMetricName, err)
+	}
 	InferenceRequestsHashLookupIterations, err = meter.Int64Histogram(InferenceRequestsHashLookupIterationsMetricName,
 		metric.WithDescription("The number of vnodes considered while searching for the best endpoint for a request"),
 		metric.WithExplicitBucketBoundaries(1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024),
@@ -48,6 +110,131 @@ func Init(meter metric.Meter) error {
 	if err != nil {
 		return fmt.Errorf("%s: %w", InferenceRequestsHashLookupIterationsMetricName, err)
 	}
+	InferenceRequestsQueueDuration, err = meter.Float64Histogram(InferenceRequestsQueueDurationMetricName,
+		metric.WithDescription("The time requests spent waiting for an available endpoint"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", InferenceRequestsQueueDurationMetricName, err)
+	}
+	InferenceRequestsQueued, err = meter.Int64UpDownCounter(InferenceRequestsQueuedMetricName,
+		metric.WithDescription("The number of requests that are waiting for an available endpoint by model"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", InferenceRequestsQueuedMetricName, err)
+	}
+	InferenceRequestsQueueTimeouts, err = meter.Int64Counter(InferenceRequestsQueueTimeoutsMetricName,
+		metric.WithDescription("The number of requests that were rejected after waiting for an available endpoint for longer than the max queue wait by model"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", InferenceRequestsQueueTimeoutsMetricName, err)
+	}
+	InferenceRequestsCacheHits, err = meter.Int64Counter(InferenceRequestsCacheHitsMetricName,
+		metric.WithDescription("The number of requests that were served from the response cache by model"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", InferenceRequestsCacheHitsMetricName, err)
+	}
+	InferenceRequestsConcurrencyLimited, err = meter.Int64Counter(InferenceRequestsConcurrencyLimitedMetricName,
+		metric.WithDescription("The number of times requests were queued because all endpoints were at the max concurrent requests per replica by model"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", InferenceRequestsConcurrencyLimitedMetricName, err)
+	}
+	InferenceStreamsActive, err = meter.Int64UpDownCounter(InferenceStreamsActiveMetricName,
+		metric.WithDescription("The number of open streaming connections (Server-Sent Events and WebSockets) by model"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", InferenceStreamsActiveMetricName, err)
+	}
+	InferenceRequests, err = meter.Int64Counter(InferenceRequestsMetricName,
+		metric.WithDescription("The number of completed requests by model and response status code"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", InferenceRequestsMetricName, err)
+	}
+	InferenceRequestsDuration, err = meter.Float64Histogram(InferenceRequestsDurationMetricName,
+		metric.WithDescription("The time taken to respond to requests by model (including queueing and retries)"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", InferenceRequestsDurationMetricName, err)
+	}
+	ModelLastActivityTimestamp, err = meter.Float64Gauge(ModelLastActivityTimestampMetricName,
+		metric.WithDescription("The unix timestamp of the last request observed by model"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ModelLastActivityTimestampMetricName, err)
+	}
+	AutoscalingPaused, err = meter.Int64Gauge(AutoscalingPausedMetricName,
+		metric.WithDescription("Whether autoscaling is paused (1) or not (0)"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", AutoscalingPausedMetricName, err)
+	}
+	ModelColdStartDuration, err = meter.Float64Histogram(ModelColdStartDurationMetricName,
+		metric.WithDescription("The time taken for models to become ready after scaling up from zero replicas"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(5, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ModelColdStartDurationMetricName, err)
+	}
+	ModelScaleNoops, err = meter.Int64Counter(ModelScaleNoopsMetricName,
+		metric.WithDescription("The number of scale decisions that matched the current replicas of the model and were skipped"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ModelScaleNoopsMetricName, err)
+	}
+	ModelReplicasTotal, err = meter.Int64Gauge(ModelReplicasTotalMetricName,
+		metric.WithDescription("The total replicas of all managed models, weighted by their replica cost (only reported when the replica budget is enabled)"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ModelReplicasTotalMetricName, err)
+	}
+	ModelReplicaBudget, err = meter.Int64Gauge(ModelReplicaBudgetMetricName,
+		metric.WithDescription("The maximum total replicas of all managed models"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ModelReplicaBudgetMetricName, err)
+	}
+	ModelReplicasLag, err = meter.Int64Gauge(ModelReplicasLagMetricName,
+		metric.WithDescription("The replicas of the most recent scale operation of the autoscaler minus the replicas that were last applied by model"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ModelReplicasLagMetricName, err)
+	}
+	ModelReplicasDesired, err = meter.Int64Gauge(ModelReplicasDesiredMetricName,
+		metric.WithDescription("The replicas that the autoscaler most recently calculated by model"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ModelReplicasDesiredMetricName, err)
+	}
+	ModelRequestsAverage, err = meter.Float64Gauge(ModelRequestsAverageMetricName,
+		metric.WithDescription("The moving average of the active requests that the autoscaler most recently calculated by model"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ModelRequestsAverageMetricName, err)
+	}
+	ModelUnscalable, err = meter.Int64Gauge(ModelUnscalableMetricName,
+		metric.WithDescription("Whether the scale target of the model does not implement the scale subresource (1) or does (0)"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ModelUnscalableMetricName, err)
+	}
+	ModelRoutingConflicts, err = meter.Int64Counter(ModelRoutingConflictsMetricName,
+		metric.WithDescription("The number of times a Pod was excluded from routing because it is owned by a different Model"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ModelRoutingConflictsMetricName, err)
+	}
+	InferenceRequestsEndpointActive, err = meter.Int64UpDownCounter(InferenceRequestsEndpointActiveMetricName,
+		metric.WithDescription("The number of in-flight requests by model and endpoint"),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", InferenceRequestsEndpointActiveMetricName, err)
+	}
 
 	return nil
 }
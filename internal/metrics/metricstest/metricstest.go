@@ -19,7 +19,7 @@ var (
 // Init should be called at the beginning of a test to wire up all global metrics
 // to a test reader. Test case should not be running in parallel with any other
 // part of the program that interacts with metrics.
-func Init(t *testing.T) {
+func Init(t testing.TB) {
 	testReader = metric.NewManualReader()
 	mp := metric.NewMeterProvider(
 		metric.WithReader(testReader),
@@ -56,6 +56,151 @@ func RequireActiveRequestsMetric(t *testing.T, mets metricdata.ResourceMetrics,
 	)
 }
 
+func RequireRequestsMetric(t *testing.T, mets metricdata.ResourceMetrics, model string, statusCode int, val int64) {
+	met := requireMetricExists(t, mets, metrics.MeterName, metrics.InferenceRequestsMetricName)
+	metricdatatest.AssertAggregationsEqual(t,
+		metricdata.Sum[int64]{
+			Temporality: metricdata.CumulativeTemporality,
+			IsMonotonic: true,
+			DataPoints: []metricdata.DataPoint[int64]{
+				{
+					Attributes: attribute.NewSet(
+						metrics.AttrRequestModel.String(model),
+						metrics.AttrRequestType.String(metrics.AttrRequestTypeHTTP),
+						metrics.AttrResponseStatusCode.Int(statusCode),
+					),
+					Value: val,
+				},
+			},
+		},
+		met.Data,
+		metricdatatest.IgnoreExemplars(),
+		metricdatatest.IgnoreTimestamp(),
+	)
+}
+
+func RequireConcurrencyLimitedMetric(t *testing.T, mets metricdata.ResourceMetrics, model string, val int64) {
+	met := requireMetricExists(t, mets, metrics.MeterName, metrics.InferenceRequestsConcurrencyLimitedMetricName)
+	metricdatatest.AssertAggregationsEqual(t,
+		metricdata.Sum[int64]{
+			Temporality: metricdata.CumulativeTemporality,
+			IsMonotonic: true,
+			DataPoints: []metricdata.DataPoint[int64]{
+				{
+					Attributes: attribute.NewSet(
+						metrics.AttrRequestModel.String(model),
+					),
+					Value: val,
+				},
+			},
+		},
+		met.Data,
+		metricdatatest.IgnoreExemplars(),
+		metricdatatest.IgnoreTimestamp(),
+	)
+}
+
+func RequireScaleNoopsMetric(t *testing.T, mets metricdata.ResourceMetrics, model string, val int64) {
+	met := requireMetricExists(t, mets, metrics.MeterName, metrics.ModelScaleNoopsMetricName)
+	metricdatatest.AssertAggregationsEqual(t,
+		metricdata.Sum[int64]{
+			Temporality: metricdata.CumulativeTemporality,
+			IsMonotonic: true,
+			DataPoints: []metricdata.DataPoint[int64]{
+				{
+					Attributes: attribute.NewSet(
+						metrics.AttrRequestModel.String(model),
+					),
+					Value: val,
+				},
+			},
+		},
+		met.Data,
+		metricdatatest.IgnoreExemplars(),
+		metricdatatest.IgnoreTimestamp(),
+	)
+}
+
+// RequireReplicaBudgetMetrics asserts the total replicas and the replica budget.
+func RequireReplicaBudgetMetrics(t *testing.T, mets metricdata.ResourceMetrics, total, budget int64) {
+	for name, val := range map[string]int64{
+		metrics.ModelReplicasTotalMetricName: total,
+		metrics.ModelReplicaBudgetMetricName: budget,
+	} {
+		met := requireMetricExists(t, mets, metrics.MeterName, name)
+		metricdatatest.AssertAggregationsEqual(t,
+			metricdata.Gauge[int64]{
+				DataPoints: []metricdata.DataPoint[int64]{
+					{Value: val},
+				},
+			},
+			met.Data,
+			metricdatatest.IgnoreExemplars(),
+			metricdatatest.IgnoreTimestamp(),
+		)
+	}
+}
+
+// RequireReplicasLagMetric asserts the replica lag of the model.
+func RequireReplicasLagMetric(t *testing.T, mets metricdata.ResourceMetrics, model string, val int64) {
+	met := requireMetricExists(t, mets, metrics.MeterName, metrics.ModelReplicasLagMetricName)
+	metricdatatest.AssertAggregationsEqual(t,
+		metricdata.Gauge[int64]{
+			DataPoints: []metricdata.DataPoint[int64]{
+				{
+					Attributes: attribute.NewSet(
+						metrics.AttrRequestModel.String(model),
+					),
+					Value: val,
+				},
+			},
+		},
+		met.Data,
+		metricdatatest.IgnoreExemplars(),
+		metricdatatest.IgnoreTimestamp(),
+	)
+}
+
+// RequireDesiredReplicasMetric asserts the replicas that the autoscaler calculated for the model.
+func RequireDesiredReplicasMetric(t *testing.T, mets metricdata.ResourceMetrics, model string, val int64) {
+	met := requireMetricExists(t, mets, metrics.MeterName, metrics.ModelReplicasDesiredMetricName)
+	metricdatatest.AssertAggregationsEqual(t,
+		metricdata.Gauge[int64]{
+			DataPoints: []metricdata.DataPoint[int64]{
+				{
+					Attributes: attribute.NewSet(
+						metrics.AttrRequestModel.String(model),
+					),
+					Value: val,
+				},
+			},
+		},
+		met.Data,
+		metricdatatest.IgnoreExemplars(),
+		metricdatatest.IgnoreTimestamp(),
+	)
+}
+
+// RequireModelUnscalableMetric asserts whether the scale target of the model was unscalable.
+func RequireModelUnscalableMetric(t *testing.T, mets metricdata.ResourceMetrics, model string, val int64) {
+	met := requireMetricExists(t, mets, metrics.MeterName, metrics.ModelUnscalableMetricName)
+	metricdatatest.AssertAggregationsEqual(t,
+		metricdata.Gauge[int64]{
+			DataPoints: []metricdata.DataPoint[int64]{
+				{
+					Attributes: attribute.NewSet(
+						metrics.AttrRequestModel.String(model),
+					),
+					Value: val,
+				},
+			},
+		},
+		met.Data,
+		metricdatatest.IgnoreExemplars(),
+		metricdatatest.IgnoreTimestamp(),
+	)
+}
+
 func requireMetricExists(t *testing.T, mets metricdata.ResourceMetrics, scope, name string) metricdata.Metrics {
 	for _, sm := range mets.ScopeMetrics {
 		if sm.Scope.Name == scope {
@@ -0,0 +1,38 @@
+package modelautoscaler
+
+import (
+	"math"
+	"time"
+
+	"github.com/substratusai/kubeai/internal/modelclient"
+)
+
+// adaptiveMaxStep is the maximum factor by which the adaptive target requests of
+// a model are changed in a single autoscaling interval.
+const adaptiveMaxStep = 1.2
+
+// adaptTargetRequests nudges the target requests per replica of the model towards
+// the value at which the observed p95 latency matches the target latency and
+// returns the rounded result. The target starts at the static target requests of
+// the model and stays within the bounds of the adaptive target. It is not changed
+// while no latencies were observed or the latency is within the latencyTolerance.
+func (a *Autoscaler) adaptTargetRequests(model string, static int32, target modelclient.AdaptiveTarget, p95 time.Duration, samples int) int32 {
+	a.adaptiveTargetByModelMtx.Lock()
+	defer a.adaptiveTargetByModelMtx.Unlock()
+
+	current, ok := a.adaptiveTargetByModel[model]
+	if !ok {
+		current = float64(static)
+	}
+	if samples > 0 {
+		ratio := float64(target.Latency) / float64(p95)
+		if ratio < 1-latencyTolerance || ratio > 1+latencyTolerance {
+			// Higher latency than targeted means that replicas should handle fewer requests.
+			current *= math.Min(math.Max(ratio, 1/adaptiveMaxStep), adaptiveMaxStep)
+		}
+	}
+	current = math.Min(math.Max(current, float64(target.MinRequests)), float64(target.MaxRequests))
+	a.adaptiveTargetByModel[model] = current
+
+	return int32(math.Round(current))
+}
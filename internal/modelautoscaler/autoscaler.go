@@ -2,18 +2,27 @@ package modelautoscaler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math"
+	"math/rand"
 	"sync"
 	"time"
 
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
 	"github.com/substratusai/kubeai/internal/config"
 	"github.com/substratusai/kubeai/internal/leader"
 	"github.com/substratusai/kubeai/internal/loadbalancer"
+	"github.com/substratusai/kubeai/internal/metrics"
 	"github.com/substratusai/kubeai/internal/modelclient"
 	"github.com/substratusai/kubeai/internal/movingaverage"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -27,19 +36,39 @@ func New(
 	metricsPort int,
 	stateConfigMapRef types.NamespacedName,
 	fixedSelfMetricAddrs []string,
+	clk clock.WithTicker,
 ) (*Autoscaler, error) {
 	a := &Autoscaler{
-		k8sClient:            k8sClient,
-		leaderElection:       leaderElection,
-		modelClient:          modelClient,
-		resolver:             resolver,
-		movingAvgByModel:     map[string]*movingaverage.Simple{},
-		cfg:                  cfg,
-		metricsPort:          metricsPort,
-		stateConfigMapRef:    stateConfigMapRef,
-		fixedSelfMetricAddrs: fixedSelfMetricAddrs,
+		k8sClient:              k8sClient,
+		leaderElection:         leaderElection,
+		modelClient:            modelClient,
+		resolver:               resolver,
+		movingAvgByModel:       map[string]*movingaverage.Simple{},
+		scaleDownJitterByModel: map[string]int{},
+		recommendationsByModel: map[string][]recommendation{},
+		floorByModel:           map[string]decayingFloor{},
+		adaptiveTargetByModel:  map[string]float64{},
+		ceilingByModel:         map[string]schedulableCeiling{},
+		cfg:                    cfg,
+		metricsPort:            metricsPort,
+		stateConfigMapRef:      stateConfigMapRef,
+		fixedSelfMetricAddrs:   fixedSelfMetricAddrs,
+		clock:                  clk,
+		DesiredReplicas:        DefaultDesiredReplicas,
 	}
 
+	if err := a.restoreState(ctx); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// restoreState loads the moving averages and decaying floors of all models from
+// the last known state. It is called on startup and whenever this instance
+// becomes the leader, because another instance might have autoscaled since.
+// Force scales are stored in the same ConfigMap, but by the ModelClient.
+func (a *Autoscaler) restoreState(ctx context.Context) error {
 	// Load preloaded moving averages from the last known state.
 	//
 	// NOTE: There is an edge case where this might load an empty state:
@@ -53,9 +82,16 @@ func New(
 	//
 	lastModelState, err := a.loadLastTotalModelState(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("loading last state of models: %w", err)
+		return fmt.Errorf("loading last state of models: %w", err)
 	}
 	log.Printf("Loaded last state of models: %d total, last calculated on %s", len(lastModelState.Models), lastModelState.LastCalculationTime)
+
+	a.movingAvgByModelMtx.Lock()
+	defer a.movingAvgByModelMtx.Unlock()
+	a.floorByModelMtx.Lock()
+	defer a.floorByModelMtx.Unlock()
+	a.movingAvgByModel = map[string]*movingaverage.Simple{}
+	a.floorByModel = map[string]decayingFloor{}
 	for m, s := range lastModelState.Models {
 		// Preload moving averages with the last known state.
 		// If the last known state was 5.5, the preloaded moving average
@@ -63,9 +99,14 @@ func New(
 		preloaded := newPrefilledFloat64Slice(a.cfg.AverageWindowCount(), s.AverageActiveRequests)
 		a.movingAvgByModel[m] = movingaverage.NewSimple(preloaded)
 		log.Printf("Preloaded moving average for model %q with %v", m, preloaded)
-	}
 
-	return a, nil
+		// Restore the state that would otherwise be lost on a rollout.
+		if s.Floor != nil {
+			a.floorByModel[m] = decayingFloor{replicas: s.Floor.Replicas, time: s.Floor.Time}
+			log.Printf("Restored decaying floor for model %q: %v replicas at %s", m, s.Floor.Replicas, s.Floor.Time)
+		}
+	}
+	return nil
 }
 
 // Autoscaler is responsible for making continuous adjustments to
@@ -88,88 +129,453 @@ type Autoscaler struct {
 	movingAvgByModelMtx sync.Mutex
 	movingAvgByModel    map[string]*movingaverage.Simple
 
+	scaleDownJitterByModelMtx sync.Mutex
+	scaleDownJitterByModel    map[string]int
+
+	recommendationsByModelMtx sync.Mutex
+	recommendationsByModel    map[string][]recommendation
+
+	floorByModelMtx sync.Mutex
+	floorByModel    map[string]decayingFloor
+
+	adaptiveTargetByModelMtx sync.Mutex
+	adaptiveTargetByModel    map[string]float64
+
+	ceilingByModelMtx sync.Mutex
+	ceilingByModel    map[string]schedulableCeiling
+
 	fixedSelfMetricAddrs []string
+
+	// clock is used for the autoscaling interval, stabilization windows, decaying
+	// floors, and the scale to zero drain delay.
+	clock clock.WithTicker
+
+	// DesiredReplicas calculates the number of replicas for each Model.
+	// Defaults to DefaultDesiredReplicas.
+	DesiredReplicas DesiredReplicasFunc
+	// Recorder is used to emit Events about Models, i.e. when the desired
+	// replicas are backed off because Pods are unschedulable. Optional.
+	Recorder record.EventRecorder
+}
+
+// DesiredReplicasFunc calculates the (unrounded) number of replicas that a Model
+// should be scaled to given the moving average of active requests across all of
+// its replicas. The result is rounded up and bounded by the Model's replica limits.
+type DesiredReplicasFunc func(model *kubeaiv1.Model, avgActiveRequests float64) float64
+
+// DefaultDesiredReplicas calculates the desired replicas as:
+//
+//	desiredReplicas = avgActiveRequests / targetRequests
+//
+// Each Model is scaled independently of the cost of its replicas. The relative
+// cost of replicas (see kubeaiv1.ModelReplicaCostAnnotation) is accounted for
+// when the desired replicas of all Models are limited by the replica budget.
+func DefaultDesiredReplicas(model *kubeaiv1.Model, avgActiveRequests float64) float64 {
+	return avgActiveRequests / float64(model.Spec.GetTargetRequests())
 }
 
+// signalsHeartbeatIntervals is the number of autoscaling intervals after which
+// instances that are not the leader publish their signals again, even if they
+// did not change (see modelclient.PublishSignals).
+const signalsHeartbeatIntervals = 6
+
+// signalsMaxAgeIntervals is the number of autoscaling intervals after which
+// the signals that another KubeAI instance published are ignored (see
+// modelclient.ClusterSignals). It allows for a missed heartbeat.
+const signalsMaxAgeIntervals = 2*signalsHeartbeatIntervals + 3
+
 func (a *Autoscaler) Start(ctx context.Context) {
-	ticker := time.NewTicker(a.cfg.Interval.Duration)
+	ticker := a.clock.NewTicker(a.cfg.Interval.Duration)
 	defer ticker.Stop()
-	for range ticker.C {
-		if ctx.Err() != nil {
+	// restored is true while leading with the state that was restored when
+	// this instance became the leader (see restoreState).
+	restored := false
+	for {
+		select {
+		case <-ctx.Done():
 			return
+		case <-ticker.C():
 		}
 		if !a.leaderElection.IsLeader.Load() {
 			log.Println("Not leader, doing nothing")
+			restored = false
+			// The leader autoscales on the signals of all instances.
+			if err := a.modelClient.PublishSignals(ctx, a.cfg.TimeWindow.Duration, signalsHeartbeatIntervals*a.cfg.Interval.Duration); err != nil {
+				log.Printf("Failed to publish signals: %v", err)
+			}
 			continue
 		}
+		if !restored {
+			if err := a.restoreState(ctx); err != nil {
+				log.Printf("Failed to restore state after becoming leader, will retry next interval: %v", err)
+			} else {
+				restored = true
+			}
+		}
 
 		log.Println("Is leader, autoscaling")
+		a.Step(ctx)
+	}
+}
+
+// Step runs a single autoscaling interval: it aggregates the metrics of all
+// KubeAI instances and scales every managed Model. Start calls Step once per
+// Interval while leading. Scale down delays are counted in Steps, so tests can
+// drive the Autoscaler deterministically by calling Step directly.
+func (a *Autoscaler) Step(ctx context.Context) {
+	// TODO: Remove hardcoded Service lookup by name "lingo".
 
-		// TODO: Remove hardcoded Service lookup by name "lingo".
+	models, err := a.modelClient.ListAllModels(ctx)
+	if err != nil {
+		log.Printf("Failed to list models: %v", err)
+		return
+	}
+
+	nextModelState := newTotalModelState(a.clock.Now())
+	var scalesToZero []scaleToZero
+
+	selfAddrs := a.selfMetricAddrs()
+	if len(selfAddrs) == 0 {
+		log.Println("Unable to resolve KubeAI addresses, skipping")
+		return
+	}
+
+	log.Printf("Aggregating metrics from KubeAI addresses %v", selfAddrs)
+	agg := newMetricsAggregation()
+	if err := aggregateAllMetrics(agg, selfAddrs, "/metrics"); err != nil {
+		log.Printf("Failed to aggregate metrics: %v", err)
+		return
+	}
+
+	signals, err := a.modelClient.ClusterSignals(ctx, a.cfg.TimeWindow.Duration, signalsMaxAgeIntervals*a.cfg.Interval.Duration)
+	if err != nil {
+		log.Printf("Failed to read the signals of other KubeAI instances, using the signals of this instance: %v", err)
+	}
 
-		models, err := a.modelClient.ListAllModels(ctx)
-		if err != nil {
-			log.Printf("Failed to list models: %v", err)
+	for _, m := range models {
+		if m.Spec.AutoscalingDisabled {
+			log.Printf("Model %q has autoscaling disabled, skipping", m.Name)
+			continue
+		}
+		if !a.modelClient.IsManaged(&m) {
+			log.Printf("Model %q is not managed, skipping", m.Name)
 			continue
 		}
 
-		nextModelState := newTotalModelState()
+		// Models are evaluated on every interval, also when no instance has
+		// observed requests for them (i.e. since a restart), so that scale
+		// down delays, stabilization windows, and decaying floors progress.
+		activeRequests, ok := agg.activeRequestsByModel[m.Name]
+		if !ok {
+			log.Printf("No metrics found for model %q, assuming no active requests", m.Name)
+		}
+		// Prefer the weighted load when all instances report it.
+		if load := agg.loadByModel[m.Name]; len(load) == len(activeRequests) {
+			activeRequests = load
+		}
+		var activeRequestSum int64
+		for _, req := range activeRequests {
+			activeRequestSum += req
+		}
 
-		var selfAddrs []string
-		if len(a.fixedSelfMetricAddrs) > 0 {
-			selfAddrs = a.fixedSelfMetricAddrs
-		} else {
-			selfIPs := a.resolver.GetSelfIPs()
-			for _, ip := range selfIPs {
-				selfAddrs = append(selfAddrs, fmt.Sprintf("%s:%d", ip, a.metricsPort))
+		avg := a.getMovingAvgActiveReqPerModel(m.Name)
+		avg.Next(float64(activeRequestSum))
+		avgActiveRequests := avg.Calculate()
+		scaled := &m
+		if adaptive, ok, err := a.modelClient.AdaptiveTarget(&m); err != nil {
+			log.Printf("Failed to get adaptive target for model %q, using static target requests: %v", m.Name, err)
+		} else if ok {
+			p95, samples := signals.LatencyP95(m.Name)
+			targetRequests := a.adaptTargetRequests(m.Name, m.Spec.GetTargetRequests(), adaptive, p95, samples)
+			log.Printf("Adapted target requests for model %q: %v, p95 latency: %v (%d samples), target latency: %v",
+				m.Name, targetRequests, p95, samples, adaptive.Latency)
+			scaled = m.DeepCopy()
+			scaled.Spec.TargetRequests = &targetRequests
+		}
+		normalized := a.DesiredReplicas(scaled, avgActiveRequests)
+		var current int32
+		if m.Spec.Replicas != nil {
+			current = *m.Spec.Replicas
+		}
+		ceil := float64(roundDesiredReplicas(normalized, current, a.cfg.ScaleUpTolerance, a.cfg.ScaleDownTolerance))
+		log.Printf("Calculated target replicas for model %q: ceil(%v) = %v, average requests: %v, target requests: %v, current requests: sum(%v) = %v, history: %v",
+			m.Name, normalized, ceil, avgActiveRequests, scaled.Spec.GetTargetRequests(), activeRequests, activeRequestSum, avg.History())
+		reason := fmt.Sprintf("average active requests %.2f / target requests %d", avgActiveRequests, scaled.Spec.GetTargetRequests())
+		if scaled != &m {
+			reason += " (adaptive)"
+		}
+		if targetMs := m.Spec.TargetLatencyMilliseconds; targetMs != nil {
+			target := time.Duration(*targetMs) * time.Millisecond
+			if p95, samples := signals.LatencyP95(m.Name); samples > 0 {
+				capacity := float64(current)
+				if grace := a.cfg.WarmupGrace.Duration; grace > 0 {
+					warming, err := a.modelClient.WarmingCapacity(ctx, m.Name, grace)
+					if err != nil {
+						log.Printf("Failed to get warming capacity for model %q: %v", m.Name, err)
+					}
+					capacity -= warming
+				}
+				normalized = latencyDesiredReplicas(capacity, p95, target)
+				if capacity < float64(current) && normalized < float64(current) {
+					// Replicas that are still warming up are not scaled down.
+					normalized = float64(current)
+				}
+				ceil = math.Ceil(normalized)
+				log.Printf("Calculated target replicas for model %q from latency: ceil(%v) = %v, p95 latency: %v (%d samples), target latency: %v, current replicas: %v, warm capacity: %.2f",
+					m.Name, normalized, ceil, p95, samples, target, current, capacity)
+				reason = fmt.Sprintf("p95 latency %s / target latency %s", p95.Round(time.Millisecond), target)
 			}
 		}
-		if len(selfAddrs) == 0 {
-			log.Println("Unable to resolve KubeAI addresses, skipping")
-			continue
+		desired := int32(ceil)
+		if window := m.Spec.ScaleDownStabilizationWindowSeconds; window != nil {
+			stabilized := a.stabilizeScaleDown(m.Name, desired, a.clock.Now(), time.Duration(*window)*time.Second)
+			if stabilized != desired {
+				log.Printf("Stabilized target replicas for model %q: %v -> %v (highest recommendation within %ds)", m.Name, desired, stabilized, *window)
+				reason += fmt.Sprintf(" (stabilized over %ds)", *window)
+				desired = stabilized
+			}
+		}
+		if halfLife := m.Spec.ScaleDownHalfLifeSeconds; halfLife != nil {
+			floor := a.decayFloor(m.Name, desired, m.Spec.MinReplicas, a.clock.Now(), time.Duration(*halfLife)*time.Second)
+			if floor > desired {
+				log.Printf("Raised target replicas for model %q to decaying peak: %v -> %v (half-life %ds)", m.Name, desired, floor, *halfLife)
+				reason += fmt.Sprintf(" (decaying from recent peak, half-life %ds)", *halfLife)
+				desired = floor
+			}
+		}
+		if unhealthy := signals.UnhealthyReplicas(m.Name); unhealthy > 0 && desired > 0 {
+			log.Printf("Adding %d replicas to model %q to compensate for unhealthy replicas", unhealthy, m.Name)
+			reason += fmt.Sprintf(" + %d unhealthy replicas", unhealthy)
+			desired += unhealthy
+		}
+		var backedOff bool
+		if m.Spec.Replicas != nil {
+			backedOff = a.backOffToSchedulable(ctx, &m, &desired, &reason)
+		}
+		requiredScaleDowns := a.cfg.RequiredConsecutiveScaleDowns(*m.Spec.ScaleDownDelaySeconds) +
+			a.getScaleDownJitter(m.Name, m.Spec.Replicas != nil && desired < *m.Spec.Replicas)
+		if backedOff {
+			// The removed Pods never served requests.
+			requiredScaleDowns = 0
 		}
 
-		log.Printf("Aggregating metrics from KubeAI addresses %v", selfAddrs)
-		agg := newMetricsAggregation()
-		if err := aggregateAllMetrics(agg, selfAddrs, "/metrics"); err != nil {
-			log.Printf("Failed to aggregate metrics: %v", err)
-			continue
+		nextModelState.Models[m.Name] = modelState{
+			AverageActiveRequests: avgActiveRequests,
+			Floor:                 a.floorState(m.Name),
 		}
 
-		for _, m := range models {
-			if m.Spec.AutoscalingDisabled {
-				log.Printf("Model %q has autoscaling disabled, skipping", m.Name)
+		if desired == 0 && m.Spec.Replicas != nil && *m.Spec.Replicas > 0 {
+			if _, queued := agg.requests(m.Name); queued > 0 {
+				log.Printf("Model %q has %d queued requests, not scaling to zero", m.Name, queued)
+				desired = 1
+				reason += fmt.Sprintf(" (%d queued requests)", queued)
+			} else if streams := agg.streams(m.Name); streams > 0 {
+				log.Printf("Model %q has %d active streams, not scaling to zero", m.Name, streams)
+				desired = 1
+				reason += fmt.Sprintf(" (%d active streams)", streams)
+			} else if a.cfg.ScaleToZeroDrainDelay.Duration > 0 && !a.cfg.ObserveOnly {
+				// Re-checked once for all models below.
+				recordDecision(ctx, m.Name, avgActiveRequests, desired)
+				scalesToZero = append(scalesToZero, scaleToZero{model: m, avgActiveRequests: avgActiveRequests, requiredScaleDowns: requiredScaleDowns, reason: reason})
 				continue
 			}
+		}
 
-			activeRequests, ok := agg.activeRequestsByModel[m.Name]
-			if !ok {
-				log.Printf("No metrics found for model %q, skipping", m.Name)
-				continue
-			}
-			var activeRequestSum int64
-			for _, req := range activeRequests {
-				activeRequestSum += req
-			}
+		recordDecision(ctx, m.Name, avgActiveRequests, desired)
+		if a.cfg.ObserveOnly {
+			log.Printf("Observe only, not scaling model %q to %v replicas: %s", m.Name, desired, reason)
+			continue
+		}
+		if !a.scale(ctx, &m, desired, requiredScaleDowns, reason) {
+			delete(nextModelState.Models, m.Name)
+		}
+	}
 
-			avg := a.getMovingAvgActiveReqPerModel(m.Name)
-			avg.Next(float64(activeRequestSum))
-			avgActiveRequests := avg.Calculate()
-			normalized := avgActiveRequests / float64(*m.Spec.TargetRequests)
-			ceil := math.Ceil(normalized)
-			log.Printf("Calculated target replicas for model %q: ceil(%v/%v) = %v, current requests: sum(%v) = %v, history: %v",
-				m.Name, avgActiveRequests, *m.Spec.TargetRequests, ceil, activeRequests, activeRequestSum, avg.History())
-			a.modelClient.Scale(ctx, &m, int32(ceil), a.cfg.RequiredConsecutiveScaleDowns(*m.Spec.ScaleDownDelaySeconds))
-
-			nextModelState.Models[m.Name] = modelState{
-				AverageActiveRequests: avgActiveRequests,
-			}
+	if len(scalesToZero) > 0 {
+		a.drainAndScaleToZero(ctx, scalesToZero, selfAddrs)
+	}
+	a.forgetDeletedModels(models)
+
+	if err := a.saveTotalModelState(ctx, nextModelState); err != nil {
+		log.Printf("Failed to save model state: %v", err)
+	}
+
+}
+
+// backOffToSchedulable limits the desired replicas of the model to the replicas
+// that the cluster can provide capacity for when Pods of the model have been
+// unschedulable for longer than the unschedulable timeout (i.e. the cluster is
+// out of GPUs). Otherwise the desired replicas would keep growing beyond what
+// can be scheduled. The schedulable replicas are held as a ceiling, because the
+// unschedulable Pods are removed by the back off. Once per unschedulable timeout,
+// one replica above the ceiling is tried, and the ceiling is lifted when more
+// Pods than the ceiling are scheduled. Returns true if the desired replicas were
+// lowered below the current replicas of the model.
+func (a *Autoscaler) backOffToSchedulable(ctx context.Context, m *kubeaiv1.Model, desired *int32, reason *string) bool {
+	current := *m.Spec.Replicas
+	scheduling, err := a.modelClient.PodScheduling(ctx, m.Name)
+	if err != nil {
+		log.Printf("Failed to check capacity of model %q: %v", m.Name, err)
+		return false
+	}
+	now := a.clock.Now()
+
+	a.ceilingByModelMtx.Lock()
+	defer a.ceilingByModelMtx.Unlock()
+
+	ceiling, held := a.ceilingByModel[m.Name]
+	if held && scheduling.Scheduled > ceiling.replicas {
+		log.Printf("Capacity available again for model %q (%d Pods are scheduled), lifting the ceiling of %d schedulable replicas", m.Name, scheduling.Scheduled, ceiling.replicas)
+		delete(a.ceilingByModel, m.Name)
+		held = false
+	}
+
+	if schedulable := max(current-scheduling.Unschedulable, 0); scheduling.Unschedulable > 0 && *desired > schedulable {
+		msg := fmt.Sprintf("Capacity unavailable (%d Pods are unschedulable), backing off from %d to %d schedulable replicas", scheduling.Unschedulable, current, schedulable)
+		log.Printf("%s for model %q", msg, m.Name)
+		if a.Recorder != nil && (!held || ceiling.replicas != schedulable) {
+			a.Recorder.Event(m, corev1.EventTypeWarning, "CapacityUnavailable", msg)
 		}
+		a.ceilingByModel[m.Name] = schedulableCeiling{replicas: schedulable, time: now}
+		*reason += fmt.Sprintf(" (backed off to %d schedulable replicas)", schedulable)
+		*desired = schedulable
+		return schedulable < current
+	}
+
+	if !held {
+		return false
+	}
+	limit := ceiling.replicas
+	if now.Sub(ceiling.time) >= a.cfg.UnschedulableTimeout.Duration {
+		// Capacity might have become available (i.e. nodes were added).
+		limit++
+	}
+	if *desired > limit {
+		log.Printf("Capacity unavailable for model %q, limiting target replicas to %d (ceiling of %d schedulable replicas)", m.Name, limit, ceiling.replicas)
+		*reason += fmt.Sprintf(" (limited to %d replicas, %d schedulable)", limit, ceiling.replicas)
+		*desired = limit
+	}
+	return false
+}
+
+// forgetDeletedModels removes the state of models that no longer exist.
+func (a *Autoscaler) forgetDeletedModels(models []kubeaiv1.Model) {
+	exists := make(map[string]bool, len(models))
+	for _, m := range models {
+		exists[m.Name] = true
+	}
+
+	a.ceilingByModelMtx.Lock()
+	for model := range a.ceilingByModel {
+		if !exists[model] {
+			delete(a.ceilingByModel, model)
+		}
+	}
+	a.ceilingByModelMtx.Unlock()
+
+	a.recommendationsByModelMtx.Lock()
+	for model := range a.recommendationsByModel {
+		if !exists[model] {
+			delete(a.recommendationsByModel, model)
+		}
+	}
+	a.recommendationsByModelMtx.Unlock()
+
+	a.floorByModelMtx.Lock()
+	for model := range a.floorByModel {
+		if !exists[model] {
+			delete(a.floorByModel, model)
+		}
+	}
+	a.floorByModelMtx.Unlock()
+
+	a.adaptiveTargetByModelMtx.Lock()
+	for model := range a.adaptiveTargetByModel {
+		if !exists[model] {
+			delete(a.adaptiveTargetByModel, model)
+		}
+	}
+	a.adaptiveTargetByModelMtx.Unlock()
+}
+
+// schedulableCeiling is the number of replicas of a model that could be
+// scheduled when the model was backed off (see backOffToSchedulable).
+type schedulableCeiling struct {
+	replicas int32
+	time     time.Time
+}
+
+// recordDecision reports the average active requests and the desired replicas
+// of the model in metrics.
+func recordDecision(ctx context.Context, model string, avgActiveRequests float64, desired int32) {
+	attrs := metric.WithAttributeSet(attribute.NewSet(
+		metrics.AttrRequestModel.String(model),
+	))
+	metrics.ModelRequestsAverage.Record(ctx, avgActiveRequests, attrs)
+	metrics.ModelReplicasDesired.Record(ctx, int64(desired), attrs)
+}
 
-		if err := a.saveTotalModelState(ctx, nextModelState); err != nil {
-			log.Printf("Failed to save model state: %v", err)
+// scale scales the model and logs errors. Returns false if the model no longer exists.
+func (a *Autoscaler) scale(ctx context.Context, m *kubeaiv1.Model, desired int32, requiredScaleDowns int, reason string) bool {
+	if err := a.modelClient.Scale(ctx, m, desired, requiredScaleDowns, reason); err != nil {
+		switch {
+		case errors.Is(err, modelclient.ErrScaleConflict):
+			log.Printf("Conflict while scaling model %q, will retry next interval: %v", m.Name, err)
+		case errors.Is(err, modelclient.ErrScaleNotFound):
+			log.Printf("Model %q no longer exists, skipping: %v", m.Name, err)
+			return false
+		case errors.Is(err, modelclient.ErrScaleUnscalable):
+			// Logged by the model client when it is detected. Not retried
+			// until the scale target of the model changes.
+		default:
+			log.Printf("Failed to scale model %q: %v", m.Name, err)
 		}
 	}
+	return true
+}
+
+// scaleToZero is a scale to zero replicas that is applied after the requests
+// for the model were re-checked (see config.ModelAutoscaling.ScaleToZeroDrainDelay).
+type scaleToZero struct {
+	model              kubeaiv1.Model
+	avgActiveRequests  float64
+	requiredScaleDowns int
+	reason             string
+}
+
+// drainAndScaleToZero waits for the ScaleToZeroDrainDelay and scrapes the metrics
+// of all KubeAI instances again. Models are only scaled to zero if no requests
+// or streams for them are active or queued, otherwise they are kept at one replica.
+// This avoids stranding requests that were received right before the scale down.
+func (a *Autoscaler) drainAndScaleToZero(ctx context.Context, scales []scaleToZero, selfAddrs []string) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-a.clock.After(a.cfg.ScaleToZeroDrainDelay.Duration):
+	}
+
+	agg := newMetricsAggregation()
+	if err := aggregateAllMetrics(agg, selfAddrs, "/metrics"); err != nil {
+		log.Printf("Failed to aggregate metrics before scaling to zero, will retry next interval: %v", err)
+		return
+	}
+
+	for _, s := range scales {
+		desired, reason := int32(0), s.reason
+		if active, queued := agg.requests(s.model.Name); active > 0 || queued > 0 {
+			log.Printf("Model %q received requests before scaling to zero (%d active, %d queued), keeping one replica", s.model.Name, active, queued)
+			desired = 1
+			reason += fmt.Sprintf(" (%d active and %d queued requests received before scaling to zero)", active, queued)
+		} else if streams := agg.streams(s.model.Name); streams > 0 {
+			log.Printf("Model %q has %d active streams, keeping one replica", s.model.Name, streams)
+			desired = 1
+			reason += fmt.Sprintf(" (%d active streams)", streams)
+		}
+		if desired > 0 {
+			recordDecision(ctx, s.model.Name, s.avgActiveRequests, desired)
+		}
+		a.scale(ctx, &s.model, desired, s.requiredScaleDowns, reason)
+	}
 }
 
 func (a *Autoscaler) getMovingAvgActiveReqPerModel(model string) *movingaverage.Simple {
@@ -183,6 +589,123 @@ func (a *Autoscaler) getMovingAvgActiveReqPerModel(model string) *movingaverage.
 	return avg
 }
 
+// selfMetricAddrs returns the metrics addresses of all KubeAI instances.
+func (a *Autoscaler) selfMetricAddrs() []string {
+	if len(a.fixedSelfMetricAddrs) > 0 {
+		return a.fixedSelfMetricAddrs
+	}
+	var selfAddrs []string
+	for _, ip := range a.resolver.GetSelfIPs() {
+		selfAddrs = append(selfAddrs, fmt.Sprintf("%s:%d", ip, a.metricsPort))
+	}
+	return selfAddrs
+}
+
+// ActiveRequests returns the total number of active requests for the given model
+// across all KubeAI instances (the same signal that is used for autoscaling).
+func (a *Autoscaler) ActiveRequests(ctx context.Context, model string) (int64, error) {
+	selfAddrs := a.selfMetricAddrs()
+	if len(selfAddrs) == 0 {
+		return 0, fmt.Errorf("unable to resolve KubeAI addresses")
+	}
+
+	agg := newMetricsAggregation()
+	if err := aggregateAllMetrics(agg, selfAddrs, "/metrics"); err != nil {
+		return 0, fmt.Errorf("aggregating metrics: %w", err)
+	}
+
+	active, _ := agg.requests(model)
+	return active, nil
+}
+
+// getScaleDownJitter returns the number of additional consecutive scale downs
+// that are required before the model is scaled down. A random value is chosen
+// when a model starts to scale down and is kept until the model stops scaling down.
+func (a *Autoscaler) getScaleDownJitter(model string, scalingDown bool) int {
+	a.scaleDownJitterByModelMtx.Lock()
+	defer a.scaleDownJitterByModelMtx.Unlock()
+
+	if !scalingDown {
+		delete(a.scaleDownJitterByModel, model)
+		return 0
+	}
+
+	jitter, ok := a.scaleDownJitterByModel[model]
+	if !ok {
+		jitter = rand.Intn(a.cfg.MaxScaleDownJitterCount() + 1)
+		a.scaleDownJitterByModel[model] = jitter
+	}
+	return jitter
+}
+
+type recommendation struct {
+	replicas int32
+	time     time.Time
+}
+
+// stabilizeScaleDown records the desired replicas for the model and returns the
+// highest recommendation within the stabilization window. Scale downs only
+// happen once the desired replicas have been at or below the returned value
+// for the whole window.
+func (a *Autoscaler) stabilizeScaleDown(model string, desired int32, now time.Time, window time.Duration) int32 {
+	a.recommendationsByModelMtx.Lock()
+	defer a.recommendationsByModelMtx.Unlock()
+
+	cutoff := now.Add(-window)
+	recs := []recommendation{{replicas: desired, time: now}}
+	stabilized := desired
+	for _, r := range a.recommendationsByModel[model] {
+		if r.time.Before(cutoff) {
+			continue
+		}
+		recs = append(recs, r)
+		if r.replicas > stabilized {
+			stabilized = r.replicas
+		}
+	}
+	a.recommendationsByModel[model] = recs
+
+	return stabilized
+}
+
+type decayingFloor struct {
+	replicas float64
+	time     time.Time
+}
+
+// decayFloor returns the minimum number of replicas for the model, which is
+// raised to the desired replicas and decays towards minReplicas with the given
+// half-life.
+func (a *Autoscaler) decayFloor(model string, desired, minReplicas int32, now time.Time, halfLife time.Duration) int32 {
+	a.floorByModelMtx.Lock()
+	defer a.floorByModelMtx.Unlock()
+
+	floor := float64(minReplicas)
+	if prev, ok := a.floorByModel[model]; ok && prev.replicas > floor {
+		elapsed := now.Sub(prev.time)
+		floor += (prev.replicas - floor) * math.Pow(0.5, elapsed.Seconds()/halfLife.Seconds())
+	}
+	if float64(desired) > floor {
+		floor = float64(desired)
+	}
+	a.floorByModel[model] = decayingFloor{replicas: floor, time: now}
+
+	return int32(math.Floor(floor))
+}
+
+// floorState returns the decaying floor of the model to be saved, or nil if the
+// model has none.
+func (a *Autoscaler) floorState(model string) *floorState {
+	a.floorByModelMtx.Lock()
+	defer a.floorByModelMtx.Unlock()
+
+	floor, ok := a.floorByModel[model]
+	if !ok {
+		return nil
+	}
+	return &floorState{Replicas: floor.replicas, Time: floor.time}
+}
+
 func newPrefilledFloat64Slice(length int, value float64) []float64 {
 	s := make([]float64, length)
 	for i := range s {
@@ -0,0 +1,530 @@
+package modelautoscaler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/config"
+	"github.com/substratusai/kubeai/internal/metrics"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	testingclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testNamespace = "default"
+
+func TestStep(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-model", Namespace: testNamespace},
+		Spec: kubeaiv1.ModelSpec{
+			Replicas:              ptr.To[int32](2),
+			MaxReplicas:           ptr.To[int32](3),
+			TargetRequests:        ptr.To[int32](1),
+			ScaleDownDelaySeconds: ptr.To[int64](30),
+		},
+	}
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "autoscaler-state"}
+	k8sClient := modelclienttest.NewClient(t, m, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name}})
+
+	srv, active := newTestMetricsServer(m.Name)
+	defer srv.Close()
+
+	cfg := config.ModelAutoscaling{
+		Interval:   config.Duration{Duration: 10 * time.Second},
+		TimeWindow: config.Duration{Duration: 10 * time.Second},
+	}
+	a, err := New(ctx, k8sClient, nil, modelclient.NewModelClient(k8sClient, testNamespace, modelclient.Options{}),
+		nil, cfg, 0, stateRef, []string{strings.TrimPrefix(srv.URL, "http://")}, testingclock.NewFakeClock(time.Now()))
+	require.NoError(t, err)
+
+	// Scale ups are applied on the next Step.
+	active.Store(3)
+	a.Step(ctx)
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// With an interval of 10s, the model is scaled down once the scale down
+	// delay of 30s has passed: on the Step after 3 consecutive scale downs.
+	active.Store(0)
+	for range 3 {
+		a.Step(ctx)
+		require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	}
+	a.Step(ctx)
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
+
+func TestStepWithoutMetrics(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-model", Namespace: testNamespace},
+		Spec: kubeaiv1.ModelSpec{
+			Replicas:              ptr.To[int32](2),
+			MaxReplicas:           ptr.To[int32](3),
+			TargetRequests:        ptr.To[int32](1),
+			ScaleDownDelaySeconds: ptr.To[int64](30),
+		},
+	}
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "autoscaler-state"}
+	k8sClient := modelclienttest.NewClient(t, m, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name}})
+	// No instance reports metrics for the model (i.e. no requests since a restart)
+	// until reported is set.
+	var reported atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reported.Load() {
+			name := metrics.OtelNameToPromName(metrics.InferenceRequestsActiveMetricName)
+			fmt.Fprintf(w, "# TYPE %s gauge\n%s{%s=%q} %d\n", name, name,
+				metrics.OtelAttrToPromLabel(metrics.AttrRequestModel), m.Name, 2)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.ModelAutoscaling{
+		Interval:   config.Duration{Duration: 10 * time.Second},
+		TimeWindow: config.Duration{Duration: 10 * time.Second},
+	}
+	a, err := New(ctx, k8sClient, nil, modelclient.NewModelClient(k8sClient, testNamespace, modelclient.Options{}),
+		nil, cfg, 0, stateRef, []string{strings.TrimPrefix(srv.URL, "http://")}, testingclock.NewFakeClock(time.Now()))
+	require.NoError(t, err)
+
+	// The model is evaluated as having no active requests, so the scale downs
+	// count towards the scale down delay.
+	for i := range 2 {
+		a.Step(ctx)
+		require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "interval %d should not scale down yet", i+1)
+	}
+	metricstest.RequireDesiredReplicasMetric(t, metricstest.Collect(t), m.Name, 0)
+
+	// Observed requests reset the consecutive scale downs.
+	reported.Store(true)
+	a.Step(ctx)
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	reported.Store(false)
+
+	// With an interval of 10s, the model is scaled down once the scale down
+	// delay of 30s has passed again: on the Step after 3 consecutive scale downs.
+	for i := range 3 {
+		a.Step(ctx)
+		require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "interval %d should not scale down yet", i+1)
+	}
+	metricstest.RequireDesiredReplicasMetric(t, metricstest.Collect(t), m.Name, 0)
+	a.Step(ctx)
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
+
+func TestStepStabilizationWindow(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-model", Namespace: testNamespace},
+		Spec: kubeaiv1.ModelSpec{
+			Replicas:                            ptr.To[int32](1),
+			MaxReplicas:                         ptr.To[int32](3),
+			TargetRequests:                      ptr.To[int32](1),
+			ScaleDownDelaySeconds:               ptr.To[int64](0),
+			ScaleDownStabilizationWindowSeconds: ptr.To[int64](60),
+		},
+	}
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "autoscaler-state"}
+	k8sClient := modelclienttest.NewClient(t, m, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name}})
+	srv, active := newTestMetricsServer(m.Name)
+	defer srv.Close()
+
+	cfg := config.ModelAutoscaling{
+		Interval:   config.Duration{Duration: 10 * time.Second},
+		TimeWindow: config.Duration{Duration: 10 * time.Second},
+	}
+	clk := testingclock.NewFakeClock(time.Now())
+	a, err := New(ctx, k8sClient, nil, modelclient.NewModelClient(k8sClient, testNamespace, modelclient.Options{}),
+		nil, cfg, 0, stateRef, []string{strings.TrimPrefix(srv.URL, "http://")}, clk)
+	require.NoError(t, err)
+
+	active.Store(3)
+	a.Step(ctx)
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// The highest recommendation is kept for the whole window, including its end.
+	active.Store(0)
+	clk.Step(60 * time.Second)
+	a.Step(ctx)
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	clk.Step(time.Nanosecond)
+	a.Step(ctx)
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
+
+func TestStepObserveOnly(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-model", Namespace: testNamespace},
+		Spec: kubeaiv1.ModelSpec{
+			Replicas:              ptr.To[int32](1),
+			MaxReplicas:           ptr.To[int32](5),
+			TargetRequests:        ptr.To[int32](1),
+			ScaleDownDelaySeconds: ptr.To[int64](0),
+		},
+	}
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "autoscaler-state"}
+	k8sClient := modelclienttest.NewClient(t, m, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name}})
+	srv, active := newTestMetricsServer(m.Name)
+	defer srv.Close()
+
+	cfg := config.ModelAutoscaling{
+		Interval:    config.Duration{Duration: 10 * time.Second},
+		TimeWindow:  config.Duration{Duration: 10 * time.Second},
+		ObserveOnly: true,
+	}
+	mc := modelclient.NewModelClient(k8sClient, testNamespace, modelclient.Options{ObserveOnly: true})
+	a, err := New(ctx, k8sClient, nil, mc, nil, cfg, 0, stateRef, []string{strings.TrimPrefix(srv.URL, "http://")}, testingclock.NewFakeClock(time.Now()))
+	require.NoError(t, err)
+
+	active.Store(3)
+	a.Step(ctx)
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	metricstest.RequireDesiredReplicasMetric(t, metricstest.Collect(t), m.Name, 3)
+
+	active.Store(0)
+	a.Step(ctx)
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	metricstest.RequireDesiredReplicasMetric(t, metricstest.Collect(t), m.Name, 0)
+
+	// Requests do not scale Models up from zero.
+	idle := m.DeepCopy()
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(m), idle))
+	idle.Spec.Replicas = ptr.To[int32](0)
+	require.NoError(t, k8sClient.Update(ctx, idle))
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
+
+func TestStepBackOffUnschedulable(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-model", Namespace: testNamespace},
+		Spec: kubeaiv1.ModelSpec{
+			Replicas:              ptr.To[int32](3),
+			MaxReplicas:           ptr.To[int32](5),
+			TargetRequests:        ptr.To[int32](1),
+			ScaleDownDelaySeconds: ptr.To[int64](30),
+		},
+	}
+	clk := testingclock.NewFakeClock(time.Now())
+	pod := func(name string, unschedulable bool) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: testNamespace,
+				Labels:    map[string]string{kubeaiv1.PodModelLabel: m.Name},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		if unschedulable {
+			p.Status.Phase = corev1.PodPending
+			p.Status.Conditions = []corev1.PodCondition{{
+				Type:               corev1.PodScheduled,
+				Status:             corev1.ConditionFalse,
+				Reason:             corev1.PodReasonUnschedulable,
+				LastTransitionTime: metav1.NewTime(clk.Now().Add(-time.Hour)),
+			}}
+		}
+		return p
+	}
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "autoscaler-state"}
+	k8sClient := modelclienttest.NewClient(t, m, pod("running-1", false), pod("running-2", false), pod("pending", true),
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name}})
+	srv, active := newTestMetricsServer(m.Name)
+	defer srv.Close()
+
+	cfg := config.ModelAutoscaling{
+		Interval:             config.Duration{Duration: 10 * time.Second},
+		TimeWindow:           config.Duration{Duration: 10 * time.Second},
+		UnschedulableTimeout: config.Duration{Duration: time.Minute},
+	}
+	mc := modelclient.NewModelClient(k8sClient, testNamespace, modelclient.Options{UnschedulableTimeout: time.Minute, Clock: clk})
+	a, err := New(ctx, k8sClient, nil, mc, nil, cfg, 0, stateRef, []string{strings.TrimPrefix(srv.URL, "http://")}, clk)
+	require.NoError(t, err)
+	recorder := record.NewFakeRecorder(10)
+	a.Recorder = recorder
+
+	// The unschedulable replica is removed right away instead of scaling up.
+	active.Store(5)
+	a.Step(ctx)
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, "CapacityUnavailable")
+
+	// The model controller removes the unschedulable Pod. The model is not
+	// scaled up again while the schedulable replicas are held.
+	require.NoError(t, k8sClient.Delete(ctx, pod("pending", true)))
+	clk.Step(cfg.Interval.Duration)
+	a.Step(ctx)
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	require.Empty(t, recorder.Events)
+
+	// One more replica is tried after the unschedulable timeout.
+	clk.Step(time.Minute)
+	a.Step(ctx)
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// It is backed off again (without another Event) if it can not be scheduled.
+	require.NoError(t, k8sClient.Create(ctx, pod("probe", true)))
+	clk.Step(cfg.Interval.Duration)
+	a.Step(ctx)
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	require.Empty(t, recorder.Events)
+	require.NoError(t, k8sClient.Delete(ctx, pod("probe", true)))
+
+	// The ceiling is lifted once the tried replica is scheduled.
+	clk.Step(time.Minute)
+	a.Step(ctx)
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	require.NoError(t, k8sClient.Create(ctx, pod("running-3", false)))
+	clk.Step(cfg.Interval.Duration)
+	a.Step(ctx)
+	require.Equal(t, int32(5), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
+
+func TestStepScaleFromZeroDelay(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-model", Namespace: testNamespace},
+		Spec: kubeaiv1.ModelSpec{
+			Replicas:                  ptr.To[int32](0),
+			MaxReplicas:               ptr.To[int32](3),
+			TargetRequests:            ptr.To[int32](1),
+			ScaleDownDelaySeconds:     ptr.To[int64](30),
+			ScaleFromZeroDelaySeconds: ptr.To[int64](60),
+		},
+	}
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "autoscaler-state"}
+	k8sClient := modelclienttest.NewClient(t, m, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name}})
+	srv, active := newTestMetricsServer(m.Name)
+	defer srv.Close()
+
+	cfg := config.ModelAutoscaling{
+		Interval:   config.Duration{Duration: 10 * time.Second},
+		TimeWindow: config.Duration{Duration: 10 * time.Second},
+	}
+	clk := testingclock.NewFakeClock(time.Now())
+	mc := modelclient.NewModelClient(k8sClient, testNamespace, modelclient.Options{Clock: clk})
+	a, err := New(ctx, k8sClient, nil, mc, nil, cfg, 0, stateRef, []string{strings.TrimPrefix(srv.URL, "http://")}, clk)
+	require.NoError(t, err)
+
+	// A single request waits for the model (and is counted as active)
+	// without scaling it up from zero.
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	active.Store(1)
+	for i := range 3 {
+		a.Step(ctx)
+		require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "interval %d should not scale up from zero", i+1)
+		clk.Step(cfg.Interval.Duration)
+	}
+
+	// The second request within the window scales the model up, after which
+	// the autoscaler scales it with the active requests.
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	active.Store(2)
+	a.Step(ctx)
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
+
+func TestStepScaleToZeroDrainDelay(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-model", Namespace: testNamespace},
+		Spec: kubeaiv1.ModelSpec{
+			Replicas:              ptr.To[int32](2),
+			MaxReplicas:           ptr.To[int32](3),
+			TargetRequests:        ptr.To[int32](1),
+			ScaleDownDelaySeconds: ptr.To[int64](0),
+		},
+	}
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "autoscaler-state"}
+	k8sClient := modelclienttest.NewClient(t, m, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name}})
+	var queued atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := metrics.OtelNameToPromName(metrics.InferenceRequestsQueuedMetricName)
+		fmt.Fprintf(w, "# TYPE %s gauge\n%s{%s=%q} %d\n", name, name,
+			metrics.OtelAttrToPromLabel(metrics.AttrRequestModel), m.Name, queued.Load())
+	}))
+	defer srv.Close()
+
+	cfg := config.ModelAutoscaling{
+		Interval:              config.Duration{Duration: 10 * time.Second},
+		TimeWindow:            config.Duration{Duration: 10 * time.Second},
+		ScaleToZeroDrainDelay: config.Duration{Duration: 2 * time.Second},
+	}
+	clk := testingclock.NewFakeClock(time.Now())
+	mc := modelclient.NewModelClient(k8sClient, testNamespace, modelclient.Options{Clock: clk})
+	a, err := New(ctx, k8sClient, nil, mc, nil, cfg, 0, stateRef, []string{strings.TrimPrefix(srv.URL, "http://")}, clk)
+	require.NoError(t, err)
+
+	// step runs a Step and calls drain while the scale to zero is held back
+	// for the drain delay.
+	step := func(drain func()) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			a.Step(ctx)
+		}()
+		require.Eventually(t, clk.HasWaiters, time.Second, time.Millisecond)
+		drain()
+		clk.Step(cfg.ScaleToZeroDrainDelay.Duration)
+		<-done
+	}
+
+	// A request that is queued during the drain delay keeps one replica.
+	step(func() {
+		metricstest.RequireDesiredReplicasMetric(t, metricstest.Collect(t), m.Name, 0)
+		queued.Store(1)
+	})
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	metricstest.RequireDesiredReplicasMetric(t, metricstest.Collect(t), m.Name, 1)
+	snapshot, _ := mc.ScalerSnapshot(m.Name)
+	require.Contains(t, snapshot.LastScaleReason, "(0 active and 1 queued requests received before scaling to zero)")
+
+	queued.Store(0)
+	step(func() {})
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	metricstest.RequireDesiredReplicasMetric(t, metricstest.Collect(t), m.Name, 0)
+}
+
+func TestStateRestore(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	decaying := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: "decaying", Namespace: testNamespace},
+		Spec: kubeaiv1.ModelSpec{
+			Replicas:                 ptr.To[int32](1),
+			MaxReplicas:              ptr.To[int32](3),
+			TargetRequests:           ptr.To[int32](1),
+			ScaleDownDelaySeconds:    ptr.To[int64](0),
+			ScaleDownHalfLifeSeconds: ptr.To[int64](600),
+		},
+	}
+	pinned := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: "pinned", Namespace: testNamespace},
+		Spec: kubeaiv1.ModelSpec{
+			Replicas:              ptr.To[int32](1),
+			TargetRequests:        ptr.To[int32](1),
+			ScaleDownDelaySeconds: ptr.To[int64](0),
+		},
+	}
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "autoscaler-state"}
+	k8sClient := modelclienttest.NewClient(t, decaying, pinned, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name}})
+	srv, active := newTestMetricsServer(decaying.Name)
+	defer srv.Close()
+
+	cfg := config.ModelAutoscaling{
+		Interval:   config.Duration{Duration: 10 * time.Second},
+		TimeWindow: config.Duration{Duration: 10 * time.Second},
+	}
+	clk := testingclock.NewFakeClock(time.Now())
+	newAutoscaler := func() (*Autoscaler, *modelclient.ModelClient) {
+		t.Helper()
+		mc := modelclient.NewModelClient(k8sClient, testNamespace, modelclient.Options{Clock: clk, StateConfigMap: stateRef})
+		a, err := New(ctx, k8sClient, nil, mc, nil, cfg, 0, stateRef, []string{strings.TrimPrefix(srv.URL, "http://")}, clk)
+		require.NoError(t, err)
+		return a, mc
+	}
+
+	a, mc := newAutoscaler()
+	// Another instance that is not the leader yet.
+	follower, _ := newAutoscaler()
+	require.NoError(t, mc.ForceScale(ctx, pinned.Name, 2, time.Hour))
+	active.Store(3)
+	a.Step(ctx)
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, decaying.Name))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, pinned.Name))
+
+	// The decaying floor and the pin survive a restart.
+	a, mc = newAutoscaler()
+	active.Store(0)
+	a.Step(ctx)
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, decaying.Name))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, pinned.Name))
+	snapshot, ok := mc.ScalerSnapshot(pinned.Name)
+	require.True(t, ok)
+	require.Equal(t, ptr.To[int32](2), snapshot.PinnedReplicas)
+
+	// The state is restored when the follower becomes the leader.
+	require.NoError(t, follower.restoreState(ctx))
+	follower.Step(ctx)
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, decaying.Name))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, pinned.Name))
+}
+
+// newTestMetricsServer returns a server that serves the metrics of a KubeAI
+// instance that reports the stored number of active requests for the model.
+func newTestMetricsServer(model string) (*httptest.Server, *atomic.Int64) {
+	active := &atomic.Int64{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := metrics.OtelNameToPromName(metrics.InferenceRequestsActiveMetricName)
+		fmt.Fprintf(w, "# TYPE %s gauge\n%s{%s=%q} %d\n", name, name,
+			metrics.OtelAttrToPromLabel(metrics.AttrRequestModel), model, active.Load())
+	}))
+	return srv, active
+}
+
+func TestForgetDeletedModels(t *testing.T) {
+	a := &Autoscaler{
+		recommendationsByModel: map[string][]recommendation{},
+		floorByModel:           map[string]decayingFloor{},
+		adaptiveTargetByModel:  map[string]float64{},
+		ceilingByModel:         map[string]schedulableCeiling{},
+	}
+	kept := kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{Name: "kept", Namespace: testNamespace}}
+	target := modelclient.AdaptiveTarget{Latency: time.Second, MinRequests: 1, MaxRequests: 100}
+	now := time.Now()
+
+	// The deleted model adapted its target to a higher latency.
+	for _, model := range []string{"deleted", kept.Name} {
+		require.Equal(t, int32(8), a.adaptTargetRequests(model, 10, target, 2*time.Second, 1))
+		a.stabilizeScaleDown(model, 5, now, time.Hour)
+		a.decayFloor(model, 5, 0, now, time.Hour)
+	}
+
+	a.forgetDeletedModels([]kubeaiv1.Model{kept})
+	require.NotContains(t, a.recommendationsByModel, "deleted")
+	require.NotContains(t, a.floorByModel, "deleted")
+	require.NotContains(t, a.adaptiveTargetByModel, "deleted")
+	require.Contains(t, a.adaptiveTargetByModel, kept.Name)
+
+	// A re-created model starts from its static target and without the
+	// stabilization window or floor of the deleted one.
+	require.Equal(t, int32(10), a.adaptTargetRequests("deleted", 10, target, 0, 0))
+	require.Equal(t, int32(1), a.stabilizeScaleDown("deleted", 1, now, time.Hour))
+	require.Equal(t, int32(1), a.decayFloor("deleted", 1, 0, now, time.Hour))
+	require.Equal(t, int32(8), a.adaptTargetRequests(kept.Name, 10, target, 0, 0))
+}
@@ -0,0 +1,127 @@
+package modelautoscaler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/substratusai/kubeai/internal/config"
+	"github.com/substratusai/kubeai/internal/modelclient"
+	"k8s.io/utils/ptr"
+)
+
+// ScalerConfig is the autoscaling configuration that is in effect for a Model:
+// the fields of the Model (with defaults applied), its annotations, and the
+// system-wide autoscaling settings.
+type ScalerConfig struct {
+	Model string `json:"model"`
+	// AutoscalingDisabled is true if the Model is not scaled by the autoscaler.
+	AutoscalingDisabled bool `json:"autoscalingDisabled"`
+	// Managed is false if KubeAI management is disabled for the Model.
+	Managed bool `json:"managed"`
+
+	MinReplicas     int32  `json:"minReplicas"`
+	MaxReplicas     *int32 `json:"maxReplicas,omitempty"`
+	IdleMinReplicas *int32 `json:"idleMinReplicas,omitempty"`
+
+	// TargetRequests is the number of active requests per replica that the
+	// Model is currently scaled for. Differs from the TargetRequests of the
+	// Model once the AdaptiveTarget has adjusted it.
+	TargetRequests int32                 `json:"targetRequests"`
+	AdaptiveTarget *AdaptiveTargetConfig `json:"adaptiveTarget,omitempty"`
+	TargetLatency  *config.Duration      `json:"targetLatency,omitempty"`
+
+	ScaleDownDelay config.Duration `json:"scaleDownDelay"`
+	// RequiredConsecutiveScaleDowns is the number of consecutive autoscaling
+	// intervals that a scale down is required for before it is applied
+	// (excluding the scale down jitter).
+	RequiredConsecutiveScaleDowns int              `json:"requiredConsecutiveScaleDowns"`
+	ScaleDownStabilizationWindow  *config.Duration `json:"scaleDownStabilizationWindow,omitempty"`
+	ScaleDownHalfLife             *config.Duration `json:"scaleDownHalfLife,omitempty"`
+	ScaleUpCooldown               *config.Duration `json:"scaleUpCooldown,omitempty"`
+	ScaleDownJitter               config.Duration  `json:"scaleDownJitter"`
+	ScaleToZeroDrainDelay         config.Duration  `json:"scaleToZeroDrainDelay"`
+
+	ScaleUpTolerance   float64 `json:"scaleUpTolerance"`
+	ScaleDownTolerance float64 `json:"scaleDownTolerance"`
+
+	Interval   config.Duration `json:"interval"`
+	TimeWindow config.Duration `json:"timeWindow"`
+}
+
+// AdaptiveTargetConfig is the JSON representation of a modelclient.AdaptiveTarget.
+type AdaptiveTargetConfig struct {
+	Latency     config.Duration `json:"latency"`
+	MinRequests int32           `json:"minRequests"`
+	MaxRequests int32           `json:"maxRequests"`
+}
+
+// ScalerConfig returns the autoscaling configuration that is in effect for the
+// given model. The Model is read from the cache, so the result reflects the
+// latest observed state of the Model. Returns nil if the model does not exist.
+func (a *Autoscaler) ScalerConfig(ctx context.Context, model string) (*ScalerConfig, error) {
+	m, err := a.modelClient.LookupModel(ctx, model, "", nil)
+	if err != nil || m == nil {
+		return nil, err
+	}
+
+	scaleDownDelaySeconds := ptr.Deref(m.Spec.ScaleDownDelaySeconds, 0)
+	cfg := &ScalerConfig{
+		Model:                         m.Name,
+		AutoscalingDisabled:           m.Spec.AutoscalingDisabled,
+		Managed:                       a.modelClient.IsManaged(m),
+		MinReplicas:                   m.Spec.MinReplicas,
+		MaxReplicas:                   m.Spec.MaxReplicas,
+		IdleMinReplicas:               m.Spec.IdleMinReplicas,
+		TargetRequests:                m.Spec.GetTargetRequests(),
+		TargetLatency:                 optionalDuration(m.Spec.TargetLatencyMilliseconds, time.Millisecond),
+		ScaleDownDelay:                config.Duration{Duration: time.Duration(scaleDownDelaySeconds) * time.Second},
+		RequiredConsecutiveScaleDowns: a.cfg.RequiredConsecutiveScaleDowns(scaleDownDelaySeconds),
+		ScaleDownStabilizationWindow:  optionalDuration(m.Spec.ScaleDownStabilizationWindowSeconds, time.Second),
+		ScaleDownHalfLife:             optionalDuration(m.Spec.ScaleDownHalfLifeSeconds, time.Second),
+		ScaleUpCooldown:               optionalDuration(m.Spec.ScaleUpCooldownSeconds, time.Second),
+		ScaleDownJitter:               a.cfg.ScaleDownJitter,
+		ScaleToZeroDrainDelay:         a.cfg.ScaleToZeroDrainDelay,
+		ScaleUpTolerance:              a.cfg.ScaleUpTolerance,
+		ScaleDownTolerance:            a.cfg.ScaleDownTolerance,
+		Interval:                      a.cfg.Interval,
+		TimeWindow:                    a.cfg.TimeWindow,
+	}
+
+	adaptive, ok, err := a.modelClient.AdaptiveTarget(m)
+	if err != nil {
+		return nil, fmt.Errorf("adaptive target: %w", err)
+	}
+	if ok {
+		cfg.AdaptiveTarget = &AdaptiveTargetConfig{
+			Latency:     config.Duration{Duration: adaptive.Latency},
+			MinRequests: adaptive.MinRequests,
+			MaxRequests: adaptive.MaxRequests,
+		}
+		cfg.TargetRequests = a.currentTargetRequests(m.Name, cfg.TargetRequests, adaptive)
+	}
+
+	return cfg, nil
+}
+
+// currentTargetRequests returns the adaptive target requests of the model without
+// adjusting them (see adaptTargetRequests).
+func (a *Autoscaler) currentTargetRequests(model string, static int32, target modelclient.AdaptiveTarget) int32 {
+	a.adaptiveTargetByModelMtx.Lock()
+	current, ok := a.adaptiveTargetByModel[model]
+	a.adaptiveTargetByModelMtx.Unlock()
+	if !ok {
+		current = float64(static)
+	}
+	current = math.Min(math.Max(current, float64(target.MinRequests)), float64(target.MaxRequests))
+	return int32(math.Round(current))
+}
+
+// optionalDuration converts an optional number of units into a duration.
+func optionalDuration(v *int64, unit time.Duration) *config.Duration {
+	if v == nil {
+		return nil
+	}
+	return &config.Duration{Duration: time.Duration(*v) * unit}
+}
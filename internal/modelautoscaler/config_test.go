@@ -0,0 +1,81 @@
+package modelautoscaler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/config"
+	"github.com/substratusai/kubeai/internal/modelclient"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScalerConfig(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubeaiv1.AddToScheme(scheme))
+
+	model := &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-model",
+			Namespace: "default",
+			Annotations: map[string]string{
+				kubeaiv1.ModelAdaptiveTargetLatencyAnnotation:  "2s",
+				kubeaiv1.ModelAdaptiveTargetRequestsAnnotation: "10-50",
+			},
+		},
+		Spec: kubeaiv1.ModelSpec{
+			MinReplicas:                         1,
+			MaxReplicas:                         ptr.To[int32](5),
+			TargetRequests:                      ptr.To[int32](100),
+			ScaleDownDelaySeconds:               ptr.To[int64](30),
+			ScaleDownStabilizationWindowSeconds: ptr.To[int64](120),
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(model).Build()
+
+	a := &Autoscaler{
+		modelClient:           modelclient.NewModelClient(k8sClient, "default", modelclient.Options{}),
+		adaptiveTargetByModel: map[string]float64{},
+		cfg: config.ModelAutoscaling{
+			Interval:   config.Duration{Duration: 10 * time.Second},
+			TimeWindow: config.Duration{Duration: 60 * time.Second},
+		},
+	}
+
+	cfg, err := a.ScalerConfig(ctx, model.Name)
+	require.NoError(t, err)
+	require.Equal(t, &ScalerConfig{
+		Model:       model.Name,
+		Managed:     true,
+		MinReplicas: 1,
+		MaxReplicas: ptr.To[int32](5),
+		// The static target requests bounded by the adaptive target.
+		TargetRequests: 50,
+		AdaptiveTarget: &AdaptiveTargetConfig{
+			Latency:     config.Duration{Duration: 2 * time.Second},
+			MinRequests: 10,
+			MaxRequests: 50,
+		},
+		ScaleDownDelay:                config.Duration{Duration: 30 * time.Second},
+		RequiredConsecutiveScaleDowns: 3,
+		ScaleDownStabilizationWindow:  &config.Duration{Duration: 120 * time.Second},
+		Interval:                      config.Duration{Duration: 10 * time.Second},
+		TimeWindow:                    config.Duration{Duration: 60 * time.Second},
+	}, cfg)
+
+	// Reflects the adjusted adaptive target.
+	a.adaptiveTargetByModel[model.Name] = 24.6
+	cfg, err = a.ScalerConfig(ctx, model.Name)
+	require.NoError(t, err)
+	require.Equal(t, int32(25), cfg.TargetRequests)
+
+	cfg, err = a.ScalerConfig(ctx, "does-not-exist")
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+}
@@ -0,0 +1,24 @@
+package modelautoscaler
+
+import "math"
+
+// roundDesiredReplicas rounds the (unrounded) desired replicas of a model with
+// the given current replicas. The model is only scaled up once the desired
+// replicas exceed the current replicas by more than upTolerance, and only scaled
+// down to the number of replicas that leaves at least downTolerance of a replica
+// of headroom. Both tolerances are fractions of a replica. Without tolerances,
+// this is the same as rounding up.
+// Models without any load are always scaled to zero (subject to the min replicas).
+func roundDesiredReplicas(normalized float64, current int32, upTolerance, downTolerance float64) int32 {
+	if normalized <= 0 {
+		return 0
+	}
+	desired := int32(math.Ceil(normalized))
+	switch {
+	case desired > current:
+		desired = max(current, int32(math.Ceil(normalized-upTolerance)))
+	case desired < current:
+		desired = min(current, int32(math.Ceil(normalized+downTolerance)))
+	}
+	return desired
+}
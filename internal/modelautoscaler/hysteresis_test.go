@@ -0,0 +1,35 @@
+package modelautoscaler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundDesiredReplicas(t *testing.T) {
+	cases := map[string]struct {
+		normalized                 float64
+		current                    int32
+		upTolerance, downTolerance float64
+		exp                        int32
+	}{
+		"no load":                         {normalized: 0, current: 2, upTolerance: 0.1, downTolerance: 0.1, exp: 0},
+		"no tolerance rounds up":          {normalized: 2.01, current: 2, exp: 3},
+		"exactly at capacity":             {normalized: 2, current: 2, upTolerance: 0.1, downTolerance: 0.1, exp: 2},
+		"exactly at capacity from above":  {normalized: 2, current: 3, exp: 2},
+		"exactly at capacity with margin": {normalized: 2, current: 3, upTolerance: 0.1, downTolerance: 0.1, exp: 3},
+		"just above capacity":             {normalized: 2.05, current: 2, upTolerance: 0.1, downTolerance: 0.1, exp: 2},
+		"above up tolerance":              {normalized: 2.15, current: 2, upTolerance: 0.1, downTolerance: 0.1, exp: 3},
+		"just below lower boundary":       {normalized: 1.95, current: 3, upTolerance: 0.1, downTolerance: 0.1, exp: 3},
+		"below down tolerance":            {normalized: 1.85, current: 3, upTolerance: 0.1, downTolerance: 0.1, exp: 2},
+		"large scale up":                  {normalized: 5.05, current: 2, upTolerance: 0.1, downTolerance: 0.1, exp: 5},
+		"large scale down":                {normalized: 0.5, current: 4, upTolerance: 0.1, downTolerance: 0.1, exp: 1},
+		"scale up from zero":              {normalized: 0.05, current: 0, upTolerance: 0.1, exp: 0},
+		"scale up from zero above tol":    {normalized: 0.5, current: 0, upTolerance: 0.1, exp: 1},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.exp, roundDesiredReplicas(c.normalized, c.current, c.upTolerance, c.downTolerance))
+		})
+	}
+}
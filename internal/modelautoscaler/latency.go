@@ -0,0 +1,29 @@
+package modelautoscaler
+
+import (
+	"time"
+)
+
+// latencyTolerance is the relative difference between the observed and the
+// target latency within which the number of replicas is not changed.
+const latencyTolerance = 0.1
+
+// latencyDesiredReplicas calculates the (unrounded) number of replicas that
+// should bring the observed latency to the target latency, assuming that
+// latency changes in proportion to the load per replica:
+//
+//	desiredReplicas = capacity * observedLatency / targetLatency
+//
+// The capacity is the current number of replicas, minus the capacity that
+// replicas which are still warming up are missing (see config.ModelAutoscaling.WarmupGrace).
+func latencyDesiredReplicas(capacity float64, observed, target time.Duration) float64 {
+	if capacity < 1 {
+		// Requests were served, so there is at least one replica.
+		capacity = 1
+	}
+	ratio := float64(observed) / float64(target)
+	if ratio >= 1-latencyTolerance && ratio <= 1+latencyTolerance {
+		return capacity
+	}
+	return capacity * ratio
+}
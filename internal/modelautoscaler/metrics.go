@@ -25,14 +25,45 @@ func aggregateAllMetrics(agg *metricsAggregation, addrs []string, path string) (
 
 type metricsAggregation struct {
 	activeRequestsByModel map[string][]int64
+	// loadByModel are the active requests weighted by request type.
+	// Missing for instances that do not report the load.
+	loadByModel map[string][]int64
+	// queuedRequestsByModel are the requests that are waiting for an endpoint
+	// (also included in activeRequestsByModel).
+	queuedRequestsByModel map[string][]int64
+	// streamsByModel are the open streaming connections (also included in
+	// activeRequestsByModel).
+	streamsByModel map[string][]int64
 }
 
 func newMetricsAggregation() *metricsAggregation {
 	return &metricsAggregation{
 		activeRequestsByModel: make(map[string][]int64),
+		loadByModel:           make(map[string][]int64),
+		queuedRequestsByModel: make(map[string][]int64),
+		streamsByModel:        make(map[string][]int64),
 	}
 }
 
+// requests returns the total number of active and queued requests for the given model.
+func (agg *metricsAggregation) requests(model string) (active, queued int64) {
+	for _, n := range agg.activeRequestsByModel[model] {
+		active += n
+	}
+	for _, n := range agg.queuedRequestsByModel[model] {
+		queued += n
+	}
+	return active, queued
+}
+
+// streams returns the total number of open streaming connections for the given model.
+func (agg *metricsAggregation) streams(model string) (streams int64) {
+	for _, n := range agg.streamsByModel[model] {
+		streams += n
+	}
+	return streams
+}
+
 func scrapeAndAggregateMetrics(agg *metricsAggregation, url string) error {
 	// Perform the HTTP GET request
 	resp, err := http.Get(url)
@@ -54,20 +85,27 @@ func scrapeAndAggregateMetrics(agg *metricsAggregation, url string) error {
 		return fmt.Errorf("failed to parse metrics: %w", err)
 	}
 
-	if fam, ok := metricFamilies[metrics.OtelNameToPromName(metrics.InferenceRequestsActiveMetricName)]; ok {
-		for _, m := range fam.Metric {
-			for _, label := range m.Label {
-				if label.GetName() == metrics.OtelAttrToPromLabel(metrics.AttrRequestModel) {
-					agg.activeRequestsByModel[label.GetValue()] = append(
-						agg.activeRequestsByModel[label.GetValue()],
-						getMetricsValue(fam, m),
-					)
-				}
+	aggregateByModel(metricFamilies, metrics.InferenceRequestsActiveMetricName, agg.activeRequestsByModel)
+	aggregateByModel(metricFamilies, metrics.InferenceRequestsLoadMetricName, agg.loadByModel)
+	aggregateByModel(metricFamilies, metrics.InferenceRequestsQueuedMetricName, agg.queuedRequestsByModel)
+	aggregateByModel(metricFamilies, metrics.InferenceStreamsActiveMetricName, agg.streamsByModel)
+
+	return nil
+}
+
+// aggregateByModel appends the values of the given metric to the values of each model.
+func aggregateByModel(metricFamilies map[string]*io_prometheus_client.MetricFamily, name string, byModel map[string][]int64) {
+	fam, ok := metricFamilies[metrics.OtelNameToPromName(name)]
+	if !ok {
+		return
+	}
+	for _, m := range fam.Metric {
+		for _, label := range m.Label {
+			if label.GetName() == metrics.OtelAttrToPromLabel(metrics.AttrRequestModel) {
+				byModel[label.GetValue()] = append(byModel[label.GetValue()], getMetricsValue(fam, m))
 			}
 		}
 	}
-
-	return nil
 }
 
 func getMetricsValue(mf *io_prometheus_client.MetricFamily, m *io_prometheus_client.Metric) int64 {
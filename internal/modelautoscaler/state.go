@@ -13,10 +13,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func newTotalModelState() totalModelState {
+func newTotalModelState(now time.Time) totalModelState {
 	return totalModelState{
 		Models:              make(map[string]modelState),
-		LastCalculationTime: time.Now(),
+		LastCalculationTime: now,
 	}
 }
 
@@ -27,6 +27,13 @@ type totalModelState struct {
 
 type modelState struct {
 	AverageActiveRequests float64 `json:"averageActiveRequests"`
+	// Floor is the decaying floor of the model (see Autoscaler.decayFloor).
+	Floor *floorState `json:"floor,omitempty"`
+}
+
+type floorState struct {
+	Replicas float64   `json:"replicas"`
+	Time     time.Time `json:"time"`
 }
 
 func (a *Autoscaler) loadLastTotalModelState(ctx context.Context) (totalModelState, error) {
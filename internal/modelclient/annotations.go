@@ -0,0 +1,259 @@
+package modelclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// getModelAnnotation returns the value of the annotation with the given name
+// from the first configured domain that the Model has it set in.
+func (c *ModelClient) getModelAnnotation(model *kubeaiv1.Model, name string) (key, value string, ok bool) {
+	return kubeaiv1.GetModelAnnotation(model, c.annotationDomains, name)
+}
+
+// patchModelAnnotations merge patches the annotations of the model.
+// Annotations with a nil value are removed.
+func (c *ModelClient) patchModelAnnotations(ctx context.Context, model string, annotations map[string]any) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+	m := &kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: model}}
+	if err := c.client.Patch(ctx, m, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return fmt.Errorf("patching model %q: %w", model, err)
+	}
+	return nil
+}
+
+// IsManaged returns false if KubeAI management was disabled for the Model
+// using the kubeaiv1.ModelManagedAnnotation or if the Model does not match
+// the model selector (see Options.ModelSelector).
+func (c *ModelClient) IsManaged(model *kubeaiv1.Model) bool {
+	if c.modelSelector != nil && !c.modelSelector.Matches(labels.Set(model.GetLabels())) {
+		return false
+	}
+	return kubeaiv1.IsModelManaged(model, c.annotationDomains)
+}
+
+// ModelProtocol returns the protocol that the backends of the given model are served over
+// (kubeaiv1.ModelProtocolHTTP unless set with the kubeaiv1.ModelProtocolAnnotation).
+func (c *ModelClient) ModelProtocol(model *kubeaiv1.Model) (string, error) {
+	key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelProtocolAnnotationName)
+	if !ok {
+		return kubeaiv1.ModelProtocolHTTP, nil
+	}
+	switch value {
+	case kubeaiv1.ModelProtocolHTTP, kubeaiv1.ModelProtocolGRPC:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid %s annotation %q: expected %q or %q",
+			key, value, kubeaiv1.ModelProtocolHTTP, kubeaiv1.ModelProtocolGRPC)
+	}
+}
+
+// MaxQueueWait returns the maximum time that requests for the given model wait for
+// an available endpoint. The system-wide default can be overridden per model with
+// the kubeaiv1.ModelMaxQueueWaitAnnotation. Disabled when 0.
+func (c *ModelClient) MaxQueueWait(model *kubeaiv1.Model) (time.Duration, error) {
+	key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelMaxQueueWaitAnnotationName)
+	if !ok {
+		return c.maxQueueWait, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s annotation %q: expected a non-negative duration", key, value)
+	}
+	return d, nil
+}
+
+// RequestTimeout returns the maximum time that requests for the given model are
+// proxied to a backend (see kubeaiv1.ModelRequestTimeoutAnnotation). Disabled when 0.
+func (c *ModelClient) RequestTimeout(model *kubeaiv1.Model) (time.Duration, error) {
+	key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelRequestTimeoutAnnotationName)
+	if !ok {
+		return 0, nil
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid %s annotation %q: expected a non-negative number of seconds", key, value)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// ResponseCache configures the response cache of a model
+// (see kubeaiv1.ModelResponseCacheTTLAnnotation).
+type ResponseCache struct {
+	// UID identifies the Model. Responses that were cached for a Model that
+	// was deleted and recreated since are not served.
+	UID types.UID
+	// Generation and PodHash identify the spec of the Model and of its Pods.
+	// Responses that were cached before the spec changed or the Pods were
+	// replaced (i.e. with another model version) are not served.
+	Generation int64
+	PodHash    string
+	// TTL is the time that responses are cached for.
+	TTL time.Duration
+	// MaxEntries is the maximum number of cached responses.
+	MaxEntries int
+}
+
+// defaultResponseCacheMaxEntries is the maximum number of cached responses per
+// model unless set with the kubeaiv1.ModelResponseCacheMaxEntriesAnnotation.
+const defaultResponseCacheMaxEntries = 1000
+
+// ResponseCache returns the response cache of the given model.
+// Returns false if the responses of the model are not cached (default).
+func (c *ModelClient) ResponseCache(model *kubeaiv1.Model) (ResponseCache, bool, error) {
+	key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelResponseCacheTTLAnnotationName)
+	if !ok {
+		return ResponseCache{}, false, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return ResponseCache{}, false, fmt.Errorf("invalid %s annotation %q: expected a positive duration", key, value)
+	}
+
+	cache := ResponseCache{
+		UID:        model.UID,
+		Generation: model.Generation,
+		PodHash:    model.Annotations[kubeaiv1.ModelPodHashAnnotation],
+		TTL:        ttl,
+		MaxEntries: defaultResponseCacheMaxEntries,
+	}
+	if key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelResponseCacheMaxEntriesAnnotationName); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 1 {
+			return ResponseCache{}, false, fmt.Errorf("invalid %s annotation %q: expected a positive integer", key, value)
+		}
+		cache.MaxEntries = n
+	}
+	return cache, true, nil
+}
+
+// modelPriority returns the priority of the model for the replica budget
+// (see kubeaiv1.ModelPriorityAnnotation).
+func (c *ModelClient) modelPriority(model *kubeaiv1.Model) (int, error) {
+	key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelPriorityAnnotationName)
+	if !ok {
+		return 0, nil
+	}
+	p, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s annotation %q: expected an integer", key, value)
+	}
+	return int(p), nil
+}
+
+// replicaCost returns the cost of one replica of the model for the replica budget
+// (see kubeaiv1.ModelReplicaCostAnnotation). Defaults to 1 if it is not set or invalid.
+func (c *ModelClient) replicaCost(model *kubeaiv1.Model) int32 {
+	key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelReplicaCostAnnotationName)
+	if !ok {
+		return 1
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+	if err != nil || n < 1 {
+		log.Printf("WARNING: ignoring invalid %s annotation %q of model %s: expected a positive integer", key, value, model.Name)
+		return 1
+	}
+	return int32(n)
+}
+
+// AdaptiveTarget configures the adaptive concurrency target of a model
+// (see kubeaiv1.ModelAdaptiveTargetLatencyAnnotation).
+type AdaptiveTarget struct {
+	// Latency is the p95 latency that the target requests are adjusted towards.
+	Latency time.Duration
+	// MinRequests and MaxRequests bound the target requests per replica.
+	MinRequests, MaxRequests int32
+}
+
+// AdaptiveTarget returns the adaptive concurrency target of the model.
+// Returns false if the model uses a static target (default).
+func (c *ModelClient) AdaptiveTarget(model *kubeaiv1.Model) (AdaptiveTarget, bool, error) {
+	key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelAdaptiveTargetLatencyAnnotationName)
+	if !ok {
+		return AdaptiveTarget{}, false, nil
+	}
+	latency, err := time.ParseDuration(value)
+	if err != nil || latency <= 0 {
+		return AdaptiveTarget{}, false, fmt.Errorf("invalid %s annotation %q: expected a positive duration", key, value)
+	}
+
+	key, value, ok = c.getModelAnnotation(model, kubeaiv1.ModelAdaptiveTargetRequestsAnnotationName)
+	if !ok {
+		return AdaptiveTarget{}, false, fmt.Errorf("missing %s annotation", kubeaiv1.ModelAdaptiveTargetRequestsAnnotation)
+	}
+	min, max, err := parseAdaptiveTargetRequests(key, value)
+	if err != nil {
+		return AdaptiveTarget{}, false, err
+	}
+
+	return AdaptiveTarget{Latency: latency, MinRequests: min, MaxRequests: max}, true, nil
+}
+
+// parseAdaptiveTargetRequests parses the value of the
+// kubeaiv1.ModelAdaptiveTargetRequestsAnnotation with the given key.
+func parseAdaptiveTargetRequests(key, value string) (int32, int32, error) {
+	minStr, maxStr, found := strings.Cut(value, "-")
+	min, minErr := strconv.ParseInt(strings.TrimSpace(minStr), 10, 32)
+	max, maxErr := strconv.ParseInt(strings.TrimSpace(maxStr), 10, 32)
+	if !found || minErr != nil || maxErr != nil || min < 1 || max < min {
+		return 0, 0, fmt.Errorf("invalid %s annotation %q: expected <min>-<max> with 1 <= min <= max", key, value)
+	}
+	return int32(min), int32(max), nil
+}
+
+// ValidateAnnotations returns the problems with the annotations of the model that
+// KubeAI reads, joined into one error. Returns nil if all of them are valid.
+func (c *ModelClient) ValidateAnnotations(model *kubeaiv1.Model) error {
+	var errs []error
+	if _, err := c.ModelProtocol(model); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := c.MaxQueueWait(model); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := c.RequestTimeout(model); err != nil {
+		errs = append(errs, err)
+	}
+	if _, _, err := c.ResponseCache(model); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := c.modelPriority(model); err != nil {
+		errs = append(errs, err)
+	}
+	if key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelReplicaCostAnnotationName); ok {
+		if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32); err != nil || n < 1 {
+			errs = append(errs, fmt.Errorf("invalid %s annotation %q: expected a positive integer", key, value))
+		}
+	}
+	if _, adaptive, err := c.AdaptiveTarget(model); err != nil {
+		errs = append(errs, err)
+	} else if key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelAdaptiveTargetRequestsAnnotationName); ok && !adaptive {
+		// Not used without a target latency, but likely a typo.
+		if _, _, err := parseAdaptiveTargetRequests(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelWarmupScheduleAnnotationName); ok {
+		if _, err := parseWarmupSchedule(value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s annotation %q: %w", key, value, err))
+		}
+	}
+	return errors.Join(errs...)
+}
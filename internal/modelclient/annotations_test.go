@@ -0,0 +1,346 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+)
+
+func TestGetModelAnnotation(t *testing.T) {
+	mc := NewModelClient(nil, testNamespace, Options{AnnotationDomains: []string{"example.com"}})
+
+	cases := map[string]struct {
+		annotations map[string]string
+		expKey      string
+		expValue    string
+		expOK       bool
+	}{
+		"not set": {
+			annotations: map[string]string{"other.com/scale-target": "x"},
+		},
+		"default domain": {
+			annotations: map[string]string{"kubeai.org/scale-target": "a"},
+			expKey:      "kubeai.org/scale-target",
+			expValue:    "a",
+			expOK:       true,
+		},
+		"legacy domain": {
+			annotations: map[string]string{"lingo.substratus.ai/scale-target": "b"},
+			expKey:      "lingo.substratus.ai/scale-target",
+			expValue:    "b",
+			expOK:       true,
+		},
+		"configured domain takes precedence": {
+			annotations: map[string]string{
+				"example.com/scale-target": "c",
+				"kubeai.org/scale-target":  "a",
+			},
+			expKey:   "example.com/scale-target",
+			expValue: "c",
+			expOK:    true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := &kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			key, value, ok := mc.getModelAnnotation(m, kubeaiv1.ModelScaleTargetAnnotationName)
+			require.Equal(t, c.expOK, ok)
+			require.Equal(t, c.expKey, key)
+			require.Equal(t, c.expValue, value)
+		})
+	}
+}
+
+func TestIsManaged(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		labels      map[string]string
+		selector    string
+		exp         bool
+	}{
+		"no annotation":    {exp: true},
+		"managed true":     {annotations: map[string]string{"kubeai.org/managed": "true"}, exp: true},
+		"managed false":    {annotations: map[string]string{"kubeai.org/managed": "false"}, exp: false},
+		"legacy false":     {annotations: map[string]string{"lingo.substratus.ai/managed": "false"}, exp: false},
+		"unknown domain":   {annotations: map[string]string{"example.com/managed": "false"}, exp: true},
+		"unexpected value": {annotations: map[string]string{"kubeai.org/managed": "no"}, exp: true},
+		"matching selector": {
+			labels:   map[string]string{"team": "ml"},
+			selector: "team=ml",
+			exp:      true,
+		},
+		"not matching selector": {
+			labels:   map[string]string{"team": "other"},
+			selector: "team=ml",
+			exp:      false,
+		},
+		"selector takes precedence over managed true": {
+			annotations: map[string]string{"kubeai.org/managed": "true"},
+			selector:    "team=ml",
+			exp:         false,
+		},
+		"managed false with matching selector": {
+			annotations: map[string]string{"kubeai.org/managed": "false"},
+			labels:      map[string]string{"team": "ml"},
+			selector:    "team=ml",
+			exp:         false,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var opts Options
+			if c.selector != "" {
+				selector, err := labels.Parse(c.selector)
+				require.NoError(t, err)
+				opts.ModelSelector = selector
+			}
+			mc := NewModelClient(nil, testNamespace, opts)
+			m := &kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations, Labels: c.labels}}
+			require.Equal(t, c.exp, mc.IsManaged(m))
+		})
+	}
+}
+
+func TestUnmanagedModelIsNotScaled(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0)})
+	m.Annotations = map[string]string{kubeaiv1.ModelManagedAnnotation: "false"}
+	mc, k8sClient := newTestModelClient(t, m)
+
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	require.NoError(t, mc.Scale(ctx, m, 2, 0, "test"))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
+
+func TestModelProtocol(t *testing.T) {
+	mc := NewModelClient(nil, testNamespace, Options{})
+
+	cases := map[string]struct {
+		annotations map[string]string
+		exp         string
+		expErr      bool
+	}{
+		"no annotation": {exp: kubeaiv1.ModelProtocolHTTP},
+		"http":          {annotations: map[string]string{"kubeai.org/protocol": "http"}, exp: kubeaiv1.ModelProtocolHTTP},
+		"grpc":          {annotations: map[string]string{"kubeai.org/protocol": "grpc"}, exp: kubeaiv1.ModelProtocolGRPC},
+		"legacy grpc":   {annotations: map[string]string{"lingo.substratus.ai/protocol": "grpc"}, exp: kubeaiv1.ModelProtocolGRPC},
+		"invalid":       {annotations: map[string]string{"kubeai.org/protocol": "udp"}, expErr: true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := &kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			protocol, err := mc.ModelProtocol(m)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, protocol)
+		})
+	}
+}
+
+func TestModelMaxQueueWait(t *testing.T) {
+	mc := NewModelClient(nil, testNamespace, Options{MaxQueueWait: time.Minute})
+
+	cases := map[string]struct {
+		annotations map[string]string
+		exp         time.Duration
+		expErr      bool
+	}{
+		"no annotation": {exp: time.Minute},
+		"override":      {annotations: map[string]string{"kubeai.org/max-queue-wait": "30s"}, exp: 30 * time.Second},
+		"disabled":      {annotations: map[string]string{"kubeai.org/max-queue-wait": "0s"}, exp: 0},
+		"invalid":       {annotations: map[string]string{"kubeai.org/max-queue-wait": "soon"}, expErr: true},
+		"negative":      {annotations: map[string]string{"kubeai.org/max-queue-wait": "-1s"}, expErr: true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := &kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			wait, err := mc.MaxQueueWait(m)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, wait)
+		})
+	}
+}
+
+func TestModelRequestTimeout(t *testing.T) {
+	mc := NewModelClient(nil, testNamespace, Options{})
+
+	cases := map[string]struct {
+		annotations map[string]string
+		exp         time.Duration
+		expErr      bool
+	}{
+		"no annotation": {exp: 0},
+		"timeout":       {annotations: map[string]string{"kubeai.org/request-timeout-seconds": "600"}, exp: 10 * time.Minute},
+		"disabled":      {annotations: map[string]string{"kubeai.org/request-timeout-seconds": "0"}, exp: 0},
+		"duration":      {annotations: map[string]string{"kubeai.org/request-timeout-seconds": "10m"}, expErr: true},
+		"negative":      {annotations: map[string]string{"kubeai.org/request-timeout-seconds": "-1"}, expErr: true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := &kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			timeout, err := mc.RequestTimeout(m)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp, timeout)
+		})
+	}
+}
+
+func TestModelResponseCache(t *testing.T) {
+	mc := NewModelClient(nil, testNamespace, Options{})
+
+	cases := map[string]struct {
+		annotations map[string]string
+		exp         ResponseCache
+		expOK       bool
+		expErr      bool
+	}{
+		"no annotation": {},
+		"ttl": {
+			annotations: map[string]string{"kubeai.org/response-cache-ttl": "5m"},
+			exp:         ResponseCache{UID: "uid", TTL: 5 * time.Minute, MaxEntries: defaultResponseCacheMaxEntries},
+			expOK:       true,
+		},
+		"max entries": {
+			annotations: map[string]string{"kubeai.org/response-cache-ttl": "30s", "kubeai.org/response-cache-max-entries": "10"},
+			exp:         ResponseCache{UID: "uid", TTL: 30 * time.Second, MaxEntries: 10},
+			expOK:       true,
+		},
+		"zero ttl":          {annotations: map[string]string{"kubeai.org/response-cache-ttl": "0s"}, expErr: true},
+		"invalid ttl":       {annotations: map[string]string{"kubeai.org/response-cache-ttl": "300"}, expErr: true},
+		"zero max entries":  {annotations: map[string]string{"kubeai.org/response-cache-ttl": "5m", "kubeai.org/response-cache-max-entries": "0"}, expErr: true},
+		"max entries alone": {annotations: map[string]string{"kubeai.org/response-cache-max-entries": "10"}},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := &kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{UID: "uid", Annotations: c.annotations}}
+			cache, ok, err := mc.ResponseCache(m)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expOK, ok)
+			require.Equal(t, c.exp, cache)
+		})
+	}
+}
+
+func TestAdaptiveTarget(t *testing.T) {
+	mc := NewModelClient(nil, testNamespace, Options{})
+
+	cases := map[string]struct {
+		annotations map[string]string
+		exp         AdaptiveTarget
+		expOK       bool
+		expErr      bool
+	}{
+		"static": {},
+		"adaptive": {
+			annotations: map[string]string{"kubeai.org/adaptive-target-latency": "2s", "kubeai.org/adaptive-target-requests": "10-50"},
+			exp:         AdaptiveTarget{Latency: 2 * time.Second, MinRequests: 10, MaxRequests: 50},
+			expOK:       true,
+		},
+		"missing bounds": {
+			annotations: map[string]string{"kubeai.org/adaptive-target-latency": "2s"},
+			expErr:      true,
+		},
+		"invalid latency": {
+			annotations: map[string]string{"kubeai.org/adaptive-target-latency": "0s", "kubeai.org/adaptive-target-requests": "10-50"},
+			expErr:      true,
+		},
+		"inverted bounds": {
+			annotations: map[string]string{"kubeai.org/adaptive-target-latency": "2s", "kubeai.org/adaptive-target-requests": "50-10"},
+			expErr:      true,
+		},
+		"zero min": {
+			annotations: map[string]string{"kubeai.org/adaptive-target-latency": "2s", "kubeai.org/adaptive-target-requests": "0-10"},
+			expErr:      true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := &kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			target, ok, err := mc.AdaptiveTarget(m)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expOK, ok)
+			require.Equal(t, c.exp, target)
+		})
+	}
+}
+
+func TestValidateAnnotations(t *testing.T) {
+	mc := NewModelClient(nil, testNamespace, Options{})
+
+	cases := map[string]struct {
+		annotations map[string]string
+		expErrs     []string
+	}{
+		"none": {},
+		"valid": {
+			annotations: map[string]string{
+				"kubeai.org/priority":                 "10",
+				"kubeai.org/replica-cost":             "4",
+				"kubeai.org/adaptive-target-latency":  "2s",
+				"kubeai.org/adaptive-target-requests": "10-50",
+				"kubeai.org/warmup-schedule":          "Mon-Fri 08:00-18:00=2",
+			},
+		},
+		"invalid": {
+			annotations: map[string]string{
+				"kubeai.org/protocol":                 "udp",
+				"kubeai.org/priority":                 "high",
+				"kubeai.org/replica-cost":             "0",
+				"kubeai.org/adaptive-target-requests": "50-10",
+				"kubeai.org/warmup-schedule":          "always",
+			},
+			expErrs: []string{
+				"kubeai.org/protocol",
+				"kubeai.org/priority",
+				"kubeai.org/replica-cost",
+				"kubeai.org/adaptive-target-requests",
+				"kubeai.org/warmup-schedule",
+			},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := &kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			err := mc.ValidateAnnotations(m)
+			if len(c.expErrs) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, key := range c.expErrs {
+				require.ErrorContains(t, err, key)
+			}
+		})
+	}
+}
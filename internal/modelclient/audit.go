@@ -0,0 +1,172 @@
+package modelclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditSink records the scale events of all models that are written by this
+// KubeAI instance (i.e. for compliance). RecordScaleEvent is called after the
+// replicas were written and before the scale operation returns, while holding
+// the locks of scale operations, so implementations must not block on I/O to
+// remote destinations. Errors are logged and do not fail the scale operation.
+// Close is called on shutdown, after all scale operations completed.
+type AuditSink interface {
+	RecordScaleEvent(ctx context.Context, e ScaleEvent) error
+	Close() error
+}
+
+// NewAuditSink returns an AuditSink for the given destination: events are POSTed
+// as JSON to http(s) URLs and appended as JSON lines to files otherwise.
+func NewAuditSink(dest string) (AuditSink, error) {
+	if strings.HasPrefix(dest, "http://") || strings.HasPrefix(dest, "https://") {
+		return NewHTTPAuditSink(dest), nil
+	}
+	return NewFileAuditSink(dest)
+}
+
+// FileAuditSink appends scale events as JSON lines to a file.
+type FileAuditSink struct {
+	mtx  sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditSink opens (or creates) the file at the given path for appending.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	return &FileAuditSink{file: f}, nil
+}
+
+func (s *FileAuditSink) RecordScaleEvent(_ context.Context, e ScaleEvent) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (s *FileAuditSink) Close() error {
+	return s.file.Close()
+}
+
+const (
+	// auditHTTPTimeout is the timeout for sending a scale event to an HTTPAuditSink.
+	auditHTTPTimeout = 5 * time.Second
+	// auditHTTPQueueSize is the number of scale events that an HTTPAuditSink
+	// buffers while they are sent.
+	auditHTTPQueueSize = 1000
+	// auditHTTPCloseTimeout is the maximum time that Close waits for the buffered
+	// scale events of an HTTPAuditSink to be sent.
+	auditHTTPCloseTimeout = 10 * time.Second
+)
+
+// ErrAuditQueueFull is returned by an HTTPAuditSink for scale events that can not
+// be buffered because the destination does not keep up.
+var ErrAuditQueueFull = errors.New("audit queue is full")
+
+// HTTPAuditSink POSTs each scale event as JSON to a URL. Scale events are
+// buffered and sent in the background, so that scale operations (which record
+// events while holding locks) are not blocked by the destination.
+type HTTPAuditSink struct {
+	url    string
+	client *http.Client
+
+	// mtx guards closed, so that no events are queued after Close.
+	mtx    sync.RWMutex
+	closed bool
+	queue  chan ScaleEvent
+	done   chan struct{}
+}
+
+// NewHTTPAuditSink returns an HTTPAuditSink that sends scale events to the given
+// URL until it is closed.
+func NewHTTPAuditSink(url string) *HTTPAuditSink {
+	s := &HTTPAuditSink{
+		url:    url,
+		client: &http.Client{Timeout: auditHTTPTimeout},
+		queue:  make(chan ScaleEvent, auditHTTPQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// RecordScaleEvent queues the event for sending without blocking. Errors of the
+// destination are logged.
+func (s *HTTPAuditSink) RecordScaleEvent(_ context.Context, e ScaleEvent) error {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return fmt.Errorf("audit sink is closed")
+	}
+	select {
+	case s.queue <- e:
+		return nil
+	default:
+		return ErrAuditQueueFull
+	}
+}
+
+func (s *HTTPAuditSink) run() {
+	defer close(s.done)
+	for e := range s.queue {
+		if err := s.send(e); err != nil {
+			log.Printf("ERROR: sending scale event of model %q to audit log: %v", e.Model, err)
+		}
+	}
+}
+
+func (s *HTTPAuditSink) send(e ScaleEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close stops queueing scale events and waits for the buffered events to be
+// sent. It returns an error if they are not sent within auditHTTPCloseTimeout.
+func (s *HTTPAuditSink) Close() error {
+	s.mtx.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mtx.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-time.After(auditHTTPCloseTimeout):
+		return fmt.Errorf("timed out sending %d buffered scale events", len(s.queue))
+	}
+}
@@ -0,0 +1,130 @@
+package modelclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	"k8s.io/utils/ptr"
+)
+
+func TestFileAuditSink(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewAuditSink(path)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](100)})
+	mc, _ := newTestModelClientWithOptions(t, Options{AuditSink: sink}, m)
+
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "test"))
+	// Scales that were deferred while paused are applied by the operator that resumed.
+	require.NoError(t, mc.PauseAutoscaling(ctx))
+	m.Spec.Replicas = ptr.To[int32](3)
+	require.NoError(t, mc.Scale(ctx, m, 2, 0, "test"))
+	require.NoError(t, mc.ResumeAutoscaling(ctx))
+	require.NoError(t, mc.ForceScale(ctx, m.Name, 5, time.Hour))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var events []ScaleEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e ScaleEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		events = append(events, e)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Len(t, events, 3)
+	require.Equal(t, "my-model", events[0].Model)
+	require.Equal(t, int32(1), events[0].FromReplicas)
+	require.Equal(t, int32(3), events[0].ToReplicas)
+	require.Equal(t, ScaleActorAuto, events[0].Actor)
+	require.False(t, events[0].Time.IsZero())
+	require.Equal(t, int32(3), events[1].FromReplicas)
+	require.Equal(t, int32(2), events[1].ToReplicas)
+	require.Equal(t, ScaleActorManual, events[1].Actor)
+	require.Equal(t, int32(2), events[2].FromReplicas)
+	require.Equal(t, int32(5), events[2].ToReplicas)
+	require.Equal(t, ScaleActorManual, events[2].Actor)
+}
+
+func TestHTTPAuditSink(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	received := make(chan ScaleEvent, 1)
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		var e ScaleEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		received <- e
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	sink, err := NewAuditSink(srv.URL)
+	require.NoError(t, err)
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](100)})
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{AuditSink: sink}, m)
+
+	require.NoError(t, mc.Scale(ctx, m, 2, 0, "test"))
+	e := <-received
+	require.Equal(t, "my-model", e.Model)
+	require.Equal(t, int32(1), e.FromReplicas)
+	require.Equal(t, int32(2), e.ToReplicas)
+	require.Equal(t, ScaleActorAuto, e.Actor)
+
+	// Failures of the sink should not fail scaling.
+	status.Store(http.StatusInternalServerError)
+	m.Spec.Replicas = ptr.To[int32](2)
+	require.NoError(t, mc.Scale(ctx, m, 4, 0, "test"))
+	<-received
+	require.Equal(t, int32(4), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// Buffered events are sent on close, and no events are queued afterwards.
+	status.Store(http.StatusOK)
+	require.NoError(t, sink.RecordScaleEvent(ctx, ScaleEvent{Model: "other-model"}))
+	require.NoError(t, sink.Close())
+	require.Equal(t, "other-model", (<-received).Model)
+	require.Error(t, sink.RecordScaleEvent(ctx, ScaleEvent{Model: "other-model"}))
+}
+
+func TestHTTPAuditSinkQueueFull(t *testing.T) {
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	sink := NewHTTPAuditSink(srv.URL)
+
+	// Recording does not block while the destination does not respond.
+	var err error
+	for i := 0; i <= auditHTTPQueueSize+1 && err == nil; i++ {
+		err = sink.RecordScaleEvent(ctx, ScaleEvent{Model: "my-model"})
+	}
+	require.ErrorIs(t, err, ErrAuditQueueFull)
+	close(release)
+	require.NoError(t, sink.Close())
+}
@@ -0,0 +1,397 @@
+package modelclient
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// applyReplicaBudget clamps a scale up of the model so that the total replicas of
+// all managed Models do not exceed the replica budget (see Options.MaxTotalReplicas).
+// Replicas are weighted by their cost (see replicaCost), so the replicas that the
+// model is allowed to scale up to are:
+//
+//	allowed = max(floor((maxTotalReplicas - used) / cost), current)
+//
+// where used is the total cost of the replicas of all other Models (see
+// budgetUsage) and cost the cost of one replica of the model.
+// Models with a lower priority are scaled down to make room (see preemptForBudget).
+// Scale ups are reserved in the state ConfigMap before they are written, so that
+// concurrent scale ups of other KubeAI instances (i.e. from zero) can not exceed
+// the budget together (see reserveReplicaBudget). The reservation is retried if
+// it conflicts with another write to the ConfigMap, without preempting again.
+// Returns false if the scale operation should be skipped because no budget is left.
+// The caller must hold the replicaBudgetMtx.
+func (c *ModelClient) applyReplicaBudget(ctx context.Context, model *kubeaiv1.Model, target ScaleTarget, replicas int32, reason string) (int32, string, bool, error) {
+	current, err := target.GetReplicas(ctx)
+	if err != nil {
+		return 0, "", false, err
+	}
+
+	requested, requestedReason := replicas, reason
+	var used, cost int32
+	attempt := 0
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		attempt++
+		replicas, reason = requested, requestedReason
+		reservations, err := c.getBudgetReservations(ctx)
+		if err != nil {
+			return err
+		}
+		used, cost, err = c.budgetUsage(ctx, model, reservations.byModel)
+		if err != nil {
+			return err
+		}
+		if replicas <= current {
+			return nil
+		}
+
+		allowed := max((c.maxTotalReplicas-used)/cost, current)
+		// Replicas that were preempted by a previous attempt might not be
+		// observed yet, which only clamps the scale up further.
+		if replicas > allowed && attempt == 1 {
+			freed, err := c.preemptForBudget(ctx, model, (replicas-allowed)*cost)
+			if err != nil {
+				return err
+			}
+			used -= freed
+			allowed = max((c.maxTotalReplicas-used)/cost, current)
+		}
+		if replicas > allowed {
+			log.Printf("replica budget of %d exhausted (%d used by other models, replica cost %d), clamping model %s from %d to %d replicas",
+				c.maxTotalReplicas, used, cost, model.Name, replicas, allowed)
+			reason += fmt.Sprintf(" (replica budget %d)", c.maxTotalReplicas)
+			replicas = allowed
+		}
+		if replicas > current {
+			return c.reserveReplicaBudget(ctx, reservations, model.Name, replicas)
+		}
+		return nil
+	}); err != nil {
+		return 0, "", false, err
+	}
+
+	metrics.ModelReplicasTotal.Record(ctx, int64(used+replicas*cost))
+	metrics.ModelReplicaBudget.Record(ctx, int64(c.maxTotalReplicas))
+	return replicas, reason, replicas != current, nil
+}
+
+// replicaBudgetKey is the key of the state ConfigMap that scale ups within the
+// replica budget are reserved in (see reserveReplicaBudget).
+const replicaBudgetKey = "replica-budget"
+
+// budgetReservationTTL is the time for which a reservation counts towards the
+// replica budget. The replicas are expected to be observed by then.
+const budgetReservationTTL = time.Minute
+
+// budgetReservation is a scale up of a Model that was reserved in the replica
+// budget, by any KubeAI instance.
+type budgetReservation struct {
+	Replicas int32     `json:"replicas"`
+	Time     time.Time `json:"time"`
+}
+
+// budgetReservations are the reservations in the state ConfigMap along with the
+// ConfigMap that they were read from (nil if no state ConfigMap is configured).
+type budgetReservations struct {
+	configMap *corev1.ConfigMap
+	byModel   map[string]budgetReservation
+}
+
+// getBudgetReservations returns the reservations of the replica budget that
+// have not expired.
+func (c *ModelClient) getBudgetReservations(ctx context.Context) (budgetReservations, error) {
+	if !c.sharedStateEnabled() {
+		return budgetReservations{}, nil
+	}
+	cm, err := c.getStateConfigMap(ctx)
+	if err != nil {
+		return budgetReservations{}, err
+	}
+	byModel := map[string]budgetReservation{}
+	if data, ok := cm.Data[replicaBudgetKey]; ok {
+		if err := json.Unmarshal([]byte(data), &byModel); err != nil {
+			log.Printf("WARNING: ignoring invalid replica budget reservations: %v", err)
+		}
+	}
+	now := c.clock.Now()
+	for model, r := range byModel {
+		if now.Sub(r.Time) >= budgetReservationTTL {
+			delete(byModel, model)
+		}
+	}
+	return budgetReservations{configMap: cm.DeepCopy(), byModel: byModel}, nil
+}
+
+// reserveReplicaBudget stores the reservations with the given replicas of the
+// model. The ConfigMap is updated (not patched) with the version that the
+// reservations were read from, so a conflict is returned if any other KubeAI
+// instance wrote to it since (see applyReplicaBudget).
+// NOTE: Without a state ConfigMap, the replica budget is only enforced for the
+// scale operations of this instance.
+func (c *ModelClient) reserveReplicaBudget(ctx context.Context, reservations budgetReservations, model string, replicas int32) error {
+	cm := reservations.configMap
+	if cm == nil {
+		return nil
+	}
+	reservations.byModel[model] = budgetReservation{Replicas: replicas, Time: c.clock.Now()}
+	data, err := json.Marshal(reservations.byModel)
+	if err != nil {
+		return fmt.Errorf("marshalling replica budget reservations: %w", err)
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[replicaBudgetKey] = string(data)
+	if err := c.client.Update(ctx, cm); err != nil {
+		if apierrors.IsConflict(err) {
+			// The reservations are read again from the API server, in case
+			// the watched copy is outdated.
+			c.forgetStateConfigMap()
+		}
+		return fmt.Errorf("updating ConfigMap %q: %w", c.stateConfigMap, err)
+	}
+	c.observeStateConfigMap(cm)
+	return nil
+}
+
+// budgetUsage returns the total cost of the replicas of all managed Models except
+// the given one (see observedReplicas, or the reserved replicas if they are
+// higher), along with the cost of one replica of the given model:
+//
+//	used = sum(replicas(t) * cost(t)) over the scale targets t of the other Models
+//
+// Models that share a scale target are counted once, with the sum of their costs
+// as the cost of a replica (their servers run in the same Pods). Models whose
+// replicas can not be determined are skipped.
+func (c *ModelClient) budgetUsage(ctx context.Context, model *kubeaiv1.Model, reservations map[string]budgetReservation) (int32, int32, error) {
+	var models kubeaiv1.ModelList
+	if err := c.client.List(ctx, &models, client.InNamespace(c.namespace)); err != nil {
+		return 0, 0, fmt.Errorf("listing models: %w", err)
+	}
+
+	modelTarget, _ := c.scaleTargetKey(model)
+	cost := c.replicaCost(model)
+	byTarget := map[string]int32{}
+	costByTarget := map[string]int32{}
+	var used int32
+	for i := range models.Items {
+		m := &models.Items[i]
+		if m.Name == model.Name || !c.IsManaged(m) {
+			continue
+		}
+		sharedTarget, shared := c.scaleTargetKey(m)
+		if shared && sharedTarget == modelTarget {
+			// Scaled together with the given model.
+			cost += c.replicaCost(m)
+			continue
+		}
+		replicas, err := c.observedReplicas(ctx, m)
+		if err != nil {
+			log.Printf("WARNING: not counting model %s for the replica budget: %v", m.Name, err)
+			continue
+		}
+		if r, ok := reservations[m.Name]; ok {
+			// The scale up might not be written or observed yet.
+			replicas = max(replicas, r.Replicas)
+		}
+		if shared {
+			byTarget[sharedTarget] = max(byTarget[sharedTarget], replicas)
+			costByTarget[sharedTarget] += c.replicaCost(m)
+			continue
+		}
+		used += replicas * c.replicaCost(m)
+	}
+	for target, replicas := range byTarget {
+		used += replicas * costByTarget[target]
+	}
+	return used, cost, nil
+}
+
+// budgetVictim is a Model that can be scaled down to make room in the replica budget.
+type budgetVictim struct {
+	model    *kubeaiv1.Model
+	target   ScaleTarget
+	state    *scalerState
+	priority int
+	replicas int32
+	// cost is the cost of one replica of the Model (see replicaCost).
+	cost int32
+	// floor is the number of replicas that the Model is not scaled below.
+	floor        int32
+	lastActivity time.Time
+}
+
+// preemptForBudget scales down Models with a lower priority than the given model
+// (see kubeaiv1.ModelPriorityAnnotation) to free up the given cost of the replica
+// budget (see applyReplicaBudget). Victims are chosen by lowest priority, then by longest idle,
+// and are not scaled below their min replicas (or one replica while they have active
+// streams). Models that are cordoned, pinned by a force scale, scaled concurrently,
+// not autoscaled, paused, or that share a scale target with another Model are not
+// preempted. Models whose priority or replicas can not be determined are skipped.
+// Returns the cost of the replicas that were freed.
+// The caller must hold the replicaBudgetMtx.
+func (c *ModelClient) preemptForBudget(ctx context.Context, model *kubeaiv1.Model, needed int32) (int32, error) {
+	if c.isScaleDownFrozen(ctx) {
+		return 0, nil
+	}
+	priority, err := c.modelPriority(model)
+	if err != nil {
+		return 0, err
+	}
+
+	var models kubeaiv1.ModelList
+	if err := c.client.List(ctx, &models, client.InNamespace(c.namespace)); err != nil {
+		return 0, fmt.Errorf("listing models: %w", err)
+	}
+	targets := map[string]int{}
+	for i := range models.Items {
+		if target, ok := c.scaleTargetKey(&models.Items[i]); ok {
+			targets[target]++
+		}
+	}
+
+	// A Model that can not be checked should not block the scale up.
+	skip := func(m *kubeaiv1.Model, err error) {
+		log.Printf("WARNING: not preempting model %s for the replica budget: %v", m.Name, err)
+	}
+	var victims []budgetVictim
+	for i := range models.Items {
+		m := &models.Items[i]
+		if m.Name == model.Name || m.Spec.AutoscalingDisabled || !c.IsManaged(m) || c.IsModelCordoned(m) {
+			continue
+		}
+		if target, ok := c.scaleTargetKey(m); ok && targets[target] > 1 {
+			continue
+		}
+		p, err := c.modelPriority(m)
+		if err != nil {
+			skip(m, err)
+			continue
+		}
+		if p >= priority {
+			continue
+		}
+		target, err := c.scaleTargetFor(m)
+		if err != nil {
+			skip(m, err)
+			continue
+		}
+		replicas, err := c.observedReplicas(ctx, m)
+		if err != nil {
+			skip(m, err)
+			continue
+		}
+		if paused, err := isScaleTargetPaused(ctx, target); err != nil {
+			skip(m, err)
+			continue
+		} else if paused {
+			continue
+		}
+
+		c.scalerStatesMtx.Lock()
+		s := c.getScalerState(m.Name)
+		v := budgetVictim{
+			model:        m,
+			target:       target,
+			state:        s,
+			priority:     p,
+			replicas:     replicas,
+			cost:         c.replicaCost(m),
+			floor:        m.Spec.MinReplicas,
+			lastActivity: s.lastActivityTime,
+		}
+		if s.activeStreams > 0 {
+			v.floor = max(v.floor, 1)
+		}
+		c.scalerStatesMtx.Unlock()
+		pdbFloor, _, err := c.disruptionBudgetFloor(ctx, m)
+		if err != nil {
+			skip(m, err)
+			continue
+		}
+		v.floor = max(v.floor, pdbFloor)
+		if v.replicas > v.floor {
+			victims = append(victims, v)
+		}
+	}
+	slices.SortFunc(victims, func(a, b budgetVictim) int {
+		if a.priority != b.priority {
+			return cmp.Compare(a.priority, b.priority)
+		}
+		return a.lastActivity.Compare(b.lastActivity)
+	})
+
+	var freed int32
+	for _, v := range victims {
+		if freed >= needed {
+			break
+		}
+		// Waiting for the lock would invert the lock order of updateScale
+		// (writeMtx before replicaBudgetMtx).
+		if !v.state.writeMtx.TryLock() {
+			continue
+		}
+		c.scalerStatesMtx.RLock()
+		pinned := v.state.pin != nil
+		c.scalerStatesMtx.RUnlock()
+		if pinned {
+			v.state.writeMtx.Unlock()
+			continue
+		}
+
+		// Rounded up, as partial replicas can not be freed.
+		take := min(v.replicas-v.floor, (needed-freed+v.cost-1)/v.cost)
+		reason := fmt.Sprintf("preempted by model %s (priority %d > %d) for the replica budget", model.Name, priority, v.priority)
+		log.Printf("scaling model %s from %d to %d replicas: %s", v.model.Name, v.replicas, v.replicas-take, reason)
+		err := c.setReplicas(ctx, v.model.Name, v.target, v.replicas-take, reason, ScaleActorAuto)
+		if err == nil {
+			c.scalerStatesMtx.Lock()
+			v.state.recordScale(c.clock.Now(), v.replicas-take, reason, ScaledToZeroPreempted)
+			c.scalerStatesMtx.Unlock()
+			c.recordScaledToZeroReason(ctx, v.model, v.replicas-take, ScaledToZeroPreempted)
+			c.NotifyStateChange(v.model.Name)
+			freed += take * v.cost
+		}
+		v.state.writeMtx.Unlock()
+		if err != nil {
+			log.Printf("ERROR: preempting model %s: %v", v.model.Name, err)
+		}
+	}
+	return freed, nil
+}
+
+// observedReplicas returns the replicas of the model without reading its scale
+// target where possible, because the replica budget counts all Models on every
+// write. Models that are scaled via their own scale subresource are read from
+// the (cached) Model, other scale targets from the replicas that were last
+// written or observed by this instance (see observeAppliedReplicas).
+func (c *ModelClient) observedReplicas(ctx context.Context, m *kubeaiv1.Model) (int32, error) {
+	if _, ok := c.scaleTargetKey(m); ok {
+		c.scalerStatesMtx.RLock()
+		var applied *int32
+		if s, ok := c.scalerStates[m.Name]; ok {
+			applied = s.appliedReplicas
+		}
+		c.scalerStatesMtx.RUnlock()
+		if applied != nil {
+			return *applied, nil
+		}
+	}
+	target, err := c.scaleTargetFor(m)
+	if err != nil {
+		return 0, err
+	}
+	return target.GetReplicas(ctx)
+}
@@ -0,0 +1,243 @@
+package modelclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestReplicaBudget(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	a := testModel("model-a", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](4), MaxReplicas: ptr.To[int32](10)})
+	b := testModel("model-b", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)})
+	unmanaged := testModel("unmanaged", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](5)})
+	unmanaged.Annotations = map[string]string{kubeaiv1.ModelManagedAnnotation: "false"}
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{MaxTotalReplicas: 6}, a, b, unmanaged)
+
+	// Clamped to the budget that is left.
+	require.NoError(t, mc.Scale(ctx, b, 5, 0, "scale up"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, b.Name))
+	snapshot, _ := mc.ScalerSnapshot(b.Name)
+	require.Equal(t, "scale up (replica budget 6)", snapshot.LastScaleReason)
+	metricstest.RequireReplicaBudgetMetrics(t, metricstest.Collect(t), 6, 6)
+
+	// No budget left.
+	b.Spec.Replicas = ptr.To[int32](2)
+	require.NoError(t, mc.Scale(ctx, b, 3, 0, "scale up"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, b.Name))
+
+	// Scale downs free up budget.
+	require.NoError(t, mc.Scale(ctx, a, 1, 0, "scale down"))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, a.Name))
+	require.NoError(t, mc.Scale(ctx, b, 5, 0, "scale up"))
+	require.Equal(t, int32(5), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, b.Name))
+	metricstest.RequireReplicaBudgetMetrics(t, metricstest.Collect(t), 6, 6)
+
+	// Scale ups from zero are limited as well.
+	a.Spec.Replicas = ptr.To[int32](1)
+	require.NoError(t, mc.Scale(ctx, a, 0, 0, "scale to zero"))
+	b.Spec.Replicas = ptr.To[int32](5)
+	require.NoError(t, mc.Scale(ctx, b, 6, 0, "scale up"))
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, a.Name))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, a.Name))
+}
+
+func TestReplicaBudgetPreemption(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	withPriority := func(m *kubeaiv1.Model, priority string) *kubeaiv1.Model {
+		m.Annotations = map[string]string{kubeaiv1.ModelPriorityAnnotation: priority}
+		return m
+	}
+	high := withPriority(testModel("high", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)}), "10")
+	idle := withPriority(testModel("low-idle", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2), MaxReplicas: ptr.To[int32](10)}), "1")
+	active := withPriority(testModel("low-active", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](3), MinReplicas: 1, MaxReplicas: ptr.To[int32](10)}), "1")
+	equal := withPriority(testModel("equal", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)}), "10")
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{MaxTotalReplicas: 7}, high, idle, active, equal)
+	mc.recordActivity(ctx, active.Name)
+
+	// The longest idle victim is scaled down first, victims are not scaled below
+	// their min replicas, and Models with the same priority are not preempted.
+	require.NoError(t, mc.Scale(ctx, high, 10, 0, "scale up"))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, idle.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, active.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, equal.Name))
+	require.Equal(t, int32(5), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, high.Name))
+	snapshot, _ := mc.ScalerSnapshot(idle.Name)
+	require.Equal(t, "preempted by model high (priority 10 > 1) for the replica budget", snapshot.LastScaleReason)
+	metricstest.RequireReplicaBudgetMetrics(t, metricstest.Collect(t), 7, 7)
+
+	// Models with a lower priority do not preempt Models with a higher priority.
+	idle.Spec.Replicas = ptr.To[int32](0)
+	require.NoError(t, mc.Scale(ctx, idle, 3, 0, "scale up"))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, idle.Name))
+	require.Equal(t, int32(5), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, high.Name))
+
+	// Invalid priorities are errors.
+	high.Spec.Replicas = ptr.To[int32](5)
+	high.Annotations[kubeaiv1.ModelPriorityAnnotation] = "urgent"
+	require.Error(t, mc.Scale(ctx, high, 10, 0, "scale up"))
+}
+
+func TestReplicaBudgetPreemptionSkipsModels(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	withPriority := func(m *kubeaiv1.Model, priority string) *kubeaiv1.Model {
+		m.Annotations = map[string]string{kubeaiv1.ModelPriorityAnnotation: priority}
+		return m
+	}
+	deployment := &unstructured.Unstructured{}
+	deployment.SetGroupVersionKind(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+	deployment.SetNamespace(testNamespace)
+	deployment.SetName("paused")
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, int64(1), "spec", "replicas"))
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, true, "spec", "paused"))
+
+	high := withPriority(testModel("high", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)}), "10")
+	invalid := withPriority(testModel("invalid", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)}), "urgent")
+	disabled := withPriority(testModel("disabled", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), AutoscalingDisabled: true}), "1")
+	paused := withPriority(testModel("paused", kubeaiv1.ModelSpec{MaxReplicas: ptr.To[int32](10)}), "1")
+	paused.Annotations[kubeaiv1.ModelScaleTargetAnnotation] = "apps/v1/Deployment/paused"
+	paused.Annotations[kubeaiv1.ModelScaleTargetStrategyAnnotation] = kubeaiv1.ScaleTargetStrategyReplicas
+	low := withPriority(testModel("low", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2), MaxReplicas: ptr.To[int32](10)}), "1")
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{MaxTotalReplicas: 6}, high, invalid, disabled, paused, deployment, low)
+
+	// Only the valid victim is preempted, the other Models do not block the scale up.
+	require.NoError(t, mc.Scale(ctx, high, 5, 0, "scale up"))
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, high.Name))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, low.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, invalid.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, disabled.Name))
+}
+
+func TestReplicaBudgetShared(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	a := testModel("model-a", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)})
+	b := testModel("model-b", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)})
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "state"}
+	clk := newTestClock()
+	opts := Options{MaxTotalReplicas: 6, StateConfigMap: stateRef, Clock: clk}
+	var conflicts int
+	other, k8sClient := newTestModelClientWithOptions(t, opts, a, b, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name},
+	})
+	mc := NewModelClient(interceptor.NewClient(k8sClient.(client.WithWatch), interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if _, ok := obj.(*corev1.ConfigMap); ok && conflicts > 0 {
+				// Another instance wrote to the state ConfigMap after it was read.
+				conflicts--
+				return apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, obj.GetName(), errors.New("modified"))
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}), testNamespace, opts)
+
+	// The scale up of the other instance is reserved before it is written.
+	reservations, err := other.getBudgetReservations(ctx)
+	require.NoError(t, err)
+	require.NoError(t, other.reserveReplicaBudget(ctx, reservations, a.Name, 4))
+	conflicts = 1
+	require.NoError(t, mc.Scale(ctx, b, 5, 0, "scale up"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, b.Name), "reserved replicas should count towards the budget")
+
+	// Reservations expire once the replicas are expected to be observed.
+	b.Spec.Replicas = ptr.To[int32](2)
+	clk.Step(budgetReservationTTL)
+	require.NoError(t, mc.Scale(ctx, b, 5, 0, "scale up"))
+	require.Equal(t, int32(5), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, b.Name))
+
+	// The other instance can not scale up beyond the reserved budget.
+	a.Spec.Replicas = ptr.To[int32](1)
+	require.NoError(t, other.Scale(ctx, a, 3, 0, "scale up"))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, a.Name))
+}
+
+func TestReplicaBudgetCost(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	withCost := func(m *kubeaiv1.Model, priority, cost string) *kubeaiv1.Model {
+		m.Annotations = map[string]string{
+			kubeaiv1.ModelPriorityAnnotation:    priority,
+			kubeaiv1.ModelReplicaCostAnnotation: cost,
+		}
+		return m
+	}
+	large := withCost(testModel("large", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2), MaxReplicas: ptr.To[int32](10)}), "1", "4")
+	small := withCost(testModel("small", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MinReplicas: 2, MaxReplicas: ptr.To[int32](10)}), "1", "invalid")
+	high := withCost(testModel("high", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MaxReplicas: ptr.To[int32](10)}), "10", "3")
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{MaxTotalReplicas: 12}, large, small, high)
+
+	// allowed = (12 - 2*4) / 1
+	require.NoError(t, mc.Scale(ctx, small, 10, 0, "scale up"))
+	require.Equal(t, int32(4), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, small.Name), "invalid costs should default to 1")
+	metricstest.RequireReplicaBudgetMetrics(t, metricstest.Collect(t), 12, 12)
+
+	// allowed = floor((12 - 2*1) / 4)
+	small.Spec.Replicas = ptr.To[int32](4)
+	require.NoError(t, mc.Scale(ctx, small, 2, 0, "scale down"))
+	require.NoError(t, mc.Scale(ctx, large, 5, 0, "scale up"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, large.Name))
+
+	// Victims free replicas by their cost, rounded up: ceil(1*3 / 4).
+	require.NoError(t, mc.Scale(ctx, high, 1, 0, "scale up"))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, high.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, large.Name))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, small.Name))
+}
+
+func TestReplicaBudgetSkipsFailingModels(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	deployment := &unstructured.Unstructured{}
+	deployment.SetGroupVersionKind(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+	deployment.SetNamespace(testNamespace)
+	deployment.SetName("my-deployment")
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, int64(2), "spec", "replicas"))
+
+	a := testModel("model-a", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)})
+	deployed := testModel("deployed", kubeaiv1.ModelSpec{MaxReplicas: ptr.To[int32](10)})
+	deployed.Annotations = map[string]string{
+		kubeaiv1.ModelScaleTargetAnnotation:         "apps/v1/Deployment/my-deployment",
+		kubeaiv1.ModelScaleTargetStrategyAnnotation: kubeaiv1.ScaleTargetStrategyReplicas,
+	}
+	missing := testModel("missing", kubeaiv1.ModelSpec{MaxReplicas: ptr.To[int32](10)})
+	missing.Annotations = map[string]string{
+		kubeaiv1.ModelScaleTargetAnnotation:         "apps/v1/Deployment/missing",
+		kubeaiv1.ModelScaleTargetStrategyAnnotation: kubeaiv1.ScaleTargetStrategyReplicas,
+	}
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{MaxTotalReplicas: 4}, a, deployed, deployment, missing)
+
+	// The Model whose scale target does not exist is not counted.
+	require.NoError(t, mc.Scale(ctx, a, 5, 0, "scale up"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, a.Name))
+
+	// Replicas that were observed while scaling are counted without reading
+	// the scale target.
+	require.NoError(t, mc.Scale(ctx, deployed, 1, 0, "scale down"))
+	require.NoError(t, k8sClient.Delete(ctx, deployment))
+	a.Spec.Replicas = ptr.To[int32](2)
+	require.NoError(t, mc.Scale(ctx, a, 5, 0, "scale up"))
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, a.Name))
+}
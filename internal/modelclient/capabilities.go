@@ -0,0 +1,133 @@
+package modelclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// modelCapabilitiesIndex is the name of the field index of Models by the tags
+// of their kubeaiv1.ModelCapabilitiesAnnotation.
+const modelCapabilitiesIndex = "kubeai.capabilities"
+
+// IndexCapabilities registers the field index of Models by capability tags that
+// ResolveCapable looks up Models with. It has to be called before the cache of
+// the client of the ModelClient is started.
+func (c *ModelClient) IndexCapabilities(ctx context.Context, indexer client.FieldIndexer) error {
+	return indexer.IndexField(ctx, &kubeaiv1.Model{}, modelCapabilitiesIndex, capabilitiesIndexer(c.annotationDomains))
+}
+
+// capabilitiesIndexer returns the client.IndexerFunc of the modelCapabilitiesIndex.
+func capabilitiesIndexer(annotationDomains []string) client.IndexerFunc {
+	return func(obj client.Object) []string {
+		m, ok := obj.(*kubeaiv1.Model)
+		if !ok {
+			return nil
+		}
+		_, value, ok := kubeaiv1.GetModelAnnotation(m, annotationDomains, kubeaiv1.ModelCapabilitiesAnnotationName)
+		if !ok {
+			return nil
+		}
+		var tags []string
+		for tag := range parseCapabilities(value) {
+			tags = append(tags, tag)
+		}
+		return tags
+	}
+}
+
+// ResolveCapable returns the name of the best available Model that has all of
+// the given capabilities (see kubeaiv1.ModelCapabilitiesAnnotation) and matches
+// the given label selectors. Models with ready replicas are preferred over
+// Models that would need to be scaled up, so that the request does not wait for
+// a cold start. Cordoned Models are skipped. Returns false if no Model has the
+// capabilities.
+func (c *ModelClient) ResolveCapable(ctx context.Context, capabilities, labelSelectors []string) (string, bool, error) {
+	if len(capabilities) == 0 {
+		return "", false, nil
+	}
+	selectors := make([]labels.Selector, 0, len(labelSelectors))
+	for _, sel := range labelSelectors {
+		parsedSel, err := labels.Parse(sel)
+		if err != nil {
+			return "", false, fmt.Errorf("parse label selector: %w", err)
+		}
+		selectors = append(selectors, parsedSel)
+	}
+
+	// Only the Models with the first capability are listed, the others are
+	// checked below.
+	var list kubeaiv1.ModelList
+	if err := c.client.List(ctx, &list, client.InNamespace(c.namespace),
+		client.MatchingFields{modelCapabilitiesIndex: normalizeCapability(capabilities[0])}); err != nil {
+		return "", false, err
+	}
+	var (
+		resolved      string
+		resolvedReady bool
+	)
+	for i := range list.Items {
+		m := &list.Items[i]
+		if c.IsModelCordoned(m) || !hasCapabilities(c.modelCapabilities(m), capabilities) || !matchesSelectors(m, selectors) {
+			continue
+		}
+		ready := m.Status.Replicas.Ready > 0
+		if resolved != "" {
+			// Prefer the first name among equally available Models so that
+			// the result is stable.
+			if resolvedReady && !ready || resolvedReady == ready && m.Name > resolved {
+				continue
+			}
+		}
+		resolved, resolvedReady = m.Name, ready
+	}
+	return resolved, resolved != "", nil
+}
+
+// modelCapabilities returns the tags of the kubeaiv1.ModelCapabilitiesAnnotation
+// of the model.
+func (c *ModelClient) modelCapabilities(model *kubeaiv1.Model) map[string]bool {
+	_, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelCapabilitiesAnnotationName)
+	if !ok {
+		return nil
+	}
+	return parseCapabilities(value)
+}
+
+// parseCapabilities parses a comma-separated list of capability tags.
+// Tags are case-insensitive.
+func parseCapabilities(value string) map[string]bool {
+	tags := map[string]bool{}
+	for _, tag := range strings.Split(value, ",") {
+		if tag = normalizeCapability(tag); tag != "" {
+			tags[tag] = true
+		}
+	}
+	return tags
+}
+
+func normalizeCapability(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+func hasCapabilities(tags map[string]bool, capabilities []string) bool {
+	for _, capability := range capabilities {
+		if !tags[normalizeCapability(capability)] {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesSelectors(model *kubeaiv1.Model, selectors []labels.Selector) bool {
+	for _, sel := range selectors {
+		if !sel.Matches(labels.Set(model.GetLabels())) {
+			return false
+		}
+	}
+	return true
+}
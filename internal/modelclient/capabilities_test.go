@@ -0,0 +1,56 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+)
+
+func TestResolveCapable(t *testing.T) {
+	ctx := context.Background()
+
+	withCapabilities := func(m *kubeaiv1.Model, capabilities string, ready int32) *kubeaiv1.Model {
+		m.Annotations = map[string]string{kubeaiv1.ModelCapabilitiesAnnotation: capabilities}
+		m.Status.Replicas.Ready = ready
+		return m
+	}
+	coldChat := withCapabilities(testModel("a-chat-7b", kubeaiv1.ModelSpec{}), "chat,7b", 0)
+	warmChat := withCapabilities(testModel("b-chat-7b", kubeaiv1.ModelSpec{}), "Chat, 7B", 1)
+	warmChat2 := withCapabilities(testModel("c-chat-7b", kubeaiv1.ModelSpec{}), "chat,7b", 2)
+	embedding := withCapabilities(testModel("embedding", kubeaiv1.ModelSpec{}), "embedding", 0)
+	cordoned := withCapabilities(testModel("cordoned-vision", kubeaiv1.ModelSpec{}), "vision", 1)
+	cordoned.Annotations[kubeaiv1.ModelCordonedAnnotation] = "true"
+	warmChat2.Labels = map[string]string{"team": "a"}
+	mc, _ := newTestModelClient(t, testModel("untagged", kubeaiv1.ModelSpec{}), coldChat, warmChat, warmChat2, embedding, cordoned)
+
+	cases := map[string]struct {
+		capabilities []string
+		selectors    []string
+		exp          string
+		expErr       bool
+	}{
+		"warm models are preferred":   {capabilities: []string{"chat"}, exp: "b-chat-7b"},
+		"all capabilities must match": {capabilities: []string{"chat", "7b"}, exp: "b-chat-7b"},
+		"cold model":                  {capabilities: []string{"embedding"}, exp: "embedding"},
+		"missing capability":          {capabilities: []string{"chat", "70b"}},
+		"cordoned models are skipped": {capabilities: []string{"vision"}},
+		"no capabilities":             {},
+		"selectors must match":        {capabilities: []string{"chat"}, selectors: []string{"team=a"}, exp: "c-chat-7b"},
+		"no model matches selectors":  {capabilities: []string{"embedding"}, selectors: []string{"team=a"}},
+		"invalid selector":            {capabilities: []string{"chat"}, selectors: []string{"team in ("}, expErr: true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			resolved, ok, err := mc.ResolveCapable(ctx, c.capabilities, c.selectors)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.exp != "", ok)
+			require.Equal(t, c.exp, resolved)
+		})
+	}
+}
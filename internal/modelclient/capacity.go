@@ -0,0 +1,116 @@
+package modelclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CapacityUnavailable returns true if the model has Pods that have been
+// unschedulable for longer than the configured unschedulable timeout.
+// This is a sign that the cluster can not provide capacity for more replicas
+// (i.e. there are no GPU nodes and none can be provisioned).
+// Always returns false when the unschedulable timeout is not configured.
+func (c *ModelClient) CapacityUnavailable(ctx context.Context, model string) (bool, error) {
+	scheduling, err := c.PodScheduling(ctx, model)
+	return scheduling.Unschedulable > 0, err
+}
+
+// PodScheduling counts the Pods of a model by their scheduling state.
+type PodScheduling struct {
+	// Scheduled is the number of Pods that were scheduled to a node.
+	Scheduled int32
+	// Unschedulable is the number of Pods that have been unschedulable for
+	// longer than the configured unschedulable timeout (see CapacityUnavailable).
+	Unschedulable int32
+}
+
+// PodScheduling returns the scheduling state of the Pods of the model. The
+// Pods that are not Unschedulable are the replicas that the cluster can
+// currently provide capacity for.
+// Always returns no Pods when the unschedulable timeout is not configured.
+func (c *ModelClient) PodScheduling(ctx context.Context, model string) (PodScheduling, error) {
+	if c.unschedulableTimeout == 0 {
+		return PodScheduling{}, nil
+	}
+
+	var pods corev1.PodList
+	if err := c.client.List(ctx, &pods, client.InNamespace(c.namespace), client.MatchingLabels{kubeaiv1.PodModelLabel: model}); err != nil {
+		return PodScheduling{}, fmt.Errorf("listing pods: %w", err)
+	}
+
+	unschedulableSince := c.clock.Now().Add(-c.unschedulableTimeout)
+	var scheduling PodScheduling
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		switch {
+		case pod.DeletionTimestamp != nil:
+		case podScheduled(pod):
+			scheduling.Scheduled++
+		case podUnschedulableBefore(pod, unschedulableSince):
+			scheduling.Unschedulable++
+		}
+	}
+	return scheduling, nil
+}
+
+// podScheduled returns true if the Pod was scheduled to a node.
+func podScheduled(pod *corev1.Pod) bool {
+	if pod.Spec.NodeName != "" || pod.Status.Phase != corev1.PodPending && pod.Status.Phase != "" {
+		return true
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podUnschedulableBefore returns true if the Pod has been unschedulable since before the given time.
+func podUnschedulableBefore(pod *corev1.Pod, t time.Time) bool {
+	if pod.Status.Phase != corev1.PodPending {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled &&
+			cond.Status == corev1.ConditionFalse &&
+			cond.Reason == corev1.PodReasonUnschedulable {
+			return cond.LastTransitionTime.Time.Before(t)
+		}
+	}
+	return false
+}
+
+// WarmingCapacity returns the capacity (in replicas) that the ready Pods of the
+// model are still missing because they became ready within the given grace period.
+// The capacity of a Pod ramps in linearly over the grace period, because model
+// servers can report readiness before their caches are warm.
+func (c *ModelClient) WarmingCapacity(ctx context.Context, model string, grace time.Duration) (float64, error) {
+	if grace <= 0 {
+		return 0, nil
+	}
+
+	var pods corev1.PodList
+	if err := c.client.List(ctx, &pods, client.InNamespace(c.namespace), client.MatchingLabels{kubeaiv1.PodModelLabel: model}); err != nil {
+		return 0, fmt.Errorf("listing pods: %w", err)
+	}
+
+	var warming float64
+	now := c.clock.Now()
+	for i := range pods.Items {
+		for _, cond := range pods.Items[i].Status.Conditions {
+			if cond.Type != corev1.PodReady || cond.Status != corev1.ConditionTrue {
+				continue
+			}
+			if ready := now.Sub(cond.LastTransitionTime.Time); ready < grace {
+				warming += 1 - float64(ready)/float64(grace)
+			}
+		}
+	}
+	return warming, nil
+}
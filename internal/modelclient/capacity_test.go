@@ -0,0 +1,117 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCapacityUnavailable(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	pod := func(name, model string, unschedulableFor time.Duration) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: testNamespace,
+				Labels:    map[string]string{kubeaiv1.PodModelLabel: model},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		}
+		if unschedulableFor > 0 {
+			p.Status.Conditions = []corev1.PodCondition{{
+				Type:               corev1.PodScheduled,
+				Status:             corev1.ConditionFalse,
+				Reason:             corev1.PodReasonUnschedulable,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-unschedulableFor)),
+			}}
+		}
+		return p
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		pod("starting", "starting-model", 0),
+		pod("recently-unschedulable", "recently-unschedulable-model", time.Second),
+		pod("unschedulable", "unschedulable-model", time.Hour),
+	).Build()
+
+	cases := map[string]struct {
+		timeout time.Duration
+		model   string
+		exp     bool
+	}{
+		"disabled": {
+			model: "unschedulable-model",
+			exp:   false,
+		},
+		"pending but not unschedulable": {
+			timeout: time.Minute,
+			model:   "starting-model",
+			exp:     false,
+		},
+		"unschedulable within timeout": {
+			timeout: time.Minute,
+			model:   "recently-unschedulable-model",
+			exp:     false,
+		},
+		"unschedulable for longer than timeout": {
+			timeout: time.Minute,
+			model:   "unschedulable-model",
+			exp:     true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			mc := NewModelClient(k8sClient, testNamespace, Options{UnschedulableTimeout: c.timeout})
+			unavailable, err := mc.CapacityUnavailable(context.Background(), c.model)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, unavailable)
+		})
+	}
+}
+
+func TestWarmingCapacity(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	const grace = 10 * time.Minute
+	pod := func(name string, readyFor time.Duration) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: testNamespace,
+				Labels:    map[string]string{kubeaiv1.PodModelLabel: "my-model"},
+			},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-readyFor)),
+			}}},
+		}
+	}
+	notReady := pod("not-ready", 0)
+	notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		pod("warm", time.Hour),
+		pod("half-warm", grace/2),
+		notReady,
+	).Build()
+	mc := NewModelClient(k8sClient, testNamespace, Options{})
+
+	warming, err := mc.WarmingCapacity(context.Background(), "my-model", grace)
+	require.NoError(t, err)
+	require.InDelta(t, 0.5, warming, 0.01)
+
+	warming, err = mc.WarmingCapacity(context.Background(), "my-model", 0)
+	require.NoError(t, err)
+	require.Zero(t, warming)
+}
@@ -4,23 +4,179 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type ModelClient struct {
 	client                   client.Client
 	namespace                string
+	clock                    clock.WithDelayedExecution
 	consecutiveScaleDownsMtx sync.RWMutex
 	consecutiveScaleDowns    map[string]int
+	scalerStatesMtx          sync.RWMutex
+	scalerStates             map[string]*scalerState
+	autoscalingPaused        atomic.Bool
+	// scaleDownFrozenUntil is the time (in unix nanoseconds) until which
+	// models are not scaled down (see FreezeScaleDown).
+	scaleDownFrozenUntil atomic.Int64
+	// stateConfigMap stores the state that is shared by all KubeAI instances
+	// (see sharedStateEnabled). Disabled when the name is empty.
+	stateConfigMap types.NamespacedName
+	// watchedStateConfigMap is the copy of the state ConfigMap that is kept
+	// up to date by WatchSharedState. Nil until it is observed.
+	watchedStateConfigMapMtx sync.RWMutex
+	watchedStateConfigMap    *corev1.ConfigMap
+	// sharedStateWatched is true once WatchSharedState was called.
+	sharedStateWatched atomic.Bool
+	// fallbackModel is the name of the Model that requests for unknown
+	// models are routed to. Disabled when empty.
+	fallbackModelMtx sync.RWMutex
+	fallbackModel    string
+	// annotationDomains are the domains that Model annotations are read from
+	// (in order of precedence).
+	annotationDomains []string
+	// modelSelector restricts the Models that are managed. Optional.
+	modelSelector labels.Selector
+	// unschedulableTimeout is the time after which unschedulable Pods
+	// indicate that capacity is unavailable. Disabled when 0.
+	unschedulableTimeout time.Duration
+	// scaleDebounceInterval is the minimum time between writes to the
+	// replicas of a model. Disabled when 0.
+	scaleDebounceInterval time.Duration
+	// maxQueueWait is the default maximum time that requests wait for
+	// an available endpoint. Disabled when 0.
+	maxQueueWait time.Duration
+	// auditSink records scale events. Optional.
+	auditSink AuditSink
+	// useScalePatch is the default for kubeaiv1.ModelScaleUpdateStrategyAnnotation.
+	useScalePatch bool
+	// maxTotalReplicas is the replica budget across all models. Disabled when 0.
+	maxTotalReplicas int32
+	// respectDisruptionBudgets limits scale downs to the minAvailable of the
+	// PodDisruptionBudgets of models (see disruptionBudgetFloor).
+	respectDisruptionBudgets bool
+	// replicaBoundsFromHPA fills in the replica bounds of models from their
+	// HorizontalPodAutoscalers (see withHPABounds).
+	replicaBoundsFromHPA bool
+	// observeOnly disables all automatic writes to the replicas of models
+	// (see updateScale).
+	observeOnly bool
+	// replicaBudgetMtx serializes scale operations while the replica budget
+	// is enabled so that concurrent scale ups can not exceed it. Scale ups of
+	// other instances are serialized with reservations in the state ConfigMap
+	// (see reserveReplicaBudget).
+	replicaBudgetMtx sync.Mutex
+	// instanceName identifies the signals of this KubeAI instance in the
+	// state ConfigMap (see PublishSignals).
+	instanceName string
+	// publishedSignals are the signals of this instance that are stored in
+	// the state ConfigMap, nil if none are.
+	publishedSignalsMtx sync.Mutex
+	publishedSignals    *instanceSignals
+	// shuttingDown prevents new scale operations once Shutdown is called.
+	shutdownMtx    sync.RWMutex
+	shuttingDown   bool
+	inflightScales sync.WaitGroup
+	// subscribers receive scale events (see Subscribe).
+	subscribersMtx sync.Mutex
+	subscribers    map[chan ScaleEvent]struct{}
+	// watches are notified of scaling state changes (see WatchStates).
+	watchesMtx sync.Mutex
+	watches    map[*StateWatch]struct{}
 }
 
-func NewModelClient(client client.Client, namespace string) *ModelClient {
-	return &ModelClient{client: client, namespace: namespace, consecutiveScaleDowns: map[string]int{}}
+// Options configure a ModelClient. The zero value disables all optional behavior.
+type Options struct {
+	// FallbackModel is the name of the Model that requests for unknown
+	// models are routed to. Disabled when empty.
+	FallbackModel string
+	// AnnotationDomains are the domains that Model annotations are read from
+	// in addition to kubeaiv1.AnnotationDomain and kubeaiv1.LegacyAnnotationDomain.
+	AnnotationDomains []string
+	// ModelSelector restricts the Models that are managed (see IsManaged).
+	// All Models are managed when nil.
+	ModelSelector labels.Selector
+	// UnschedulableTimeout is used to detect that capacity is unavailable
+	// (see CapacityUnavailable). Disabled when 0.
+	UnschedulableTimeout time.Duration
+	// ScaleDebounceInterval is the minimum time between writes to the replicas
+	// of a model. Writes within the interval are coalesced and the latest value
+	// is written once the interval has passed. Disabled when 0.
+	ScaleDebounceInterval time.Duration
+	// MaxQueueWait is the maximum time that requests wait for an available
+	// endpoint unless overridden per model (see MaxQueueWait). Disabled when 0.
+	MaxQueueWait time.Duration
+	// AuditSink records every scale event (see AuditSink). Optional.
+	AuditSink AuditSink
+	// UseScalePatch merge patches the scale subresource of Models instead of
+	// updating it, which avoids conflicts with concurrent writers. Can be
+	// overridden per Model (see kubeaiv1.ModelScaleUpdateStrategyAnnotation).
+	UseScalePatch bool
+	// MaxTotalReplicas is the replica budget: scale ups are clamped so that the
+	// total replicas of all managed Models do not exceed it. Force scales are
+	// not limited. Disabled when 0.
+	MaxTotalReplicas int32
+	// RespectPodDisruptionBudgets prevents scale downs below the absolute
+	// minAvailable of the PodDisruptionBudgets that select the Pods of a Model.
+	RespectPodDisruptionBudgets bool
+	// ReplicaBoundsFromHPA reads the replica bounds that a Model does not set
+	// from the HorizontalPodAutoscaler that targets its scale target.
+	ReplicaBoundsFromHPA bool
+	// ObserveOnly prevents the ModelClient from scaling Models, i.e. so that
+	// KubeAI only reports the load of Models that are scaled by another
+	// autoscaler. Force scales are still applied.
+	ObserveOnly bool
+	// Clock is used for all time-dependent scaling state (i.e. pins,
+	// debouncing, activity and health tracking). Defaults to the real clock.
+	Clock clock.WithDelayedExecution
+	// StateConfigMap is the ConfigMap that state which all KubeAI instances
+	// need to agree on (i.e. force scales and the autoscaling pause) is stored
+	// in. It is shared with the autoscaler state. The state is only kept in the
+	// memory of each instance when unset.
+	StateConfigMap types.NamespacedName
+	// InstanceName identifies this KubeAI instance (i.e. the name of its Pod).
+	// Required to publish the signals of this instance to the state ConfigMap.
+	InstanceName string
+}
+
+// NewModelClient returns a new ModelClient.
+func NewModelClient(client client.Client, namespace string, opts Options) *ModelClient {
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	return &ModelClient{
+		client:                   client,
+		namespace:                namespace,
+		clock:                    clk,
+		stateConfigMap:           opts.StateConfigMap,
+		fallbackModel:            opts.FallbackModel,
+		unschedulableTimeout:     opts.UnschedulableTimeout,
+		scaleDebounceInterval:    opts.ScaleDebounceInterval,
+		maxQueueWait:             opts.MaxQueueWait,
+		auditSink:                opts.AuditSink,
+		maxTotalReplicas:         opts.MaxTotalReplicas,
+		useScalePatch:            opts.UseScalePatch,
+		respectDisruptionBudgets: opts.RespectPodDisruptionBudgets,
+		replicaBoundsFromHPA:     opts.ReplicaBoundsFromHPA,
+		observeOnly:              opts.ObserveOnly,
+		annotationDomains:        kubeaiv1.AnnotationDomains(opts.AnnotationDomains),
+		modelSelector:            opts.ModelSelector,
+		instanceName:             opts.InstanceName,
+		consecutiveScaleDowns:    map[string]int{},
+		scalerStates:             map[string]*scalerState{},
+		subscribers:              map[chan ScaleEvent]struct{}{},
+		watches:                  map[*StateWatch]struct{}{},
+	}
 }
 
 // LookupModel checks if a model exists and matches the given label selectors.
@@ -63,6 +219,85 @@ func (c *ModelClient) LookupModel(ctx context.Context, model, adapter string, la
 	return m, nil
 }
 
+// SetFallbackModel sets the name of the Model that requests for unknown models
+// are routed to (see ResolveModel), overriding Options.FallbackModel until the
+// next restart. An empty name disables the fallback.
+func (c *ModelClient) SetFallbackModel(model string) {
+	c.fallbackModelMtx.Lock()
+	defer c.fallbackModelMtx.Unlock()
+	c.fallbackModel = model
+}
+
+// FallbackModel returns the name of the Model that requests for unknown models
+// are routed to, or "" if the fallback is disabled.
+func (c *ModelClient) FallbackModel() string {
+	c.fallbackModelMtx.RLock()
+	defer c.fallbackModelMtx.RUnlock()
+	return c.fallbackModel
+}
+
+// ResolveModel looks up a model like LookupModel. If the model is not found and a
+// fallback model is configured, the fallback model is returned instead.
+// The second return value is true if the fallback model was returned.
+func (c *ModelClient) ResolveModel(ctx context.Context, model, adapter string, labelSelectors []string) (*kubeaiv1.Model, bool, error) {
+	m, err := c.LookupModel(ctx, model, adapter, labelSelectors)
+	fallback := c.FallbackModel()
+	if err != nil || m != nil || fallback == "" || fallback == model {
+		return m, false, err
+	}
+
+	m, err = c.LookupModel(ctx, fallback, "", labelSelectors)
+	if err != nil {
+		return nil, false, fmt.Errorf("lookup fallback model: %w", err)
+	}
+	if m == nil {
+		return nil, false, nil
+	}
+	return m, true, nil
+}
+
+// ResolvedModel describes the Model that a request was resolved to
+// (i.e. for debugging which backend served a response).
+type ResolvedModel struct {
+	Model *kubeaiv1.Model
+	// Name and Namespace of the Model.
+	Name, Namespace string
+	// ScaleTarget is the object that the replicas of the Model are scaled with:
+	// the value of the kubeaiv1.ModelScaleTargetAnnotation, or "Model/<name>".
+	ScaleTarget string
+	// Replicas is the current number of replicas of the ScaleTarget.
+	Replicas int32
+	// Fallback is true if the fallback model was resolved instead of the requested model.
+	Fallback bool
+}
+
+// ResolveModelInfo resolves a model like ResolveModel and describes the resolved Model.
+// Returns nil if the model does not exist.
+func (c *ModelClient) ResolveModelInfo(ctx context.Context, model, adapter string, labelSelectors []string) (*ResolvedModel, error) {
+	m, fallback, err := c.ResolveModel(ctx, model, adapter, labelSelectors)
+	if err != nil || m == nil {
+		return nil, err
+	}
+
+	_, replicas, err := c.getReplicas(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	scaleTarget := "Model/" + m.Name
+	if key, ok := c.scaleTargetKey(m); ok {
+		scaleTarget = key
+	}
+
+	return &ResolvedModel{
+		Model:       m,
+		Name:        m.Name,
+		Namespace:   m.Namespace,
+		ScaleTarget: scaleTarget,
+		Replicas:    replicas,
+		Fallback:    fallback,
+	}, nil
+}
+
 func (s *ModelClient) ListAllModels(ctx context.Context) ([]kubeaiv1.Model, error) {
 	models := &kubeaiv1.ModelList{}
 	if err := s.client.List(ctx, models, client.InNamespace(s.namespace)); err != nil {
@@ -0,0 +1,94 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+)
+
+func TestResolveModel(t *testing.T) {
+	ctx := context.Background()
+
+	mc, _ := newTestModelClient(t,
+		testModel("my-model", kubeaiv1.ModelSpec{}),
+		testModel("fallback-model", kubeaiv1.ModelSpec{}),
+	)
+
+	m, fallback, err := mc.ResolveModel(ctx, "does-not-exist", "", nil)
+	require.NoError(t, err)
+	require.Nil(t, m, "no fallback model configured")
+	require.False(t, fallback)
+
+	mc.SetFallbackModel("fallback-model")
+
+	m, fallback, err = mc.ResolveModel(ctx, "my-model", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, "my-model", m.Name)
+	require.False(t, fallback, "exact matches should not use the fallback")
+
+	m, fallback, err = mc.ResolveModel(ctx, "does-not-exist", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, "fallback-model", m.Name)
+	require.True(t, fallback)
+
+	m, fallback, err = mc.ResolveModel(ctx, "does-not-exist", "", []string{"team=a"})
+	require.NoError(t, err)
+	require.Nil(t, m, "fallback model should respect label selectors")
+	require.False(t, fallback)
+
+	mc.SetFallbackModel("")
+	m, fallback, err = mc.ResolveModel(ctx, "does-not-exist", "", nil)
+	require.NoError(t, err)
+	require.Nil(t, m, "fallback model should be disabled")
+	require.False(t, fallback)
+}
+
+func TestResolveModelInfo(t *testing.T) {
+	ctx := context.Background()
+
+	workload := &unstructured.Unstructured{}
+	workload.SetGroupVersionKind(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Workload"})
+	workload.SetNamespace(testNamespace)
+	workload.SetName("my-workload")
+	require.NoError(t, unstructured.SetNestedField(workload.Object, int64(2), "spec", "replicas"))
+
+	delegated := testModel("delegated-model", kubeaiv1.ModelSpec{})
+	delegated.Annotations = map[string]string{
+		kubeaiv1.ModelScaleTargetAnnotation:         "example.com/v1/Workload/my-workload",
+		kubeaiv1.ModelScaleTargetStrategyAnnotation: kubeaiv1.ScaleTargetStrategyReplicas,
+	}
+	mc, _ := newTestModelClient(t,
+		testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](3)}),
+		testModel("fallback-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1)}),
+		delegated,
+		workload,
+	)
+	mc.SetFallbackModel("fallback-model")
+
+	info, err := mc.ResolveModelInfo(ctx, "my-model", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, "my-model", info.Name)
+	require.Equal(t, testNamespace, info.Namespace)
+	require.Equal(t, "Model/my-model", info.ScaleTarget)
+	require.Equal(t, int32(3), info.Replicas)
+	require.False(t, info.Fallback)
+
+	info, err = mc.ResolveModelInfo(ctx, "does-not-exist", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, "fallback-model", info.Name)
+	require.True(t, info.Fallback)
+
+	info, err = mc.ResolveModelInfo(ctx, "delegated-model", "", nil)
+	require.NoError(t, err)
+	require.Equal(t, "example.com/v1/Workload/my-workload", info.ScaleTarget)
+	require.Equal(t, int32(2), info.Replicas)
+
+	info, err = mc.ResolveModelInfo(ctx, "does-not-exist", "", []string{"team=a"})
+	require.NoError(t, err)
+	require.Nil(t, info)
+}
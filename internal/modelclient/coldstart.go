@@ -0,0 +1,197 @@
+package modelclient
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// coldStartSamples is the number of recent cold start durations that the
+// cold start statistics of a model are calculated from.
+const coldStartSamples = 20
+
+// ColdStartSnapshot describes the cold starts (scale ups from zero replicas)
+// of a model that were observed by this KubeAI instance.
+type ColdStartSnapshot struct {
+	// Began is the time that the current cold start began.
+	// Zero when no cold start is in progress.
+	Began time.Time
+	// AverageDuration is the rolling average of the durations of recent cold starts.
+	// Zero when no cold starts have been observed.
+	AverageDuration time.Duration
+	// P50Duration and P90Duration are percentiles of the durations of recent cold starts.
+	P50Duration time.Duration
+	P90Duration time.Duration
+	// Observed is the number of cold starts that the statistics are based on.
+	Observed int
+}
+
+// MarshalJSON encodes durations in seconds.
+func (s ColdStartSnapshot) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Began          *time.Time `json:"began,omitempty"`
+		AverageSeconds float64    `json:"averageSeconds"`
+		P50Seconds     float64    `json:"p50Seconds"`
+		P90Seconds     float64    `json:"p90Seconds"`
+		Observed       int        `json:"observed"`
+	}{
+		AverageSeconds: s.AverageDuration.Seconds(),
+		P50Seconds:     s.P50Duration.Seconds(),
+		P90Seconds:     s.P90Duration.Seconds(),
+		Observed:       s.Observed,
+	}
+	if !s.Began.IsZero() {
+		v.Began = &s.Began
+	}
+	return json.Marshal(v)
+}
+
+// ColdStartSnapshot returns the cold start state of the given model.
+func (c *ModelClient) ColdStartSnapshot(model string) ColdStartSnapshot {
+	c.scalerStatesMtx.RLock()
+	defer c.scalerStatesMtx.RUnlock()
+
+	s, ok := c.scalerStates[model]
+	if !ok {
+		return ColdStartSnapshot{}
+	}
+	return s.coldStartSnapshot()
+}
+
+// coldStartSnapshot must be called while holding the scalerStatesMtx.
+func (s *scalerState) coldStartSnapshot() ColdStartSnapshot {
+	snapshot := ColdStartSnapshot{
+		Began:    s.coldStartBegan,
+		Observed: len(s.coldStartDurations),
+	}
+	if snapshot.Observed == 0 {
+		return snapshot
+	}
+
+	sorted := slices.Clone(s.coldStartDurations)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	snapshot.AverageDuration = total / time.Duration(len(sorted))
+	snapshot.P50Duration = percentile(sorted, 50)
+	snapshot.P90Duration = percentile(sorted, 90)
+	return snapshot
+}
+
+// percentile returns the nearest-rank percentile of the given sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// beginColdStart records that the model is being scaled up from zero replicas.
+func (c *ModelClient) beginColdStart(model string) {
+	c.scalerStatesMtx.Lock()
+	defer c.scalerStatesMtx.Unlock()
+
+	s := c.getScalerState(model)
+	if s.coldStartBegan.IsZero() {
+		s.coldStartBegan = c.clock.Now()
+		s.coldStartObserved = false
+	}
+}
+
+// observeColdStart records the observed status of the model. The duration of an
+// in-progress cold start is recorded once the model is observed to be ready.
+func (c *ModelClient) observeColdStart(ctx context.Context, model string, status ModelStatus) {
+	c.scalerStatesMtx.Lock()
+	s, ok := c.scalerStates[model]
+	if !ok || s.coldStartBegan.IsZero() {
+		c.scalerStatesMtx.Unlock()
+		return
+	}
+	began := s.coldStartBegan
+
+	switch status {
+	case ModelStatusScalingUp:
+		s.coldStartObserved = true
+		c.scalerStatesMtx.Unlock()
+		return
+	case ModelStatusScaledToZero:
+		// The Model is read from a cache that might not have observed the
+		// scale up yet. Once the scale up was observed, a Model without replicas
+		// means that the cold start was aborted and should not be recorded.
+		if s.coldStartObserved {
+			s.coldStartBegan = time.Time{}
+		}
+		c.scalerStatesMtx.Unlock()
+		return
+	case ModelStatusReady:
+		// Recorded below.
+	default:
+		s.coldStartBegan = time.Time{}
+		c.scalerStatesMtx.Unlock()
+		return
+	}
+	c.scalerStatesMtx.Unlock()
+
+	// The model might have been ready for a while before it was observed here,
+	// so prefer the time that the first Pod became ready.
+	readyTime, err := c.firstPodReadyTime(ctx, model, began)
+	if err != nil {
+		log.Printf("ERROR: finding ready time of model %s: %v", model, err)
+	}
+	if readyTime.IsZero() {
+		readyTime = c.clock.Now()
+	}
+	duration := readyTime.Sub(began)
+
+	c.scalerStatesMtx.Lock()
+	if s.coldStartBegan != began {
+		// Recorded concurrently.
+		c.scalerStatesMtx.Unlock()
+		return
+	}
+	s.coldStartBegan = time.Time{}
+	s.coldStartDurations = append(s.coldStartDurations, duration)
+	if n := len(s.coldStartDurations); n > coldStartSamples {
+		s.coldStartDurations = s.coldStartDurations[n-coldStartSamples:]
+	}
+	c.scalerStatesMtx.Unlock()
+
+	log.Printf("model %s became ready %s after scaling up from zero", model, duration)
+	metrics.ModelColdStartDuration.Record(ctx, duration.Seconds(), metric.WithAttributeSet(attribute.NewSet(
+		metrics.AttrRequestModel.String(model),
+	)))
+}
+
+// firstPodReadyTime returns the earliest time after the given time that a Pod of
+// the model became ready. Zero if no such Pod was found.
+func (c *ModelClient) firstPodReadyTime(ctx context.Context, model string, after time.Time) (time.Time, error) {
+	var pods corev1.PodList
+	if err := c.client.List(ctx, &pods, client.InNamespace(c.namespace), client.MatchingLabels{kubeaiv1.PodModelLabel: model}); err != nil {
+		return time.Time{}, err
+	}
+
+	var first time.Time
+	for _, pod := range pods.Items {
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type != corev1.PodReady || cond.Status != corev1.ConditionTrue {
+				continue
+			}
+			if t := cond.LastTransitionTime.Time; t.After(after) && (first.IsZero() || t.Before(first)) {
+				first = t
+			}
+		}
+	}
+	return first, nil
+}
@@ -0,0 +1,42 @@
+package modelclient
+
+import (
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+)
+
+// recordScaleUp records that the replicas of the model were increased, which
+// starts its scale up cooldown (see kubeaiv1.ModelSpec.ScaleUpCooldownSeconds).
+// Called by updateScale once the replicas are written, so scale ups that fail
+// or are deferred do not start it.
+func (c *ModelClient) recordScaleUp(model string) {
+	now := c.clock.Now()
+
+	c.scalerStatesMtx.Lock()
+	c.getScalerState(model).lastScaleUpTime = now
+	c.scalerStatesMtx.Unlock()
+}
+
+// scaleUpCooldown returns the remaining time that the model should not be
+// scaled down for after its most recent scale up. Returns 0 if the cooldown
+// has passed or is disabled.
+func (c *ModelClient) scaleUpCooldown(model *kubeaiv1.Model) time.Duration {
+	seconds := model.Spec.ScaleUpCooldownSeconds
+	if seconds == nil || *seconds <= 0 {
+		return 0
+	}
+
+	c.scalerStatesMtx.RLock()
+	s, ok := c.scalerStates[model.Name]
+	var last time.Time
+	if ok {
+		last = s.lastScaleUpTime
+	}
+	c.scalerStatesMtx.RUnlock()
+	if last.IsZero() {
+		return 0
+	}
+
+	return max(0, last.Add(time.Duration(*seconds)*time.Second).Sub(c.clock.Now()))
+}
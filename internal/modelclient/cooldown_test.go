@@ -0,0 +1,91 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestScaleUpCooldown(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	const cooldown = time.Minute
+	m := testModel("my-model", kubeaiv1.ModelSpec{
+		Replicas:               ptr.To[int32](1),
+		MaxReplicas:            ptr.To[int32](5),
+		ScaleUpCooldownSeconds: ptr.To[int64](int64(cooldown.Seconds())),
+	})
+	clk := newTestClock()
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{Clock: clk}, m)
+	scale := func(replicas int32) {
+		t.Helper()
+		require.NoError(t, mc.Scale(ctx, m, replicas, 2, "test"))
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(m), m))
+	}
+
+	scale(3)
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// The scale down delay is counted during the cooldown.
+	for range 4 {
+		scale(1)
+		require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	}
+	clk.Step(cooldown - time.Nanosecond)
+	scale(1)
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	clk.Step(time.Nanosecond)
+	scale(1)
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// Models without a cooldown are scaled down after the scale down delay.
+	m.Spec.ScaleUpCooldownSeconds = nil
+	require.NoError(t, k8sClient.Update(ctx, m))
+	scale(3)
+	for range 2 {
+		scale(1)
+	}
+	scale(1)
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
+
+func TestScaleUpCooldownStartsOnWrite(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	const cooldown = time.Minute
+	m := testModel("my-model", kubeaiv1.ModelSpec{
+		Replicas:               ptr.To[int32](1),
+		MaxReplicas:            ptr.To[int32](5),
+		ScaleUpCooldownSeconds: ptr.To[int64](int64(cooldown.Seconds())),
+	})
+	clk := newTestClock()
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{Clock: clk}, m)
+
+	// Deferred scale ups do not start the cooldown.
+	require.NoError(t, mc.PauseAutoscaling(ctx))
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "test"))
+	require.Zero(t, mc.scaleUpCooldown(m))
+
+	// The cooldown starts once the replicas are written.
+	clk.Step(cooldown)
+	require.NoError(t, mc.ResumeAutoscaling(ctx))
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	require.Equal(t, cooldown, mc.scaleUpCooldown(m))
+
+	// Scale downs do not restart it.
+	clk.Step(cooldown)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(m), m))
+	require.NoError(t, mc.Scale(ctx, m, 2, 0, "test"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	require.Zero(t, mc.scaleUpCooldown(m))
+}
@@ -0,0 +1,42 @@
+package modelclient
+
+import (
+	"context"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Cordon stops routing new requests to the model (see kubeaiv1.ModelCordonedAnnotation).
+// Requests that are in progress are not affected and the model is not scaled down.
+func (c *ModelClient) Cordon(ctx context.Context, model string) error {
+	return c.patchModelAnnotations(ctx, model, map[string]any{kubeaiv1.ModelCordonedAnnotation: "true"})
+}
+
+// Uncordon resumes routing requests to the model.
+func (c *ModelClient) Uncordon(ctx context.Context, model string) error {
+	annotations := map[string]any{}
+	for _, domain := range c.annotationDomains {
+		annotations[domain+"/"+kubeaiv1.ModelCordonedAnnotationName] = nil
+	}
+	return c.patchModelAnnotations(ctx, model, annotations)
+}
+
+// IsCordoned returns true if the model is cordoned. Returns false if the model
+// does not exist.
+func (c *ModelClient) IsCordoned(ctx context.Context, model string) (bool, error) {
+	m := &kubeaiv1.Model{}
+	if err := c.client.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: model}, m); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return c.IsModelCordoned(m), nil
+}
+
+// IsModelCordoned returns true if the given Model is cordoned.
+func (c *ModelClient) IsModelCordoned(m *kubeaiv1.Model) bool {
+	return kubeaiv1.IsModelCordoned(m, c.annotationDomains)
+}
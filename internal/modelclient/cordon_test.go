@@ -0,0 +1,53 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCordon(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	primary := testModel("primary", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2), MaxReplicas: ptr.To[int32](4), FailoverModels: []string{"secondary"}})
+	secondary := testModel("secondary", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1)})
+	mc, k8sClient := newTestModelClient(t, primary, secondary)
+
+	cordoned, err := mc.IsCordoned(ctx, primary.Name)
+	require.NoError(t, err)
+	require.False(t, cordoned)
+
+	require.NoError(t, mc.Cordon(ctx, primary.Name))
+	cordoned, err = mc.IsCordoned(ctx, primary.Name)
+	require.NoError(t, err)
+	require.True(t, cordoned)
+
+	candidates, err := mc.ModelCandidates(ctx, primary.Name, "", nil)
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	require.True(t, candidates[0].Cordoned)
+	require.False(t, candidates[0].Healthy())
+	require.False(t, candidates[1].Cordoned)
+
+	// Cordoned models are not scaled down.
+	m := &kubeaiv1.Model{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(primary), m))
+	require.NoError(t, mc.Scale(ctx, m, 0, 0, "idle"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, primary.Name))
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "scale up"))
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, primary.Name))
+
+	require.NoError(t, mc.Uncordon(ctx, primary.Name))
+	cordoned, err = mc.IsCordoned(ctx, primary.Name)
+	require.NoError(t, err)
+	require.False(t, cordoned)
+
+	require.Error(t, mc.Cordon(ctx, "does-not-exist"))
+}
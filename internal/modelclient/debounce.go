@@ -0,0 +1,71 @@
+package modelclient
+
+import (
+	"context"
+	"log"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+)
+
+// pendingScale is a scale operation that was deferred by the debounce interval.
+type pendingScale struct {
+	model    *kubeaiv1.Model
+	target   ScaleTarget
+	replicas int32
+	reason   string
+	// zeroReason is recorded if the model is scaled to zero (see updateScale).
+	zeroReason string
+	// actor is recorded in the scale event (see updateScale).
+	actor string
+}
+
+// debounceScale returns true if the scale operation should be deferred because
+// the model was scaled within the scaleDebounceInterval. Deferred operations
+// replace any operation that is already pending for the model, so only the
+// latest number of replicas is written once the interval has passed.
+func (c *ModelClient) debounceScale(op *pendingScale) bool {
+	if c.scaleDebounceInterval <= 0 {
+		return false
+	}
+
+	c.scalerStatesMtx.Lock()
+	defer c.scalerStatesMtx.Unlock()
+	s := c.getScalerState(op.model.Name)
+
+	if s.pendingScale != nil {
+		s.pendingScale = op
+		return true
+	}
+
+	now := c.clock.Now()
+	wait := s.scaleDebouncedUntil.Sub(now)
+	if wait <= 0 {
+		s.scaleDebouncedUntil = now.Add(c.scaleDebounceInterval)
+		return false
+	}
+
+	s.pendingScale = op
+	c.clock.AfterFunc(wait, func() { c.flushPendingScale(op.model.Name) })
+	return true
+}
+
+// flushPendingScale writes the pending scale operation of the model (if any).
+func (c *ModelClient) flushPendingScale(model string) {
+	c.scalerStatesMtx.Lock()
+	s := c.getScalerState(model)
+	pending := s.pendingScale
+	s.pendingScale = nil
+	c.scalerStatesMtx.Unlock()
+
+	if pending == nil {
+		return
+	}
+
+	log.Printf("scaling model %s to %d replicas after debounce: %s", model, pending.replicas, pending.reason)
+	if err := refreshScaleTarget(context.Background(), pending.target); err != nil {
+		log.Printf("WARNING: refreshing the scale target of model %s after debounce: %v", model, err)
+	}
+	if err := c.updateScale(context.Background(), pending.model, pending.target, pending.replicas, pending.reason, pending.zeroReason, pending.actor); err != nil {
+		log.Printf("ERROR: scaling model %s after debounce: %v", model, err)
+	}
+}
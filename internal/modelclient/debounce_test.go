@@ -0,0 +1,45 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	"k8s.io/utils/ptr"
+)
+
+func TestScaleDebounce(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	const interval = time.Minute
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1)})
+	clk := newTestClock()
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{ScaleDebounceInterval: interval, Clock: clk}, m)
+	target, _, err := mc.getReplicas(ctx, m)
+	require.NoError(t, err)
+
+	// The first write is not delayed.
+	require.NoError(t, mc.updateScale(ctx, m, target, 2, "first", ScaledToZeroIdle, ScaleActorAuto))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// Writes within the interval are coalesced into the latest value.
+	for _, replicas := range []int32{3, 5, 4} {
+		require.NoError(t, mc.updateScale(ctx, m, target, replicas, "burst", ScaledToZeroIdle, ScaleActorAuto))
+	}
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	clk.Step(interval - time.Nanosecond)
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	clk.Step(time.Nanosecond)
+	require.Eventually(t, func() bool {
+		return modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name) == 4
+	}, time.Second, time.Millisecond)
+	snapshot, ok := mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.Equal(t, "burst", snapshot.LastScaleReason)
+}
@@ -0,0 +1,61 @@
+package modelclient
+
+import (
+	"context"
+	"fmt"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// disruptionBudgetFloor returns the number of replicas that the model should not
+// be scaled below because of the PodDisruptionBudgets that select its Pods: the
+// highest absolute minAvailable, along with the name of that PodDisruptionBudget.
+// Percentages and maxUnavailable are relative to the number of replicas and do
+// not imply a floor. Returns 0 if the feature is disabled or no budget applies.
+func (c *ModelClient) disruptionBudgetFloor(ctx context.Context, model *kubeaiv1.Model) (int32, string, error) {
+	if !c.respectDisruptionBudgets {
+		return 0, "", nil
+	}
+
+	var pods corev1.PodList
+	if err := c.client.List(ctx, &pods, client.InNamespace(c.namespace), client.MatchingLabels{kubeaiv1.PodModelLabel: model.Name}); err != nil {
+		return 0, "", fmt.Errorf("listing pods: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return 0, "", nil
+	}
+	var pdbs policyv1.PodDisruptionBudgetList
+	if err := c.client.List(ctx, &pdbs, client.InNamespace(c.namespace)); err != nil {
+		return 0, "", fmt.Errorf("listing pod disruption budgets: %w", err)
+	}
+
+	var (
+		floor int32
+		by    string
+	)
+	for i := range pdbs.Items {
+		pdb := &pdbs.Items[i]
+		minAvailable := pdb.Spec.MinAvailable
+		if minAvailable == nil || minAvailable.Type != intstr.Int || minAvailable.IntVal <= floor {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			// Invalid and empty selectors do not select any Pods.
+			continue
+		}
+		for j := range pods.Items {
+			if selector.Matches(labels.Set(pods.Items[j].Labels)) {
+				floor, by = minAvailable.IntVal, pdb.Name
+				break
+			}
+		}
+	}
+	return floor, by, nil
+}
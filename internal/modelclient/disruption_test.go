@@ -0,0 +1,81 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestDisruptionBudgetFloor(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubeaiv1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, policyv1.AddToScheme(scheme))
+
+	pdb := func(name string, minAvailable intstr.IntOrString, app string) *policyv1.PodDisruptionBudget {
+		return &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: &minAvailable,
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+			},
+		}
+	}
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](3), MaxReplicas: ptr.To[int32](5)})
+	objs := []client.Object{
+		m,
+		pdb("absolute", intstr.FromInt32(2), "my-model-server"),
+		pdb("percentage", intstr.FromString("100%"), "my-model-server"),
+		pdb("other-app", intstr.FromInt32(3), "other"),
+	}
+	for _, name := range []string{"pod-a", "pod-b", "pod-c"} {
+		objs = append(objs, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{kubeaiv1.PodModelLabel: m.Name, "app": "my-model-server"},
+		}})
+	}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithInterceptorFuncs(interceptor.Funcs{SubResourceUpdate: modelclienttest.UpdateModelScale}).
+		Build()
+
+	// Disabled by default.
+	mc := NewModelClient(k8sClient, testNamespace, Options{})
+	floor, _, err := mc.disruptionBudgetFloor(ctx, m)
+	require.NoError(t, err)
+	require.Zero(t, floor)
+
+	mc = NewModelClient(k8sClient, testNamespace, Options{RespectPodDisruptionBudgets: true})
+	floor, by, err := mc.disruptionBudgetFloor(ctx, m)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), floor)
+	require.Equal(t, "absolute", by)
+
+	// Scale downs stop at the floor, scale ups are not affected.
+	require.NoError(t, mc.Scale(ctx, m, 0, 0, "test"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	snapshot, _ := mc.ScalerSnapshot(m.Name)
+	require.Contains(t, snapshot.LastScaleReason, "PodDisruptionBudget absolute floor")
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(m), m))
+	require.NoError(t, mc.Scale(ctx, m, 4, 0, "test"))
+	require.Equal(t, int32(4), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
@@ -0,0 +1,88 @@
+package modelclient
+
+import (
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ScaleErrorKind categorizes the reason that a scale operation failed.
+type ScaleErrorKind string
+
+const (
+	// ScaleErrorConflict indicates that the Model was modified concurrently.
+	// The operation can be retried.
+	ScaleErrorConflict ScaleErrorKind = "Conflict"
+	// ScaleErrorNotFound indicates that the Model no longer exists.
+	ScaleErrorNotFound ScaleErrorKind = "NotFound"
+	// ScaleErrorForbidden indicates that KubeAI is not permitted to scale the Model.
+	ScaleErrorForbidden ScaleErrorKind = "Forbidden"
+	// ScaleErrorUnscalable indicates that the scale target of the Model exists
+	// but does not implement the scale subresource. The operation should not be
+	// retried until the scale target of the Model changes.
+	ScaleErrorUnscalable ScaleErrorKind = "Unscalable"
+	// ScaleErrorUnknown is used for all other errors.
+	ScaleErrorUnknown ScaleErrorKind = "Unknown"
+)
+
+// Sentinel errors that can be used with errors.Is() to check the kind of a ScaleError.
+var (
+	ErrScaleConflict   = errors.New("scale conflict")
+	ErrScaleNotFound   = errors.New("scale target not found")
+	ErrScaleForbidden  = errors.New("scale forbidden")
+	ErrScaleUnscalable = errors.New("scale subresource not found")
+)
+
+// errNoScaleSubresource is wrapped by the errors of ScaleTargets whose object
+// exists but does not implement the scale subresource.
+var errNoScaleSubresource = errors.New("object does not implement the scale subresource")
+
+// ScaleError is returned when scaling a Model fails. All scaling operations of
+// the ModelClient (i.e. Scale, ScaleAtLeastOneReplica, ForceScale) return errors
+// of this type.
+type ScaleError struct {
+	Kind  ScaleErrorKind
+	Model string
+	// Op is the operation that failed (i.e. "get", "update").
+	Op  string
+	Err error
+}
+
+func newScaleError(op, model string, err error) *ScaleError {
+	kind := ScaleErrorUnknown
+	switch {
+	case errors.Is(err, errNoScaleSubresource):
+		kind = ScaleErrorUnscalable
+	case apierrors.IsConflict(err):
+		kind = ScaleErrorConflict
+	case apierrors.IsNotFound(err):
+		kind = ScaleErrorNotFound
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err):
+		kind = ScaleErrorForbidden
+	}
+	return &ScaleError{Kind: kind, Model: model, Op: op, Err: err}
+}
+
+func (e *ScaleError) Error() string {
+	return fmt.Sprintf("%s scale: %v", e.Op, e.Err)
+}
+
+func (e *ScaleError) Unwrap() error {
+	return e.Err
+}
+
+// Is allows for comparing ScaleErrors against the sentinel errors.
+func (e *ScaleError) Is(target error) bool {
+	switch target {
+	case ErrScaleConflict:
+		return e.Kind == ScaleErrorConflict
+	case ErrScaleNotFound:
+		return e.Kind == ScaleErrorNotFound
+	case ErrScaleForbidden:
+		return e.Kind == ScaleErrorForbidden
+	case ErrScaleUnscalable:
+		return e.Kind == ScaleErrorUnscalable
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package modelclient
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestScaleError(t *testing.T) {
+	gr := schema.GroupResource{Group: "kubeai.org", Resource: "models"}
+	cases := map[string]struct {
+		err      error
+		expKind  ScaleErrorKind
+		expIs    error
+		expIsNot []error
+	}{
+		"conflict": {
+			err:      apierrors.NewConflict(gr, "my-model", errors.New("modified")),
+			expKind:  ScaleErrorConflict,
+			expIs:    ErrScaleConflict,
+			expIsNot: []error{ErrScaleNotFound, ErrScaleForbidden},
+		},
+		"not found": {
+			err:      apierrors.NewNotFound(gr, "my-model"),
+			expKind:  ScaleErrorNotFound,
+			expIs:    ErrScaleNotFound,
+			expIsNot: []error{ErrScaleConflict, ErrScaleForbidden},
+		},
+		"forbidden": {
+			err:      apierrors.NewForbidden(gr, "my-model", errors.New("rbac")),
+			expKind:  ScaleErrorForbidden,
+			expIs:    ErrScaleForbidden,
+			expIsNot: []error{ErrScaleConflict, ErrScaleNotFound},
+		},
+		"unscalable": {
+			err:      fmt.Errorf("apps/v1/Deployment my-deployment: %w", errNoScaleSubresource),
+			expKind:  ScaleErrorUnscalable,
+			expIs:    ErrScaleUnscalable,
+			expIsNot: []error{ErrScaleConflict, ErrScaleNotFound, ErrScaleForbidden},
+		},
+		"unknown": {
+			err:      errors.New("something else"),
+			expKind:  ScaleErrorUnknown,
+			expIsNot: []error{ErrScaleConflict, ErrScaleNotFound, ErrScaleForbidden, ErrScaleUnscalable},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := error(newScaleError("update", "my-model", c.err))
+
+			var scaleErr *ScaleError
+			require.ErrorAs(t, err, &scaleErr)
+			require.Equal(t, c.expKind, scaleErr.Kind)
+			require.ErrorIs(t, err, c.err, "underlying error should be preserved")
+			if c.expIs != nil {
+				require.ErrorIs(t, err, c.expIs)
+			}
+			for _, notErr := range c.expIsNot {
+				require.NotErrorIs(t, err, notErr)
+			}
+		})
+	}
+}
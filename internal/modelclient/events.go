@@ -0,0 +1,126 @@
+package modelclient
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/retry"
+)
+
+// scaleEventBuffer is the number of events that are buffered per subscriber.
+// Events are dropped for subscribers that fall further behind.
+const scaleEventBuffer = 64
+
+// ScaleEvent describes a change of the replicas of a model that was written
+// by this KubeAI instance.
+type ScaleEvent struct {
+	Model        string    `json:"model"`
+	FromReplicas int32     `json:"fromReplicas"`
+	ToReplicas   int32     `json:"toReplicas"`
+	Reason       string    `json:"reason"`
+	Actor        string    `json:"actor"`
+	Time         time.Time `json:"time"`
+}
+
+// Actors of scale events.
+const (
+	// ScaleActorAuto is the actor of scale operations that KubeAI decided on
+	// (autoscaling, scale from zero, and replica bounds).
+	ScaleActorAuto = "auto"
+	// ScaleActorManual is the actor of manual overrides (see ForceScale) and of
+	// the scale operations that are applied when autoscaling is resumed.
+	ScaleActorManual = "manual"
+)
+
+// Subscribe returns a channel that receives the scale events of all models
+// and a function that unsubscribes (and closes the channel). Event delivery
+// never blocks scaling: events are dropped for subscribers that do not keep up.
+func (c *ModelClient) Subscribe() (<-chan ScaleEvent, func()) {
+	ch := make(chan ScaleEvent, scaleEventBuffer)
+
+	c.subscribersMtx.Lock()
+	c.subscribers[ch] = struct{}{}
+	c.subscribersMtx.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			c.subscribersMtx.Lock()
+			delete(c.subscribers, ch)
+			close(ch)
+			c.subscribersMtx.Unlock()
+		})
+	}
+}
+
+// hasSubscribers returns true if scale events should be published.
+func (c *ModelClient) hasSubscribers() bool {
+	c.subscribersMtx.Lock()
+	defer c.subscribersMtx.Unlock()
+	return len(c.subscribers) > 0
+}
+
+func (c *ModelClient) publishScaleEvent(e ScaleEvent) {
+	c.subscribersMtx.Lock()
+	defer c.subscribersMtx.Unlock()
+
+	for ch := range c.subscribers {
+		select {
+		case ch <- e:
+		default:
+			log.Printf("WARNING: dropping scale event of model %s for a slow subscriber", e.Model)
+		}
+	}
+}
+
+// setReplicas writes the replicas to the ScaleTarget of the model and
+// publishes a ScaleEvent to subscribers and the audit sink (if any).
+func (c *ModelClient) setReplicas(ctx context.Context, model string, target ScaleTarget, replicas int32, reason, actor string) error {
+	// The current replicas are only read when they are needed for an event.
+	var from int32
+	subscribed := c.hasSubscribers()
+	if subscribed || c.auditSink != nil {
+		var err error
+		if from, err = target.GetReplicas(ctx); err != nil {
+			return err
+		}
+	}
+
+	// The object of the target might have changed since it was read. Conflicts
+	// are retried (a bounded number of times) with the re-fetched object.
+	attempt := 0
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		attempt++
+		if attempt > 1 {
+			log.Printf("conflict while scaling model %s, retrying (attempt %d)", model, attempt)
+			if err := refreshScaleTarget(ctx, target); err != nil {
+				return err
+			}
+		}
+		return target.SetReplicas(ctx, replicas)
+	}); err != nil {
+		return err
+	}
+	c.observeAppliedReplicas(model, replicas)
+	c.trackReplicaLag(ctx, model, nil)
+
+	e := ScaleEvent{
+		Model:        model,
+		FromReplicas: from,
+		ToReplicas:   replicas,
+		Reason:       reason,
+		Actor:        actor,
+		Time:         c.clock.Now(),
+	}
+	if c.auditSink != nil {
+		if err := c.auditSink.RecordScaleEvent(ctx, e); err != nil {
+			log.Printf("ERROR: recording scale event of model %s in the audit log: %v", model, err)
+		}
+	}
+	if subscribed {
+		c.publishScaleEvent(e)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"k8s.io/utils/ptr"
+)
+
+func TestSubscribe(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](100)})
+	mc, _ := newTestModelClient(t, m)
+
+	events, unsubscribe := mc.Subscribe()
+	slow, unsubscribeSlow := mc.Subscribe()
+	defer unsubscribeSlow()
+
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "test"))
+	e := <-events
+	require.Equal(t, "my-model", e.Model)
+	require.Equal(t, int32(1), e.FromReplicas)
+	require.Equal(t, int32(3), e.ToReplicas)
+	require.Equal(t, "test", e.Reason)
+	require.False(t, e.Time.IsZero())
+
+	unsubscribe()
+	_, ok := <-events
+	require.False(t, ok, "the channel should be closed after unsubscribing")
+	unsubscribe()
+
+	// Slow subscribers should not block scaling.
+	for i := 0; i < scaleEventBuffer+1; i++ {
+		require.NoError(t, mc.Scale(ctx, m, int32(4+i), 0, "test"))
+	}
+	require.Len(t, slow, scaleEventBuffer)
+}
@@ -0,0 +1,87 @@
+package modelclient
+
+import (
+	"context"
+	"fmt"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+)
+
+// ModelCandidate is a Model that a request can be routed to.
+type ModelCandidate struct {
+	Model  *kubeaiv1.Model
+	Status ModelStatus
+	// Saturated is true when the Model is running at its max replicas
+	// and the autoscaler would scale beyond it.
+	Saturated bool
+	// CapacityUnavailable is true when Pods of the Model have been
+	// unschedulable for longer than the unschedulable timeout.
+	CapacityUnavailable bool
+	// Cordoned is true when new requests should not be routed to the Model
+	// (see kubeaiv1.ModelCordonedAnnotation).
+	Cordoned bool
+}
+
+// Healthy returns true if the candidate is expected to be able to serve
+// additional requests.
+func (c ModelCandidate) Healthy() bool {
+	switch {
+	case c.Status == ModelStatusUnknown:
+		return false
+	case c.Cordoned:
+		return false
+	case c.Saturated:
+		return false
+	case c.CapacityUnavailable && c.Status != ModelStatusReady:
+		return false
+	case c.Status == ModelStatusScaledToZero && c.Model.Spec.AutoscalingDisabled:
+		// Nothing will scale the Model up.
+		return false
+	}
+	return true
+}
+
+// ModelCandidates returns the given model followed by its failover Models
+// (see kubeaiv1.ModelSpec.FailoverModels) in order of preference. Failover
+// Models that do not exist, do not match the label selectors, or do not serve
+// the adapter are omitted. Returns nil if the model does not exist.
+func (c *ModelClient) ModelCandidates(ctx context.Context, model, adapter string, labelSelectors []string) ([]ModelCandidate, error) {
+	primary, err := c.LookupModel(ctx, model, adapter, labelSelectors)
+	if err != nil || primary == nil {
+		return nil, err
+	}
+
+	models := []*kubeaiv1.Model{primary}
+	for _, name := range primary.Spec.FailoverModels {
+		if name == model {
+			continue
+		}
+		m, err := c.LookupModel(ctx, name, adapter, labelSelectors)
+		if err != nil {
+			return nil, fmt.Errorf("lookup failover model %q: %w", name, err)
+		}
+		if m != nil {
+			models = append(models, m)
+		}
+	}
+
+	candidates := make([]ModelCandidate, 0, len(models))
+	for _, m := range models {
+		status, err := c.getModelStatus(ctx, m)
+		if err != nil {
+			return nil, fmt.Errorf("model %q: %w", m.Name, err)
+		}
+		unavailable, err := c.CapacityUnavailable(ctx, m.Name)
+		if err != nil {
+			return nil, fmt.Errorf("model %q: %w", m.Name, err)
+		}
+		candidates = append(candidates, ModelCandidate{
+			Model:               m,
+			Status:              status,
+			Saturated:           c.IsSaturated(m),
+			CapacityUnavailable: unavailable,
+			Cordoned:            c.IsModelCordoned(m),
+		})
+	}
+	return candidates, nil
+}
@@ -0,0 +1,45 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestModelCandidates(t *testing.T) {
+	ctx := context.Background()
+
+	primary := testModel("primary", kubeaiv1.ModelSpec{
+		Replicas:       ptr.To[int32](2),
+		FailoverModels: []string{"does-not-exist", "scaled-to-zero", "disabled"},
+	})
+	scaledToZero := testModel("scaled-to-zero", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0)})
+	disabled := testModel("disabled", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), AutoscalingDisabled: true})
+	mc, _ := newTestModelClient(t, primary, scaledToZero, disabled)
+
+	candidates, err := mc.ModelCandidates(ctx, primary.Name, "", nil)
+	require.NoError(t, err)
+	var names []string
+	for _, c := range candidates {
+		names = append(names, c.Model.Name)
+	}
+	require.Equal(t, []string{"primary", "scaled-to-zero", "disabled"}, names)
+	require.Equal(t, ModelStatusScalingUp, candidates[0].Status)
+	require.True(t, candidates[0].Healthy())
+	require.Equal(t, ModelStatusScaledToZero, candidates[1].Status)
+	require.True(t, candidates[1].Healthy(), "a scaled to zero Model is scaled up by requests")
+	require.False(t, candidates[2].Healthy(), "a scaled to zero Model without autoscaling can not serve requests")
+
+	mc.setSaturated(ctx, primary, true)
+	candidates, err = mc.ModelCandidates(ctx, primary.Name, "", nil)
+	require.NoError(t, err)
+	require.True(t, candidates[0].Saturated)
+	require.False(t, candidates[0].Healthy())
+
+	candidates, err = mc.ModelCandidates(ctx, "does-not-exist", "", nil)
+	require.NoError(t, err)
+	require.Nil(t, candidates)
+}
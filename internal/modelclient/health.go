@@ -0,0 +1,83 @@
+package modelclient
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// backendErrorHalfLife is the time it takes for the error score of an
+	// endpoint to decay by half. Keeps transient errors from permanently
+	// penalizing a replica.
+	backendErrorHalfLife = time.Minute
+	// unhealthyBackendErrorScore is the (decayed) number of errors at which an
+	// endpoint is considered to be unhealthy: 3 errors in quick succession.
+	unhealthyBackendErrorScore = 2.5
+	// minBackendErrorScore is the score below which an endpoint is forgotten.
+	minBackendErrorScore = 0.1
+)
+
+// backendErrorScore is an exponentially decaying count of errors.
+type backendErrorScore struct {
+	score float64
+	time  time.Time
+}
+
+func (s *backendErrorScore) valueAt(now time.Time) float64 {
+	return s.score * math.Pow(0.5, float64(now.Sub(s.time))/float64(backendErrorHalfLife))
+}
+
+// ReportBackendError records that a request to the given endpoint of the model
+// failed (i.e. returned a 5xx status code). Endpoints that keep failing are
+// counted as unhealthy (see UnhealthyReplicas).
+func (c *ModelClient) ReportBackendError(model, endpoint string) {
+	now := c.clock.Now()
+
+	c.scalerStatesMtx.Lock()
+	defer c.scalerStatesMtx.Unlock()
+
+	s := c.getScalerState(model)
+	if s.backendErrors == nil {
+		s.backendErrors = map[string]*backendErrorScore{}
+	}
+	e, ok := s.backendErrors[endpoint]
+	if !ok {
+		e = &backendErrorScore{}
+		s.backendErrors[endpoint] = e
+	}
+	e.score = e.valueAt(now) + 1
+	e.time = now
+}
+
+// UnhealthyReplicas returns the number of endpoints of the model that have
+// recently been reported as failing to this KubeAI instance. The errors that
+// all instances observed are merged by ClusterSignals.
+func (c *ModelClient) UnhealthyReplicas(model string) int32 {
+	c.scalerStatesMtx.Lock()
+	defer c.scalerStatesMtx.Unlock()
+
+	return int32(len(c.unhealthyEndpoints(model)))
+}
+
+// unhealthyEndpoints returns the endpoints of the model that have recently been
+// reported as failing. Endpoints whose errors decayed are forgotten.
+// The caller must hold the scalerStatesMtx.
+func (c *ModelClient) unhealthyEndpoints(model string) []string {
+	now := c.clock.Now()
+	s, ok := c.scalerStates[model]
+	if !ok {
+		return nil
+	}
+	var unhealthy []string
+	for endpoint, e := range s.backendErrors {
+		v := e.valueAt(now)
+		if v < minBackendErrorScore {
+			delete(s.backendErrors, endpoint)
+			continue
+		}
+		if v >= unhealthyBackendErrorScore {
+			unhealthy = append(unhealthy, endpoint)
+		}
+	}
+	return unhealthy
+}
@@ -0,0 +1,36 @@
+package modelclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnhealthyReplicas(t *testing.T) {
+	mc := NewModelClient(nil, testNamespace, Options{})
+	const model = "my-model"
+
+	require.Equal(t, int32(0), mc.UnhealthyReplicas(model))
+
+	// A transient error does not mark the endpoint as unhealthy.
+	mc.ReportBackendError(model, "10.0.0.1:8000")
+	require.Equal(t, int32(0), mc.UnhealthyReplicas(model))
+
+	for i := 0; i < 3; i++ {
+		mc.ReportBackendError(model, "10.0.0.2:8000")
+	}
+	require.Equal(t, int32(1), mc.UnhealthyReplicas(model))
+
+	// Errors decay over time.
+	mc.scalerStatesMtx.Lock()
+	for _, e := range mc.scalerStates[model].backendErrors {
+		e.time = e.time.Add(-10 * backendErrorHalfLife)
+	}
+	mc.scalerStatesMtx.Unlock()
+	require.Equal(t, int32(0), mc.UnhealthyReplicas(model))
+	require.Empty(t, mc.scalerStates[model].backendErrors, "decayed endpoints should be forgotten")
+
+	// A decayed endpoint starts from a low score again.
+	mc.ReportBackendError(model, "10.0.0.2:8000")
+	require.Equal(t, int32(0), mc.UnhealthyReplicas(model))
+}
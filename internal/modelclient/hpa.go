@@ -0,0 +1,79 @@
+package modelclient
+
+import (
+	"context"
+	"fmt"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// withHPABounds returns the model with the replica bounds of the
+// HorizontalPodAutoscaler that targets the scale target of the model (see
+// scaleTargetObject) for the bounds that are not set on the Model itself:
+// maxReplicas if unset, and minReplicas if 0 and the Model opts in with the
+// kubeaiv1.ModelMinReplicasFromHPAAnnotation (a minReplicas of 0 otherwise
+// means scale to zero). The Model is returned unchanged if the feature is
+// disabled or no HorizontalPodAutoscaler applies.
+func (c *ModelClient) withHPABounds(ctx context.Context, model *kubeaiv1.Model) (*kubeaiv1.Model, error) {
+	if !c.replicaBoundsFromHPA {
+		return model, nil
+	}
+	_, value, _ := c.getModelAnnotation(model, kubeaiv1.ModelMinReplicasFromHPAAnnotationName)
+	minFromHPA := value == "true" && model.Spec.MinReplicas == 0
+	if model.Spec.MaxReplicas != nil && !minFromHPA {
+		return model, nil
+	}
+	gk, name, ok := c.scaleTargetObject(model)
+	if !ok {
+		return model, nil
+	}
+
+	var hpas autoscalingv2.HorizontalPodAutoscalerList
+	if err := c.client.List(ctx, &hpas, client.InNamespace(model.Namespace)); err != nil {
+		return model, fmt.Errorf("listing horizontal pod autoscalers: %w", err)
+	}
+	for i := range hpas.Items {
+		ref := hpas.Items[i].Spec.ScaleTargetRef
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil || gv.Group != gk.Group || ref.Kind != gk.Kind || ref.Name != name {
+			continue
+		}
+
+		model = model.DeepCopy()
+		if model.Spec.MaxReplicas == nil {
+			max := hpas.Items[i].Spec.MaxReplicas
+			model.Spec.MaxReplicas = &max
+		}
+		if minFromHPA {
+			// Defaults to 1 (see autoscalingv2.HorizontalPodAutoscalerSpec).
+			model.Spec.MinReplicas = 1
+			if min := hpas.Items[i].Spec.MinReplicas; min != nil {
+				model.Spec.MinReplicas = *min
+			}
+		}
+		return model, nil
+	}
+	return model, nil
+}
+
+// scaleTargetObject returns the kind and name of the single object that the
+// replicas of the model are written to: the Model itself, or the object of the
+// kubeaiv1.ModelScaleTargetAnnotation. Returns false for targets that are
+// resolved by a selector or that are weighted across multiple objects.
+func (c *ModelClient) scaleTargetObject(model *kubeaiv1.Model) (schema.GroupKind, string, bool) {
+	_, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelScaleTargetAnnotationName)
+	if !ok {
+		return kubeaiv1.GroupVersion.WithKind("Model").GroupKind(), model.Name, true
+	}
+	if _, _, ok := c.getModelAnnotation(model, kubeaiv1.ModelScaleTargetSelectorAnnotationName); ok {
+		return schema.GroupKind{}, "", false
+	}
+	refs, err := parseScaleTargetRefs(value)
+	if err != nil || len(refs) != 1 {
+		return schema.GroupKind{}, "", false
+	}
+	return refs[0].gvk.GroupKind(), refs[0].name, true
+}
@@ -0,0 +1,97 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestHPABounds(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubeaiv1.AddToScheme(scheme))
+	require.NoError(t, autoscalingv2.AddToScheme(scheme))
+
+	hpa := func(name, apiVersion, kind, target string, min *int32, max int32) *autoscalingv2.HorizontalPodAutoscaler {
+		return &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: apiVersion, Kind: kind, Name: target},
+				MinReplicas:    min,
+				MaxReplicas:    max,
+			},
+		}
+	}
+
+	unbounded := testModel("unbounded", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](3)})
+	unbounded.Annotations = map[string]string{kubeaiv1.ModelMinReplicasFromHPAAnnotation: "true"}
+	bounded := testModel("bounded", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](3), MaxReplicas: ptr.To[int32](6)})
+	deployment := testModel("deployment", kubeaiv1.ModelSpec{})
+	deployment.Annotations = map[string]string{
+		kubeaiv1.ModelScaleTargetAnnotation:        "apps/v1/Deployment/my-deployment",
+		kubeaiv1.ModelMinReplicasFromHPAAnnotation: "true",
+	}
+	scaleToZero := testModel("scale-to-zero", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1)})
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			unbounded,
+			bounded,
+			deployment,
+			scaleToZero,
+			hpa("scale-to-zero", "kubeai.org/v1", "Model", scaleToZero.Name, nil, 4),
+			hpa("unbounded", "kubeai.org/v1", "Model", unbounded.Name, ptr.To[int32](2), 4),
+			hpa("bounded", "kubeai.org/v1", "Model", bounded.Name, nil, 4),
+			hpa("deployment", "apps/v1", "Deployment", "my-deployment", ptr.To[int32](2), 8),
+			hpa("other-group", "example.com/v1", "Model", deployment.Name, ptr.To[int32](5), 5),
+		).
+		WithInterceptorFuncs(interceptor.Funcs{SubResourceUpdate: modelclienttest.UpdateModelScale}).
+		Build()
+
+	// Disabled by default.
+	mc := NewModelClient(k8sClient, testNamespace, Options{})
+	got, err := mc.withHPABounds(ctx, unbounded)
+	require.NoError(t, err)
+	require.Same(t, unbounded, got)
+
+	mc = NewModelClient(k8sClient, testNamespace, Options{ReplicaBoundsFromHPA: true})
+
+	// Bounds on the Model take precedence.
+	got, err = mc.withHPABounds(ctx, bounded)
+	require.NoError(t, err)
+	require.Same(t, bounded, got)
+
+	// The min replicas are only read from the HPA on opt-in, so Models still
+	// scale to zero.
+	got, err = mc.withHPABounds(ctx, scaleToZero)
+	require.NoError(t, err)
+	require.Equal(t, ptr.To[int32](4), got.Spec.MaxReplicas)
+	require.Equal(t, int32(0), got.Spec.MinReplicas)
+	require.NoError(t, mc.Scale(ctx, scaleToZero, 0, 0, "test"))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, scaleToZero.Name))
+
+	got, err = mc.withHPABounds(ctx, deployment)
+	require.NoError(t, err)
+	require.Equal(t, ptr.To[int32](8), got.Spec.MaxReplicas)
+	require.Equal(t, int32(2), got.Spec.MinReplicas)
+	require.Nil(t, deployment.Spec.MaxReplicas, "The Model should not be modified")
+
+	// Scaling is bounded by the HPA.
+	require.NoError(t, mc.Scale(ctx, unbounded, 10, 0, "test"))
+	require.Equal(t, int32(4), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, unbounded.Name))
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(unbounded), unbounded))
+	require.NoError(t, mc.Scale(ctx, unbounded, 0, 0, "test"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, unbounded.Name))
+}
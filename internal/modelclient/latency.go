@@ -0,0 +1,57 @@
+package modelclient
+
+import (
+	"slices"
+	"time"
+)
+
+// maxLatencySamples bounds the memory that is used to track the latency of a model.
+const maxLatencySamples = 10000
+
+type latencySample struct {
+	time     time.Time
+	duration time.Duration
+}
+
+// RecordLatency records the time that was taken to respond to a request for the model.
+func (c *ModelClient) RecordLatency(model string, d time.Duration) {
+	c.scalerStatesMtx.Lock()
+	defer c.scalerStatesMtx.Unlock()
+
+	s := c.getScalerState(model)
+	s.latencies = append(s.latencies, latencySample{time: c.clock.Now(), duration: d})
+	if n := len(s.latencies); n > maxLatencySamples {
+		s.latencies = s.latencies[n-maxLatencySamples:]
+	}
+}
+
+// LatencyPercentile returns the p-th percentile of the latencies that were recorded
+// for the model within the given window, along with the number of samples that it
+// is based on. Samples that are older than the window are discarded.
+// Only requests that were served by this instance are included. The latencies
+// of all instances are merged by ClusterSignals.
+func (c *ModelClient) LatencyPercentile(model string, window time.Duration, p int) (time.Duration, int) {
+	since := c.clock.Now().Add(-window)
+
+	c.scalerStatesMtx.Lock()
+	s, ok := c.scalerStates[model]
+	if !ok {
+		c.scalerStatesMtx.Unlock()
+		return 0, 0
+	}
+	i, _ := slices.BinarySearchFunc(s.latencies, since, func(l latencySample, t time.Time) int {
+		return l.time.Compare(t)
+	})
+	s.latencies = s.latencies[i:]
+	durations := make([]time.Duration, len(s.latencies))
+	for i, l := range s.latencies {
+		durations[i] = l.duration
+	}
+	c.scalerStatesMtx.Unlock()
+
+	if len(durations) == 0 {
+		return 0, 0
+	}
+	slices.Sort(durations)
+	return percentile(durations, p), len(durations)
+}
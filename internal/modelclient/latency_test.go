@@ -0,0 +1,33 @@
+package modelclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyPercentile(t *testing.T) {
+	mc := NewModelClient(nil, testNamespace, Options{})
+
+	p95, n := mc.LatencyPercentile("my-model", time.Minute, 95)
+	require.Zero(t, n)
+	require.Zero(t, p95)
+
+	for i := 1; i <= 100; i++ {
+		mc.RecordLatency("my-model", time.Duration(i)*time.Millisecond)
+	}
+	p95, n = mc.LatencyPercentile("my-model", time.Minute, 95)
+	require.Equal(t, 100, n)
+	require.Equal(t, 95*time.Millisecond, p95)
+
+	// Samples outside of the window are discarded.
+	mc.scalerStatesMtx.Lock()
+	for i := range mc.scalerStates["my-model"].latencies[:50] {
+		mc.scalerStates["my-model"].latencies[i].time = time.Now().Add(-time.Hour)
+	}
+	mc.scalerStatesMtx.Unlock()
+	p95, n = mc.LatencyPercentile("my-model", time.Minute, 95)
+	require.Equal(t, 50, n)
+	require.Equal(t, 98*time.Millisecond, p95)
+}
@@ -0,0 +1,94 @@
+// Package modelclienttest provides a fake Kubernetes client for tests of
+// packages that scale Models.
+package modelclienttest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// NewClientBuilder returns a builder of a fake Kubernetes client with the given
+// Models, Pods and ConfigMaps. The fake client does not support the scale
+// subresource of Models, so writes to it are translated into writes of
+// .spec.replicas (see UpdateModelScale and PatchModelScale).
+func NewClientBuilder(t testing.TB, objs ...client.Object) *fake.ClientBuilder {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubeaiv1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: UpdateModelScale,
+			SubResourcePatch:  PatchModelScale,
+		})
+}
+
+// NewClient returns a fake Kubernetes client (see NewClientBuilder).
+func NewClient(t testing.TB, objs ...client.Object) client.WithWatch {
+	t.Helper()
+	return NewClientBuilder(t, objs...).Build()
+}
+
+// UpdateModelScale translates an update of the scale subresource of a Model
+// into an update of its .spec.replicas.
+func UpdateModelScale(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	if subResourceName != "scale" {
+		return fmt.Errorf("unsupported subresource: %q", subResourceName)
+	}
+	updateOpts := &client.SubResourceUpdateOptions{}
+	updateOpts.ApplyOptions(opts)
+	scale, ok := updateOpts.SubResourceBody.(*autoscalingv1.Scale)
+	if !ok {
+		return fmt.Errorf("unexpected scale body: %T", updateOpts.SubResourceBody)
+	}
+	return setModelReplicas(ctx, c, obj, scale.Spec.Replicas)
+}
+
+// PatchModelScale translates a merge patch of the scale subresource of a Model
+// into an update of its .spec.replicas. Patches of other objects are passed through.
+func PatchModelScale(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	if _, ok := obj.(*kubeaiv1.Model); !ok || subResourceName != "scale" {
+		return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+	}
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	var scale autoscalingv1.Scale
+	if err := json.Unmarshal(data, &scale); err != nil {
+		return err
+	}
+	return setModelReplicas(ctx, c, obj, scale.Spec.Replicas)
+}
+
+func setModelReplicas(ctx context.Context, c client.Client, obj client.Object, replicas int32) error {
+	m := &kubeaiv1.Model{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), m); err != nil {
+		return err
+	}
+	m.Spec.Replicas = ptr.To(replicas)
+	return c.Update(ctx, m)
+}
+
+// ModelReplicas returns the .spec.replicas of the Model, 0 if they are not set.
+func ModelReplicas(t testing.TB, c client.Client, namespace, name string) int32 {
+	t.Helper()
+	m := &kubeaiv1.Model{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, m))
+	return ptr.Deref(m.Spec.Replicas, 0)
+}
@@ -0,0 +1,246 @@
+package modelclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// autoscalingPauseKey is the key of the state ConfigMap that the pause of
+// autoscaling is stored in (see PauseAutoscaling).
+const autoscalingPauseKey = "autoscaling-pause"
+
+// storedPause is a pause of autoscaling in the state ConfigMap (see getSharedState).
+type storedPause struct {
+	Since time.Time `json:"since"`
+}
+
+// pausedReplicasKeyPrefix is the prefix of the keys of the state ConfigMap that
+// the scale operations that were deferred while paused are stored in.
+const pausedReplicasKeyPrefix = "paused."
+
+// pausedReplicasKey returns the key of the state ConfigMap that the deferred
+// replicas of the model are stored in.
+func pausedReplicasKey(model string) string {
+	return pausedReplicasKeyPrefix + model
+}
+
+// storedPausedReplicas are the deferred replicas of a model in the state
+// ConfigMap (see getSharedState).
+type storedPausedReplicas struct {
+	Replicas int32 `json:"replicas"`
+}
+
+// PauseAutoscaling stops all scale operations from being applied until
+// ResumeAutoscaling is called. Scale operations that are requested while
+// paused are recorded and applied on resume.
+// The pause and the deferred scale operations are stored in the state ConfigMap
+// (see Options.StateConfigMap), so they apply to all KubeAI instances, including
+// the leader that autoscales and instances that scale models up from zero.
+func (c *ModelClient) PauseAutoscaling(ctx context.Context) error {
+	if err := c.setSharedState(ctx, autoscalingPauseKey, storedPause{Since: c.clock.Now()}); err != nil {
+		return fmt.Errorf("storing the autoscaling pause: %w", err)
+	}
+	c.setAutoscalingPaused(ctx, true)
+	return nil
+}
+
+// ResumeAutoscaling resumes scale operations and applies the replica counts
+// that were requested while autoscaling was paused, by any KubeAI instance.
+// It is called by operators (i.e. through the admin API), so the applied
+// replicas are recorded with the ScaleActorManual actor.
+func (c *ModelClient) ResumeAutoscaling(ctx context.Context) error {
+	// The deferred replicas are read before the pause is removed, so that
+	// none are stored after they were read.
+	stored, err := c.listSharedState(ctx, pausedReplicasKeyPrefix)
+	if err != nil {
+		return fmt.Errorf("reading the deferred scale operations: %w", err)
+	}
+	if err := c.setSharedState(ctx, autoscalingPauseKey, nil); err != nil {
+		return fmt.Errorf("removing the autoscaling pause: %w", err)
+	}
+	c.setAutoscalingPaused(ctx, false)
+
+	pending := c.takePausedReplicas()
+	for key, value := range stored {
+		model := strings.TrimPrefix(key, pausedReplicasKeyPrefix)
+		var replicas storedPausedReplicas
+		if err := json.Unmarshal([]byte(value), &replicas); err != nil {
+			log.Printf("WARNING: invalid deferred scale of model %s: %v", model, err)
+		} else {
+			// The latest scale operation that was deferred by any instance.
+			pending[model] = replicas.Replicas
+		}
+		if err := c.setSharedState(ctx, key, nil); err != nil {
+			log.Printf("WARNING: removing the deferred scale of model %s: %v", model, err)
+		}
+	}
+	for model := range pending {
+		c.NotifyStateChange(model)
+	}
+
+	var errs error
+	for model, replicas := range pending {
+		if err := c.applyPausedReplicas(ctx, model, replicas); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("model %q: %w", model, err))
+		}
+	}
+	return errs
+}
+
+// IsAutoscalingPaused returns true if autoscaling is currently paused, by any
+// KubeAI instance (see PauseAutoscaling). The state of this instance is used if
+// the stored pause can not be read.
+func (c *ModelClient) IsAutoscalingPaused(ctx context.Context) bool {
+	var stored storedPause
+	if ok, err := c.getSharedState(ctx, autoscalingPauseKey, &stored); err != nil {
+		log.Printf("WARNING: reading the stored autoscaling pause: %v", err)
+	} else if ok {
+		c.setAutoscalingPaused(ctx, true)
+	} else if c.sharedStateEnabled() && c.autoscalingPaused.Load() {
+		// Autoscaling was resumed by another instance, which applied the
+		// deferred scale operations.
+		c.setAutoscalingPaused(ctx, false)
+		c.takePausedReplicas()
+	}
+	return c.autoscalingPaused.Load()
+}
+
+// scaleDownFreezeKey is the key of the state ConfigMap that the scale down
+// freeze is stored in (see FreezeScaleDown).
+const scaleDownFreezeKey = "scale-down-freeze"
+
+// storedFreeze is a scale down freeze in the state ConfigMap (see getSharedState).
+type storedFreeze struct {
+	Until time.Time `json:"until"`
+}
+
+// FreezeScaleDown prevents the replicas of all models from being reduced for the
+// given duration (i.e. during a rollout of KubeAI or cluster maintenance). Scale ups
+// are still applied. A new freeze replaces an existing one, a duration of 0 ends it.
+// Returns the time until which scale downs are frozen.
+// Freezes are stored in the state ConfigMap (see Options.StateConfigMap), so they
+// apply to all KubeAI instances, including the leader that autoscales.
+func (c *ModelClient) FreezeScaleDown(ctx context.Context, d time.Duration) (time.Time, error) {
+	if d <= 0 {
+		if err := c.setSharedState(ctx, scaleDownFreezeKey, nil); err != nil {
+			return time.Time{}, fmt.Errorf("removing the scale down freeze: %w", err)
+		}
+		c.scaleDownFrozenUntil.Store(0)
+		log.Println("Scale down freeze ended")
+		return time.Time{}, nil
+	}
+	until := c.clock.Now().Add(d)
+	if err := c.setSharedState(ctx, scaleDownFreezeKey, storedFreeze{Until: until}); err != nil {
+		return time.Time{}, fmt.Errorf("storing the scale down freeze: %w", err)
+	}
+	c.scaleDownFrozenUntil.Store(until.UnixNano())
+	log.Printf("Scale downs frozen until %s", until.Format(time.RFC3339))
+	return until, nil
+}
+
+// ScaleDownFrozenUntil returns the time until which scale downs are frozen
+// (see FreezeScaleDown). Zero if scale downs are not frozen. The state of
+// this instance is used if the stored freeze can not be read.
+func (c *ModelClient) ScaleDownFrozenUntil(ctx context.Context) time.Time {
+	var stored storedFreeze
+	if ok, err := c.getSharedState(ctx, scaleDownFreezeKey, &stored); err != nil {
+		log.Printf("WARNING: reading the stored scale down freeze: %v", err)
+	} else if ok {
+		c.scaleDownFrozenUntil.Store(stored.Until.UnixNano())
+	} else if c.sharedStateEnabled() {
+		// The freeze was ended by another instance.
+		c.scaleDownFrozenUntil.Store(0)
+	}
+
+	until := c.scaleDownFrozenUntil.Load()
+	if until == 0 || c.clock.Now().UnixNano() >= until {
+		return time.Time{}
+	}
+	return time.Unix(0, until)
+}
+
+func (c *ModelClient) isScaleDownFrozen(ctx context.Context) bool {
+	return !c.ScaleDownFrozenUntil(ctx).IsZero()
+}
+
+// setAutoscalingPaused records whether autoscaling is paused on this instance.
+func (c *ModelClient) setAutoscalingPaused(ctx context.Context, paused bool) {
+	if !c.autoscalingPaused.CompareAndSwap(!paused, paused) {
+		return
+	}
+	if paused {
+		log.Println("Autoscaling paused")
+		metrics.AutoscalingPaused.Record(ctx, 1)
+	} else {
+		log.Println("Autoscaling resumed")
+		metrics.AutoscalingPaused.Record(ctx, 0)
+	}
+}
+
+// deferPausedReplicas records the replicas of the model that are applied when
+// autoscaling is resumed (see ResumeAutoscaling).
+func (c *ModelClient) deferPausedReplicas(ctx context.Context, model string, replicas int32) {
+	c.scalerStatesMtx.Lock()
+	c.getScalerState(model).pausedReplicas = &replicas
+	c.scalerStatesMtx.Unlock()
+
+	// The autoscaler repeats its scale operations every interval, they are only
+	// stored when they change.
+	var stored storedPausedReplicas
+	if ok, err := c.getSharedState(ctx, pausedReplicasKey(model), &stored); err == nil && ok && stored.Replicas == replicas {
+		return
+	}
+	if err := c.setSharedState(ctx, pausedReplicasKey(model), storedPausedReplicas{Replicas: replicas}); err != nil {
+		// Applied on resume if this instance resumes autoscaling.
+		log.Printf("WARNING: storing the deferred scale of model %s: %v", model, err)
+	}
+}
+
+// takePausedReplicas clears and returns the replicas that were deferred on this
+// instance while autoscaling was paused, by model.
+func (c *ModelClient) takePausedReplicas() map[string]int32 {
+	pending := map[string]int32{}
+	c.scalerStatesMtx.Lock()
+	for model, s := range c.scalerStates {
+		if s.pausedReplicas != nil {
+			pending[model] = *s.pausedReplicas
+			s.pausedReplicas = nil
+		}
+	}
+	c.scalerStatesMtx.Unlock()
+	return pending
+}
+
+func (c *ModelClient) applyPausedReplicas(ctx context.Context, model string, replicas int32) error {
+	m := &kubeaiv1.Model{}
+	if err := c.client.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: model}, m); err != nil {
+		return newScaleError("get", model, err)
+	}
+	if m.Spec.AutoscalingDisabled {
+		return nil
+	}
+	replicas = enforceReplicaBounds(replicas, m)
+	target, current, err := c.getReplicas(ctx, m)
+	if err != nil {
+		return err
+	}
+	if current == replicas {
+		return nil
+	}
+	if current > replicas && c.isScaleDownFrozen(ctx) {
+		log.Printf("scale downs are frozen, not applying deferred scale of model %s to %d replicas", model, replicas)
+		return nil
+	}
+	log.Printf("applying deferred scale of model %s to %d replicas", model, replicas)
+	// Autoscaling is resumed by an operator (see ResumeAutoscaling).
+	return c.updateScale(ctx, m, target, replicas, "deferred scale applied after autoscaling was resumed", ScaledToZeroIdle, ScaleActorManual)
+}
@@ -0,0 +1,105 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+func TestPauseAutoscaling(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MaxReplicas: ptr.To[int32](5)})
+	mc, k8sClient := newTestModelClient(t, m)
+
+	require.NoError(t, mc.PauseAutoscaling(ctx))
+	require.True(t, mc.IsAutoscalingPaused(ctx))
+
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "replicas should not change while paused")
+
+	snapshot, ok := mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.Equal(t, ptr.To[int32](1), snapshot.PausedReplicas)
+
+	require.NoError(t, mc.ResumeAutoscaling(ctx))
+	require.False(t, mc.IsAutoscalingPaused(ctx))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "deferred scale should be applied on resume")
+
+	snapshot, ok = mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.Nil(t, snapshot.PausedReplicas)
+}
+
+func TestFreezeScaleDown(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](3), MaxReplicas: ptr.To[int32](5)})
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "state"}
+	opts := Options{StateConfigMap: stateRef}
+	other, k8sClient := newTestModelClientWithOptions(t, opts, m, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name},
+	})
+	// The leader, which did not receive the freeze.
+	mc := NewModelClient(k8sClient, testNamespace, opts)
+
+	until, err := other.FreezeScaleDown(ctx, time.Hour)
+	require.NoError(t, err)
+	require.True(t, until.Equal(mc.ScaleDownFrozenUntil(ctx)))
+
+	require.NoError(t, mc.Scale(ctx, m, 1, 0, "scale down"))
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "scale downs should be frozen")
+
+	require.NoError(t, mc.Scale(ctx, m, 4, 0, "scale up"))
+	require.Equal(t, int32(4), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "scale ups should be applied while frozen")
+
+	until, err = other.FreezeScaleDown(ctx, 0)
+	require.NoError(t, err)
+	require.True(t, until.IsZero())
+	require.True(t, mc.ScaleDownFrozenUntil(ctx).IsZero())
+	m.Spec.Replicas = ptr.To[int32](4)
+	require.NoError(t, mc.Scale(ctx, m, 1, 0, "scale down"))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "scale downs should resume once the freeze ends")
+}
+
+func TestPauseAutoscalingShared(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MaxReplicas: ptr.To[int32](5)})
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "state"}
+	opts := Options{StateConfigMap: stateRef}
+	other, k8sClient := newTestModelClientWithOptions(t, opts, m, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name},
+	})
+	// The leader, which did not receive the pause.
+	mc := NewModelClient(k8sClient, testNamespace, opts)
+
+	require.NoError(t, other.PauseAutoscaling(ctx))
+	require.True(t, mc.IsAutoscalingPaused(ctx))
+
+	// Neither the leader nor the other instance scale while paused.
+	require.NoError(t, other.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "autoscaler"))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "replicas should not change while paused")
+
+	// The scale operation that was deferred by the leader is applied by the
+	// instance that resumes.
+	require.NoError(t, other.ResumeAutoscaling(ctx))
+	require.False(t, mc.IsAutoscalingPaused(ctx))
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "deferred scale should be applied on resume")
+	snapshot, ok := mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.Nil(t, snapshot.PausedReplicas)
+}
@@ -0,0 +1,214 @@
+package modelclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// scalePin is a number of replicas that was forced for a model. While a pin
+// is active, other scale operations for the model are queued instead of applied.
+type scalePin struct {
+	replicas int32
+	// since is the time of the force scale, which orders pins of different
+	// KubeAI instances (see syncStoredPin).
+	since time.Time
+	until time.Time
+	// queued is the latest scale operation that was requested during the pin.
+	// It is applied when the pin expires.
+	queued *pendingScale
+}
+
+// storedPin is a pin in the state ConfigMap (see getSharedState).
+type storedPin struct {
+	Replicas int32     `json:"replicas"`
+	Since    time.Time `json:"since"`
+	Until    time.Time `json:"until"`
+}
+
+// pinKey returns the key of the state ConfigMap that the pin of the model is stored in.
+func pinKey(model string) string {
+	return "pin." + model
+}
+
+// ForceScale scales the model to the given number of replicas and pins it there
+// for the given duration. Autoscaling decisions that are made during the pin are
+// not applied, the latest one is applied once the pin expires. A new pin replaces
+// an existing one. Pins are applied even when autoscaling is paused.
+// Pins are stored in the state ConfigMap (see Options.StateConfigMap), so they
+// apply to all KubeAI instances and survive restarts (see syncStoredPin).
+// Errors returned from scaling operations are of type *ScaleError.
+func (c *ModelClient) ForceScale(ctx context.Context, model string, replicas int32, duration time.Duration) error {
+	if replicas < 0 {
+		return fmt.Errorf("replicas must not be negative, got %d", replicas)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("duration must be positive, got %s", duration)
+	}
+
+	m := &kubeaiv1.Model{}
+	if err := c.client.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: model}, m); err != nil {
+		return newScaleError("get", model, err)
+	}
+	if !c.IsManaged(m) {
+		return fmt.Errorf("model %q is not managed", model)
+	}
+	target, err := c.scaleTargetFor(m)
+	if err != nil {
+		return newScaleError("get", model, err)
+	}
+
+	if !c.beginScale() {
+		return newScaleError("update", model, ErrShuttingDown)
+	}
+	defer c.endScale()
+
+	// Hold the write lock so that a scale operation that is in progress
+	// completes (and is overwritten) before the pin takes effect.
+	s := c.lockScaleWrites(model)
+	defer s.writeMtx.Unlock()
+
+	now := c.clock.Now()
+	pin := &scalePin{replicas: replicas, since: now, until: now.Add(duration)}
+	// The pin is stored before the replicas are written, so that the leader
+	// does not autoscale them once they are written.
+	if err := c.setSharedState(ctx, pinKey(model), storedPin{Replicas: pin.replicas, Since: pin.since, Until: pin.until}); err != nil {
+		return newScaleError("update", model, err)
+	}
+	reason := fmt.Sprintf("forced to %d replicas until %s", replicas, pin.until.Format(time.RFC3339))
+	if err := c.setReplicas(ctx, model, target, replicas, reason, ScaleActorManual); err != nil {
+		return newScaleError("update", model, err)
+	}
+
+	c.scalerStatesMtx.Lock()
+	if s.pin != nil {
+		pin.queued = s.pin.queued
+	}
+	s.pin = pin
+	s.recordScale(c.clock.Now(), replicas, reason, ScaledToZeroManual)
+	c.scalerStatesMtx.Unlock()
+	c.recordScaledToZeroReason(ctx, m, replicas, ScaledToZeroManual)
+	c.NotifyStateChange(model)
+
+	log.Printf("model %s %s", model, reason)
+	c.clock.AfterFunc(duration, func() { c.expirePin(model, pin) })
+	return nil
+}
+
+// syncStoredPin applies a pin of the model that was forced by another KubeAI
+// instance, or before a restart (see ForceScale). Pins that are older than the
+// pin of this instance are ignored. The state of this instance is used if the
+// stored pin can not be read. While the model is pinned, its replicas are
+// written again if the scale target differs, because the leader might have
+// autoscaled them concurrently with a force scale on another instance.
+// The caller must hold the writeMtx of the model.
+func (c *ModelClient) syncStoredPin(ctx context.Context, model string, target ScaleTarget) {
+	var stored storedPin
+	ok, err := c.getSharedState(ctx, pinKey(model), &stored)
+	if err != nil {
+		log.Printf("WARNING: reading the stored force scale of model %s: %v", model, err)
+	}
+	now := c.clock.Now()
+
+	c.scalerStatesMtx.Lock()
+	s := c.getScalerState(model)
+	adopt := ok && now.Before(stored.Until) && (s.pin == nil || stored.Since.After(s.pin.since))
+	if adopt {
+		pin := &scalePin{replicas: stored.Replicas, since: stored.Since, until: stored.Until}
+		if s.pin != nil {
+			pin.queued = s.pin.queued
+		}
+		s.pin = pin
+	}
+	pin := s.pin
+	c.scalerStatesMtx.Unlock()
+	if pin == nil || !now.Before(pin.until) {
+		return
+	}
+	if adopt {
+		c.NotifyStateChange(model)
+		log.Printf("model %s is forced to %d replicas until %s", model, pin.replicas, pin.until.Format(time.RFC3339))
+		c.clock.AfterFunc(pin.until.Sub(now), func() { c.expirePin(model, pin) })
+	}
+
+	current, err := target.GetReplicas(ctx)
+	if err != nil || current == pin.replicas {
+		return
+	}
+	log.Printf("model %s has %d replicas while it is forced to %d, restoring them", model, current, pin.replicas)
+	reason := fmt.Sprintf("forced to %d replicas until %s", pin.replicas, pin.until.Format(time.RFC3339))
+	if err := c.setReplicas(ctx, model, target, pin.replicas, reason, ScaleActorManual); err != nil {
+		log.Printf("ERROR: scaling model %s to the replicas of its force scale: %v", model, err)
+	}
+}
+
+// lockScaleWrites locks the scale writes of the given model and returns its state.
+// The caller must call writeMtx.Unlock() on the returned state.
+func (c *ModelClient) lockScaleWrites(model string) *scalerState {
+	c.scalerStatesMtx.Lock()
+	s := c.getScalerState(model)
+	c.scalerStatesMtx.Unlock()
+
+	s.writeMtx.Lock()
+	return s
+}
+
+// queueDuringPin returns true if the scale operation should not be applied
+// because the model is pinned. The operation is queued (replacing any previously
+// queued operation) and applied once the pin expires.
+// The caller must hold the writeMtx of the model.
+func (c *ModelClient) queueDuringPin(op *pendingScale) bool {
+	c.scalerStatesMtx.Lock()
+	defer c.scalerStatesMtx.Unlock()
+
+	s := c.getScalerState(op.model.Name)
+	if s.pin == nil {
+		return false
+	}
+	if !c.clock.Now().Before(s.pin.until) {
+		// The pin expired but was not cleared yet. This operation is newer
+		// than the queued one, so the queued one is dropped.
+		s.pin = nil
+		return false
+	}
+	s.pin.queued = op
+	return true
+}
+
+// expirePin clears the given pin (unless it was replaced) and applies the
+// scale operation that was queued during the pin (if any).
+func (c *ModelClient) expirePin(model string, pin *scalePin) {
+	c.scalerStatesMtx.Lock()
+	s := c.getScalerState(model)
+	if s.pin != pin {
+		c.scalerStatesMtx.Unlock()
+		return
+	}
+	s.pin = nil
+	queued := pin.queued
+	c.scalerStatesMtx.Unlock()
+	c.NotifyStateChange(model)
+
+	log.Printf("force scale of model %s expired", model)
+	var stored storedPin
+	if ok, err := c.getSharedState(context.Background(), pinKey(model), &stored); err == nil && ok && stored.Since.Equal(pin.since) {
+		if err := c.setSharedState(context.Background(), pinKey(model), nil); err != nil {
+			log.Printf("WARNING: removing the expired force scale of model %s: %v", model, err)
+		}
+	}
+	if queued == nil {
+		return
+	}
+
+	log.Printf("scaling model %s to %d replicas after force scale expired: %s", model, queued.replicas, queued.reason)
+	if err := refreshScaleTarget(context.Background(), queued.target); err != nil {
+		log.Printf("WARNING: refreshing the scale target of model %s after force scale expired: %v", model, err)
+	}
+	if err := c.updateScale(context.Background(), queued.model, queued.target, queued.replicas, queued.reason, queued.zeroReason, queued.actor); err != nil {
+		log.Printf("ERROR: scaling model %s after force scale expired: %v", model, err)
+	}
+}
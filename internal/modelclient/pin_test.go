@@ -0,0 +1,172 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestForceScaleSharedState(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](3)})
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "state"}
+	clk := newTestClock()
+	opts := Options{Clock: clk, StateConfigMap: stateRef}
+	mc, k8sClient := newTestModelClientWithOptions(t, opts, m, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name},
+	})
+	// The leader, which did not receive the force scale.
+	leader := NewModelClient(k8sClient, testNamespace, opts)
+	target, _, err := leader.getReplicas(ctx, m)
+	require.NoError(t, err)
+	getStored := func() (string, bool) {
+		t.Helper()
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, k8sClient.Get(ctx, stateRef, cm))
+		v, ok := cm.Data[pinKey(m.Name)]
+		return v, ok
+	}
+
+	const duration = time.Minute
+	require.NoError(t, mc.ForceScale(ctx, m.Name, 5, duration))
+	_, ok := getStored()
+	require.True(t, ok)
+
+	// The pin applies to the scale operations of the leader.
+	require.NoError(t, leader.updateScale(ctx, m, target, 1, "auto scale down", ScaledToZeroIdle, ScaleActorAuto))
+	require.Equal(t, int32(5), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// A write of the leader that raced with the force scale is reverted.
+	require.NoError(t, target.SetReplicas(ctx, 2))
+	require.NoError(t, leader.updateScale(ctx, m, target, 1, "auto scale down", ScaledToZeroIdle, ScaleActorAuto))
+	require.Equal(t, int32(5), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	snapshot, ok := leader.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.Equal(t, ptr.To[int32](5), snapshot.PinnedReplicas)
+
+	// The pin is removed once it expires, and the leader applies its queued operation.
+	clk.Step(duration)
+	require.Eventually(t, func() bool {
+		_, ok := getStored()
+		return !ok && modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name) == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestForceScaleWatchedState(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](3)})
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "state"}
+	opts := Options{StateConfigMap: stateRef}
+	mc, k8sClient := newTestModelClientWithOptions(t, opts, m, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name},
+	})
+	var gets int
+	countingClient := interceptor.NewClient(k8sClient.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*corev1.ConfigMap); ok {
+				gets++
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+	// The leader, which watches the state ConfigMap.
+	leader := NewModelClient(countingClient, testNamespace, opts)
+	informers := &informertest.FakeInformers{}
+	require.NoError(t, leader.WatchSharedState(ctx, informers))
+	informer, err := informers.FakeInformerFor(ctx, &corev1.ConfigMap{})
+	require.NoError(t, err)
+	target, _, err := leader.getReplicas(ctx, m)
+	require.NoError(t, err)
+
+	require.NoError(t, mc.ForceScale(ctx, m.Name, 5, time.Minute))
+	cm := &corev1.ConfigMap{}
+	require.NoError(t, k8sClient.Get(ctx, stateRef, cm))
+	informer.Add(cm)
+
+	require.NoError(t, leader.updateScale(ctx, m, target, 1, "auto scale down", ScaledToZeroIdle, ScaleActorAuto))
+	require.Equal(t, int32(5), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	require.Equal(t, 0, gets, "the stored pin should be read from the watched state ConfigMap")
+}
+
+// gatedScaleTarget blocks SetReplicas until released.
+type gatedScaleTarget struct {
+	ScaleTarget
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (t *gatedScaleTarget) SetReplicas(ctx context.Context, replicas int32) error {
+	close(t.entered)
+	<-t.release
+	return t.ScaleTarget.SetReplicas(ctx, replicas)
+}
+
+func TestForceScale(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](3)})
+	clk := newTestClock()
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{Clock: clk}, m)
+	target, _, err := mc.getReplicas(ctx, m)
+	require.NoError(t, err)
+
+	// Start an automatic scale down and pin the model while it is being written.
+	gated := &gatedScaleTarget{ScaleTarget: target, entered: make(chan struct{}), release: make(chan struct{})}
+	autoErr := make(chan error)
+	go func() {
+		autoErr <- mc.updateScale(ctx, m, gated, 1, "auto scale down", ScaledToZeroIdle, ScaleActorAuto)
+	}()
+	<-gated.entered
+
+	forceErr := make(chan error)
+	go func() { forceErr <- mc.ForceScale(ctx, m.Name, 5, time.Hour) }()
+	time.Sleep(50 * time.Millisecond)
+	close(gated.release)
+
+	require.NoError(t, <-autoErr)
+	require.NoError(t, <-forceErr)
+	require.Equal(t, int32(5), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// Automatic scale operations are not applied during the pin.
+	require.NoError(t, mc.updateScale(ctx, m, target, 1, "auto scale down", ScaledToZeroIdle, ScaleActorAuto))
+	require.Equal(t, int32(5), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	snapshot, ok := mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.Equal(t, ptr.To[int32](5), snapshot.PinnedReplicas)
+
+	// The latest queued operation is applied once a (replacing) pin expires.
+	const duration = time.Minute
+	require.NoError(t, mc.ForceScale(ctx, m.Name, 4, duration))
+	require.Equal(t, int32(4), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	require.NoError(t, mc.updateScale(ctx, m, target, 2, "auto scale down", ScaledToZeroIdle, ScaleActorAuto))
+	clk.Step(duration - time.Nanosecond)
+	require.Equal(t, int32(4), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	clk.Step(time.Nanosecond)
+	require.Eventually(t, func() bool {
+		return modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name) == 2
+	}, time.Second, time.Millisecond)
+	snapshot, ok = mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.Nil(t, snapshot.PinnedReplicas)
+	require.Equal(t, "auto scale down", snapshot.LastScaleReason)
+
+	require.Error(t, mc.ForceScale(ctx, m.Name, -1, time.Hour))
+	require.Error(t, mc.ForceScale(ctx, m.Name, 1, 0))
+}
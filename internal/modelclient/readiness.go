@@ -0,0 +1,113 @@
+package modelclient
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/k8sutils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Models that are scaled via another object (see kubeaiv1.ModelScaleTargetAnnotation)
+// do not choose the Pods that are removed on a scale down, the controller of the
+// object does. To avoid removing Ready Pods while Pods that are still starting
+// up are kept, scale downs of these Models do not go below the number of Ready
+// Pods (see readyFloor), and the Pods are annotated with a deletion cost before
+// the replicas are written (see setPodDeletionCosts).
+
+// podDeletionCostAnnotation is the annotation that the ReplicaSet controller
+// reads to choose the Pods that are removed on a scale down (lowest cost first).
+const podDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+
+// scaleTargetPods returns the Pods of the model that are not being deleted, or
+// nil if the model is scaled via its own scale subresource. The Pods of those
+// Models are removed by the model controller, Pods that are not Ready first.
+func (c *ModelClient) scaleTargetPods(ctx context.Context, model *kubeaiv1.Model) ([]corev1.Pod, error) {
+	if _, ok := c.scaleTargetKey(model); !ok {
+		return nil, nil
+	}
+	var pods corev1.PodList
+	if err := c.client.List(ctx, &pods, client.InNamespace(c.namespace), client.MatchingLabels{kubeaiv1.PodModelLabel: model.Name}); err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	return slices.DeleteFunc(pods.Items, func(p corev1.Pod) bool {
+		return p.DeletionTimestamp != nil
+	}), nil
+}
+
+// readyFloor returns the number of Ready Pods of the model if fewer than the
+// current replicas are Ready. A scale down to the floor removes the Pods that
+// are not Ready, and the next scale down continues once they are gone, so that
+// the model is not scaled down faster than its Ready Pods appear. Returns 0 if
+// all replicas are Ready.
+func (c *ModelClient) readyFloor(ctx context.Context, model *kubeaiv1.Model, current int32) (int32, error) {
+	pods, err := c.scaleTargetPods(ctx, model)
+	if err != nil {
+		return 0, err
+	}
+	var ready int32
+	for i := range pods {
+		if k8sutils.PodIsReady(&pods[i]) {
+			ready++
+		}
+	}
+	if ready >= current {
+		return 0, nil
+	}
+	return ready, nil
+}
+
+// setPodDeletionCosts annotates the Pods of the model with their deletion cost
+// (see podDeletionCostAnnotation): Pods that are not Ready cost 0, and Ready Pods
+// cost more the longer they have been Ready, so that the newest Pods are removed
+// first. Only Pods whose cost changed are patched.
+func (c *ModelClient) setPodDeletionCosts(ctx context.Context, model *kubeaiv1.Model) error {
+	pods, err := c.scaleTargetPods(ctx, model)
+	if err != nil {
+		return err
+	}
+
+	var ready []*corev1.Pod
+	for i := range pods {
+		if k8sutils.PodIsReady(&pods[i]) {
+			ready = append(ready, &pods[i])
+		}
+	}
+	// Oldest first.
+	slices.SortStableFunc(ready, func(a, b *corev1.Pod) int {
+		return podReadyTime(a).Compare(podReadyTime(b))
+	})
+	costs := map[string]int{}
+	for i, p := range ready {
+		costs[p.Name] = len(ready) - i
+	}
+
+	for i := range pods {
+		p := &pods[i]
+		value := strconv.Itoa(costs[p.Name])
+		if p.Annotations[podDeletionCostAnnotation] == value {
+			continue
+		}
+		patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, podDeletionCostAnnotation, value)
+		if err := c.client.Patch(ctx, p, client.RawPatch(types.MergePatchType, []byte(patch))); err != nil {
+			return fmt.Errorf("patching pod %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// podReadyTime returns the time that the Pod became Ready.
+func podReadyTime(p *corev1.Pod) time.Time {
+	for _, cond := range p.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.LastTransitionTime.Time
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,100 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestReadinessAwareScaleDown(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	deployment := &unstructured.Unstructured{}
+	deployment.SetGroupVersionKind(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+	deployment.SetNamespace(testNamespace)
+	deployment.SetName("my-deployment")
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, int64(4), "spec", "replicas"))
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{MaxReplicas: ptr.To[int32](5)})
+	m.Annotations = map[string]string{
+		kubeaiv1.ModelScaleTargetAnnotation:         "apps/v1/Deployment/my-deployment",
+		kubeaiv1.ModelScaleTargetStrategyAnnotation: kubeaiv1.ScaleTargetStrategyReplicas,
+	}
+	now := time.Now()
+	pod := func(name string, readySince time.Duration) *corev1.Pod {
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{kubeaiv1.PodModelLabel: m.Name},
+		}}
+		if readySince > 0 {
+			p.Status.Conditions = []corev1.PodCondition{{
+				Type:               corev1.PodReady,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(now.Add(-readySince)),
+			}}
+		}
+		return p
+	}
+	mc, k8sClient := newTestModelClient(t, m, deployment,
+		pod("oldest", time.Hour),
+		pod("newest", time.Minute),
+		pod("older", 10*time.Minute),
+		pod("starting", 0),
+	)
+
+	getReplicas := func() int64 {
+		got := &unstructured.Unstructured{}
+		got.SetGroupVersionKind(deployment.GroupVersionKind())
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(deployment), got))
+		replicas, _, err := unstructured.NestedInt64(got.Object, "spec", "replicas")
+		require.NoError(t, err)
+		return replicas
+	}
+	deletionCosts := func() map[string]string {
+		var pods corev1.PodList
+		require.NoError(t, k8sClient.List(ctx, &pods))
+		costs := map[string]string{}
+		for _, p := range pods.Items {
+			costs[p.Name] = p.Annotations[podDeletionCostAnnotation]
+		}
+		return costs
+	}
+
+	// Not scaled below the Ready Pods while a Pod is starting up.
+	require.NoError(t, mc.Scale(ctx, m, 1, 0, "test"))
+	require.Equal(t, int64(3), getReplicas())
+	snapshot, _ := mc.ScalerSnapshot(m.Name)
+	require.Contains(t, snapshot.LastScaleReason, "3 of 4 replicas ready")
+	require.Equal(t, map[string]string{
+		"oldest":   "3",
+		"older":    "2",
+		"newest":   "1",
+		"starting": "0",
+	}, deletionCosts())
+
+	// Scale downs continue once all replicas are Ready.
+	starting := &corev1.Pod{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: "starting"}, starting))
+	require.NoError(t, k8sClient.Delete(ctx, starting))
+	require.NoError(t, mc.Scale(ctx, m, 1, 0, "test"))
+	require.Equal(t, int64(1), getReplicas())
+
+	// Models that are scaled via their own scale subresource are not affected.
+	own := testModel("own", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2), MaxReplicas: ptr.To[int32](5)})
+	require.NoError(t, k8sClient.Create(ctx, own))
+	require.NoError(t, mc.Scale(ctx, own, 0, 0, "test"))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, own.Name))
+}
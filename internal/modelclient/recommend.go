@@ -0,0 +1,77 @@
+package modelclient
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"k8s.io/utils/ptr"
+)
+
+// recommendedScaleTarget is used for Models that are scaled by KEDA (see
+// kubeaiv1.ScalingBackendKEDA). Replicas are recorded as the desired replicas
+// of the Model (see DesiredReplicas) instead of being written, so all scale
+// operations (autoscaling, scale from zero, bounds, force scales, and the
+// replica budget) become recommendations that KEDA applies.
+type recommendedScaleTarget struct {
+	c     *ModelClient
+	model *kubeaiv1.Model
+}
+
+// GetReplicas returns the replicas of the Model, so that KubeAI observes
+// whether KEDA applied its recommendations.
+func (t *recommendedScaleTarget) GetReplicas(ctx context.Context) (int32, error) {
+	return ptr.Deref(t.model.Spec.Replicas, 0), nil
+}
+
+// SetReplicas records the replicas in the kubeaiv1.ModelDesiredReplicasAnnotation
+// of the Model, so that every KubeAI instance serves the same recommendation.
+func (t *recommendedScaleTarget) SetReplicas(ctx context.Context, replicas int32) error {
+	if desired, ok := t.c.recommendedReplicas(t.model); ok && desired == replicas {
+		return nil
+	}
+	return t.c.patchModelAnnotations(ctx, t.model.Name, map[string]any{
+		kubeaiv1.ModelDesiredReplicasAnnotation: strconv.Itoa(int(replicas)),
+	})
+}
+
+// pending returns true if the recorded recommendation differs from the given replicas.
+func (t *recommendedScaleTarget) pending(replicas int32) bool {
+	desired, ok := t.c.recommendedReplicas(t.model)
+	return ok && desired != replicas
+}
+
+// DesiredReplicas returns the replicas that KubeAI most recently decided on
+// for a Model that is scaled by KEDA (see kubeaiv1.ScalingBackendKEDA). Falls
+// back to the current replicas of the Model before the first decision.
+func (c *ModelClient) DesiredReplicas(model *kubeaiv1.Model) int32 {
+	if desired, ok := c.recommendedReplicas(model); ok {
+		return desired
+	}
+	return ptr.Deref(model.Spec.Replicas, 0)
+}
+
+// recommendedReplicas returns the value of the kubeaiv1.ModelDesiredReplicasAnnotation
+// of the model. Returns false if it is not set or invalid.
+func (c *ModelClient) recommendedReplicas(model *kubeaiv1.Model) (int32, bool) {
+	key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelDesiredReplicasAnnotationName)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+	if err != nil || n < 0 {
+		log.Printf("WARNING: ignoring invalid %s annotation %q of model %s: expected a non-negative integer", key, value, model.Name)
+		return 0, false
+	}
+	return int32(n), true
+}
+
+// recommendationPending returns true if the target records recommendations
+// (see recommendedScaleTarget) and the recorded recommendation differs from
+// the given replicas, i.e. because the load dropped before KEDA applied a scale up.
+func recommendationPending(target ScaleTarget, replicas int32) bool {
+	t, ok := target.(*recommendedScaleTarget)
+	return ok && t.pending(replicas)
+}
@@ -0,0 +1,66 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+func TestKEDAScalingBackend(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MaxReplicas: ptr.To[int32](5)})
+	m.Annotations = map[string]string{kubeaiv1.ModelScalingBackendAnnotation: kubeaiv1.ScalingBackendKEDA}
+	mc, k8sClient := newTestModelClient(t, m)
+	// Another KubeAI instance that serves the external scaler.
+	other := NewModelClient(k8sClient, testNamespace, Options{})
+	get := func() *kubeaiv1.Model {
+		t.Helper()
+		m := &kubeaiv1.Model{}
+		require.NoError(t, k8sClient.Get(ctx, types.NamespacedName{Namespace: testNamespace, Name: "my-model"}, m))
+		return m
+	}
+
+	// Falls back to the replicas of the Model before the first decision.
+	require.Equal(t, int32(0), mc.DesiredReplicas(m))
+
+	// Decisions are recorded on the Model instead of written.
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, "1", get().Annotations[kubeaiv1.ModelDesiredReplicasAnnotation])
+	require.Equal(t, int32(1), other.DesiredReplicas(get()), "all instances should serve the decision")
+	require.NoError(t, mc.Scale(ctx, get(), 7, 0, "test"))
+	require.Equal(t, int32(5), other.DesiredReplicas(get()), "replica bounds should apply")
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// KEDA applies the decision.
+	applied := get()
+	applied.Spec.Replicas = ptr.To[int32](5)
+	require.NoError(t, k8sClient.Update(ctx, applied))
+	require.NoError(t, mc.Scale(ctx, get(), 5, 0, "test"))
+	require.Equal(t, int32(5), other.DesiredReplicas(get()))
+
+	// A scale down of the leader is served by all instances.
+	require.NoError(t, mc.Scale(ctx, get(), 2, 0, "test"))
+	require.Equal(t, int32(2), other.DesiredReplicas(get()))
+
+	// A recommendation that was not applied yet is lowered when the load drops
+	// back to the current replicas.
+	applied = get()
+	applied.Spec.Replicas = ptr.To[int32](2)
+	require.NoError(t, k8sClient.Update(ctx, applied))
+	require.NoError(t, mc.Scale(ctx, get(), 4, 0, "test"))
+	require.Equal(t, int32(4), other.DesiredReplicas(get()))
+	require.NoError(t, mc.Scale(ctx, get(), 2, 0, "test"))
+	require.Equal(t, int32(2), other.DesiredReplicas(get()))
+
+	m.Annotations[kubeaiv1.ModelScalingBackendAnnotation] = "unknown"
+	_, err := mc.scaleTargetFor(m)
+	require.Error(t, err)
+}
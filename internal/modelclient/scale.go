@@ -4,34 +4,67 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
-	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"github.com/substratusai/kubeai/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	"k8s.io/utils/ptr"
 )
 
+// ScaleAtLeastOneReplica scales the model up from zero replicas (to ScaleFromZeroReplicas, default 1)
+// if it is currently scaled to zero.
 func (c *ModelClient) ScaleAtLeastOneReplica(ctx context.Context, model string) error {
+	c.recordActivity(ctx, model)
+
 	obj := &kubeaiv1.Model{}
 	if err := c.client.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: model}, obj); err != nil {
-		return fmt.Errorf("get scale: %w", err)
+		return newScaleError("get", model, err)
 	}
 
-	if obj.Spec.AutoscalingDisabled {
+	if obj.Spec.AutoscalingDisabled || !c.IsManaged(obj) || c.observeOnly {
 		return nil
 	}
 
-	replicas := int32(0)
-	if obj.Spec.Replicas != nil {
-		replicas = *obj.Spec.Replicas
+	scaleTarget, replicas, err := c.getReplicas(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if _, ok := scaleTarget.(*recommendedScaleTarget); ok && replicas == 0 {
+		// The scale up from zero might be recommended already and not
+		// applied by KEDA yet.
+		replicas = c.DesiredReplicas(obj)
+	}
+	if obj, err = c.withReplicaBounds(ctx, obj); err != nil {
+		return newScaleError("get", model, err)
 	}
 
 	if replicas == 0 && !obj.Spec.AutoscalingDisabled {
-		scale := &autoscalingv1.Scale{
-			Spec: autoscalingv1.ScaleSpec{Replicas: 1},
+		if n, window, ok := scaleFromZeroGate(obj); ok {
+			if !c.sustainedScaleFromZeroDemand(model, window, n) {
+				log.Printf("model %s received a request while scaled to zero, waiting for %d requests within %s before scaling up", model, n, window)
+				return nil
+			}
+		}
+
+		target := int32(1)
+		if obj.Spec.ScaleFromZeroReplicas != nil {
+			target = *obj.Spec.ScaleFromZeroReplicas
+		}
+		reason := "request received while scaled to zero"
+		bounded := enforceReplicaBounds(target, obj)
+		reason += replicaBoundsReason(target, bounded, obj)
+		log.Printf("scaling model %s from zero to %d replicas: %s", model, bounded, reason)
+		if err := c.updateScale(ctx, obj, scaleTarget, bounded, reason, ScaledToZeroIdle, ScaleActorAuto); err != nil {
+			return err
 		}
-		if err := c.client.SubResource("scale").Update(ctx, obj, client.WithSubResourceBody(scale)); err != nil {
-			return fmt.Errorf("update scale: %w", err)
+		if bounded > 0 {
+			c.beginColdStart(model)
+			if _, err := c.claimWarmPods(ctx, obj, bounded); err != nil {
+				log.Printf("ERROR: claiming warm pool pods for model %s: %v", model, err)
+			}
 		}
 	}
 
@@ -39,22 +72,102 @@ func (c *ModelClient) ScaleAtLeastOneReplica(ctx context.Context, model string)
 }
 
 // Scale scales the model to the desired number of replicas, enforcing the min and max replica bounds.
+// The reason describes why the desired number of replicas was chosen and is recorded if the model is scaled.
 // Model should have .Spec defined before calling Scale().
-func (c *ModelClient) Scale(ctx context.Context, model *kubeaiv1.Model, replicas int32, requiredConsecutiveScaleDowns int) error {
+func (c *ModelClient) Scale(ctx context.Context, model *kubeaiv1.Model, replicas int32, requiredConsecutiveScaleDowns int, reason string) error {
 	//obj := &kubeaiv1.Model{}
 	//if err := s.client.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: model}, obj); err != nil {
 	//	return fmt.Errorf("get scale: %w", err)
 	//}
 
-	replicas = enforceReplicaBounds(replicas, model)
+	target, existingReplicas, err := c.getReplicas(ctx, model)
+	if err != nil {
+		return err
+	}
+	if model, err = c.withReplicaBounds(ctx, model); err != nil {
+		return newScaleError("get", model.Name, err)
+	}
+	// Models might be scaled up without KubeAI, which resets the reason of a
+	// previous scale to zero. Recommendations are excluded, because KEDA
+	// applies them after they were recorded (see recommendedScaleTarget).
+	if _, ok := target.(*recommendedScaleTarget); !ok && existingReplicas > 0 && c.IsManaged(model) && !c.observeOnly {
+		c.recordScaledToZeroReason(ctx, model, existingReplicas, "")
+	}
+
+	max := model.Spec.MaxReplicas
+	c.setSaturated(ctx, model, max != nil && replicas > *max && existingReplicas >= *max)
+
+	bounded := enforceReplicaBounds(replicas, model)
+	reason += replicaBoundsReason(replicas, bounded, model)
+	replicas = bounded
+
+	if existingReplicas == 0 {
+		// Requests that wait for a scale up from zero are counted as active
+		// requests, so they should not scale the model up before the demand
+		// is sustained (see ScaleAtLeastOneReplica). Replicas that are
+		// required by the min replicas are still applied.
+		if n, window, ok := scaleFromZeroGate(model); ok && !c.scaleFromZeroDemandSustained(model.Name) {
+			if floor := enforceReplicaBounds(0, model); replicas > floor {
+				log.Printf("model %s is scaled to zero, waiting for %d requests within %s before scaling up to %d replicas", model.Name, n, window, replicas)
+				reason += fmt.Sprintf(" (waiting for %d requests within %s to scale from zero)", n, window)
+				replicas = floor
+			}
+		}
+	} else {
+		c.resetScaleFromZeroDemand(model.Name)
+	}
+
+	// Models that share a scale target (i.e. multiple model servers packed into
+	// the Pods of one Deployment) should not scale down each other.
+	shared, sharedWith, err := c.sharedTargetReplicas(ctx, model, replicas)
+	if err != nil {
+		return newScaleError("get", model.Name, err)
+	}
+	if shared > replicas {
+		reason += fmt.Sprintf(" (scale target shared with model %s)", sharedWith)
+		replicas = shared
+	}
+
+	if replicas == 0 && existingReplicas > 0 {
+		if streams := c.ActiveStreams(model.Name); streams > 0 {
+			log.Printf("model %s has %d active streams, not scaling to zero", model.Name, streams)
+			reason += fmt.Sprintf(" (%d active streams)", streams)
+			replicas = 1
+		}
+	}
 
-	var existingReplicas int32 = 0
-	if model.Spec.Replicas != nil {
-		existingReplicas = *model.Spec.Replicas
+	if existingReplicas > replicas {
+		floor, pdb, err := c.disruptionBudgetFloor(ctx, model)
+		if err != nil {
+			return newScaleError("get", model.Name, err)
+		}
+		if floor > replicas {
+			reason += fmt.Sprintf(" (PodDisruptionBudget %s floor)", pdb)
+			replicas = min(floor, existingReplicas)
+		}
+	}
+
+	if existingReplicas > replicas {
+		ready, err := c.readyFloor(ctx, model, existingReplicas)
+		if err != nil {
+			return newScaleError("get", model.Name, err)
+		}
+		if ready > replicas {
+			reason += fmt.Sprintf(" (%d of %d replicas ready)", ready, existingReplicas)
+			replicas = ready
+		}
 	}
 
 	if existingReplicas > replicas {
 		// Scale down
+		if c.isScaleDownFrozen(ctx) {
+			log.Printf("scale downs are frozen, not scaling model %s down from %d to %d replicas", model.Name, existingReplicas, replicas)
+			return nil
+		}
+		if c.IsModelCordoned(model) {
+			log.Printf("model %s is cordoned, not scaling down from %d to %d replicas", model.Name, existingReplicas, replicas)
+			return nil
+		}
 		c.consecutiveScaleDownsMtx.RLock()
 		consec := c.consecutiveScaleDowns[model.Name]
 		c.consecutiveScaleDownsMtx.RUnlock()
@@ -65,6 +178,10 @@ func (c *ModelClient) Scale(ctx context.Context, model *kubeaiv1.Model, replicas
 			c.consecutiveScaleDownsMtx.Unlock()
 			return nil
 		}
+		if remaining := c.scaleUpCooldown(model); remaining > 0 {
+			log.Printf("model %s was scaled up recently, not scaling down from %d to %d replicas for another %s", model.Name, existingReplicas, replicas, remaining)
+			return nil
+		}
 	} else {
 		// Scale up or constant scale.
 		c.consecutiveScaleDownsMtx.Lock()
@@ -72,22 +189,299 @@ func (c *ModelClient) Scale(ctx context.Context, model *kubeaiv1.Model, replicas
 		c.consecutiveScaleDownsMtx.Unlock()
 	}
 
-	if existingReplicas != replicas {
-		log.Printf("scaling model %s from %d to %d replicas", model.Name, existingReplicas, replicas)
-		scale := &autoscalingv1.Scale{
-			Spec: autoscalingv1.ScaleSpec{Replicas: replicas},
+	// A recommendation that KEDA did not apply yet is lowered to the current
+	// replicas without the scale down checks, because no replicas are removed.
+	if existingReplicas == replicas && !recommendationPending(target, replicas) {
+		c.trackReplicaLag(ctx, model.Name, &replicas)
+		metrics.ModelScaleNoops.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+			metrics.AttrRequestModel.String(model.Name),
+		)))
+		return nil
+	}
+
+	if existingReplicas > replicas {
+		if err := c.setPodDeletionCosts(ctx, model); err != nil {
+			log.Printf("WARNING: failed to set the deletion costs of the pods of model %s: %v", model.Name, err)
 		}
-		if err := c.client.SubResource("scale").Update(ctx, model, client.WithSubResourceBody(scale)); err != nil {
-			return fmt.Errorf("update scale: %w", err)
+	}
+	log.Printf("scaling model %s from %d to %d replicas: %s", model.Name, existingReplicas, replicas, reason)
+	if err := c.updateScale(ctx, model, target, replicas, reason, ScaledToZeroIdle, ScaleActorAuto); err != nil {
+		return err
+	}
+	// The replicas were written or deferred (see updateScale).
+	c.trackReplicaLag(ctx, model.Name, &replicas)
+	return nil
+}
+
+// observeAppliedReplicas records the replicas that were written to (or observed
+// on) the scale target of the model, for the next trackReplicaLag.
+func (c *ModelClient) observeAppliedReplicas(model string, applied int32) {
+	c.scalerStatesMtx.Lock()
+	c.getScalerState(model).appliedReplicas = &applied
+	c.scalerStatesMtx.Unlock()
+}
+
+// trackReplicaLag records the replicas that the autoscaler requested (unless nil)
+// and reports the difference to the applied replicas (see observeAppliedReplicas)
+// in the ModelReplicasLag metric. It should only be called once a scale operation
+// succeeded, so the lag stays non-zero while the writes of the autoscaler are
+// deferred, and failed writes do not change it.
+func (c *ModelClient) trackReplicaLag(ctx context.Context, model string, requested *int32) {
+	c.scalerStatesMtx.Lock()
+	s := c.getScalerState(model)
+	if requested != nil {
+		r := *requested
+		s.requestedReplicas = &r
+	}
+	lag, ok := int64(0), s.requestedReplicas != nil && s.appliedReplicas != nil
+	if ok {
+		lag = int64(*s.requestedReplicas) - int64(*s.appliedReplicas)
+	}
+	c.scalerStatesMtx.Unlock()
+
+	if ok {
+		metrics.ModelReplicasLag.Record(ctx, lag, metric.WithAttributeSet(attribute.NewSet(
+			metrics.AttrRequestModel.String(model),
+		)))
+	}
+}
+
+// EnforceMinReplicas scales the model up to its replica floor (MinReplicas, or
+// IdleMinReplicas while scaled to zero) if it is currently below it, independent
+// of traffic. This covers scale targets that are not the Model itself (see
+// kubeaiv1.ModelScaleTargetAnnotation) and that were scaled down externally.
+// Models are never scaled down.
+// Errors returned from scaling operations are of type *ScaleError.
+func (c *ModelClient) EnforceMinReplicas(ctx context.Context, model *kubeaiv1.Model) error {
+	if model.Spec.AutoscalingDisabled || !c.IsManaged(model) {
+		return nil
+	}
+
+	target, replicas, err := c.getReplicas(ctx, model)
+	if err != nil {
+		return err
+	}
+	if model, err = c.withReplicaBounds(ctx, model); err != nil {
+		return newScaleError("get", model.Name, err)
+	}
+	bounded := enforceReplicaBounds(replicas, model)
+	if bounded <= replicas {
+		return nil
+	}
+
+	// Models are reconciled often. Repeating a scale up that was deferred
+	// would replace newer deferred scale operations of the autoscaler.
+	// The record is cleared by recordScale once the replicas are written, by
+	// updateScale if they are neither written nor deferred, and expires after
+	// enforcedMinReplicasTTL in case a deferred write never happens.
+	now := c.clock.Now()
+	c.scalerStatesMtx.Lock()
+	s := c.getScalerState(model.Name)
+	if prev := s.enforcedMinReplicas; prev != nil && prev.replicas == replicas && prev.bounded == bounded &&
+		now.Sub(prev.time) < max(enforcedMinReplicasTTL, c.scaleDebounceInterval) {
+		c.scalerStatesMtx.Unlock()
+		return nil
+	}
+	s.enforcedMinReplicas = &enforcedMinReplicas{replicas: replicas, bounded: bounded, time: now}
+	c.scalerStatesMtx.Unlock()
+
+	reason := "replicas below the configured minimum" + replicaBoundsReason(replicas, bounded, model)
+	log.Printf("scaling model %s from %d to %d replicas: %s", model.Name, replicas, bounded, reason)
+	return c.updateScale(ctx, model, target, bounded, reason, ScaledToZeroIdle, ScaleActorAuto)
+}
+
+// enforcedMinReplicasTTL is the time after which EnforceMinReplicas repeats a
+// scale up that was deferred (at least the scale debounce interval).
+const enforcedMinReplicasTTL = time.Minute
+
+// enforcedMinReplicas is a scale up of EnforceMinReplicas: from the replicas
+// that the model had to its bounded number of replicas at the given time.
+type enforcedMinReplicas struct {
+	replicas, bounded int32
+	time              time.Time
+}
+
+// forgetEnforcedMinReplicas clears the record of EnforceMinReplicas for a scale
+// operation that was neither written nor deferred (see updateScale), so that
+// EnforceMinReplicas retries it.
+func (c *ModelClient) forgetEnforcedMinReplicas(model string) {
+	c.scalerStatesMtx.Lock()
+	if s, ok := c.scalerStates[model]; ok {
+		s.enforcedMinReplicas = nil
+	}
+	c.scalerStatesMtx.Unlock()
+}
+
+// getReplicas returns the ScaleTarget of the model and its current number of replicas
+// (which are recorded, see observeAppliedReplicas).
+// The returned ScaleTarget should be passed to updateScale.
+func (c *ModelClient) getReplicas(ctx context.Context, model *kubeaiv1.Model) (ScaleTarget, int32, error) {
+	target, err := c.scaleTargetFor(model)
+	if err != nil {
+		return nil, 0, newScaleError("get", model.Name, err)
+	}
+	if err := c.unscalableError(model); err != nil {
+		return nil, 0, newScaleError("get", model.Name, err)
+	}
+	replicas, err := target.GetReplicas(ctx)
+	c.recordUnscalable(ctx, model, err)
+	if err != nil {
+		return nil, 0, newScaleError("get", model.Name, err)
+	}
+	c.observeAppliedReplicas(model.Name, replicas)
+	return target, replicas, nil
+}
+
+// updateScale writes the desired number of replicas to the ScaleTarget of the model.
+// zeroReason (one of the ScaledToZero* reasons) is recorded if the model is scaled
+// to zero replicas (see recordScaledToZeroReason), and actor (one of the
+// ScaleActor* values) in the scale event, also if the operation is deferred.
+// All scale operations should go through this method.
+func (c *ModelClient) updateScale(ctx context.Context, model *kubeaiv1.Model, target ScaleTarget, replicas int32, reason, zeroReason, actor string) error {
+	// Operations that are deferred (pins, paused autoscaling, and the debounce
+	// interval) return early with deferred set.
+	var written, deferred bool
+	defer func() {
+		if !written && !deferred {
+			c.forgetEnforcedMinReplicas(model.Name)
+		}
+	}()
+
+	if !c.beginScale() {
+		return newScaleError("update", model.Name, ErrShuttingDown)
+	}
+	defer c.endScale()
+
+	if !c.IsManaged(model) {
+		log.Printf("model %s is not managed, not scaling to %d replicas", model.Name, replicas)
+		return nil
+	}
+	if c.observeOnly {
+		log.Printf("observe only, not scaling model %s to %d replicas: %s", model.Name, replicas, reason)
+		return nil
+	}
+
+	// Serialize writes so that a scale operation can not overwrite a concurrent force scale.
+	s := c.lockScaleWrites(model.Name)
+	defer s.writeMtx.Unlock()
+
+	c.syncStoredPin(ctx, model.Name, target)
+	op := &pendingScale{model: model, target: target, replicas: replicas, reason: reason, zeroReason: zeroReason, actor: actor}
+	if c.queueDuringPin(op) {
+		log.Printf("model %s is pinned by a force scale, deferring scaling to %d replicas until it expires", model.Name, replicas)
+		deferred = true
+		return nil
+	}
+
+	if c.IsAutoscalingPaused(ctx) {
+		log.Printf("autoscaling paused, deferring scaling model %s to %d replicas until resumed", model.Name, replicas)
+		c.deferPausedReplicas(ctx, model.Name, replicas)
+		c.NotifyStateChange(model.Name)
+		deferred = true
+		return nil
+	}
+
+	paused, err := isScaleTargetPaused(ctx, target)
+	if err != nil {
+		return newScaleError("get", model.Name, err)
+	}
+	c.scalerStatesMtx.Lock()
+	pauseChanged := s.targetPaused != paused
+	s.targetPaused = paused
+	c.scalerStatesMtx.Unlock()
+	if pauseChanged {
+		c.NotifyStateChange(model.Name)
+	}
+	if paused {
+		log.Printf("scale target of model %s is paused, not scaling to %d replicas", model.Name, replicas)
+		return nil
+	}
+
+	if c.debounceScale(op) {
+		log.Printf("model %s was scaled within the debounce interval, deferring scaling to %d replicas", model.Name, replicas)
+		deferred = true
+		return nil
+	}
+
+	if c.maxTotalReplicas > 0 {
+		c.replicaBudgetMtx.Lock()
+		defer c.replicaBudgetMtx.Unlock()
+		var write bool
+		replicas, reason, write, err = c.applyReplicaBudget(ctx, model, target, replicas, reason)
+		if err != nil {
+			return newScaleError("get", model.Name, err)
+		}
+		if !write {
+			return nil
 		}
 	}
 
+	c.scalerStatesMtx.RLock()
+	from := s.appliedReplicas
+	c.scalerStatesMtx.RUnlock()
+	if err := c.setReplicas(ctx, model.Name, target, replicas, reason, actor); err != nil {
+		c.recordUnscalable(ctx, model, err)
+		return newScaleError("update", model.Name, err)
+	}
+	written = true
+
+	c.scalerStatesMtx.Lock()
+	s.recordScale(c.clock.Now(), replicas, reason, zeroReason)
+	c.scalerStatesMtx.Unlock()
+	if replicas > ptr.Deref(from, 0) {
+		c.recordScaleUp(model.Name)
+	}
+	c.recordScaledToZeroReason(ctx, model, replicas, zeroReason)
+	c.NotifyStateChange(model.Name)
+
 	return nil
 }
 
+// withReplicaBounds returns the model with the replica bounds that apply to it
+// at the current time: its own, completed by its HorizontalPodAutoscaler (see
+// withHPABounds) and raised by its warmup schedule (see withWarmupSchedule).
+func (c *ModelClient) withReplicaBounds(ctx context.Context, model *kubeaiv1.Model) (*kubeaiv1.Model, error) {
+	model, err := c.withHPABounds(ctx, model)
+	if err != nil {
+		return model, err
+	}
+	return c.withWarmupSchedule(model)
+}
+
+// replicaBoundsReason returns a suffix for a scale reason that describes
+// which replica bound was applied (if any).
+func replicaBoundsReason(desired, bounded int32, model *kubeaiv1.Model) string {
+	switch {
+	case bounded == desired:
+		return ""
+	case bounded < desired:
+		return " (max replicas ceiling)"
+	case desired == 0 && model.Spec.IdleMinReplicas != nil:
+		return " (idle min replicas floor)"
+	default:
+		return " (min replicas floor)"
+	}
+}
+
+// scaleFromZeroGate returns the number of requests that need to be received
+// within the window (see kubeaiv1.ModelSpec.ScaleFromZeroDelaySeconds) before
+// the model is scaled up from zero replicas. Returns false if the first request
+// scales the model up.
+func scaleFromZeroGate(model *kubeaiv1.Model) (requests int, window time.Duration, ok bool) {
+	n := model.Spec.GetScaleFromZeroRequests()
+	if n <= 1 {
+		return 0, 0, false
+	}
+	return int(n), time.Duration(ptr.Deref(model.Spec.ScaleFromZeroDelaySeconds, 0)) * time.Second, true
+}
+
 func enforceReplicaBounds(replicas int32, model *kubeaiv1.Model) int32 {
 	max := model.Spec.MaxReplicas
 	min := model.Spec.MinReplicas
+	// A model is only allowed to drop to its idle minimum when there is no demand.
+	if replicas == 0 && model.Spec.IdleMinReplicas != nil {
+		min = *model.Spec.IdleMinReplicas
+	}
 	if max != nil {
 		if replicas > *max {
 			return *max
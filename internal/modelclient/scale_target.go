@@ -0,0 +1,588 @@
+package modelclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ScaleTarget is the object that scaling decisions for a model are applied to.
+type ScaleTarget interface {
+	GetReplicas(ctx context.Context) (int32, error)
+	SetReplicas(ctx context.Context, replicas int32) error
+}
+
+// scaleTargetFor returns the ScaleTarget for the given model.
+// By default the scale subresource of the Model is used. Alternative
+// objects can be specified with the kubeaiv1.ModelScaleTargetAnnotation, and
+// Models that are scaled by KEDA only record the replicas (see
+// kubeaiv1.ModelScalingBackendAnnotation).
+func (c *ModelClient) scaleTargetFor(model *kubeaiv1.Model) (ScaleTarget, error) {
+	if key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelScalingBackendAnnotationName); ok {
+		switch value {
+		case kubeaiv1.ScalingBackendKubeAI:
+		case kubeaiv1.ScalingBackendKEDA:
+			return &recommendedScaleTarget{c: c, model: model}, nil
+		default:
+			return nil, fmt.Errorf("invalid %s annotation %q: expected %q or %q",
+				key, value, kubeaiv1.ScalingBackendKubeAI, kubeaiv1.ScalingBackendKEDA)
+		}
+	}
+
+	key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelScaleTargetAnnotationName)
+	if !ok {
+		patch := c.useScalePatch
+		if key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelScaleUpdateStrategyAnnotationName); ok {
+			switch value {
+			case kubeaiv1.ScaleUpdateStrategyUpdate, kubeaiv1.ScaleUpdateStrategyPatch:
+				patch = value == kubeaiv1.ScaleUpdateStrategyPatch
+			default:
+				return nil, fmt.Errorf("invalid %s annotation %q: expected %q or %q",
+					key, value, kubeaiv1.ScaleUpdateStrategyUpdate, kubeaiv1.ScaleUpdateStrategyPatch)
+			}
+		}
+		return &modelScaleTarget{client: c.client, model: model, patch: patch}, nil
+	}
+
+	strategy := kubeaiv1.ScaleTargetStrategySubresource
+	if key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelScaleTargetStrategyAnnotationName); ok {
+		switch value {
+		case kubeaiv1.ScaleTargetStrategySubresource, kubeaiv1.ScaleTargetStrategyReplicas:
+			strategy = value
+		default:
+			return nil, fmt.Errorf("invalid %s annotation %q: expected %q or %q",
+				key, value, kubeaiv1.ScaleTargetStrategySubresource, kubeaiv1.ScaleTargetStrategyReplicas)
+		}
+	}
+
+	if selectorKey, selectorValue, ok := c.getModelAnnotation(model, kubeaiv1.ModelScaleTargetSelectorAnnotationName); ok {
+		gvk, err := parseScaleTargetKind(value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s annotation: %w", key, err)
+		}
+		selector, err := labels.Parse(selectorValue)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s annotation: %w", selectorKey, err)
+		}
+		return &selectorScaleTarget{
+			client:    c.client,
+			gvk:       gvk,
+			namespace: model.Namespace,
+			selector:  selector,
+			strategy:  strategy,
+		}, nil
+	}
+
+	refs, err := parseScaleTargetRefs(value)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s annotation: %w", key, err)
+	}
+
+	targets := make([]ScaleTarget, len(refs))
+	weights := make([]int, len(refs))
+	for i, ref := range refs {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(ref.gvk)
+		obj.SetNamespace(model.Namespace)
+		obj.SetName(ref.name)
+		targets[i] = newObjectScaleTarget(c.client, obj, strategy)
+		weights[i] = ref.weight
+	}
+
+	if len(targets) == 1 {
+		return targets[0], nil
+	}
+	return &weightedScaleTarget{targets: targets, weights: weights}, nil
+}
+
+// newObjectScaleTarget returns the ScaleTarget for the given object
+// and kubeaiv1.ModelScaleTargetStrategyAnnotation.
+func newObjectScaleTarget(c client.Client, obj *unstructured.Unstructured, strategy string) ScaleTarget {
+	if strategy == kubeaiv1.ScaleTargetStrategyReplicas {
+		return &objectReplicasTarget{client: c, obj: obj}
+	}
+	return &objectScaleTarget{client: c, obj: obj}
+}
+
+// scaleTargetKey identifies the scale target of the model (see
+// kubeaiv1.ModelScaleTargetAnnotation). Models with the same key share their
+// target. Returns false if the Model is scaled via its own scale subresource.
+func (c *ModelClient) scaleTargetKey(model *kubeaiv1.Model) (string, bool) {
+	_, target, ok := c.getModelAnnotation(model, kubeaiv1.ModelScaleTargetAnnotationName)
+	if !ok {
+		return "", false
+	}
+	if _, selector, ok := c.getModelAnnotation(model, kubeaiv1.ModelScaleTargetSelectorAnnotationName); ok {
+		target += "?" + selector
+	}
+	return target, true
+}
+
+type scaleTargetRef struct {
+	gvk    schema.GroupVersionKind
+	name   string
+	weight int
+}
+
+// parseScaleTargetRefs parses the references of a scale target annotation.
+// The annotation is parsed on every scale operation, as the RESTMapping of each
+// kind that the client needs to scale the objects is already cached by the
+// client (see client.Client.RESTMapper).
+func parseScaleTargetRefs(value string) ([]scaleTargetRef, error) {
+	var refs []scaleTargetRef
+	for _, ref := range strings.Split(value, ",") {
+		ref, weight, err := parseScaleTargetWeight(strings.TrimSpace(ref))
+		if err != nil {
+			return nil, err
+		}
+		gvk, name, err := parseScaleTargetRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, scaleTargetRef{gvk: gvk, name: name, weight: weight})
+	}
+	return refs, nil
+}
+
+// parseScaleTargetWeight splits an optional "=<weight>" suffix from a reference.
+// The weight defaults to 1.
+func parseScaleTargetWeight(ref string) (string, int, error) {
+	ref, weightStr, ok := strings.Cut(ref, "=")
+	if !ok {
+		return ref, 1, nil
+	}
+	weight, err := strconv.Atoi(weightStr)
+	if err != nil || weight < 1 {
+		return "", 0, fmt.Errorf("weight must be a positive integer, got %q", weightStr)
+	}
+	return ref, weight, nil
+}
+
+// parseScaleTargetRef parses a reference of the form "<apiVersion>/<kind>/<name>",
+// for example "apps/v1/Deployment/my-deployment" or "v1/ReplicationController/my-rc".
+func parseScaleTargetRef(ref string) (schema.GroupVersionKind, string, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) < 3 || len(parts) > 4 {
+		return schema.GroupVersionKind{}, "", fmt.Errorf("expected <apiVersion>/<kind>/<name>, got %q", ref)
+	}
+	for _, p := range parts {
+		if p == "" {
+			return schema.GroupVersionKind{}, "", fmt.Errorf("expected <apiVersion>/<kind>/<name>, got %q", ref)
+		}
+	}
+	n := len(parts)
+	gv, err := schema.ParseGroupVersion(strings.Join(parts[:n-2], "/"))
+	if err != nil {
+		return schema.GroupVersionKind{}, "", err
+	}
+	return gv.WithKind(parts[n-2]), parts[n-1], nil
+}
+
+// parseScaleTargetKind parses a reference of the form "<apiVersion>/<kind>" that
+// is used with the kubeaiv1.ModelScaleTargetSelectorAnnotation, for example
+// "apps/v1/Deployment".
+func parseScaleTargetKind(ref string) (schema.GroupVersionKind, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) < 2 || len(parts) > 3 || slices.Contains(parts, "") {
+		return schema.GroupVersionKind{}, fmt.Errorf("expected <apiVersion>/<kind>, got %q", ref)
+	}
+	n := len(parts)
+	gv, err := schema.ParseGroupVersion(strings.Join(parts[:n-1], "/"))
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return gv.WithKind(parts[n-1]), nil
+}
+
+// modelScaleTarget scales the Model itself via its scale subresource.
+type modelScaleTarget struct {
+	client client.Client
+	model  *kubeaiv1.Model
+	// patch merge patches the scale subresource instead of updating it.
+	patch bool
+}
+
+func (t *modelScaleTarget) GetReplicas(ctx context.Context) (int32, error) {
+	if t.model.Spec.Replicas == nil {
+		return 0, nil
+	}
+	return *t.model.Spec.Replicas, nil
+}
+
+func (t *modelScaleTarget) SetReplicas(ctx context.Context, replicas int32) error {
+	if t.patch {
+		patch := fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)
+		return t.client.SubResource("scale").Patch(ctx, t.model, client.RawPatch(types.MergePatchType, []byte(patch)))
+	}
+	scale := &autoscalingv1.Scale{
+		Spec: autoscalingv1.ScaleSpec{Replicas: replicas},
+	}
+	return t.client.SubResource("scale").Update(ctx, t.model, client.WithSubResourceBody(scale))
+}
+
+// objectScaleTarget scales an arbitrary object that implements the scale subresource.
+type objectScaleTarget struct {
+	client client.Client
+	obj    *unstructured.Unstructured
+	// paused is the paused state of the object once it was fetched (see IsPaused).
+	paused *bool
+}
+
+func (t *objectScaleTarget) GetReplicas(ctx context.Context) (int32, error) {
+	scale := &unstructured.Unstructured{}
+	scale.SetGroupVersionKind(autoscalingv1.SchemeGroupVersion.WithKind("Scale"))
+	if err := t.client.SubResource("scale").Get(ctx, t.obj, scale); err != nil {
+		return 0, scaleSubresourceError(ctx, t.client, t.obj, err)
+	}
+	replicas, _, err := unstructured.NestedInt64(scale.Object, "spec", "replicas")
+	if err != nil {
+		return 0, err
+	}
+	return int32(replicas), nil
+}
+
+func (t *objectScaleTarget) SetReplicas(ctx context.Context, replicas int32) error {
+	patch := fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)
+	if err := t.client.SubResource("scale").Patch(ctx, t.obj, client.RawPatch(types.MergePatchType, []byte(patch))); err != nil {
+		return scaleSubresourceError(ctx, t.client, t.obj, err)
+	}
+	return nil
+}
+
+// scaleSubresourceError distinguishes a scale subresource that is not found
+// because the object does not implement it (wrapping errNoScaleSubresource)
+// from an object that does not exist. Other errors are returned as is.
+func scaleSubresourceError(ctx context.Context, c client.Client, ref *unstructured.Unstructured, err error) error {
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(ref.GroupVersionKind())
+	if getErr := c.Get(ctx, client.ObjectKeyFromObject(ref), obj); getErr != nil {
+		// The object itself is missing (or can not be checked).
+		return err
+	}
+	gvk := ref.GroupVersionKind()
+	return fmt.Errorf("%s/%s %s: %w (use the %s annotation %q for objects with a .spec.replicas field)",
+		gvk.GroupVersion(), gvk.Kind, ref.GetName(), errNoScaleSubresource,
+		kubeaiv1.ModelScaleTargetStrategyAnnotationName, kubeaiv1.ScaleTargetStrategyReplicas)
+}
+
+// objectReplicasTarget scales an arbitrary object by patching its .spec.replicas
+// field. Used for objects that do not implement the scale subresource.
+type objectReplicasTarget struct {
+	client client.Client
+	obj    *unstructured.Unstructured
+	// paused is the paused state of the object from the last time it was
+	// fetched (see IsPaused).
+	paused *bool
+}
+
+func (t *objectReplicasTarget) GetReplicas(ctx context.Context) (int32, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(t.obj.GroupVersionKind())
+	if err := t.client.Get(ctx, client.ObjectKeyFromObject(t.obj), obj); err != nil {
+		return 0, err
+	}
+	replicas, _, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return 0, err
+	}
+	if paused, err := objectPaused(obj); err == nil {
+		t.paused = &paused
+	}
+	return int32(replicas), nil
+}
+
+func (t *objectReplicasTarget) SetReplicas(ctx context.Context, replicas int32) error {
+	patch := fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)
+	return t.client.Patch(ctx, t.obj, client.RawPatch(types.MergePatchType, []byte(patch)))
+}
+
+// selectorScaleTarget scales the newest object of a kind that matches a label
+// selector (see kubeaiv1.ModelScaleTargetSelectorAnnotation). The object is
+// resolved on first use and on Refresh, so objects that are replaced under a
+// new name (i.e. a Deployment per revision) are picked up by the next scale
+// operation.
+type selectorScaleTarget struct {
+	client    client.Client
+	gvk       schema.GroupVersionKind
+	namespace string
+	selector  labels.Selector
+	strategy  string
+	// resolved is the target of the selected object.
+	resolved ScaleTarget
+}
+
+func (t *selectorScaleTarget) resolve(ctx context.Context) (ScaleTarget, error) {
+	if t.resolved != nil {
+		return t.resolved, nil
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(t.gvk.GroupVersion().WithKind(t.gvk.Kind + "List"))
+	if err := t.client.List(ctx, list, client.InNamespace(t.namespace), client.MatchingLabelsSelector{Selector: t.selector}); err != nil {
+		return nil, err
+	}
+	var newest *unstructured.Unstructured
+	for i := range list.Items {
+		obj := &list.Items[i]
+		if obj.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if newest == nil {
+			newest = obj
+			continue
+		}
+		created, newestCreated := obj.GetCreationTimestamp().Time, newest.GetCreationTimestamp().Time
+		if created.After(newestCreated) || (created.Equal(newestCreated) && obj.GetName() > newest.GetName()) {
+			newest = obj
+		}
+	}
+	if newest == nil {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: t.gvk.Group, Resource: t.gvk.Kind}, t.selector.String())
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(t.gvk)
+	obj.SetNamespace(t.namespace)
+	obj.SetName(newest.GetName())
+	t.resolved = newObjectScaleTarget(t.client, obj, t.strategy)
+	return t.resolved, nil
+}
+
+func (t *selectorScaleTarget) GetReplicas(ctx context.Context) (int32, error) {
+	target, err := t.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return target.GetReplicas(ctx)
+}
+
+func (t *selectorScaleTarget) SetReplicas(ctx context.Context, replicas int32) error {
+	target, err := t.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return target.SetReplicas(ctx, replicas)
+}
+
+// Refresh re-selects the object, i.e. after it was replaced.
+func (t *selectorScaleTarget) Refresh(ctx context.Context) error {
+	t.resolved = nil
+	_, err := t.resolve(ctx)
+	return err
+}
+
+func (t *selectorScaleTarget) IsPaused(ctx context.Context) (bool, error) {
+	target, err := t.resolve(ctx)
+	if err != nil {
+		return false, err
+	}
+	return isScaleTargetPaused(ctx, target)
+}
+
+// refreshableScaleTarget is implemented by ScaleTargets that hold a copy of
+// their object that can become stale. Refresh re-fetches the object so that a
+// write that failed with a conflict can be retried.
+type refreshableScaleTarget interface {
+	Refresh(ctx context.Context) error
+}
+
+func (t *modelScaleTarget) Refresh(ctx context.Context) error {
+	m := &kubeaiv1.Model{}
+	if err := t.client.Get(ctx, client.ObjectKeyFromObject(t.model), m); err != nil {
+		return err
+	}
+	t.model = m
+	return nil
+}
+
+// Refresh refreshes all targets and re-observes their replicas so that targets
+// that already have their share are still skipped.
+func (t *weightedScaleTarget) Refresh(ctx context.Context) error {
+	for _, target := range t.targets {
+		if err := refreshScaleTarget(ctx, target); err != nil {
+			return err
+		}
+	}
+	if t.observed == nil {
+		return nil
+	}
+	_, err := t.GetReplicas(ctx)
+	return err
+}
+
+// Refresh forgets the paused state, so that it is fetched again.
+func (t *objectScaleTarget) Refresh(ctx context.Context) error {
+	t.paused = nil
+	return nil
+}
+
+// Refresh forgets the paused state, so that it is fetched again.
+func (t *objectReplicasTarget) Refresh(ctx context.Context) error {
+	t.paused = nil
+	return nil
+}
+
+func refreshScaleTarget(ctx context.Context, target ScaleTarget) error {
+	if t, ok := target.(refreshableScaleTarget); ok {
+		return t.Refresh(ctx)
+	}
+	return nil
+}
+
+// pausableScaleTarget is implemented by ScaleTargets whose objects can be paused
+// with a .spec.paused field (i.e. Deployments).
+type pausableScaleTarget interface {
+	IsPaused(ctx context.Context) (bool, error)
+}
+
+// IsPaused fetches the object once per target (until Refresh), as the scale
+// subresource does not include the paused state. Targets are created for each
+// scale operation (see scaleTargetFor) and refreshed before deferred operations
+// are applied, so the state is not older than the operation.
+func (t *objectScaleTarget) IsPaused(ctx context.Context) (bool, error) {
+	if t.paused == nil {
+		paused, err := fetchObjectPaused(ctx, t.client, t.obj)
+		if err != nil {
+			return false, err
+		}
+		t.paused = &paused
+	}
+	return *t.paused, nil
+}
+
+// IsPaused reuses the object that GetReplicas fetched (if any), so checking the
+// paused state does not read the object again.
+func (t *objectReplicasTarget) IsPaused(ctx context.Context) (bool, error) {
+	if t.paused == nil {
+		paused, err := fetchObjectPaused(ctx, t.client, t.obj)
+		if err != nil {
+			return false, err
+		}
+		t.paused = &paused
+	}
+	return *t.paused, nil
+}
+
+func fetchObjectPaused(ctx context.Context, c client.Client, ref *unstructured.Unstructured) (bool, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(ref.GroupVersionKind())
+	if err := c.Get(ctx, client.ObjectKeyFromObject(ref), obj); err != nil {
+		return false, err
+	}
+	return objectPaused(obj)
+}
+
+func objectPaused(obj *unstructured.Unstructured) (bool, error) {
+	paused, _, err := unstructured.NestedBool(obj.Object, "spec", "paused")
+	if err != nil {
+		return false, err
+	}
+	return paused, nil
+}
+
+// isScaleTargetPaused returns true if the target (or any of the targets of a
+// weightedScaleTarget) is paused.
+func isScaleTargetPaused(ctx context.Context, target ScaleTarget) (bool, error) {
+	switch t := target.(type) {
+	case *weightedScaleTarget:
+		for _, target := range t.targets {
+			if paused, err := isScaleTargetPaused(ctx, target); err != nil || paused {
+				return paused, err
+			}
+		}
+		return false, nil
+	case pausableScaleTarget:
+		return t.IsPaused(ctx)
+	}
+	return false, nil
+}
+
+// weightedScaleTarget distributes replicas across multiple ScaleTargets
+// (i.e. Deployments in different failure domains) in proportion to their weights.
+type weightedScaleTarget struct {
+	targets []ScaleTarget
+	weights []int
+	// observed holds the replicas of each target from the last call to GetReplicas.
+	observed []int32
+}
+
+// GetReplicas returns the total number of replicas across all targets.
+func (t *weightedScaleTarget) GetReplicas(ctx context.Context) (int32, error) {
+	observed := make([]int32, len(t.targets))
+	var total int32
+	for i, target := range t.targets {
+		replicas, err := target.GetReplicas(ctx)
+		if err != nil {
+			return 0, err
+		}
+		observed[i] = replicas
+		total += replicas
+	}
+	t.observed = observed
+	return total, nil
+}
+
+// SetReplicas distributes the replicas across all targets. Targets that were
+// observed to already have their share of replicas are not updated.
+func (t *weightedScaleTarget) SetReplicas(ctx context.Context, replicas int32) error {
+	var errs error
+	for i, n := range distributeReplicas(replicas, t.weights) {
+		if t.observed != nil && t.observed[i] == n {
+			continue
+		}
+		if err := t.targets[i].SetReplicas(ctx, n); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+// distributeReplicas splits replicas according to the given weights:
+//
+//	share[i] = replicas * weights[i] / sum(weights)
+//
+// Each share is rounded down and the remaining replicas are assigned to the
+// shares with the largest remainders (earlier targets win ties).
+func distributeReplicas(replicas int32, weights []int) []int32 {
+	var totalWeight int64
+	for _, w := range weights {
+		totalWeight += int64(w)
+	}
+
+	shares := make([]int32, len(weights))
+	remainders := make([]int64, len(weights))
+	assigned := int32(0)
+	for i, w := range weights {
+		shares[i] = int32(int64(replicas) * int64(w) / totalWeight)
+		remainders[i] = int64(replicas) * int64(w) % totalWeight
+		assigned += shares[i]
+	}
+
+	for ; assigned < replicas; assigned++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		shares[best]++
+		remainders[best] = -1
+	}
+
+	return shares
+}
@@ -0,0 +1,156 @@
+package modelclient
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// BenchmarkScaleWithScaleTargets measures a burst of 1000 scale calls for a
+// Model that delegates scaling to multiple objects.
+func BenchmarkScaleWithScaleTargets(b *testing.B) {
+	const burst = 1000
+
+	metricstest.Init(b)
+	scheme := runtime.NewScheme()
+	if err := kubeaiv1.AddToScheme(scheme); err != nil {
+		b.Fatal(err)
+	}
+	// The Deployments are read to check whether they are paused.
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		b.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		b.Fatal(err)
+	}
+	var deployments []client.Object
+	for _, name := range []string{"primary", "failover"} {
+		deployments = append(deployments, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: name}})
+	}
+	var apiCalls int
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(deployments...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceGet: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+				apiCalls++
+				return unstructured.SetNestedField(subResource.(*unstructured.Unstructured).Object, int64(1), "spec", "replicas")
+			},
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				apiCalls++
+				return nil
+			},
+		}).
+		Build()
+	mc := NewModelClient(k8sClient, testNamespace, Options{})
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{MaxReplicas: ptr.To[int32](10)})
+	m.Annotations = map[string]string{
+		kubeaiv1.ModelScaleTargetAnnotation: "apps/v1/Deployment/primary=2,apps/v1/Deployment/failover=1",
+	}
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < burst; j++ {
+			if err := mc.Scale(ctx, m, int32(j%2+2), 0, "benchmark"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.ReportMetric(float64(apiCalls)/float64(b.N*burst), "apicalls/scale")
+}
+
+// BenchmarkScaleConflicts measures the conflicts of scale writes to a Model that
+// is modified by a concurrent writer for both scale update strategies.
+func BenchmarkScaleConflicts(b *testing.B) {
+	for _, strategy := range []string{kubeaiv1.ScaleUpdateStrategyUpdate, kubeaiv1.ScaleUpdateStrategyPatch} {
+		b.Run(strategy, func(b *testing.B) {
+			metricstest.Init(b)
+			scheme := runtime.NewScheme()
+			if err := kubeaiv1.AddToScheme(scheme); err != nil {
+				b.Fatal(err)
+			}
+			var writes, conflicts atomic.Int64
+			k8sClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)})).
+				WithInterceptorFuncs(interceptor.Funcs{
+					// Reject updates of a stale Model like the API server would.
+					SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+						writes.Add(1)
+						current := &kubeaiv1.Model{}
+						if err := c.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+							return err
+						}
+						if current.ResourceVersion != obj.GetResourceVersion() {
+							conflicts.Add(1)
+							return apierrors.NewConflict(kubeaiv1.GroupVersion.WithResource("models").GroupResource(), obj.GetName(), errors.New("the object has been modified"))
+						}
+						return modelclienttest.UpdateModelScale(ctx, c, subResourceName, obj, opts...)
+					},
+					SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+						writes.Add(1)
+						return modelclienttest.PatchModelScale(ctx, c, subResourceName, obj, patch, opts...)
+					},
+				}).
+				Build()
+			mc := NewModelClient(k8sClient, testNamespace, Options{UseScalePatch: strategy == kubeaiv1.ScaleUpdateStrategyPatch})
+			ctx := context.Background()
+			key := client.ObjectKey{Namespace: testNamespace, Name: "my-model"}
+
+			// Another operator that keeps modifying the Model.
+			done := make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; ; i++ {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					m := &kubeaiv1.Model{}
+					if err := k8sClient.Get(ctx, key, m); err != nil {
+						continue
+					}
+					m.Labels = map[string]string{"generation": strconv.Itoa(i)}
+					_ = k8sClient.Update(ctx, m)
+				}
+			}()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m := &kubeaiv1.Model{}
+				if err := k8sClient.Get(ctx, key, m); err != nil {
+					b.Fatal(err)
+				}
+				// Conflicts that persist after the retries are counted above.
+				_ = mc.Scale(ctx, m, int32(i%2+2), 0, "benchmark")
+			}
+			b.StopTimer()
+			close(done)
+			wg.Wait()
+			b.ReportMetric(float64(conflicts.Load())/float64(writes.Load()), "conflicts/write")
+		})
+	}
+}
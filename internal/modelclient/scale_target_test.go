@@ -0,0 +1,319 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestParseScaleTargetRef(t *testing.T) {
+	cases := map[string]struct {
+		ref     string
+		expGVK  schema.GroupVersionKind
+		expName string
+		expErr  bool
+	}{
+		"deployment": {
+			ref:     "apps/v1/Deployment/my-deployment",
+			expGVK:  schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			expName: "my-deployment",
+		},
+		"core group": {
+			ref:     "v1/ReplicationController/my-rc",
+			expGVK:  schema.GroupVersionKind{Version: "v1", Kind: "ReplicationController"},
+			expName: "my-rc",
+		},
+		"missing kind and name": {
+			ref:    "apps/v1",
+			expErr: true,
+		},
+		"empty part": {
+			ref:    "apps/v1//my-deployment",
+			expErr: true,
+		},
+		"too many parts": {
+			ref:    "a/b/c/d/e",
+			expErr: true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			gvk, objName, err := parseScaleTargetRef(c.ref)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expGVK, gvk)
+			require.Equal(t, c.expName, objName)
+		})
+	}
+}
+
+func TestParseScaleTargetWeight(t *testing.T) {
+	ref, weight, err := parseScaleTargetWeight("apps/v1/Deployment/a")
+	require.NoError(t, err)
+	require.Equal(t, "apps/v1/Deployment/a", ref)
+	require.Equal(t, 1, weight)
+
+	ref, weight, err = parseScaleTargetWeight("apps/v1/Deployment/a=3")
+	require.NoError(t, err)
+	require.Equal(t, "apps/v1/Deployment/a", ref)
+	require.Equal(t, 3, weight)
+
+	_, _, err = parseScaleTargetWeight("apps/v1/Deployment/a=0")
+	require.Error(t, err)
+	_, _, err = parseScaleTargetWeight("apps/v1/Deployment/a=x")
+	require.Error(t, err)
+}
+
+func TestDistributeReplicas(t *testing.T) {
+	cases := map[string]struct {
+		replicas int32
+		weights  []int
+		exp      []int32
+	}{
+		"zero":            {replicas: 0, weights: []int{1, 1}, exp: []int32{0, 0}},
+		"even":            {replicas: 4, weights: []int{1, 1}, exp: []int32{2, 2}},
+		"remainder tie":   {replicas: 3, weights: []int{1, 1}, exp: []int32{2, 1}},
+		"weighted":        {replicas: 6, weights: []int{2, 1}, exp: []int32{4, 2}},
+		"largest remain":  {replicas: 5, weights: []int{1, 3}, exp: []int32{1, 4}},
+		"fewer than many": {replicas: 1, weights: []int{1, 2, 1}, exp: []int32{0, 1, 0}},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.exp, distributeReplicas(c.replicas, c.weights))
+		})
+	}
+}
+
+func TestWeightedScaleTargetSkipsUnchangedTargets(t *testing.T) {
+	ctx := context.Background()
+	primary := &testScaleTarget{replicas: 2}
+	failover := &testScaleTarget{replicas: 1}
+	target := &weightedScaleTarget{targets: []ScaleTarget{primary, failover}, weights: []int{2, 1}}
+
+	total, err := target.GetReplicas(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), total)
+
+	require.NoError(t, target.SetReplicas(ctx, 4))
+	require.Equal(t, int32(3), primary.replicas)
+	require.Equal(t, 1, primary.sets)
+	require.Equal(t, int32(1), failover.replicas)
+	require.Equal(t, 0, failover.sets, "target that already has its share should not be updated")
+}
+
+type testScaleTarget struct {
+	replicas int32
+	sets     int
+}
+
+func (t *testScaleTarget) GetReplicas(ctx context.Context) (int32, error) {
+	return t.replicas, nil
+}
+
+func (t *testScaleTarget) SetReplicas(ctx context.Context, replicas int32) error {
+	t.replicas = replicas
+	t.sets++
+	return nil
+}
+
+func TestReplicasScaleTargetStrategy(t *testing.T) {
+	ctx := context.Background()
+
+	workload := &unstructured.Unstructured{}
+	workload.SetGroupVersionKind(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Workload"})
+	workload.SetNamespace(testNamespace)
+	workload.SetName("my-workload")
+	require.NoError(t, unstructured.SetNestedField(workload.Object, int64(1), "spec", "replicas"))
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{})
+	m.Annotations = map[string]string{
+		kubeaiv1.ModelScaleTargetAnnotation:         "example.com/v1/Workload/my-workload",
+		kubeaiv1.ModelScaleTargetStrategyAnnotation: kubeaiv1.ScaleTargetStrategyReplicas,
+	}
+	mc, k8sClient := newTestModelClient(t, m, workload)
+
+	target, err := mc.scaleTargetFor(m)
+	require.NoError(t, err)
+	require.IsType(t, &objectReplicasTarget{}, target)
+	replicas, err := target.GetReplicas(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), replicas)
+
+	require.NoError(t, target.SetReplicas(ctx, 3))
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(workload.GroupVersionKind())
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(workload), got))
+	replicas64, _, err := unstructured.NestedInt64(got.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), replicas64)
+
+	m.Annotations[kubeaiv1.ModelScaleTargetStrategyAnnotation] = "unknown"
+	_, err = mc.scaleTargetFor(m)
+	require.Error(t, err)
+}
+
+func TestPausedScaleTarget(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	deployment := &unstructured.Unstructured{}
+	deployment.SetGroupVersionKind(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+	deployment.SetNamespace(testNamespace)
+	deployment.SetName("my-deployment")
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, int64(1), "spec", "replicas"))
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, true, "spec", "paused"))
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{MaxReplicas: ptr.To[int32](5)})
+	m.Annotations = map[string]string{
+		kubeaiv1.ModelScaleTargetAnnotation:         "apps/v1/Deployment/my-deployment",
+		kubeaiv1.ModelScaleTargetStrategyAnnotation: kubeaiv1.ScaleTargetStrategyReplicas,
+	}
+	mc, k8sClient := newTestModelClient(t, m, deployment)
+
+	getReplicas := func() int64 {
+		got := &unstructured.Unstructured{}
+		got.SetGroupVersionKind(deployment.GroupVersionKind())
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(deployment), got))
+		replicas, _, err := unstructured.NestedInt64(got.Object, "spec", "replicas")
+		require.NoError(t, err)
+		return replicas
+	}
+
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "test"))
+	require.Equal(t, int64(1), getReplicas(), "paused scale targets should not be scaled")
+	snapshot, ok := mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.True(t, snapshot.TargetPaused)
+
+	require.NoError(t, k8sClient.Patch(ctx, deployment, client.RawPatch(types.MergePatchType, []byte(`{"spec":{"paused":false}}`))))
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "test"))
+	require.Equal(t, int64(3), getReplicas(), "scaling should resume once the scale target is unpaused")
+	snapshot, _ = mc.ScalerSnapshot(m.Name)
+	require.False(t, snapshot.TargetPaused)
+
+	// The paused state is read from the object that was fetched for its replicas.
+	var gets int
+	counting := interceptor.NewClient(k8sClient.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if key.Name == deployment.GetName() {
+				gets++
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+	mc = NewModelClient(counting, testNamespace, Options{})
+	require.NoError(t, mc.Scale(ctx, m, 4, 0, "test"))
+	require.Equal(t, int64(4), getReplicas())
+	require.Equal(t, 1, gets)
+}
+
+func TestScaleUpdateStrategy(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		useScalePatch bool
+		annotation    string
+		expPatch      bool
+		expErr        bool
+	}{
+		"default":            {},
+		"system-wide patch":  {useScalePatch: true, expPatch: true},
+		"annotation patch":   {annotation: kubeaiv1.ScaleUpdateStrategyPatch, expPatch: true},
+		"annotation update":  {useScalePatch: true, annotation: kubeaiv1.ScaleUpdateStrategyUpdate},
+		"invalid annotation": {annotation: "apply", expErr: true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)})
+			if c.annotation != "" {
+				m.Annotations = map[string]string{kubeaiv1.ModelScaleUpdateStrategyAnnotation: c.annotation}
+			}
+			mc, k8sClient := newTestModelClientWithOptions(t, Options{UseScalePatch: c.useScalePatch}, m)
+
+			target, err := mc.scaleTargetFor(m)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expPatch, target.(*modelScaleTarget).patch)
+
+			require.NoError(t, mc.Scale(ctx, m, 3, 0, "scale up"))
+			require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+		})
+	}
+}
+
+func TestSelectorScaleTarget(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	deployment := func(name string, created time.Time) *unstructured.Unstructured {
+		d := &unstructured.Unstructured{}
+		d.SetGroupVersionKind(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+		d.SetNamespace(testNamespace)
+		d.SetName(name)
+		d.SetLabels(map[string]string{"app": "my-model-server"})
+		d.SetCreationTimestamp(metav1.NewTime(created))
+		require.NoError(t, unstructured.SetNestedField(d.Object, int64(1), "spec", "replicas"))
+		return d
+	}
+	now := time.Now()
+	previous := deployment("my-model-server-abc12", now.Add(-time.Hour))
+	current := deployment("my-model-server-def34", now)
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{MaxReplicas: ptr.To[int32](5)})
+	m.Annotations = map[string]string{
+		kubeaiv1.ModelScaleTargetAnnotation:         "apps/v1/Deployment",
+		kubeaiv1.ModelScaleTargetSelectorAnnotation: "app=my-model-server",
+		kubeaiv1.ModelScaleTargetStrategyAnnotation: kubeaiv1.ScaleTargetStrategyReplicas,
+	}
+	mc, k8sClient := newTestModelClient(t, m, previous)
+
+	getReplicas := func(obj *unstructured.Unstructured) int64 {
+		t.Helper()
+		got := &unstructured.Unstructured{}
+		got.SetGroupVersionKind(obj.GroupVersionKind())
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), got))
+		replicas, _, err := unstructured.NestedInt64(got.Object, "spec", "replicas")
+		require.NoError(t, err)
+		return replicas
+	}
+
+	require.NoError(t, mc.Scale(ctx, m, 2, 0, "test"))
+	require.Equal(t, int64(2), getReplicas(previous))
+
+	// The newest matching object is scaled once it replaces the previous one.
+	require.NoError(t, k8sClient.Create(ctx, current))
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "test"))
+	require.Equal(t, int64(3), getReplicas(current))
+	require.Equal(t, int64(2), getReplicas(previous))
+
+	key, ok := mc.scaleTargetKey(m)
+	require.True(t, ok)
+	require.Equal(t, "apps/v1/Deployment?app=my-model-server", key)
+
+	m.Annotations[kubeaiv1.ModelScaleTargetSelectorAnnotation] = "app=other"
+	err := mc.Scale(ctx, m, 3, 0, "test")
+	require.ErrorIs(t, err, ErrScaleNotFound)
+
+	m.Annotations[kubeaiv1.ModelScaleTargetAnnotation] = "apps/v1/Deployment/my-model-server"
+	_, err = mc.scaleTargetFor(m)
+	require.Error(t, err)
+}
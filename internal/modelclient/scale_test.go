@@ -0,0 +1,503 @@
+package modelclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/clock"
+	testingclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const testNamespace = "default"
+
+func TestScaleAtLeastOneReplica(t *testing.T) {
+	cases := map[string]struct {
+		spec   kubeaiv1.ModelSpec
+		expect int32
+	}{
+		"default": {
+			spec:   kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0)},
+			expect: 1,
+		},
+		"already scaled": {
+			spec:   kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2)},
+			expect: 2,
+		},
+		"scale from zero replicas": {
+			spec:   kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), ScaleFromZeroReplicas: ptr.To[int32](3)},
+			expect: 3,
+		},
+		"scale from zero replicas above max": {
+			spec:   kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), ScaleFromZeroReplicas: ptr.To[int32](3), MaxReplicas: ptr.To[int32](2)},
+			expect: 2,
+		},
+		"autoscaling disabled": {
+			spec:   kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), AutoscalingDisabled: true},
+			expect: 0,
+		},
+		"zero scale from zero delay": {
+			spec:   kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), ScaleFromZeroDelaySeconds: ptr.To[int64](0)},
+			expect: 1,
+		},
+		"zero scale from zero delay with requests": {
+			spec:   kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), ScaleFromZeroDelaySeconds: ptr.To[int64](0), ScaleFromZeroRequests: ptr.To[int32](3)},
+			expect: 1,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			metricstest.Init(t)
+			m := testModel("my-model", c.spec)
+			mc, k8sClient := newTestModelClient(t, m)
+
+			require.NoError(t, mc.ScaleAtLeastOneReplica(context.Background(), m.Name))
+			require.Equal(t, c.expect, modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+		})
+	}
+}
+
+func TestEnforceReplicaBounds(t *testing.T) {
+	cases := map[string]struct {
+		spec     kubeaiv1.ModelSpec
+		replicas int32
+		exp      int32
+	}{
+		"within bounds": {
+			spec:     kubeaiv1.ModelSpec{MinReplicas: 1, MaxReplicas: ptr.To[int32](5)},
+			replicas: 3,
+			exp:      3,
+		},
+		"above max": {
+			spec:     kubeaiv1.ModelSpec{MinReplicas: 1, MaxReplicas: ptr.To[int32](5)},
+			replicas: 7,
+			exp:      5,
+		},
+		"below min": {
+			spec:     kubeaiv1.ModelSpec{MinReplicas: 2},
+			replicas: 1,
+			exp:      2,
+		},
+		"idle without idle min": {
+			spec:     kubeaiv1.ModelSpec{MinReplicas: 3},
+			replicas: 0,
+			exp:      3,
+		},
+		"idle with idle min": {
+			spec:     kubeaiv1.ModelSpec{MinReplicas: 3, IdleMinReplicas: ptr.To[int32](1)},
+			replicas: 0,
+			exp:      1,
+		},
+		"active with idle min": {
+			spec:     kubeaiv1.ModelSpec{MinReplicas: 3, IdleMinReplicas: ptr.To[int32](1)},
+			replicas: 1,
+			exp:      3,
+		},
+		"idle with standby replicas": {
+			spec:     kubeaiv1.ModelSpec{MinReplicas: 0, IdleMinReplicas: ptr.To[int32](1)},
+			replicas: 0,
+			exp:      1,
+		},
+		"active with standby replicas": {
+			spec:     kubeaiv1.ModelSpec{MinReplicas: 0, IdleMinReplicas: ptr.To[int32](1)},
+			replicas: 2,
+			exp:      2,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.exp, enforceReplicaBounds(c.replicas, &kubeaiv1.Model{Spec: c.spec}))
+		})
+	}
+}
+
+func testModel(name string, spec kubeaiv1.ModelSpec) *kubeaiv1.Model {
+	return &kubeaiv1.Model{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec:       spec,
+	}
+}
+
+// newTestModelClient returns a ModelClient backed by a fake Kubernetes client
+// (see modelclienttest.NewClientBuilder).
+func newTestModelClient(t *testing.T, objs ...client.Object) (*ModelClient, client.Client) {
+	t.Helper()
+	return newTestModelClientWithOptions(t, Options{}, objs...)
+}
+
+func newTestModelClientWithOptions(t *testing.T, opts Options, objs ...client.Object) (*ModelClient, client.Client) {
+	t.Helper()
+	k8sClient := modelclienttest.NewClientBuilder(t, objs...).
+		WithIndex(&kubeaiv1.Model{}, modelCapabilitiesIndex, capabilitiesIndexer(kubeaiv1.AnnotationDomains(opts.AnnotationDomains))).
+		Build()
+
+	return NewModelClient(k8sClient, testNamespace, opts), k8sClient
+}
+
+// testClock is a fake clock for the ModelClient. The FakeClock runs AfterFunc
+// callbacks while holding its lock, so they are started in a goroutine to be
+// able to read the time.
+type testClock struct {
+	*testingclock.FakeClock
+}
+
+func newTestClock() testClock {
+	return testClock{testingclock.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))}
+}
+
+func (c testClock) AfterFunc(d time.Duration, f func()) clock.Timer {
+	return c.FakeClock.AfterFunc(d, func() { go f() })
+}
+
+func TestIsSaturated(t *testing.T) {
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2), MaxReplicas: ptr.To[int32](3)})
+	mc, k8sClient := newTestModelClient(t, m)
+	// Another KubeAI instance that serves requests.
+	other := NewModelClient(k8sClient, testNamespace, Options{})
+	get := func() *kubeaiv1.Model {
+		t.Helper()
+		m := &kubeaiv1.Model{}
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: "my-model"}, m))
+		return m
+	}
+
+	require.False(t, other.IsSaturated(get()))
+
+	// Scaling up to max is not saturated.
+	require.NoError(t, mc.Scale(ctx, get(), 3, 0, "test"))
+	require.False(t, other.IsSaturated(get()))
+
+	// Wanting more than max while at max is saturated.
+	require.NoError(t, mc.Scale(ctx, get(), 5, 0, "test"))
+	require.True(t, other.IsSaturated(get()), "all instances should observe saturation")
+	snapshot, ok := mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.True(t, snapshot.Saturated)
+
+	// Demand dropping back within bounds clears saturation.
+	require.NoError(t, mc.Scale(ctx, get(), 3, 0, "test"))
+	require.False(t, other.IsSaturated(get()))
+	require.NotContains(t, get().Annotations, kubeaiv1.ModelSaturatedAnnotation)
+}
+
+func TestScaleAtLeastOneReplicaWithScaleFromZeroDelay(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), ScaleFromZeroDelaySeconds: ptr.To[int64](60)})
+	mc, k8sClient := newTestModelClient(t, m)
+
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "a single request should not trigger a scale up")
+
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "a second request within the window should trigger a scale up")
+}
+
+func TestLastScaleReason(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MinReplicas: 2, MaxReplicas: ptr.To[int32](4)})
+	mc, k8sClient := newTestModelClient(t, m)
+
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	snapshot, ok := mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.Equal(t, "request received while scaled to zero (min replicas floor)", snapshot.LastScaleReason)
+	require.False(t, snapshot.LastScaleTime.IsZero())
+
+	m.Spec.Replicas = ptr.To(modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	require.NoError(t, mc.Scale(ctx, m, 10, 0, "average active requests 10.00 / target requests 1"))
+	snapshot, _ = mc.ScalerSnapshot(m.Name)
+	require.Equal(t, "average active requests 10.00 / target requests 1 (max replicas ceiling)", snapshot.LastScaleReason)
+}
+
+func TestScaleNoopMetric(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2), MaxReplicas: ptr.To[int32](4)})
+	mc, k8sClient := newTestModelClient(t, m)
+
+	require.NoError(t, mc.Scale(ctx, m, 2, 0, "no change"))
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "scale up"))
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	metricstest.RequireScaleNoopsMetric(t, metricstest.Collect(t), m.Name, 1)
+}
+
+func TestScaleReplicasLagMetric(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubeaiv1.AddToScheme(scheme))
+	failing := false
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)})
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(m).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if failing {
+					return errors.New("unavailable")
+				}
+				return modelclienttest.UpdateModelScale(ctx, c, subResourceName, obj, opts...)
+			},
+		}).
+		Build()
+	mc := NewModelClient(k8sClient, testNamespace, Options{})
+
+	require.NoError(t, mc.Scale(ctx, m, 2, 0, "scale up"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	metricstest.RequireReplicasLagMetric(t, metricstest.Collect(t), m.Name, 0)
+
+	// Failed writes do not change the lag.
+	failing = true
+	require.Error(t, mc.Scale(ctx, m, 4, 0, "scale up"))
+	metricstest.RequireReplicasLagMetric(t, metricstest.Collect(t), m.Name, 0)
+
+	// Deferred writes are reported until they are applied.
+	failing = false
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(m), m))
+	require.NoError(t, mc.PauseAutoscaling(ctx))
+	require.NoError(t, mc.Scale(ctx, m, 4, 0, "scale up"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	metricstest.RequireReplicasLagMetric(t, metricstest.Collect(t), m.Name, 2)
+
+	require.NoError(t, mc.ResumeAutoscaling(ctx))
+	require.Equal(t, int32(4), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	metricstest.RequireReplicasLagMetric(t, metricstest.Collect(t), m.Name, 0)
+}
+
+func TestScaleRetriesOnConflict(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubeaiv1.AddToScheme(scheme))
+	var updates int
+	conflictAlways := false
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](10)})).
+		WithInterceptorFuncs(interceptor.Funcs{
+			// Reject updates of a stale Model like the API server would.
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				updates++
+				current := &kubeaiv1.Model{}
+				if err := c.Get(ctx, client.ObjectKeyFromObject(obj), current); err != nil {
+					return err
+				}
+				if conflictAlways || current.ResourceVersion != obj.GetResourceVersion() {
+					return apierrors.NewConflict(kubeaiv1.GroupVersion.WithResource("models").GroupResource(), obj.GetName(), errors.New("the object has been modified"))
+				}
+				return modelclienttest.UpdateModelScale(ctx, c, subResourceName, obj, opts...)
+			},
+		}).
+		Build()
+	mc := NewModelClient(k8sClient, testNamespace, Options{})
+
+	m := &kubeaiv1.Model{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: "my-model"}, m))
+
+	// Modify the Model after it was read so that the first update conflicts.
+	changed := m.DeepCopy()
+	changed.Labels = map[string]string{"changed": "true"}
+	require.NoError(t, k8sClient.Update(ctx, changed))
+
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "scale up"))
+	require.Equal(t, int32(3), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	require.Equal(t, 2, updates, "the update should be retried once with the re-fetched Model")
+
+	// Conflicts that persist are returned after a bounded number of attempts.
+	conflictAlways = true
+	updates = 0
+	m.Spec.Replicas = ptr.To[int32](3)
+	err := mc.Scale(ctx, m, 5, 0, "scale up")
+	require.ErrorIs(t, err, ErrScaleConflict)
+	require.Equal(t, retry.DefaultRetry.Steps, updates)
+}
+
+func TestScaleAtLeastOneReplicaWithScaleFromZeroRequests(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{
+		Replicas:                  ptr.To[int32](0),
+		ScaleFromZeroDelaySeconds: ptr.To[int64](60),
+		ScaleFromZeroRequests:     ptr.To[int32](3),
+	})
+	mc, k8sClient := newTestModelClient(t, m)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+		require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "request %d should not trigger a scale up", i+1)
+	}
+
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "the third request within the window should trigger a scale up")
+}
+
+func TestScaleStandbyReplicas(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	// Models with standby replicas are scaled down to them instead of zero.
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2), MinReplicas: 0, IdleMinReplicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](3)})
+	mc, k8sClient := newTestModelClient(t, m)
+
+	require.NoError(t, mc.Scale(ctx, m, 0, 0, "no active requests"))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	snapshot, ok := mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.Equal(t, "no active requests (idle min replicas floor)", snapshot.LastScaleReason)
+
+	// Requests are served by the standby replica without a scale from zero.
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
+
+func TestEnforceMinReplicas(t *testing.T) {
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MinReplicas: 2})
+	mc, k8sClient := newTestModelClient(t, m)
+
+	require.NoError(t, mc.EnforceMinReplicas(ctx, m))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	snapshot, ok := mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.Equal(t, "replicas below the configured minimum (min replicas floor)", snapshot.LastScaleReason)
+
+	// Models above the floor are not scaled down.
+	above := testModel("above", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](3), MinReplicas: 2, MaxReplicas: ptr.To[int32](2)})
+	idle := testModel("idle", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MinReplicas: 2, IdleMinReplicas: ptr.To[int32](0)})
+	disabled := testModel("disabled", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MinReplicas: 2, AutoscalingDisabled: true})
+	mc, k8sClient = newTestModelClient(t, above, idle, disabled)
+	for _, m := range []*kubeaiv1.Model{above, idle, disabled} {
+		require.NoError(t, mc.EnforceMinReplicas(ctx, m))
+		require.Equal(t, *m.Spec.Replicas, modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), m.Name)
+	}
+}
+
+func TestEnforceMinReplicasRepeated(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	// Repeated reconciles do not replace a newer scale of the autoscaler that
+	// was deferred while autoscaling is paused, until the record expires.
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MinReplicas: 2, MaxReplicas: ptr.To[int32](5)})
+	clk := newTestClock()
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{Clock: clk}, m)
+	pausedReplicas := func() *int32 {
+		t.Helper()
+		snapshot, ok := mc.ScalerSnapshot(m.Name)
+		require.True(t, ok)
+		return snapshot.PausedReplicas
+	}
+	require.NoError(t, mc.PauseAutoscaling(ctx))
+	require.NoError(t, mc.EnforceMinReplicas(ctx, m))
+	require.Equal(t, ptr.To[int32](2), pausedReplicas())
+	require.NoError(t, mc.Scale(ctx, m, 4, 0, "autoscaler"))
+	require.NoError(t, mc.EnforceMinReplicas(ctx, m))
+	require.Equal(t, ptr.To[int32](4), pausedReplicas())
+	clk.Step(enforcedMinReplicasTTL)
+	require.NoError(t, mc.EnforceMinReplicas(ctx, m))
+	require.Equal(t, ptr.To[int32](2), pausedReplicas())
+	require.NoError(t, mc.ResumeAutoscaling(ctx))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	// Scale ups that are neither written nor deferred are enforced by the next
+	// reconcile.
+	deployment := &unstructured.Unstructured{}
+	deployment.SetGroupVersionKind(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+	deployment.SetNamespace(testNamespace)
+	deployment.SetName("my-deployment")
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, int64(0), "spec", "replicas"))
+	require.NoError(t, unstructured.SetNestedField(deployment.Object, true, "spec", "paused"))
+	m = testModel("paused", kubeaiv1.ModelSpec{MinReplicas: 2, MaxReplicas: ptr.To[int32](5)})
+	m.Annotations = map[string]string{
+		kubeaiv1.ModelScaleTargetAnnotation:         "apps/v1/Deployment/my-deployment",
+		kubeaiv1.ModelScaleTargetStrategyAnnotation: kubeaiv1.ScaleTargetStrategyReplicas,
+	}
+	mc, k8sClient = newTestModelClient(t, m, deployment)
+	require.NoError(t, mc.EnforceMinReplicas(ctx, m))
+	require.NoError(t, k8sClient.Patch(ctx, deployment, client.RawPatch(types.MergePatchType, []byte(`{"spec":{"paused":false}}`))))
+	require.NoError(t, mc.EnforceMinReplicas(ctx, m))
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(deployment.GroupVersionKind())
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(deployment), got))
+	replicas, _, err := unstructured.NestedInt64(got.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), replicas)
+
+	// Repeated reconciles do not replace a newer deferred scale of the autoscaler.
+	const interval = time.Minute
+	m = testModel("debounced", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MinReplicas: 2, MaxReplicas: ptr.To[int32](5)})
+	clk = newTestClock()
+	mc, k8sClient = newTestModelClientWithOptions(t, Options{ScaleDebounceInterval: interval, Clock: clk}, m)
+	target, _, err := mc.getReplicas(ctx, m)
+	require.NoError(t, err)
+	require.NoError(t, mc.updateScale(ctx, m, target, 0, "first", ScaledToZeroIdle, ScaleActorAuto))
+
+	require.NoError(t, mc.EnforceMinReplicas(ctx, m))
+	require.NoError(t, mc.Scale(ctx, m, 4, 0, "autoscaler"))
+	require.NoError(t, mc.EnforceMinReplicas(ctx, m))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+
+	clk.Step(interval)
+	require.Eventually(t, func() bool {
+		return modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name) == 4
+	}, time.Second, time.Millisecond)
+
+	// Scale ups are enforced again once the replicas were written.
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(m), m))
+	m.Spec.Replicas = ptr.To[int32](0)
+	require.NoError(t, k8sClient.Update(ctx, m))
+	clk.Step(interval)
+	require.NoError(t, mc.EnforceMinReplicas(ctx, m))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
+
+func TestScaleWithScaleFromZeroDelay(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MaxReplicas: ptr.To[int32](3), ScaleFromZeroDelaySeconds: ptr.To[int64](60)})
+	mc, k8sClient := newTestModelClient(t, m)
+
+	// A single request waits for the model (and is counted as active by the
+	// autoscaler) without scaling it up from zero.
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.NoError(t, mc.Scale(ctx, m, 1, 0, "test"))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "the autoscaler should not bypass the delay")
+
+	// The second request within the window scales the model up, after which
+	// the autoscaler scales it with the active requests.
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	m.Spec.Replicas = ptr.To[int32](1)
+	require.NoError(t, mc.Scale(ctx, m, 2, 0, "test"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
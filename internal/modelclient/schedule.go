@@ -0,0 +1,163 @@
+package modelclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+)
+
+// warmupWindow is a window of the kubeaiv1.ModelWarmupScheduleAnnotation
+// during which the model is kept at minReplicas or more.
+type warmupWindow struct {
+	// days are the weekdays that the window starts on.
+	days [7]bool
+	// start and end are the offsets since midnight (UTC). Windows with an end
+	// before their start continue on the next day.
+	start, end  time.Duration
+	minReplicas int32
+}
+
+func (w warmupWindow) contains(t time.Time) bool {
+	t = t.UTC()
+	offset := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	day := t.Weekday()
+	if w.start < w.end {
+		return w.days[day] && offset >= w.start && offset < w.end
+	}
+	if offset >= w.start {
+		return w.days[day]
+	}
+	// The part of the window after midnight belongs to the previous day.
+	return offset < w.end && w.days[(day+6)%7]
+}
+
+// withWarmupSchedule returns the model with the min replicas of its warmup
+// schedule (see kubeaiv1.ModelWarmupScheduleAnnotation) if they are higher
+// than its own at the current time. The idle min replicas are raised as well,
+// so that the model is kept warm while it receives no requests.
+func (c *ModelClient) withWarmupSchedule(model *kubeaiv1.Model) (*kubeaiv1.Model, error) {
+	key, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelWarmupScheduleAnnotationName)
+	if !ok {
+		return model, nil
+	}
+	windows, err := parseWarmupSchedule(value)
+	if err != nil {
+		return model, fmt.Errorf("invalid %s annotation %q: %w", key, value, err)
+	}
+
+	min := scheduledMinReplicas(windows, c.clock.Now(), model.Spec.MinReplicas)
+	if min <= model.Spec.MinReplicas {
+		return model, nil
+	}
+	model = model.DeepCopy()
+	model.Spec.MinReplicas = min
+	if idle := model.Spec.IdleMinReplicas; idle != nil && *idle < min {
+		model.Spec.IdleMinReplicas = &min
+	}
+	return model, nil
+}
+
+// scheduledMinReplicas returns the highest min replicas of the windows that
+// contain the given time, or base if none of them is higher.
+func scheduledMinReplicas(windows []warmupWindow, now time.Time, base int32) int32 {
+	min := base
+	for _, w := range windows {
+		if w.minReplicas > min && w.contains(now) {
+			min = w.minReplicas
+		}
+	}
+	return min
+}
+
+// parseWarmupSchedule parses a comma-separated list of windows of the form
+// "[<day>[-<day>]] <HH:MM>-<HH:MM>=<min replicas>". Windows without days apply
+// every day.
+func parseWarmupSchedule(value string) ([]warmupWindow, error) {
+	var windows []warmupWindow
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		spec, minStr, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("window %q: expected [<days>] <HH:MM>-<HH:MM>=<min replicas>", entry)
+		}
+		min, err := strconv.ParseInt(strings.TrimSpace(minStr), 10, 32)
+		if err != nil || min < 0 {
+			return nil, fmt.Errorf("window %q: min replicas must be a non-negative integer", entry)
+		}
+		w := warmupWindow{minReplicas: int32(min)}
+
+		fields := strings.Fields(spec)
+		switch len(fields) {
+		case 1:
+			for i := range w.days {
+				w.days[i] = true
+			}
+		case 2:
+			if w.days, err = parseWeekdays(fields[0]); err != nil {
+				return nil, fmt.Errorf("window %q: %w", entry, err)
+			}
+		default:
+			return nil, fmt.Errorf("window %q: expected [<days>] <HH:MM>-<HH:MM>=<min replicas>", entry)
+		}
+
+		startStr, endStr, ok := strings.Cut(fields[len(fields)-1], "-")
+		if !ok {
+			return nil, fmt.Errorf("window %q: expected <HH:MM>-<HH:MM>", entry)
+		}
+		if w.start, err = parseTimeOfDay(startStr); err != nil {
+			return nil, fmt.Errorf("window %q: %w", entry, err)
+		}
+		if w.end, err = parseTimeOfDay(endStr); err != nil {
+			return nil, fmt.Errorf("window %q: %w", entry, err)
+		}
+		if w.start == w.end || w.start == 24*time.Hour {
+			return nil, fmt.Errorf("window %q: start and end must differ", entry)
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseWeekdays parses a day ("Mon") or a range of days ("Mon-Fri", "Fri-Mon").
+func parseWeekdays(value string) ([7]bool, error) {
+	var days [7]bool
+	firstStr, lastStr, isRange := strings.Cut(value, "-")
+	if !isRange {
+		lastStr = firstStr
+	}
+	first, ok := weekdays[strings.ToLower(firstStr)]
+	if !ok {
+		return days, fmt.Errorf("invalid day %q", firstStr)
+	}
+	last, ok := weekdays[strings.ToLower(lastStr)]
+	if !ok {
+		return days, fmt.Errorf("invalid day %q", lastStr)
+	}
+	for d := first; ; d = (d + 1) % 7 {
+		days[d] = true
+		if d == last {
+			break
+		}
+	}
+	return days, nil
+}
+
+// parseTimeOfDay parses "HH:MM" (up to "24:00") into the offset since midnight.
+func parseTimeOfDay(value string) (time.Duration, error) {
+	hourStr, minuteStr, ok := strings.Cut(value, ":")
+	hour, hourErr := strconv.Atoi(hourStr)
+	minute, minuteErr := strconv.Atoi(minuteStr)
+	if !ok || len(minuteStr) != 2 || hourErr != nil || minuteErr != nil ||
+		hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %q: expected HH:MM", value)
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
@@ -0,0 +1,100 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	"k8s.io/utils/ptr"
+)
+
+func TestScheduledMinReplicas(t *testing.T) {
+	windows, err := parseWarmupSchedule("Mon-Fri 08:00-18:00=2, Mon-Fri 11:00-14:00=3, Sat-Sun 10:00-16:00=1, Fri-Mon 22:00-06:00=1")
+	require.NoError(t, err)
+
+	// 2024-01-01 is a Monday.
+	at := func(day int, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	cases := map[string]struct {
+		now  time.Time
+		base int32
+		exp  int32
+	}{
+		"before business hours":      {now: at(1, 7, 59), exp: 0},
+		"business hours":             {now: at(1, 8, 0), exp: 2},
+		"highest applicable window":  {now: at(3, 12, 0), exp: 3},
+		"end is exclusive":           {now: at(5, 18, 0), exp: 0},
+		"weekend":                    {now: at(6, 12, 0), exp: 1},
+		"base min is higher":         {now: at(6, 12, 0), base: 2, exp: 2},
+		"overnight window":           {now: at(5, 23, 0), exp: 1},
+		"overnight window next day":  {now: at(6, 5, 59), exp: 1},
+		"overnight window ends":      {now: at(6, 6, 0), exp: 0},
+		"overnight window not begun": {now: at(3, 5, 0), exp: 0},
+		"overnight window from mon":  {now: at(2, 5, 0), exp: 1},
+		"other time zone":            {now: at(1, 9, 0).In(time.FixedZone("UTC-10", -10*60*60)), exp: 2},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.exp, scheduledMinReplicas(windows, c.now, c.base))
+		})
+	}
+}
+
+func TestParseWarmupSchedule(t *testing.T) {
+	cases := map[string]struct {
+		value   string
+		expDays [7]bool
+		expErr  bool
+	}{
+		"every day":       {value: "00:00-24:00=1", expDays: [7]bool{true, true, true, true, true, true, true}},
+		"single day":      {value: "wed 09:00-17:00=1", expDays: [7]bool{time.Wednesday: true}},
+		"wrapping days":   {value: "Fri-Mon 09:00-17:00=1", expDays: [7]bool{time.Sunday: true, time.Monday: true, time.Friday: true, time.Saturday: true}},
+		"missing min":     {value: "Mon-Fri 09:00-17:00", expErr: true},
+		"negative min":    {value: "09:00-17:00=-1", expErr: true},
+		"invalid day":     {value: "Monday 09:00-17:00=1", expErr: true},
+		"invalid time":    {value: "9-17=1", expErr: true},
+		"invalid minute":  {value: "09:60-17:00=1", expErr: true},
+		"empty window":    {value: "09:00-09:00=1", expErr: true},
+		"too many fields": {value: "Mon Tue 09:00-17:00=1", expErr: true},
+		"trailing comma":  {value: "09:00-17:00=1,", expErr: true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			windows, err := parseWarmupSchedule(c.value)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, windows, 1)
+			require.Equal(t, c.expDays, windows[0].days)
+		})
+	}
+}
+
+func TestScaleWithWarmupSchedule(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MaxReplicas: ptr.To[int32](5)})
+	m.Annotations = map[string]string{kubeaiv1.ModelWarmupScheduleAnnotation: "Mon-Fri 08:00-18:00=2"}
+	clk := newTestClock() // Monday 00:00 UTC.
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{Clock: clk}, m)
+
+	require.NoError(t, mc.Scale(ctx, m, 0, 0, "idle"))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "off-hours should use the min replicas of the model")
+
+	clk.Step(8 * time.Hour)
+	require.NoError(t, mc.Scale(ctx, m, 0, 0, "idle"))
+	require.Equal(t, int32(2), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name), "business hours should raise the min replicas")
+	snapshot, _ := mc.ScalerSnapshot(m.Name)
+	require.Equal(t, "idle (min replicas floor)", snapshot.LastScaleReason)
+
+	m.Annotations[kubeaiv1.ModelWarmupScheduleAnnotation] = "invalid"
+	require.Error(t, mc.Scale(ctx, m, 0, 0, "idle"))
+}
@@ -0,0 +1,55 @@
+package modelclient
+
+import (
+	"context"
+	"fmt"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// sharedTargetReplicas records the desired number of replicas of the model and
+// returns the highest number of replicas that is desired by another Model that
+// has the same scale target (see kubeaiv1.ModelScaleTargetAnnotation), along with
+// the name of that Model. Models without a scale target annotation never share
+// their target. Returns 0 if no other Model desires replicas of the target.
+func (c *ModelClient) sharedTargetReplicas(ctx context.Context, model *kubeaiv1.Model, desired int32) (int32, string, error) {
+	c.scalerStatesMtx.Lock()
+	c.getScalerState(model.Name).desiredReplicas = &desired
+	c.scalerStatesMtx.Unlock()
+
+	target, ok := c.scaleTargetKey(model)
+	if !ok {
+		return 0, "", nil
+	}
+
+	var models kubeaiv1.ModelList
+	if err := c.client.List(ctx, &models, client.InNamespace(c.namespace)); err != nil {
+		return 0, "", fmt.Errorf("listing models: %w", err)
+	}
+
+	c.scalerStatesMtx.RLock()
+	defer c.scalerStatesMtx.RUnlock()
+
+	var (
+		shared int32
+		by     string
+	)
+	for i := range models.Items {
+		m := &models.Items[i]
+		if m.Name == model.Name || !c.IsManaged(m) {
+			continue
+		}
+		if t, ok := c.scaleTargetKey(m); !ok || t != target {
+			continue
+		}
+		s, ok := c.scalerStates[m.Name]
+		if !ok || s.desiredReplicas == nil {
+			continue
+		}
+		if *s.desiredReplicas > shared {
+			shared, by = *s.desiredReplicas, m.Name
+		}
+	}
+	return shared, by, nil
+}
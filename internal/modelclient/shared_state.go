@@ -0,0 +1,195 @@
+package modelclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Operations such as force scales or pausing autoscaling can be received by any
+// KubeAI instance (i.e. through the admin API or SIGUSR1), while only the leader
+// autoscales.
+// Their state is shared by all instances through keys of the state ConfigMap
+// (see Options.StateConfigMap). Each key is written with its own patch, so
+// instances do not overwrite each other, nor the state of the autoscaler,
+// which is stored in the same ConfigMap.
+// The state is read on every scale operation (i.e. to check if autoscaling is
+// paused), so it is read from a watched copy of the ConfigMap once the watch is
+// set up (see WatchSharedState), instead of from the API server.
+
+// sharedStateEnabled returns true if a state ConfigMap is configured.
+// Otherwise shared state is only kept in the memory of each instance.
+func (c *ModelClient) sharedStateEnabled() bool {
+	return c.stateConfigMap.Name != ""
+}
+
+// WatchSharedState keeps a copy of the state ConfigMap up to date with the
+// informer of ConfigMaps, which shared state is then read from. It has to be
+// called before the informers are started.
+func (c *ModelClient) WatchSharedState(ctx context.Context, informers cache.Informers) error {
+	if !c.sharedStateEnabled() {
+		return nil
+	}
+	informer, err := informers.GetInformer(ctx, &corev1.ConfigMap{})
+	if err != nil {
+		return fmt.Errorf("get ConfigMap informer: %w", err)
+	}
+	observe := func(obj interface{}) {
+		if cm, ok := obj.(*corev1.ConfigMap); ok && client.ObjectKeyFromObject(cm) == c.stateConfigMap {
+			c.observeStateConfigMap(cm)
+		}
+	}
+	if _, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    observe,
+		UpdateFunc: func(_, obj interface{}) { observe(obj) },
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if cm, ok := obj.(*corev1.ConfigMap); ok && client.ObjectKeyFromObject(cm) == c.stateConfigMap {
+				c.forgetStateConfigMap()
+			}
+		},
+	}); err != nil {
+		return fmt.Errorf("watch ConfigMap %q: %w", c.stateConfigMap, err)
+	}
+	c.sharedStateWatched.Store(true)
+	return nil
+}
+
+// observeStateConfigMap updates the watched copy of the state ConfigMap, i.e.
+// with the result of a write of this instance, so that it is read back before
+// the watch event arrives. Watch events of earlier writes that arrive after it
+// are ignored, as they would undo the write (i.e. resume a pause of this
+// instance). No-op unless the state ConfigMap is watched.
+func (c *ModelClient) observeStateConfigMap(cm *corev1.ConfigMap) {
+	if !c.sharedStateWatched.Load() {
+		return
+	}
+	c.watchedStateConfigMapMtx.Lock()
+	defer c.watchedStateConfigMapMtx.Unlock()
+	if c.watchedStateConfigMap != nil && olderResourceVersion(cm.ResourceVersion, c.watchedStateConfigMap.ResourceVersion) {
+		return
+	}
+	c.watchedStateConfigMap = cm.DeepCopy()
+}
+
+// olderResourceVersion returns true if resource version a is older than b.
+// Resource versions are opaque, so false if either is not an integer (as
+// they are with etcd).
+func olderResourceVersion(a, b string) bool {
+	av, err := strconv.ParseUint(a, 10, 64)
+	if err != nil {
+		return false
+	}
+	bv, err := strconv.ParseUint(b, 10, 64)
+	if err != nil {
+		return false
+	}
+	return av < bv
+}
+
+// forgetStateConfigMap drops the watched copy of the state ConfigMap, so that
+// it is read from the API server until the next watch event (i.e. after a
+// write conflicted because the copy was outdated).
+func (c *ModelClient) forgetStateConfigMap() {
+	c.watchedStateConfigMapMtx.Lock()
+	c.watchedStateConfigMap = nil
+	c.watchedStateConfigMapMtx.Unlock()
+}
+
+// getStateConfigMap returns the watched copy of the state ConfigMap, or reads
+// it with the client if there is none. The result must not be modified.
+func (c *ModelClient) getStateConfigMap(ctx context.Context) (*corev1.ConfigMap, error) {
+	c.watchedStateConfigMapMtx.RLock()
+	cm := c.watchedStateConfigMap
+	c.watchedStateConfigMapMtx.RUnlock()
+	if cm != nil {
+		return cm, nil
+	}
+	cm = &corev1.ConfigMap{}
+	if err := c.client.Get(ctx, c.stateConfigMap, cm); err != nil {
+		return nil, fmt.Errorf("get ConfigMap %q: %w", c.stateConfigMap, err)
+	}
+	c.observeStateConfigMap(cm)
+	return cm, nil
+}
+
+// getSharedState unmarshals the value of the given key of the state ConfigMap
+// into v. Returns false if the key is not set or no state ConfigMap is configured.
+func (c *ModelClient) getSharedState(ctx context.Context, key string, v any) (bool, error) {
+	if !c.sharedStateEnabled() {
+		return false, nil
+	}
+	cm, err := c.getStateConfigMap(ctx)
+	if err != nil {
+		return false, err
+	}
+	data, ok := cm.Data[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		return false, fmt.Errorf("unmarshalling key %q of ConfigMap %q: %w", key, c.stateConfigMap, err)
+	}
+	return true, nil
+}
+
+// listSharedState returns the values of all keys of the state ConfigMap with
+// the given prefix, by key. Returns nil if no state ConfigMap is configured.
+func (c *ModelClient) listSharedState(ctx context.Context, prefix string) (map[string]string, error) {
+	if !c.sharedStateEnabled() {
+		return nil, nil
+	}
+	cm, err := c.getStateConfigMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for key, value := range cm.Data {
+		if strings.HasPrefix(key, prefix) {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// setSharedState stores v as the value of the given key of the state ConfigMap,
+// or removes the key if v is nil. No-op if no state ConfigMap is configured.
+func (c *ModelClient) setSharedState(ctx context.Context, key string, v any) error {
+	if !c.sharedStateEnabled() {
+		return nil
+	}
+	var value any
+	if v != nil {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("marshalling key %q: %w", key, err)
+		}
+		value = string(data)
+	}
+	patch, err := json.Marshal(map[string]any{"data": map[string]any{key: value}})
+	if err != nil {
+		return fmt.Errorf("marshalling patch: %w", err)
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: c.stateConfigMap.Namespace,
+			Name:      c.stateConfigMap.Name,
+		},
+	}
+	if err := c.client.Patch(ctx, cm, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return fmt.Errorf("patching ConfigMap %q: %w", c.stateConfigMap, err)
+	}
+	c.observeStateConfigMap(cm)
+	return nil
+}
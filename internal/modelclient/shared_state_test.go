@@ -0,0 +1,76 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestWatchSharedState(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "state"}
+	opts := Options{StateConfigMap: stateRef}
+	other, k8sClient := newTestModelClientWithOptions(t, opts, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name},
+	})
+	var gets int
+	countingClient := interceptor.NewClient(k8sClient.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*corev1.ConfigMap); ok {
+				gets++
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+	mc := NewModelClient(countingClient, testNamespace, opts)
+
+	informers := &informertest.FakeInformers{}
+	require.NoError(t, mc.WatchSharedState(ctx, informers))
+	informer, err := informers.FakeInformerFor(ctx, &corev1.ConfigMap{})
+	require.NoError(t, err)
+
+	observe := func() *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, k8sClient.Get(ctx, stateRef, cm))
+		return cm
+	}
+	informer.Add(observe())
+	informer.Add(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: "other"},
+		Data:       map[string]string{autoscalingPauseKey: "{}"},
+	})
+	require.False(t, mc.IsAutoscalingPaused(ctx))
+	require.True(t, mc.ScaleDownFrozenUntil(ctx).IsZero())
+
+	require.NoError(t, other.PauseAutoscaling(ctx))
+	_, err = other.FreezeScaleDown(ctx, time.Hour)
+	require.NoError(t, err)
+	require.False(t, mc.IsAutoscalingPaused(ctx), "the pause should be read once it is observed")
+	paused := observe()
+	informer.Update(nil, paused)
+	require.True(t, mc.IsAutoscalingPaused(ctx))
+	require.False(t, mc.ScaleDownFrozenUntil(ctx).IsZero())
+	require.Equal(t, 0, gets, "the state ConfigMap should be read from the watched copy")
+
+	// Writes of this instance are read back before the watch event arrives.
+	require.NoError(t, mc.ResumeAutoscaling(ctx))
+	require.False(t, mc.IsAutoscalingPaused(ctx))
+	informer.Update(nil, paused)
+	require.False(t, mc.IsAutoscalingPaused(ctx), "events of earlier writes should be ignored")
+	require.Equal(t, 0, gets)
+
+	informer.Delete(observe())
+	require.False(t, mc.IsAutoscalingPaused(ctx))
+	require.Equal(t, 1, gets, "the state ConfigMap should be read from the API server without a watched copy")
+}
@@ -0,0 +1,44 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+)
+
+func TestSharedTargetReplicas(t *testing.T) {
+	ctx := context.Background()
+
+	withTarget := func(m *kubeaiv1.Model, target string) *kubeaiv1.Model {
+		m.Annotations = map[string]string{kubeaiv1.ModelScaleTargetAnnotation: target}
+		return m
+	}
+	a := withTarget(testModel("model-a", kubeaiv1.ModelSpec{}), "apps/v1/Deployment/packed")
+	b := withTarget(testModel("model-b", kubeaiv1.ModelSpec{}), "apps/v1/Deployment/packed")
+	c := withTarget(testModel("model-c", kubeaiv1.ModelSpec{}), "apps/v1/Deployment/other")
+	d := testModel("model-d", kubeaiv1.ModelSpec{})
+	mc, _ := newTestModelClient(t, a, b, c, d)
+
+	shared, _, err := mc.sharedTargetReplicas(ctx, a, 1)
+	require.NoError(t, err)
+	require.Equal(t, int32(0), shared, "no other model has desired replicas yet")
+
+	for _, m := range []*kubeaiv1.Model{c, d} {
+		_, _, err := mc.sharedTargetReplicas(ctx, m, 5)
+		require.NoError(t, err)
+	}
+	shared, _, err = mc.sharedTargetReplicas(ctx, b, 3)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), shared)
+
+	shared, by, err := mc.sharedTargetReplicas(ctx, a, 0)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), shared, "models with other scale targets should be ignored")
+	require.Equal(t, "model-b", by)
+
+	shared, _, err = mc.sharedTargetReplicas(ctx, d, 0)
+	require.NoError(t, err)
+	require.Equal(t, int32(0), shared, "models without a scale target should not share one")
+}
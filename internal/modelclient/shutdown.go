@@ -0,0 +1,48 @@
+package modelclient
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrShuttingDown is returned (wrapped in a ScaleError) for scale operations
+// that are attempted after Shutdown was called.
+var ErrShuttingDown = errors.New("model client is shutting down")
+
+// beginScale registers an in-flight scale operation. It returns false if the
+// ModelClient is shutting down, in which case the operation must not proceed.
+func (c *ModelClient) beginScale() bool {
+	c.shutdownMtx.RLock()
+	defer c.shutdownMtx.RUnlock()
+	if c.shuttingDown {
+		return false
+	}
+	c.inflightScales.Add(1)
+	return true
+}
+
+func (c *ModelClient) endScale() {
+	c.inflightScales.Done()
+}
+
+// Shutdown stops all future scale operations and waits for in-flight scale
+// operations to complete. It returns the context error if the context is
+// done before all operations complete.
+func (c *ModelClient) Shutdown(ctx context.Context) error {
+	c.shutdownMtx.Lock()
+	c.shuttingDown = true
+	c.shutdownMtx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.inflightScales.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
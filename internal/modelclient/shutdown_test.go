@@ -0,0 +1,36 @@
+package modelclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	"k8s.io/utils/ptr"
+)
+
+func TestShutdown(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1)})
+	mc, k8sClient := newTestModelClient(t, m)
+
+	// An in-flight scale operation delays shutdown.
+	require.True(t, mc.beginScale())
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, mc.Shutdown(timeoutCtx), context.DeadlineExceeded)
+
+	mc.endScale()
+	require.NoError(t, mc.Shutdown(ctx))
+
+	// No scale operations are allowed after shutdown.
+	err := mc.Scale(ctx, m, 3, 0, "test")
+	require.True(t, errors.Is(err, ErrShuttingDown), "unexpected error: %v", err)
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
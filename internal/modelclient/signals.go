@@ -0,0 +1,218 @@
+package modelclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Backend errors and latencies are observed by the proxy of the KubeAI instance
+// that served a request, while only the leader autoscales. Every other instance
+// publishes the signals that it observed to the state ConfigMap (see
+// PublishSignals), which the leader merges with its own (see ClusterSignals).
+
+// signalsKeyPrefix is the prefix of the keys of the state ConfigMap that the
+// signals of each KubeAI instance are stored in.
+const signalsKeyPrefix = "signals."
+
+// instanceSignals are the signals that one KubeAI instance observed.
+type instanceSignals struct {
+	// Time is when the signals were published. Signals that are older than
+	// the max age of ClusterSignals are ignored.
+	Time time.Time `json:"time"`
+	// Unhealthy are the endpoints that recently failed requests by model
+	// (see ReportBackendError).
+	Unhealthy map[string][]string `json:"unhealthy,omitempty"`
+	// Latencies are the p95 latencies of the requests that were served within
+	// the window by model (see RecordLatency).
+	Latencies map[string]latencySummary `json:"latencies,omitempty"`
+}
+
+type latencySummary struct {
+	P95     time.Duration `json:"p95"`
+	Samples int           `json:"samples"`
+}
+
+func (s instanceSignals) empty() bool {
+	return len(s.Unhealthy) == 0 && len(s.Latencies) == 0
+}
+
+// signalsLatencyTolerance is the relative change of a p95 latency below which
+// signals are not published again (see PublishSignals), as latencies change
+// slightly on every interval while requests are served.
+const signalsLatencyTolerance = 0.1
+
+// changedFrom returns true if the signals differ from the published signals.
+// The time and the samples of latencies are ignored.
+func (s instanceSignals) changedFrom(published instanceSignals) bool {
+	if len(s.Unhealthy) != len(published.Unhealthy) || len(s.Latencies) != len(published.Latencies) {
+		return true
+	}
+	for model, endpoints := range s.Unhealthy {
+		if !slices.Equal(endpoints, published.Unhealthy[model]) {
+			return true
+		}
+	}
+	for model, l := range s.Latencies {
+		p, ok := published.Latencies[model]
+		if !ok || math.Abs(float64(l.P95-p.P95)) > signalsLatencyTolerance*float64(p.P95) {
+			return true
+		}
+	}
+	return false
+}
+
+// localSignals returns the signals that this instance observed. Latencies are
+// summarized over the given window.
+func (c *ModelClient) localSignals(window time.Duration) instanceSignals {
+	signals := instanceSignals{Time: c.clock.Now()}
+	var models []string
+	c.scalerStatesMtx.Lock()
+	for model := range c.scalerStates {
+		models = append(models, model)
+		if endpoints := c.unhealthyEndpoints(model); len(endpoints) > 0 {
+			if signals.Unhealthy == nil {
+				signals.Unhealthy = map[string][]string{}
+			}
+			slices.Sort(endpoints)
+			signals.Unhealthy[model] = endpoints
+		}
+	}
+	c.scalerStatesMtx.Unlock()
+
+	for _, model := range models {
+		if p95, samples := c.LatencyPercentile(model, window, 95); samples > 0 {
+			if signals.Latencies == nil {
+				signals.Latencies = map[string]latencySummary{}
+			}
+			signals.Latencies[model] = latencySummary{P95: p95, Samples: samples}
+		}
+	}
+	return signals
+}
+
+// PublishSignals stores the signals that this instance observed in the state
+// ConfigMap, so that they are taken into account by the leader. It should be
+// called on every autoscaling interval by instances that are not the leader,
+// with the window that latencies are autoscaled on.
+// Signals are only written when they changed, or once the heartbeat elapsed
+// since they were last written (which has to be shorter than the max age of
+// ClusterSignals), so that instances do not write to the ConfigMap on every
+// interval. The key of the instance is removed while it has no signals. No-op
+// if no state ConfigMap or instance name is configured.
+func (c *ModelClient) PublishSignals(ctx context.Context, window, heartbeat time.Duration) error {
+	if c.instanceName == "" {
+		return nil
+	}
+	signals := c.localSignals(window)
+	c.publishedSignalsMtx.Lock()
+	defer c.publishedSignalsMtx.Unlock()
+	published := c.publishedSignals
+	if signals.empty() {
+		if published == nil {
+			return nil
+		}
+		if err := c.setSharedState(ctx, signalsKeyPrefix+c.instanceName, nil); err != nil {
+			return err
+		}
+		c.publishedSignals = nil
+		return nil
+	}
+	if published != nil && signals.Time.Sub(published.Time) < heartbeat && !signals.changedFrom(*published) {
+		return nil
+	}
+	if err := c.setSharedState(ctx, signalsKeyPrefix+c.instanceName, signals); err != nil {
+		return err
+	}
+	if c.sharedStateEnabled() {
+		c.publishedSignals = &signals
+	}
+	return nil
+}
+
+// Signals are the signals that all KubeAI instances observed (see ClusterSignals).
+type Signals struct {
+	// unhealthy are the endpoints that failed requests by model.
+	unhealthy map[string]map[string]struct{}
+	// latencies are the latencies that each instance observed by model.
+	latencies map[string][]latencySummary
+}
+
+// LatencyP95 returns the p95 latency of the requests for the model that were
+// served within the window, along with the number of samples that it is based
+// on. The percentiles of instances can not be merged exactly, so the p95
+// latencies of all instances are averaged, weighted by their samples.
+func (s *Signals) LatencyP95(model string) (time.Duration, int) {
+	var weighted float64
+	var samples int
+	for _, l := range s.latencies[model] {
+		weighted += float64(l.P95) * float64(l.Samples)
+		samples += l.Samples
+	}
+	if samples == 0 {
+		return 0, 0
+	}
+	return time.Duration(weighted / float64(samples)), samples
+}
+
+// UnhealthyReplicas returns the number of endpoints of the model that have
+// recently been reported as failing by any instance. The autoscaler adds these
+// on top of the desired replicas to compensate for replicas that are ready but
+// not serving.
+func (s *Signals) UnhealthyReplicas(model string) int32 {
+	return int32(len(s.unhealthy[model]))
+}
+
+func (s *Signals) add(signals instanceSignals) {
+	for model, endpoints := range signals.Unhealthy {
+		if s.unhealthy[model] == nil {
+			s.unhealthy[model] = map[string]struct{}{}
+		}
+		for _, endpoint := range endpoints {
+			s.unhealthy[model][endpoint] = struct{}{}
+		}
+	}
+	for model, l := range signals.Latencies {
+		s.latencies[model] = append(s.latencies[model], l)
+	}
+}
+
+// ClusterSignals merges the signals that this instance observed within the
+// given window with the signals that other instances published within the
+// given max age (see PublishSignals). Older signals are removed, as their instance stopped
+// publishing them (i.e. because it was terminated or became the leader).
+// The signals of this instance are returned along with the error if the
+// signals of other instances can not be read.
+func (c *ModelClient) ClusterSignals(ctx context.Context, window, maxAge time.Duration) (*Signals, error) {
+	signals := &Signals{unhealthy: map[string]map[string]struct{}{}, latencies: map[string][]latencySummary{}}
+	signals.add(c.localSignals(window))
+
+	published, err := c.listSharedState(ctx, signalsKeyPrefix)
+	if err != nil {
+		return signals, err
+	}
+	now := c.clock.Now()
+	var errs error
+	for key, value := range published {
+		var s instanceSignals
+		if err := json.Unmarshal([]byte(value), &s); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("unmarshalling key %q: %w", key, err))
+			continue
+		}
+		if strings.TrimPrefix(key, signalsKeyPrefix) == c.instanceName || now.Sub(s.Time) > maxAge {
+			log.Printf("Removing signals of KubeAI instance %q, last published at %s", strings.TrimPrefix(key, signalsKeyPrefix), s.Time)
+			if err := c.setSharedState(ctx, key, nil); err != nil {
+				errs = errors.Join(errs, err)
+			}
+			continue
+		}
+		signals.add(s)
+	}
+	return signals, errs
+}
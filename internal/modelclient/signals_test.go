@@ -0,0 +1,105 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestClusterSignals(t *testing.T) {
+	ctx := context.Background()
+	const model = "my-model"
+
+	stateRef := types.NamespacedName{Namespace: testNamespace, Name: "state"}
+	clk := newTestClock()
+	leader, k8sClient := newTestModelClientWithOptions(t, Options{Clock: clk, StateConfigMap: stateRef, InstanceName: "leader"}, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: stateRef.Namespace, Name: stateRef.Name},
+	})
+	follower := NewModelClient(k8sClient, testNamespace, Options{Clock: clk, StateConfigMap: stateRef, InstanceName: "follower"})
+	const heartbeat = 5 * time.Second
+	getResourceVersion := func() string {
+		t.Helper()
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, k8sClient.Get(ctx, stateRef, cm))
+		return cm.ResourceVersion
+	}
+	getKeys := func() []string {
+		t.Helper()
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, k8sClient.Get(ctx, stateRef, cm))
+		var keys []string
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	// Idle instances do not publish.
+	require.NoError(t, follower.PublishSignals(ctx, time.Minute, heartbeat))
+	require.Empty(t, getKeys())
+
+	// Endpoints that fail on either instance are unhealthy, and are counted once.
+	for i := 0; i < 3; i++ {
+		leader.ReportBackendError(model, "10.0.0.1:8000")
+		follower.ReportBackendError(model, "10.0.0.1:8000")
+		follower.ReportBackendError(model, "10.0.0.2:8000")
+	}
+	require.NoError(t, follower.PublishSignals(ctx, time.Minute, heartbeat))
+	require.Equal(t, []string{"signals.follower"}, getKeys())
+	const maxAge = 10 * time.Second
+	signals, err := leader.ClusterSignals(ctx, time.Minute, maxAge)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), signals.UnhealthyReplicas(model))
+	require.Equal(t, int32(1), leader.UnhealthyReplicas(model))
+
+	// Signals of instances that stopped publishing are removed.
+	clk.Step(maxAge + time.Second)
+	signals, err = leader.ClusterSignals(ctx, time.Minute, maxAge)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), signals.UnhealthyReplicas(model))
+	require.Empty(t, getKeys())
+
+	// The key of an instance is removed once its errors decay.
+	require.NoError(t, follower.PublishSignals(ctx, time.Minute, heartbeat))
+	require.Equal(t, []string{"signals.follower"}, getKeys())
+	clk.Step(10 * backendErrorHalfLife)
+	require.NoError(t, follower.PublishSignals(ctx, time.Minute, heartbeat))
+	require.Empty(t, getKeys())
+
+	// Latencies are averaged over all instances by their samples.
+	leader.RecordLatency(model, time.Second)
+	for i := 0; i < 3; i++ {
+		follower.RecordLatency(model, 3*time.Second)
+	}
+	require.NoError(t, follower.PublishSignals(ctx, time.Minute, heartbeat))
+	signals, err = leader.ClusterSignals(ctx, time.Minute, maxAge)
+	require.NoError(t, err)
+	p95, samples := signals.LatencyP95(model)
+	require.Equal(t, 2500*time.Millisecond, p95)
+	require.Equal(t, 4, samples)
+	p95, samples = signals.LatencyP95("other-model")
+	require.Zero(t, p95)
+	require.Zero(t, samples)
+
+	// Unchanged signals are only published again once the heartbeat elapsed.
+	published := getResourceVersion()
+	follower.RecordLatency(model, 3*time.Second)
+	require.NoError(t, follower.PublishSignals(ctx, time.Minute, heartbeat))
+	require.Equal(t, published, getResourceVersion())
+	clk.Step(heartbeat)
+	require.NoError(t, follower.PublishSignals(ctx, time.Minute, heartbeat))
+	require.NotEqual(t, published, getResourceVersion())
+
+	// Changed signals are published right away.
+	published = getResourceVersion()
+	for i := 0; i < 10; i++ {
+		follower.RecordLatency(model, 10*time.Second)
+	}
+	require.NoError(t, follower.PublishSignals(ctx, time.Minute, heartbeat))
+	require.NotEqual(t, published, getResourceVersion())
+}
@@ -0,0 +1,380 @@
+package modelclient
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"k8s.io/utils/ptr"
+)
+
+// scalerState is the scaling-related state that is tracked for a single model.
+// All access should be done while holding the ModelClient.scalerStatesMtx
+// (except for the writeMtx).
+type scalerState struct {
+	// writeMtx serializes writes to the replicas of the model.
+	writeMtx sync.Mutex
+	// pin is the active force scale of the model (if any).
+	pin *scalePin
+
+	lastActivityTime time.Time
+	// pausedReplicas is the most recently requested number of replicas
+	// while autoscaling was paused.
+	pausedReplicas *int32
+	// saturated is true when the model is at its max replicas
+	// and the most recent autoscaling decision wanted more.
+	saturated bool
+	// scaleFromZeroRequestTimes are the times of recent requests that were received
+	// while the model was scaled to zero and did not trigger a scale up.
+	scaleFromZeroRequestTimes []time.Time
+	// lastScaleReason describes why the model was last scaled.
+	lastScaleReason string
+	lastScaleTime   time.Time
+	// lastScaleUpTime is the time of the most recent scale up of the model
+	// (see scaleUpCooldown).
+	lastScaleUpTime time.Time
+	// scaledToZeroReason is the ScaledToZero* reason of the most recent scale
+	// operation if it scaled the model to zero replicas.
+	scaledToZeroReason string
+	// backendErrors tracks recent errors by endpoint address.
+	backendErrors map[string]*backendErrorScore
+	// scaleDebouncedUntil is the time until which scale operations are deferred.
+	scaleDebouncedUntil time.Time
+	// coldStartBegan is the time that the model was last scaled up from zero
+	// replicas. Zero once the model is observed to be ready.
+	coldStartBegan time.Time
+	// coldStartObserved is true once the Model was observed to be scaling up
+	// during the current cold start.
+	coldStartObserved bool
+	// coldStartDurations are the most recent observed cold start durations.
+	coldStartDurations []time.Duration
+	// latencies are the recently recorded request latencies (oldest first).
+	latencies []latencySample
+	// pendingScale is the latest scale operation that was deferred
+	// by the scale debounce interval.
+	pendingScale *pendingScale
+	// desiredReplicas is the bounded number of replicas of the most recent
+	// autoscaling decision. Used to coordinate Models that share a scale target.
+	desiredReplicas *int32
+	// targetPaused is true if the scale target of the model was paused
+	// (.spec.paused) during the most recent scale operation.
+	targetPaused bool
+	// activeStreams is the number of registered streaming connections (see RegisterStream).
+	activeStreams int
+	// enforcedMinReplicas is the scale up of the most recent EnforceMinReplicas
+	// call that did not change the replicas yet (i.e. because the write was
+	// deferred). Nil once the replicas of the model are written.
+	enforcedMinReplicas *enforcedMinReplicas
+	// requestedReplicas is the number of replicas of the most recent scale
+	// operation of the autoscaler and appliedReplicas the number of replicas
+	// that were last written (or observed) by this instance (see trackReplicaLag).
+	requestedReplicas *int32
+	appliedReplicas   *int32
+	// unscalable is set while the scale target of the model does not
+	// implement the scale subresource (see recordUnscalable).
+	unscalable *unscalableTarget
+	// scaleFromZeroSustained is true once sustained demand was observed while the
+	// model was scaled to zero, until the model is observed with replicas.
+	scaleFromZeroSustained bool
+}
+
+// ScalerSnapshot is a point-in-time view of the scaling state tracked for a model.
+// NOTE: State is tracked per KubeAI instance and is not shared across replicas.
+type ScalerSnapshot struct {
+	Model string `json:"model"`
+	// LastActivityTime is the last time a request for the model was observed
+	// by this instance. Zero if no requests have been observed.
+	LastActivityTime time.Time `json:"lastActivityTime,omitempty"`
+	// PausedReplicas is the number of replicas that will be applied
+	// when autoscaling is resumed. Nil if no scale operation is pending.
+	PausedReplicas *int32 `json:"pausedReplicas,omitempty"`
+	// Saturated is true when the model is running at its max replicas
+	// and the autoscaler would scale beyond it if allowed.
+	Saturated bool `json:"saturated"`
+	// LastScaleReason describes why this instance last changed the replicas
+	// of the model. Empty if the model has not been scaled by this instance.
+	LastScaleReason string    `json:"lastScaleReason,omitempty"`
+	LastScaleTime   time.Time `json:"lastScaleTime,omitempty"`
+	// ScaledToZeroReason is one of the ScaledToZero* reasons if this instance
+	// last scaled the model to zero replicas. Empty otherwise.
+	ScaledToZeroReason string `json:"scaledToZeroReason,omitempty"`
+	// ColdStart describes the observed cold starts of the model.
+	ColdStart ColdStartSnapshot `json:"coldStart"`
+	// PinnedReplicas is the number of replicas that the model is forced to
+	// until PinnedUntil. Nil if the model is not pinned.
+	PinnedReplicas *int32    `json:"pinnedReplicas,omitempty"`
+	PinnedUntil    time.Time `json:"pinnedUntil,omitempty"`
+	// TargetPaused is true if scaling was skipped because the scale target
+	// of the model (i.e. a Deployment) is paused.
+	TargetPaused bool `json:"targetPaused"`
+	// Unscalable is true if the scale target of the model (i.e. a custom
+	// resource) does not implement the scale subresource. Scale operations
+	// are skipped until the scale target annotation of the model changes.
+	Unscalable bool `json:"unscalable"`
+	// ActiveStreams is the number of streaming connections to the model that
+	// are open through this instance (see RegisterStream).
+	ActiveStreams int `json:"activeStreams"`
+}
+
+// Reasons that a model is scaled to zero replicas (see ScalerSnapshot.ScaledToZeroReason).
+const (
+	// ScaledToZeroIdle is the reason of autoscaling decisions, i.e. when no
+	// requests were observed within the scale down delay.
+	ScaledToZeroIdle = "Idle"
+	// ScaledToZeroManual is the reason of force scales to zero replicas (see ForceScale).
+	ScaledToZeroManual = "Manual"
+	// ScaledToZeroPreempted is the reason of scale downs that made room for a
+	// higher priority model within the replica budget.
+	ScaledToZeroPreempted = "Preempted"
+	// ScaledToZeroExternal is used for models that were scaled to zero replicas
+	// without KubeAI (i.e. with kubectl scale or by editing the Model) after
+	// KubeAI scaled them up or observed them with replicas.
+	ScaledToZeroExternal = "External"
+)
+
+// recordScale records that the replicas of the model were written at the given time
+// for the given reason. zeroReason is recorded if the model was scaled to zero replicas.
+// The caller must hold the scalerStatesMtx write lock.
+func (s *scalerState) recordScale(now time.Time, replicas int32, reason, zeroReason string) {
+	s.lastScaleReason = reason
+	s.lastScaleTime = now
+	s.enforcedMinReplicas = nil
+	s.scaledToZeroReason = ""
+	if replicas == 0 {
+		s.scaledToZeroReason = zeroReason
+	}
+}
+
+// getScalerState returns the state for the given model, creating it if it does not exist.
+// The caller must hold the scalerStatesMtx write lock.
+func (c *ModelClient) getScalerState(model string) *scalerState {
+	s, ok := c.scalerStates[model]
+	if !ok {
+		s = &scalerState{}
+		c.scalerStates[model] = s
+	}
+	return s
+}
+
+// recordActivity records that a request was observed for the given model.
+func (c *ModelClient) recordActivity(ctx context.Context, model string) {
+	now := c.clock.Now()
+
+	c.scalerStatesMtx.Lock()
+	c.getScalerState(model).lastActivityTime = now
+	c.scalerStatesMtx.Unlock()
+
+	metrics.ModelLastActivityTimestamp.Record(ctx, float64(now.Unix()), metric.WithAttributeSet(attribute.NewSet(
+		metrics.AttrRequestModel.String(model),
+	)))
+}
+
+// ScalerSnapshot returns the current scaling state for the given model.
+// The second return value is false if no state has been tracked for the model.
+func (c *ModelClient) ScalerSnapshot(model string) (ScalerSnapshot, bool) {
+	c.scalerStatesMtx.RLock()
+	defer c.scalerStatesMtx.RUnlock()
+
+	s, ok := c.scalerStates[model]
+	if !ok {
+		return ScalerSnapshot{}, false
+	}
+	snapshot := ScalerSnapshot{
+		Model:              model,
+		LastActivityTime:   s.lastActivityTime,
+		Saturated:          s.saturated,
+		LastScaleReason:    s.lastScaleReason,
+		LastScaleTime:      s.lastScaleTime,
+		ScaledToZeroReason: s.scaledToZeroReason,
+		ColdStart:          s.coldStartSnapshot(),
+		TargetPaused:       s.targetPaused,
+		Unscalable:         s.unscalable != nil,
+		ActiveStreams:      s.activeStreams,
+	}
+	if s.pausedReplicas != nil {
+		snapshot.PausedReplicas = ptr.To(*s.pausedReplicas)
+	}
+	if s.pin != nil {
+		snapshot.PinnedReplicas = ptr.To(s.pin.replicas)
+		snapshot.PinnedUntil = s.pin.until
+	}
+	return snapshot, true
+}
+
+// ScaledToZeroReason returns why the model is at zero replicas: the reason that
+// was recorded on the Model when KubeAI scaled it to zero (see
+// recordScaledToZeroReason), or ScaledToZeroExternal. Empty if the model does
+// not exist, has replicas, or the reason is unknown (i.e. for Models that were
+// created without replicas).
+func (c *ModelClient) ScaledToZeroReason(ctx context.Context, model string) (string, error) {
+	m, err := c.LookupModel(ctx, model, "", nil)
+	if err != nil || m == nil {
+		return "", err
+	}
+	_, replicas, err := c.getReplicas(ctx, m)
+	if err != nil {
+		return "", err
+	}
+	if replicas > 0 {
+		return "", nil
+	}
+
+	_, reason, ok := c.getModelAnnotation(m, kubeaiv1.ModelScaledToZeroReasonAnnotationName)
+	switch {
+	case !ok:
+		return "", nil
+	case reason == "":
+		return ScaledToZeroExternal, nil
+	}
+	return reason, nil
+}
+
+// recordScaledToZeroReason records why the model was scaled to the given replicas
+// in its kubeaiv1.ModelScaledToZeroReasonAnnotation: zeroReason at zero replicas,
+// "" otherwise. The annotation is only written when it changes, which is when the
+// model is scaled to or from zero. Errors are logged, because the replicas were
+// written already.
+func (c *ModelClient) recordScaledToZeroReason(ctx context.Context, model *kubeaiv1.Model, replicas int32, zeroReason string) {
+	value := ""
+	if replicas == 0 {
+		value = zeroReason
+	}
+	if _, current, ok := c.getModelAnnotation(model, kubeaiv1.ModelScaledToZeroReasonAnnotationName); ok && current == value {
+		return
+	}
+	if err := c.patchModelAnnotations(ctx, model.Name, map[string]any{
+		kubeaiv1.ModelScaledToZeroReasonAnnotation: value,
+	}); err != nil {
+		log.Printf("WARNING: recording the scaled to zero reason of model %s: %v", model.Name, err)
+	}
+}
+
+// IsSaturated returns true if the model is running at its max replicas
+// and the most recent autoscaling decision would have exceeded it.
+// Callers can use this as a signal to apply backpressure.
+// Saturation is recorded on the Model by the leader (see
+// kubeaiv1.ModelSaturatedAnnotation), so all KubeAI instances agree on it.
+func (c *ModelClient) IsSaturated(model *kubeaiv1.Model) bool {
+	_, value, ok := c.getModelAnnotation(model, kubeaiv1.ModelSaturatedAnnotationName)
+	return ok && value == "true"
+}
+
+// setSaturated records whether the model is saturated. The annotation of the
+// Model is only patched if it differs.
+func (c *ModelClient) setSaturated(ctx context.Context, model *kubeaiv1.Model, saturated bool) {
+	if c.IsSaturated(model) != saturated {
+		var value any
+		if saturated {
+			value = "true"
+		}
+		if err := c.patchModelAnnotations(ctx, model.Name, map[string]any{
+			kubeaiv1.ModelSaturatedAnnotation: value,
+		}); err != nil {
+			log.Printf("WARNING: recording the saturation of model %s: %v", model.Name, err)
+		}
+	}
+
+	c.scalerStatesMtx.Lock()
+	if _, ok := c.scalerStates[model.Name]; !ok && !saturated {
+		// Avoid creating state for models that have never been saturated.
+		c.scalerStatesMtx.Unlock()
+		return
+	}
+	s := c.getScalerState(model.Name)
+	changed := s.saturated != saturated
+	s.saturated = saturated
+	c.scalerStatesMtx.Unlock()
+
+	if changed {
+		c.NotifyStateChange(model.Name)
+	}
+}
+
+// sustainedScaleFromZeroDemand returns true if the current request and the previous
+// requests that were received for the model (while scaled to zero) within the given
+// window add up to the given number of requests. Otherwise the current request is
+// recorded and false is returned.
+func (c *ModelClient) sustainedScaleFromZeroDemand(model string, window time.Duration, requests int) bool {
+	now := c.clock.Now()
+
+	c.scalerStatesMtx.Lock()
+	defer c.scalerStatesMtx.Unlock()
+
+	s := c.getScalerState(model)
+	recent := s.scaleFromZeroRequestTimes[:0]
+	for _, t := range s.scaleFromZeroRequestTimes {
+		if now.Sub(t) <= window {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent)+1 >= requests {
+		s.scaleFromZeroRequestTimes = nil
+		s.scaleFromZeroSustained = true
+		return true
+	}
+	s.scaleFromZeroRequestTimes = append(recent, now)
+	return false
+}
+
+// IdleModels returns the names of Models that have replicas but no requests
+// observed by this instance within the given duration. Useful for finding
+// Models that are consuming resources without serving traffic (candidates for
+// scale-to-zero).
+// NOTE: Activity is tracked per KubeAI instance, so a Model that only receives
+// requests through other instances will be reported as idle.
+func (c *ModelClient) IdleModels(ctx context.Context, since time.Duration) ([]string, error) {
+	models, err := c.ListAllModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := c.clock.Now().Add(-since)
+	var idle []string
+	for i := range models {
+		m := &models[i]
+
+		c.scalerStatesMtx.RLock()
+		s, ok := c.scalerStates[m.Name]
+		active := ok && !s.lastActivityTime.IsZero() && s.lastActivityTime.After(cutoff)
+		c.scalerStatesMtx.RUnlock()
+		if active {
+			continue
+		}
+
+		_, replicas, err := c.getReplicas(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		if replicas > 0 {
+			idle = append(idle, m.Name)
+		}
+	}
+
+	return idle, nil
+}
+
+// scaleFromZeroDemandSustained returns true if sustained demand was observed
+// (see sustainedScaleFromZeroDemand) since the model was scaled to zero.
+func (c *ModelClient) scaleFromZeroDemandSustained(model string) bool {
+	c.scalerStatesMtx.RLock()
+	defer c.scalerStatesMtx.RUnlock()
+	s, ok := c.scalerStates[model]
+	return ok && s.scaleFromZeroSustained
+}
+
+// resetScaleFromZeroDemand forgets the sustained demand of the model once it
+// was observed with replicas, so that the next scale up from zero is gated again.
+func (c *ModelClient) resetScaleFromZeroDemand(model string) {
+	if !c.scaleFromZeroDemandSustained(model) {
+		// Avoid taking the write lock for models that were not gated.
+		return
+	}
+	c.scalerStatesMtx.Lock()
+	c.getScalerState(model).scaleFromZeroSustained = false
+	c.scalerStatesMtx.Unlock()
+}
@@ -0,0 +1,96 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestIdleModels(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	active := testModel("active", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1)})
+	idle := testModel("idle", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2)})
+	scaledToZero := testModel("scaled-to-zero", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0)})
+	mc, _ := newTestModelClient(t, active, idle, scaledToZero)
+
+	mc.recordActivity(ctx, active.Name)
+
+	models, err := mc.IdleModels(ctx, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []string{idle.Name}, models)
+
+	// Activity outside of the window does not count.
+	mc.scalerStatesMtx.Lock()
+	mc.getScalerState(active.Name).lastActivityTime = time.Now().Add(-2 * time.Hour)
+	mc.scalerStatesMtx.Unlock()
+
+	models, err = mc.IdleModels(ctx, time.Hour)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{active.Name, idle.Name}, models)
+}
+
+func TestScaledToZeroReason(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1), MaxReplicas: ptr.To[int32](2)})
+	neverUsed := testModel("never-used", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0)})
+	mc, k8sClient := newTestModelClient(t, m, neverUsed)
+	// Another KubeAI instance that did not scale the model.
+	other := NewModelClient(k8sClient, testNamespace, Options{})
+
+	requireReason := func(model, expected string) {
+		t.Helper()
+		for _, c := range []*ModelClient{mc, other} {
+			reason, err := c.ScaledToZeroReason(ctx, model)
+			require.NoError(t, err)
+			require.Equal(t, expected, reason)
+		}
+	}
+	get := func() *kubeaiv1.Model {
+		t.Helper()
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(m), m))
+		return m
+	}
+	setReplicas := func(replicas int32) {
+		t.Helper()
+		get().Spec.Replicas = ptr.To(replicas)
+		require.NoError(t, k8sClient.Update(ctx, m))
+	}
+
+	requireReason(neverUsed.Name, "")
+	requireReason("does-not-exist", "")
+	requireReason(m.Name, "")
+
+	target, _, err := mc.getReplicas(ctx, m)
+	require.NoError(t, err)
+	require.NoError(t, mc.updateScale(ctx, m, target, 0, "no requests", ScaledToZeroIdle, ScaleActorAuto))
+	requireReason(m.Name, ScaledToZeroIdle)
+	snapshot, _ := mc.ScalerSnapshot(m.Name)
+	require.Equal(t, ScaledToZeroIdle, snapshot.ScaledToZeroReason)
+
+	// Cleared by a scale up.
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	requireReason(m.Name, "")
+	snapshot, _ = mc.ScalerSnapshot(m.Name)
+	require.Empty(t, snapshot.ScaledToZeroReason)
+
+	require.NoError(t, mc.ForceScale(ctx, m.Name, 0, time.Hour))
+	requireReason(m.Name, ScaledToZeroManual)
+
+	// Scale ups without KubeAI are observed by the autoscaler, so a later
+	// scale to zero without KubeAI is not reported with the previous reason.
+	require.NoError(t, mc.ForceScale(ctx, m.Name, 0, time.Nanosecond))
+	setReplicas(2)
+	require.NoError(t, mc.Scale(ctx, get(), 2, 0, "test"))
+	setReplicas(0)
+	requireReason(m.Name, ScaledToZeroExternal)
+}
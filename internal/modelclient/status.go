@@ -0,0 +1,76 @@
+package modelclient
+
+import (
+	"context"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ModelStatus describes whether a model can currently serve requests.
+type ModelStatus int
+
+const (
+	// ModelStatusUnknown means that the Model does not exist.
+	ModelStatusUnknown ModelStatus = iota
+	// ModelStatusScaledToZero means that the Model exists but has no desired replicas.
+	ModelStatusScaledToZero
+	// ModelStatusScalingUp means that the Model has desired replicas but none of them are ready.
+	ModelStatusScalingUp
+	// ModelStatusReady means that at least one replica of the Model is ready to serve requests.
+	ModelStatusReady
+)
+
+func (s ModelStatus) String() string {
+	switch s {
+	case ModelStatusScaledToZero:
+		return "ScaledToZero"
+	case ModelStatusScalingUp:
+		return "ScalingUp"
+	case ModelStatusReady:
+		return "Ready"
+	default:
+		return "Unknown"
+	}
+}
+
+// ModelStatus returns the status of the given model. Readiness is based on the
+// ready replica count that the Model controller tracks from Pod readiness.
+// Observed statuses are used to measure cold start durations (see ColdStartSnapshot).
+func (c *ModelClient) ModelStatus(ctx context.Context, model string) (ModelStatus, error) {
+	obj := &kubeaiv1.Model{}
+	if err := c.client.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: model}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ModelStatusUnknown, nil
+		}
+		return ModelStatusUnknown, err
+	}
+	return c.ResolvedModelStatus(ctx, obj)
+}
+
+// ResolvedModelStatus returns the status of the given Model (see ModelStatus)
+// without getting it again, i.e. for the Model that a request was resolved to.
+func (c *ModelClient) ResolvedModelStatus(ctx context.Context, model *kubeaiv1.Model) (ModelStatus, error) {
+	status, err := c.getModelStatus(ctx, model)
+	if err != nil {
+		return status, err
+	}
+	c.observeColdStart(ctx, model.Name, status)
+	return status, nil
+}
+
+func (c *ModelClient) getModelStatus(ctx context.Context, obj *kubeaiv1.Model) (ModelStatus, error) {
+	if obj.Status.Replicas.Ready > 0 {
+		return ModelStatusReady, nil
+	}
+
+	_, replicas, err := c.getReplicas(ctx, obj)
+	if err != nil {
+		return ModelStatusUnknown, err
+	}
+	if replicas == 0 {
+		return ModelStatusScaledToZero, nil
+	}
+	return ModelStatusScalingUp, nil
+}
@@ -0,0 +1,86 @@
+package modelclient
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestModelStatus(t *testing.T) {
+	scaledToZero := testModel("scaled-to-zero", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0)})
+	scalingUp := testModel("scaling-up", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1)})
+	ready := testModel("ready", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1)})
+	ready.Status.Replicas = kubeaiv1.ModelStatusReplicas{All: 1, Ready: 1}
+
+	mc, _ := newTestModelClient(t, scaledToZero, scalingUp, ready)
+
+	cases := map[string]ModelStatus{
+		"does-not-exist":  ModelStatusUnknown,
+		scaledToZero.Name: ModelStatusScaledToZero,
+		scalingUp.Name:    ModelStatusScalingUp,
+		ready.Name:        ModelStatusReady,
+	}
+	for model, exp := range cases {
+		t.Run(model, func(t *testing.T) {
+			status, err := mc.ModelStatus(context.Background(), model)
+			require.NoError(t, err)
+			require.Equal(t, exp, status, "got %v, expected %v", status, exp)
+		})
+	}
+}
+
+func TestColdStartSnapshot(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0)})
+	clk := newTestClock()
+	mc, k8sClient := newTestModelClientWithOptions(t, Options{Clock: clk}, m)
+
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	snapshot := mc.ColdStartSnapshot(m.Name)
+	require.False(t, snapshot.Began.IsZero(), "Scaling up from zero should begin a cold start")
+	require.Zero(t, snapshot.Observed)
+
+	status, err := mc.ModelStatus(ctx, m.Name)
+	require.NoError(t, err)
+	require.Equal(t, ModelStatusScalingUp, status)
+	require.False(t, mc.ColdStartSnapshot(m.Name).Began.IsZero())
+
+	clk.Step(10 * time.Second)
+	obj := &kubeaiv1.Model{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(m), obj))
+	obj.Status.Replicas = kubeaiv1.ModelStatusReplicas{All: 1, Ready: 1}
+	require.NoError(t, k8sClient.Update(ctx, obj))
+
+	status, err = mc.ModelStatus(ctx, m.Name)
+	require.NoError(t, err)
+	require.Equal(t, ModelStatusReady, status)
+	snapshot = mc.ColdStartSnapshot(m.Name)
+	require.True(t, snapshot.Began.IsZero(), "The cold start should end once the model is ready")
+	require.Equal(t, 1, snapshot.Observed)
+	require.Equal(t, 10*time.Second, snapshot.AverageDuration)
+}
+
+func TestColdStartPercentiles(t *testing.T) {
+	s := &scalerState{}
+	for i := 1; i <= 10; i++ {
+		s.coldStartDurations = append(s.coldStartDurations, time.Duration(11-i)*time.Second)
+	}
+	snapshot := s.coldStartSnapshot()
+	require.Equal(t, 10, snapshot.Observed)
+	require.Equal(t, 5500*time.Millisecond, snapshot.AverageDuration)
+	require.Equal(t, 5*time.Second, snapshot.P50Duration)
+	require.Equal(t, 9*time.Second, snapshot.P90Duration)
+
+	b, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"averageSeconds":5.5,"p50Seconds":5,"p90Seconds":9,"observed":10}`, string(b))
+}
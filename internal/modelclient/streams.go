@@ -0,0 +1,51 @@
+package modelclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/substratusai/kubeai/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RegisterStream records a long-lived streaming connection (i.e. a Server-Sent
+// Events response or a WebSocket) to the model. Models are not scaled to zero
+// while streams are active. The returned function deregisters the stream once
+// it is closed and may be called more than once.
+func (c *ModelClient) RegisterStream(ctx context.Context, model string) func() {
+	attrs := metric.WithAttributeSet(attribute.NewSet(
+		metrics.AttrRequestModel.String(model),
+	))
+	metrics.InferenceStreamsActive.Add(ctx, 1, attrs)
+
+	c.scalerStatesMtx.Lock()
+	c.getScalerState(model).activeStreams++
+	c.scalerStatesMtx.Unlock()
+	c.NotifyStateChange(model)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			metrics.InferenceStreamsActive.Add(context.WithoutCancel(ctx), -1, attrs)
+
+			c.scalerStatesMtx.Lock()
+			c.getScalerState(model).activeStreams--
+			c.scalerStatesMtx.Unlock()
+			c.NotifyStateChange(model)
+		})
+	}
+}
+
+// ActiveStreams returns the number of streams to the model that are registered
+// with this instance (see RegisterStream).
+func (c *ModelClient) ActiveStreams(model string) int {
+	c.scalerStatesMtx.RLock()
+	defer c.scalerStatesMtx.RUnlock()
+
+	s, ok := c.scalerStates[model]
+	if !ok {
+		return 0
+	}
+	return s.activeStreams
+}
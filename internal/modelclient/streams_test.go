@@ -0,0 +1,38 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	"k8s.io/utils/ptr"
+)
+
+func TestScaleToZeroWaitsForStreams(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](2), MaxReplicas: ptr.To[int32](4)})
+	mc, k8sClient := newTestModelClient(t, m)
+
+	deregister := mc.RegisterStream(ctx, m.Name)
+	require.Equal(t, 1, mc.ActiveStreams(m.Name))
+
+	// Scale downs to a non-zero number of replicas are not blocked.
+	require.NoError(t, mc.Scale(ctx, m, 0, 0, "idle"))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	snapshot, _ := mc.ScalerSnapshot(m.Name)
+	require.Equal(t, "idle (1 active streams)", snapshot.LastScaleReason)
+	require.Equal(t, 1, snapshot.ActiveStreams)
+
+	deregister()
+	deregister()
+	require.Equal(t, 0, mc.ActiveStreams(m.Name))
+
+	m.Spec.Replicas = ptr.To[int32](1)
+	require.NoError(t, mc.Scale(ctx, m, 0, 0, "idle"))
+	require.Equal(t, int32(0), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+}
@@ -0,0 +1,102 @@
+package modelclient
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// unscalableRetryInterval is the time after which the scale subresource of a
+// scale target that did not implement it is checked again (i.e. after the CRD
+// of the object was updated).
+const unscalableRetryInterval = 10 * time.Minute
+
+// unscalableTarget is a scale target of a model that exists but does not
+// implement the scale subresource.
+type unscalableTarget struct {
+	// key is the unscalableKey of the model when the error was observed.
+	key   string
+	err   error
+	since time.Time
+}
+
+// unscalableError returns the error of the scale target of the model if it was
+// recently observed to not implement the scale subresource. Scale operations
+// should not call the API for it, as it would fail the same way every cycle.
+// Returns nil once the scale target annotations of the model change or the
+// unscalableRetryInterval has passed.
+func (c *ModelClient) unscalableError(model *kubeaiv1.Model) error {
+	key := c.unscalableKey(model)
+
+	c.scalerStatesMtx.RLock()
+	defer c.scalerStatesMtx.RUnlock()
+
+	s, ok := c.scalerStates[model.Name]
+	if !ok || s.unscalable == nil || s.unscalable.key != key {
+		return nil
+	}
+	if c.clock.Since(s.unscalable.since) >= unscalableRetryInterval {
+		return nil
+	}
+	return s.unscalable.err
+}
+
+// recordUnscalable records whether the scale target of the model implements the
+// scale subresource based on the result of a scale operation. Errors other
+// than errNoScaleSubresource do not change the recorded state.
+func (c *ModelClient) recordUnscalable(ctx context.Context, model *kubeaiv1.Model, err error) {
+	unscalable := errors.Is(err, errNoScaleSubresource)
+	if err != nil && !unscalable {
+		return
+	}
+	if !unscalable {
+		// Avoid taking the write lock for models that are not unscalable.
+		c.scalerStatesMtx.RLock()
+		s, ok := c.scalerStates[model.Name]
+		recorded := ok && s.unscalable != nil
+		c.scalerStatesMtx.RUnlock()
+		if !recorded {
+			return
+		}
+	}
+	key := c.unscalableKey(model)
+
+	c.scalerStatesMtx.Lock()
+	s := c.getScalerState(model.Name)
+	changed := (s.unscalable != nil) != unscalable
+	if unscalable {
+		s.unscalable = &unscalableTarget{key: key, err: err, since: c.clock.Now()}
+	} else {
+		s.unscalable = nil
+	}
+	c.scalerStatesMtx.Unlock()
+
+	if unscalable {
+		log.Printf("scale target of model %s does not implement the scale subresource, not scaling it for %s: %v", model.Name, unscalableRetryInterval, err)
+	}
+	if !changed {
+		return
+	}
+	var value int64
+	if unscalable {
+		value = 1
+	}
+	metrics.ModelUnscalable.Record(ctx, value, metric.WithAttributeSet(attribute.NewSet(
+		metrics.AttrRequestModel.String(model.Name),
+	)))
+	c.NotifyStateChange(model.Name)
+}
+
+// unscalableKey identifies the scale target of the model and how it is scaled
+// (see kubeaiv1.ModelScaleTargetStrategyAnnotation).
+func (c *ModelClient) unscalableKey(model *kubeaiv1.Model) string {
+	key, _ := c.scaleTargetKey(model)
+	_, strategy, _ := c.getModelAnnotation(model, kubeaiv1.ModelScaleTargetStrategyAnnotationName)
+	return key + "|" + strategy
+}
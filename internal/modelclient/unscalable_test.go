@@ -0,0 +1,99 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestUnscalableScaleTarget(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	workload := &unstructured.Unstructured{}
+	workload.SetGroupVersionKind(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Workload"})
+	workload.SetNamespace(testNamespace)
+	workload.SetName("my-workload")
+	require.NoError(t, unstructured.SetNestedField(workload.Object, int64(1), "spec", "replicas"))
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{MinReplicas: 2, MaxReplicas: ptr.To[int32](5)})
+	m.Annotations = map[string]string{kubeaiv1.ModelScaleTargetAnnotation: "example.com/v1/Workload/my-workload"}
+
+	// The Workload does not implement the scale subresource.
+	var scaleGets int
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubeaiv1.AddToScheme(scheme))
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(m, workload).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceGet: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+				scaleGets++
+				return apierrors.NewNotFound(schema.GroupResource{Group: "example.com", Resource: "workloads"}, obj.GetName())
+			},
+		}).
+		Build()
+	clk := newTestClock()
+	mc := NewModelClient(k8sClient, testNamespace, Options{Clock: clk})
+
+	err := mc.Scale(ctx, m, 3, 0, "test")
+	require.ErrorIs(t, err, ErrScaleUnscalable)
+	require.NotErrorIs(t, err, ErrScaleNotFound, "the model should not be treated as deleted")
+	require.Equal(t, 1, scaleGets)
+	snapshot, ok := mc.ScalerSnapshot(m.Name)
+	require.True(t, ok)
+	require.True(t, snapshot.Unscalable)
+	metricstest.RequireModelUnscalableMetric(t, metricstest.Collect(t), m.Name, 1)
+
+	// Subsequent scale operations fail without calling the API.
+	require.ErrorIs(t, mc.Scale(ctx, m, 3, 0, "test"), ErrScaleUnscalable)
+	require.ErrorIs(t, mc.EnforceMinReplicas(ctx, m), ErrScaleUnscalable)
+	require.Equal(t, 1, scaleGets)
+
+	// The scale subresource is checked again after the retry interval.
+	clk.Step(unscalableRetryInterval)
+	require.ErrorIs(t, mc.Scale(ctx, m, 3, 0, "test"), ErrScaleUnscalable)
+	require.Equal(t, 2, scaleGets)
+
+	// Changing the scale target strategy makes the model scalable again.
+	m.Annotations[kubeaiv1.ModelScaleTargetStrategyAnnotation] = kubeaiv1.ScaleTargetStrategyReplicas
+	require.NoError(t, mc.Scale(ctx, m, 3, 0, "test"))
+	snapshot, _ = mc.ScalerSnapshot(m.Name)
+	require.False(t, snapshot.Unscalable)
+	metricstest.RequireModelUnscalableMetric(t, metricstest.Collect(t), m.Name, 0)
+}
+
+func TestUnscalableScaleTargetNotFound(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{MaxReplicas: ptr.To[int32](5)})
+	m.Annotations = map[string]string{kubeaiv1.ModelScaleTargetAnnotation: "example.com/v1/Workload/missing"}
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubeaiv1.AddToScheme(scheme))
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(m).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceGet: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+				return apierrors.NewNotFound(schema.GroupResource{Group: "example.com", Resource: "workloads"}, obj.GetName())
+			},
+		}).
+		Build()
+	mc := NewModelClient(k8sClient, testNamespace, Options{})
+
+	err := mc.Scale(ctx, m, 3, 0, "test")
+	require.ErrorIs(t, err, ErrScaleNotFound, "a missing object is not unscalable")
+	require.NotErrorIs(t, err, ErrScaleUnscalable)
+}
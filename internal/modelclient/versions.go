@@ -0,0 +1,55 @@
+package modelclient
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResolveVersioned returns the name of the Model that serves the given version of
+// the model (see kubeaiv1.ModelVersionAnnotation). Returns false if no Model is
+// annotated with the version.
+func (c *ModelClient) ResolveVersioned(ctx context.Context, model, version string) (string, bool, error) {
+	var list kubeaiv1.ModelList
+	if err := c.client.List(ctx, &list, client.InNamespace(c.namespace)); err != nil {
+		return "", false, err
+	}
+	var resolved string
+	for i := range list.Items {
+		m := &list.Items[i]
+		key, value, ok := c.getModelAnnotation(m, kubeaiv1.ModelVersionAnnotationName)
+		if !ok {
+			continue
+		}
+		base, v, ok := parseModelVersion(value)
+		if !ok {
+			log.Printf("WARNING: ignoring invalid %s annotation %q of model %s: expected <model>:<version>", key, value, m.Name)
+			continue
+		}
+		if base != model || v != version {
+			continue
+		}
+		// Prefer the first name so that the result is stable when multiple
+		// Models claim the same version.
+		if resolved != "" {
+			log.Printf("WARNING: models %s and %s are both annotated as version %q of model %s", resolved, m.Name, version, model)
+			if m.Name > resolved {
+				continue
+			}
+		}
+		resolved = m.Name
+	}
+	return resolved, resolved != "", nil
+}
+
+// parseModelVersion parses a value of the kubeaiv1.ModelVersionAnnotation.
+func parseModelVersion(value string) (model, version string, ok bool) {
+	model, version, ok = strings.Cut(value, ":")
+	if !ok || model == "" || version == "" {
+		return "", "", false
+	}
+	return model, version, true
+}
@@ -0,0 +1,41 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+)
+
+func TestResolveVersioned(t *testing.T) {
+	ctx := context.Background()
+
+	v1 := testModel("llama-v1", kubeaiv1.ModelSpec{})
+	v1.Annotations = map[string]string{kubeaiv1.ModelVersionAnnotation: "llama:v1"}
+	v2 := testModel("llama-v2", kubeaiv1.ModelSpec{})
+	v2.Annotations = map[string]string{kubeaiv1.LegacyAnnotationDomain + "/" + kubeaiv1.ModelVersionAnnotationName: "llama:v2"}
+	invalid := testModel("llama-invalid", kubeaiv1.ModelSpec{})
+	invalid.Annotations = map[string]string{kubeaiv1.ModelVersionAnnotation: "v3"}
+	mc, _ := newTestModelClient(t, testModel("llama", kubeaiv1.ModelSpec{}), v1, v2, invalid)
+
+	name, ok, err := mc.ResolveVersioned(ctx, "llama", "v1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "llama-v1", name)
+
+	name, ok, err = mc.ResolveVersioned(ctx, "llama", "v2")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "llama-v2", name)
+
+	for _, version := range []string{"v3", ""} {
+		_, ok, err = mc.ResolveVersioned(ctx, "llama", version)
+		require.NoError(t, err)
+		require.False(t, ok, version)
+	}
+
+	_, ok, err = mc.ResolveVersioned(ctx, "other", "v1")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,81 @@
+package modelclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/k8sutils"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// claimWarmPods claims up to n ready Pods of the warm pool of the model (see
+// kubeaiv1.ModelWarmPoolAnnotation) by labeling them with the model, so that
+// requests are routed to them while the Pods of the model are starting.
+// Pods that are claimed concurrently (i.e. by another KubeAI instance) are skipped.
+// Returns the number of claimed Pods.
+func (c *ModelClient) claimWarmPods(ctx context.Context, model *kubeaiv1.Model, n int32) (int32, error) {
+	_, pool, ok := c.getModelAnnotation(model, kubeaiv1.ModelWarmPoolAnnotationName)
+	if !ok || n <= 0 {
+		return 0, nil
+	}
+
+	var pods corev1.PodList
+	if err := c.client.List(ctx, &pods, client.InNamespace(c.namespace), client.MatchingLabels{kubeaiv1.WarmPoolLabel: pool}); err != nil {
+		return 0, fmt.Errorf("listing warm pool pods: %w", err)
+	}
+
+	var claimed int32
+	for i := range pods.Items {
+		if claimed >= n {
+			break
+		}
+		pod := &pods.Items[i]
+		if _, ok := pod.Labels[kubeaiv1.WarmPoolModelLabel]; ok || pod.DeletionTimestamp != nil || !k8sutils.PodIsReady(pod) {
+			continue
+		}
+
+		patch := client.MergeFromWithOptions(pod.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		pod.Labels[kubeaiv1.WarmPoolModelLabel] = model.Name
+		if err := c.client.Patch(ctx, pod, patch); err != nil {
+			if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+				continue
+			}
+			return claimed, fmt.Errorf("claiming pod %q: %w", pod.Name, err)
+		}
+		log.Printf("claimed pod %s of warm pool %s for model %s", pod.Name, pool, model.Name)
+		claimed++
+	}
+	return claimed, nil
+}
+
+// ReleaseWarmPods returns the warm pool Pods that are claimed by the model to
+// their pool once Pods of the model are ready, or when the model was scaled to
+// zero replicas or is being deleted. Model.Status.Replicas should be up to date.
+func (c *ModelClient) ReleaseWarmPods(ctx context.Context, model *kubeaiv1.Model) error {
+	if model.DeletionTimestamp == nil && model.Status.Replicas.Ready == 0 && ptr.Deref(model.Spec.Replicas, 0) > 0 {
+		return nil
+	}
+
+	var pods corev1.PodList
+	if err := c.client.List(ctx, &pods, client.InNamespace(c.namespace), client.MatchingLabels{kubeaiv1.WarmPoolModelLabel: model.Name}); err != nil {
+		return fmt.Errorf("listing claimed warm pool pods: %w", err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		patch := client.MergeFrom(pod.DeepCopy())
+		delete(pod.Labels, kubeaiv1.WarmPoolModelLabel)
+		if err := c.client.Patch(ctx, pod, patch); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("releasing pod %q: %w", pod.Name, err)
+		}
+		log.Printf("released pod %s of warm pool %s from model %s", pod.Name, pod.Labels[kubeaiv1.WarmPoolLabel], model.Name)
+	}
+	return nil
+}
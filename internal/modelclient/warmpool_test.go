@@ -0,0 +1,79 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient/modelclienttest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestWarmPool(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, kubeaiv1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	warmPod := func(name string, ready bool, labels map[string]string) *corev1.Pod {
+		labels[kubeaiv1.WarmPoolLabel] = "gpu"
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: labels},
+			Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+		}
+	}
+
+	m := testModel("my-model", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](0), MaxReplicas: ptr.To[int32](3)})
+	m.Annotations = map[string]string{kubeaiv1.ModelWarmPoolAnnotation: "gpu"}
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			m,
+			warmPod("claimed-by-other", true, map[string]string{kubeaiv1.WarmPoolModelLabel: "other-model"}),
+			warmPod("not-ready", false, map[string]string{}),
+			warmPod("available", true, map[string]string{}),
+			warmPod("other-pool", true, map[string]string{kubeaiv1.WarmPoolLabel: "cpu"}),
+		).
+		WithInterceptorFuncs(interceptor.Funcs{SubResourceUpdate: modelclienttest.UpdateModelScale}).
+		Build()
+	mc := NewModelClient(k8sClient, testNamespace, Options{})
+
+	requireClaimedBy := func(pod, model string) {
+		t.Helper()
+		var p corev1.Pod
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: testNamespace, Name: pod}, &p))
+		require.Equal(t, model, p.Labels[kubeaiv1.WarmPoolModelLabel])
+	}
+
+	// Scaling up from zero claims the ready, unclaimed Pods of the pool.
+	require.NoError(t, mc.ScaleAtLeastOneReplica(ctx, m.Name))
+	require.Equal(t, int32(1), modelclienttest.ModelReplicas(t, k8sClient, testNamespace, m.Name))
+	requireClaimedBy("available", m.Name)
+	requireClaimedBy("not-ready", "")
+	requireClaimedBy("claimed-by-other", "other-model")
+	requireClaimedBy("other-pool", "")
+
+	// Kept while the Pods of the model are starting.
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(m), m))
+	require.NoError(t, mc.ReleaseWarmPods(ctx, m))
+	requireClaimedBy("available", m.Name)
+
+	// Released once a Pod of the model is ready.
+	m.Status.Replicas.Ready = 1
+	require.NoError(t, mc.ReleaseWarmPods(ctx, m))
+	requireClaimedBy("available", "")
+	requireClaimedBy("claimed-by-other", "other-model")
+}
@@ -0,0 +1,82 @@
+package modelclient
+
+import (
+	"slices"
+	"sync"
+)
+
+// StateWatch is notified of the models whose scaling state changed (see WatchStates).
+// Changes are coalesced per model until they are consumed, so a watcher that
+// falls behind receives the latest state of each changed model instead of
+// every intermediate change, and never blocks scaling.
+type StateWatch struct {
+	mtx     sync.Mutex
+	changed map[string]struct{}
+	ready   chan struct{}
+}
+
+// Ready returns a channel that receives a value when changes are available (see Changed).
+func (w *StateWatch) Ready() <-chan struct{} {
+	return w.ready
+}
+
+// Changed returns the (sorted) names of the models that changed since the
+// previous call and resets them.
+func (w *StateWatch) Changed() []string {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	models := make([]string, 0, len(w.changed))
+	for model := range w.changed {
+		models = append(models, model)
+	}
+	clear(w.changed)
+	slices.Sort(models)
+	return models
+}
+
+func (w *StateWatch) notify(model string) {
+	w.mtx.Lock()
+	w.changed[model] = struct{}{}
+	w.mtx.Unlock()
+
+	select {
+	case w.ready <- struct{}{}:
+	default:
+		// Already signaled.
+	}
+}
+
+// WatchStates returns a StateWatch that is notified when the scaling state
+// of a model changes: its replicas were written, the model was pinned or
+// unpinned, and changes of the deferred replicas, saturation, scale target
+// pause and active streams (see ScalerSnapshot). Changes of the last activity
+// time are not notified. The returned function stops the watch.
+func (c *ModelClient) WatchStates() (*StateWatch, func()) {
+	w := &StateWatch{
+		changed: map[string]struct{}{},
+		ready:   make(chan struct{}, 1),
+	}
+
+	c.watchesMtx.Lock()
+	c.watches[w] = struct{}{}
+	c.watchesMtx.Unlock()
+
+	return w, func() {
+		c.watchesMtx.Lock()
+		delete(c.watches, w)
+		c.watchesMtx.Unlock()
+	}
+}
+
+// NotifyStateChange notifies the state watches that the state of the model
+// changed. Used for state that is tracked outside of the ModelClient (i.e. the
+// endpoints of the model).
+func (c *ModelClient) NotifyStateChange(model string) {
+	c.watchesMtx.Lock()
+	defer c.watchesMtx.Unlock()
+
+	for w := range c.watches {
+		w.notify(model)
+	}
+}
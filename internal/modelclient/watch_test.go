@@ -0,0 +1,48 @@
+package modelclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"k8s.io/utils/ptr"
+)
+
+func TestWatchStates(t *testing.T) {
+	metricstest.Init(t)
+	ctx := context.Background()
+
+	a := testModel("model-a", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1)})
+	b := testModel("model-b", kubeaiv1.ModelSpec{Replicas: ptr.To[int32](1)})
+	mc, _ := newTestModelClient(t, a, b)
+
+	watch, stop := mc.WatchStates()
+
+	// Changes are coalesced until they are consumed.
+	require.NoError(t, mc.ForceScale(ctx, b.Name, 2, time.Hour))
+	require.NoError(t, mc.ForceScale(ctx, b.Name, 3, time.Hour))
+	mc.setSaturated(ctx, a, true)
+	select {
+	case <-watch.Ready():
+	default:
+		t.Fatal("expected the watch to be ready")
+	}
+	require.Equal(t, []string{a.Name, b.Name}, watch.Changed())
+	require.Empty(t, watch.Changed())
+
+	// Unchanged state is not notified.
+	mc.setSaturated(ctx, a, true)
+	require.Empty(t, watch.Changed())
+
+	closeStream := mc.RegisterStream(ctx, a.Name)
+	closeStream()
+	require.Equal(t, []string{a.Name}, watch.Changed())
+
+	// Stopped watches are not notified.
+	stop()
+	mc.NotifyStateChange(a.Name)
+	require.Empty(t, watch.Changed())
+}
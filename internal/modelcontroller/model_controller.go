@@ -27,17 +27,25 @@ import (
 
 	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
 	"github.com/substratusai/kubeai/internal/config"
 	"github.com/substratusai/kubeai/internal/k8sutils"
+	"github.com/substratusai/kubeai/internal/modelclient"
 	"github.com/substratusai/kubeai/internal/vllmclient"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -63,6 +71,33 @@ type ModelReconciler struct {
 	ModelServerPods         config.ModelServerPods
 	ModelLoaders            config.ModelLoading
 	ModelRollouts           config.ModelRollouts
+	// AnnotationDomains are the domains that Model annotations are read from
+	// (see kubeaiv1.AnnotationDomains).
+	AnnotationDomains []string
+	// ModelSelector restricts the Models that are reconciled. Models that do not
+	// match are ignored, regardless of the kubeaiv1.ModelManagedAnnotation.
+	// All Models are reconciled when nil.
+	ModelSelector labels.Selector
+	// Recorder is used to emit Events about Models.
+	// Defaults to a recorder from the manager.
+	Recorder record.EventRecorder
+	// Scaler enforces the replica floor of Models on every reconcile,
+	// including Models that are scaled via a scale target annotation, and
+	// releases the warm pool Pods of Models once their own Pods are ready.
+	// Optional.
+	Scaler Scaler
+
+	// reconcileRequests is used to trigger reconciles outside of watch events.
+	reconcileRequests chan event.GenericEvent
+	// elected is closed once this instance is elected as the leader of the
+	// manager, which starts the controller.
+	elected <-chan struct{}
+}
+
+// Scaler scales Models.
+type Scaler interface {
+	EnforceMinReplicas(ctx context.Context, model *kubeaiv1.Model) error
+	ReleaseWarmPods(ctx context.Context, model *kubeaiv1.Model) error
 }
 
 // +kubebuilder:rbac:groups=kubeai.org,resources=models,verbs=get;list;watch;create;update;patch;delete
@@ -82,6 +117,11 @@ func (r *ModelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 	if err := r.Get(ctx, req.NamespacedName, model); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	// Reconciles can be triggered by owned objects and EnqueueReconcile.
+	if !r.selectsModel(model) {
+		log.Info("Model does not match the model selector, skipping")
+		return ctrl.Result{}, nil
+	}
 
 	status0 := model.Status.DeepCopy()
 
@@ -93,6 +133,15 @@ func (r *ModelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 		}
 	}()
 
+	if model.DeletionTimestamp == nil && !kubeaiv1.IsModelManaged(model, r.AnnotationDomains) {
+		// Only report the status of the existing Pods so that routing keeps working.
+		log.Info("Model is not managed, skipping Pod management")
+		if _, err := r.reconcileReplicaStatus(ctx, model); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// Apply self labels based on features so that we can easily filter models.
 	shouldUpdate := r.applySelfLabels(model)
 	// Apply replica bounds to handle cases where min/max replicas were updated but a scale event was not triggered.
@@ -104,6 +153,14 @@ func (r *ModelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 			return ctrl.Result{}, fmt.Errorf("updating model: %w", err)
 		}
 	}
+	if r.Scaler != nil && model.DeletionTimestamp == nil {
+		err := r.Scaler.EnforceMinReplicas(ctx, model)
+		if err != nil && !errors.Is(err, modelclient.ErrScaleUnscalable) {
+			return ctrl.Result{}, fmt.Errorf("enforcing min replicas: %w", err)
+		}
+		// Retrying the same error every reconcile would not help.
+		r.reconcileScalable(model, err)
+	}
 
 	modelConfig, err := r.getModelConfig(model)
 	if err != nil {
@@ -111,6 +168,11 @@ func (r *ModelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 	}
 
 	if model.DeletionTimestamp != nil {
+		if r.Scaler != nil {
+			if err := r.Scaler.ReleaseWarmPods(ctx, model); err != nil {
+				return ctrl.Result{}, fmt.Errorf("releasing warm pool pods: %w", err)
+			}
+		}
 		// Get rid of all Pods for the Model.
 		// This should help avoid any issues with cache cleanup.
 		if err := r.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace(model.Namespace), client.MatchingLabels{
@@ -146,22 +208,16 @@ func (r *ModelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 		}
 	}
 
-	allPods := &corev1.PodList{}
-	if err := r.List(ctx, allPods, client.InNamespace(model.Namespace), client.MatchingLabels{
-		kubeaiv1.PodModelLabel: model.Name,
-	}); err != nil {
-		return ctrl.Result{}, fmt.Errorf("listing all node pools: %w", err)
+	allPods, err := r.reconcileReplicaStatus(ctx, model)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
-
-	// Summarize all pods.
-	var readyPods int32
-	for _, pod := range allPods.Items {
-		if k8sutils.PodIsReady(&pod) {
-			readyPods++
+	if r.Scaler != nil {
+		if err := r.Scaler.ReleaseWarmPods(ctx, model); err != nil {
+			return ctrl.Result{}, fmt.Errorf("releasing warm pool pods: %w", err)
 		}
 	}
-	model.Status.Replicas.All = int32(len(allPods.Items))
-	model.Status.Replicas.Ready = readyPods
+	requeueAfter := minRequeueAfter(r.reconcileReadiness(model, allPods), r.convergingRequeueAfter(model))
 
 	scaled := false
 	defer func() {
@@ -175,6 +231,11 @@ func (r *ModelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 	}()
 
 	plan := r.calculatePodPlan(allPods, model, modelConfig)
+	// Recorded before the Pods are created, so that routing knows the latest
+	// generation during the rollout.
+	if err := r.reconcilePodHash(ctx, model, plan.podHash); err != nil {
+		return ctrl.Result{}, err
+	}
 	if plan.containsActions() {
 		var err error
 		scaled, err = plan.execute(ctx, r.Client, r.Scheme)
@@ -190,20 +251,113 @@ func (r *ModelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 		return ctrl.Result{}, fmt.Errorf("reconciling adapters: %w", err)
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// reconcilePodHash records the hash of the Pods that are created for the Model
+// in the kubeaiv1.ModelPodHashAnnotation.
+func (r *ModelReconciler) reconcilePodHash(ctx context.Context, model *kubeaiv1.Model, hash string) error {
+	if model.Annotations[kubeaiv1.ModelPodHashAnnotation] == hash {
+		return nil
+	}
+	// The status of the Model is updated separately, so the patch is applied to a copy.
+	patched := model.DeepCopy()
+	patch := client.MergeFrom(model.DeepCopy())
+	if patched.Annotations == nil {
+		patched.Annotations = map[string]string{}
+	}
+	patched.Annotations[kubeaiv1.ModelPodHashAnnotation] = hash
+	if err := r.Patch(ctx, patched, patch); err != nil {
+		return fmt.Errorf("patching pod hash annotation: %w", err)
+	}
+	model.Annotations = patched.Annotations
+	model.ResourceVersion = patched.ResourceVersion
+	return nil
+}
+
+// reconcileReplicaStatus lists all Pods of the Model and summarizes them in the Model status.
+func (r *ModelReconciler) reconcileReplicaStatus(ctx context.Context, model *kubeaiv1.Model) (*corev1.PodList, error) {
+	allPods := &corev1.PodList{}
+	if err := r.List(ctx, allPods, client.InNamespace(model.Namespace), client.MatchingLabels{
+		kubeaiv1.PodModelLabel: model.Name,
+	}); err != nil {
+		return nil, fmt.Errorf("listing all node pools: %w", err)
+	}
+
+	// Summarize all pods.
+	var readyPods int32
+	for _, pod := range allPods.Items {
+		if k8sutils.PodIsReady(&pod) {
+			readyPods++
+		}
+	}
+	model.Status.Replicas.All = int32(len(allPods.Items))
+	model.Status.Replicas.Ready = readyPods
+
+	return allPods, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ModelReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.reconcileRequests = make(chan event.GenericEvent, reconcileRequestsBufferSize)
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor(modelReconcilerName)
+	}
+	r.elected = mgr.Elected()
 	// TODO: Set Model concurrency. Pod rollouts can be slow.
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&kubeaiv1.Model{}).
+		For(&kubeaiv1.Model{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			model, ok := obj.(*kubeaiv1.Model)
+			return ok && r.selectsModel(model)
+		}))).
 		Owns(&corev1.Pod{}).
 		Owns(&corev1.PersistentVolumeClaim{}).
 		Owns(&batchv1.Job{}).
+		WatchesRawSource(source.Channel(r.reconcileRequests, &handler.EnqueueRequestForObject{})).
 		Complete(r)
 }
 
+// selectsModel returns true if the Model matches the ModelSelector. Models that
+// are being deleted are always selected so that their finalizers are removed.
+func (r *ModelReconciler) selectsModel(model *kubeaiv1.Model) bool {
+	return r.ModelSelector == nil || model.DeletionTimestamp != nil || r.ModelSelector.Matches(labels.Set(model.GetLabels()))
+}
+
+const reconcileRequestsBufferSize = 1000
+
+// EnqueueReconcile triggers an immediate reconcile of the given Models.
+// It returns the number of Models that were enqueued. Nothing is enqueued
+// when this instance is not the leader, as the controller is not running and
+// the requests would be stale once it is elected. Requests are dropped when
+// the buffer is full.
+func (r *ModelReconciler) EnqueueReconcile(models []kubeaiv1.Model) int {
+	if !r.leading() {
+		return 0
+	}
+	var enqueued int
+	for i := range models {
+		select {
+		case r.reconcileRequests <- event.GenericEvent{Object: &models[i]}:
+			enqueued++
+		default:
+		}
+	}
+	return enqueued
+}
+
+// leading returns true once this instance is the leader and reconciles Models.
+func (r *ModelReconciler) leading() bool {
+	if r.elected == nil {
+		return false
+	}
+	select {
+	case <-r.elected:
+		return true
+	default:
+		return false
+	}
+}
+
 var errReturnEarly = fmt.Errorf("return early")
 
 const (
@@ -350,7 +504,21 @@ func (r *ModelReconciler) applyAutoscalingReplicaBounds(model *kubeaiv1.Model) b
 	min := model.Spec.MinReplicas
 	max := model.Spec.MaxReplicas
 
-	if model.Spec.Replicas == nil || *model.Spec.Replicas < min {
+	if model.Spec.Replicas == nil {
+		// Models without a minimum start at their standby replicas (if any).
+		if model.Spec.IdleMinReplicas != nil && *model.Spec.IdleMinReplicas > min {
+			model.Spec.Replicas = ptr.To(*model.Spec.IdleMinReplicas)
+		} else {
+			model.Spec.Replicas = ptr.To(min)
+		}
+		return true
+	}
+
+	// The autoscaler is allowed to scale idle models below MinReplicas.
+	if model.Spec.IdleMinReplicas != nil {
+		min = *model.Spec.IdleMinReplicas
+	}
+	if *model.Spec.Replicas < min {
 		model.Spec.Replicas = ptr.To(min)
 		return true
 	}
@@ -9,6 +9,9 @@ import (
 	"github.com/substratusai/kubeai/internal/config"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 func Test_getModelConfig(t *testing.T) {
@@ -146,3 +149,45 @@ func requireEqualJSON(t *testing.T, a, b interface{}) {
 	require.NoError(t, err)
 	require.JSONEq(t, string(jsonA), string(jsonB))
 }
+
+func TestSelectsModel(t *testing.T) {
+	selector, err := labels.Parse("team=ml")
+	require.NoError(t, err)
+	now := metav1.Now()
+
+	cases := map[string]struct {
+		selector labels.Selector
+		model    metav1.ObjectMeta
+		exp      bool
+	}{
+		"no selector":  {model: metav1.ObjectMeta{}, exp: true},
+		"matching":     {selector: selector, model: metav1.ObjectMeta{Labels: map[string]string{"team": "ml"}}, exp: true},
+		"not matching": {selector: selector, model: metav1.ObjectMeta{Labels: map[string]string{"team": "other"}}, exp: false},
+		"not matching but deleted": {
+			selector: selector,
+			model:    metav1.ObjectMeta{DeletionTimestamp: &now},
+			exp:      true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := ModelReconciler{ModelSelector: c.selector}
+			require.Equal(t, c.exp, r.selectsModel(&v1.Model{ObjectMeta: c.model}))
+		})
+	}
+}
+
+func TestEnqueueReconcile(t *testing.T) {
+	elected := make(chan struct{})
+	r := ModelReconciler{
+		reconcileRequests: make(chan event.GenericEvent, 2),
+		elected:           elected,
+	}
+	models := []v1.Model{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}, {ObjectMeta: metav1.ObjectMeta{Name: "b"}}, {ObjectMeta: metav1.ObjectMeta{Name: "c"}}}
+
+	require.Equal(t, 0, r.EnqueueReconcile(models), "should not enqueue before being elected")
+	require.Empty(t, r.reconcileRequests)
+
+	close(elected)
+	require.Equal(t, 2, r.EnqueueReconcile(models), "should drop requests when the buffer is full")
+}
@@ -142,6 +142,7 @@ func (r *ModelReconciler) calculatePodPlan(allPods *corev1.PodList, model *kubea
 
 	return &podPlan{
 		model:    model,
+		podHash:  expectedHash,
 		toCreate: toCreate,
 		toDelete: toDelete,
 		toRemain: toRemain,
@@ -150,7 +151,9 @@ func (r *ModelReconciler) calculatePodPlan(allPods *corev1.PodList, model *kubea
 }
 
 type podPlan struct {
-	model    *kubeaiv1.Model
+	model *kubeaiv1.Model
+	// podHash is the PodHashLabel of the Pods that are created for the Model.
+	podHash  string
 	toCreate []*corev1.Pod
 	toDelete []*corev1.Pod
 	toRemain []*corev1.Pod
@@ -205,7 +208,9 @@ func (pp *podPlan) execute(ctx context.Context, client client.Client, scheme *ru
 }
 
 // sortPodsByDeletionOrder ensures Pods that are to be deleted/recreated
-// first are lower index.
+// first are lower index. This ordering also determines which Pods are removed
+// when a Model is scaled down, so that Ready Pods are not removed while Pods
+// that are still starting up are kept.
 func sortPodsByDeletionOrder(pods []corev1.Pod, expectedHash string) {
 	sort.SliceStable(pods, func(i, j int) bool {
 		// Not ready Pods should be deleted first.
@@ -163,6 +163,9 @@ func Test_calculatePodPlan(t *testing.T) {
 			}
 			require.Lenf(t, deletionNames, len(c.wantDeletions), "Unexpected deletion count, details: %v", detailsCSV)
 			require.Equalf(t, c.wantDeletions, deletionNames, "Unexpected deleteion names, details: %v", detailsCSV)
+			for _, p := range plan.toCreate {
+				require.Equal(t, plan.podHash, p.Labels[v1.PodHashLabel], "Created Pods should have the recorded pod hash")
+			}
 		})
 	}
 }
@@ -0,0 +1,101 @@
+package modelcontroller
+
+import (
+	"fmt"
+	"time"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reconcileReadiness records whether the Model has ever had a ready replica
+// in the kubeaiv1.ModelConditionNeverReady condition. It returns the duration
+// after which the condition should be re-evaluated (0 if not needed).
+// Should be called after the replica status was reconciled.
+func (r *ModelReconciler) reconcileReadiness(model *kubeaiv1.Model, allPods *corev1.PodList) time.Duration {
+	timeout := r.ModelRollouts.ReadyTimeout.Duration
+	if timeout == 0 {
+		return 0
+	}
+
+	if model.Status.Replicas.Ready > 0 {
+		meta.SetStatusCondition(&model.Status.Conditions, metav1.Condition{
+			Type:               kubeaiv1.ModelConditionNeverReady,
+			Status:             metav1.ConditionFalse,
+			Reason:             "ReplicaReady",
+			Message:            "At least one replica has been ready.",
+			ObservedGeneration: model.Generation,
+		})
+		return 0
+	}
+
+	if cond := meta.FindStatusCondition(model.Status.Conditions, kubeaiv1.ModelConditionNeverReady); cond != nil {
+		// The Model has been ready before or was already reported.
+		return 0
+	}
+
+	// Measure from the creation of the oldest Pod so that a Model that
+	// is scaled to zero is never reported.
+	var oldest time.Time
+	for _, pod := range allPods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if created := pod.CreationTimestamp.Time; oldest.IsZero() || created.Before(oldest) {
+			oldest = created
+		}
+	}
+	if oldest.IsZero() {
+		return 0
+	}
+	if waiting := time.Since(oldest); waiting < timeout {
+		return timeout - waiting
+	}
+
+	msg := fmt.Sprintf("No replica became ready within %s, check the logs of the model server Pods.", timeout)
+	meta.SetStatusCondition(&model.Status.Conditions, metav1.Condition{
+		Type:               kubeaiv1.ModelConditionNeverReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ReadyTimeout",
+		Message:            msg,
+		ObservedGeneration: model.Generation,
+	})
+	if r.Recorder != nil {
+		r.Recorder.Event(model, corev1.EventTypeWarning, "NeverReady", msg)
+	}
+	return 0
+}
+
+// convergingRequeueAfter returns the duration after which the Model should be
+// reconciled again because not all of its desired replicas are ready (0 if not needed).
+// Pod events also trigger reconciles, but requeueing keeps the status fresh when
+// endpoints lag behind.
+// Should be called after the replica status was reconciled.
+func (r *ModelReconciler) convergingRequeueAfter(model *kubeaiv1.Model) time.Duration {
+	interval := r.ModelRollouts.ConvergingRequeueInterval.Duration
+	if interval == 0 {
+		return 0
+	}
+
+	var desired int32
+	if model.Spec.Replicas != nil {
+		desired = *model.Spec.Replicas
+	}
+	if model.Status.Replicas.Ready == desired && model.Status.Replicas.All == desired {
+		return 0
+	}
+	return interval
+}
+
+// minRequeueAfter returns the shortest of the given durations, ignoring zero durations.
+func minRequeueAfter(durations ...time.Duration) time.Duration {
+	var min time.Duration
+	for _, d := range durations {
+		if d > 0 && (min == 0 || d < min) {
+			min = d
+		}
+	}
+	return min
+}
@@ -0,0 +1,116 @@
+package modelcontroller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+)
+
+func Test_reconcileReadiness(t *testing.T) {
+	const timeout = 10 * time.Minute
+
+	podCreatedAgo := func(ago time.Duration) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(time.Now().Add(-ago))}}
+	}
+
+	cases := map[string]struct {
+		readyReplicas   int32
+		pods            []corev1.Pod
+		conditions      []metav1.Condition
+		expStatus       metav1.ConditionStatus
+		expEvent        bool
+		expRequeueAfter bool
+	}{
+		"scaled to zero": {},
+		"waiting for first replica": {
+			pods:            []corev1.Pod{podCreatedAgo(time.Minute)},
+			expRequeueAfter: true,
+		},
+		"timed out": {
+			pods:      []corev1.Pod{podCreatedAgo(time.Minute), podCreatedAgo(time.Hour)},
+			expStatus: metav1.ConditionTrue,
+			expEvent:  true,
+		},
+		"ready": {
+			readyReplicas: 1,
+			pods:          []corev1.Pod{podCreatedAgo(time.Hour)},
+			expStatus:     metav1.ConditionFalse,
+		},
+		"ready before": {
+			pods:       []corev1.Pod{podCreatedAgo(time.Hour)},
+			conditions: []metav1.Condition{{Type: v1.ModelConditionNeverReady, Status: metav1.ConditionFalse, Reason: "ReplicaReady"}},
+			expStatus:  metav1.ConditionFalse,
+		},
+		"ready after timing out": {
+			readyReplicas: 1,
+			pods:          []corev1.Pod{podCreatedAgo(time.Hour)},
+			conditions:    []metav1.Condition{{Type: v1.ModelConditionNeverReady, Status: metav1.ConditionTrue, Reason: "ReadyTimeout"}},
+			expStatus:     metav1.ConditionFalse,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &ModelReconciler{
+				ModelRollouts: config.ModelRollouts{ReadyTimeout: config.Duration{Duration: timeout}},
+				Recorder:      recorder,
+			}
+			model := &v1.Model{}
+			model.Status.Replicas.Ready = c.readyReplicas
+			model.Status.Conditions = c.conditions
+
+			requeueAfter := r.reconcileReadiness(model, &corev1.PodList{Items: c.pods})
+			require.Equal(t, c.expRequeueAfter, requeueAfter > 0, "unexpected requeue after: %v", requeueAfter)
+
+			cond := meta.FindStatusCondition(model.Status.Conditions, v1.ModelConditionNeverReady)
+			if c.expStatus == "" {
+				require.Nil(t, cond)
+			} else {
+				require.NotNil(t, cond)
+				require.Equal(t, c.expStatus, cond.Status)
+			}
+			if c.expEvent {
+				require.Len(t, recorder.Events, 1)
+				require.Contains(t, <-recorder.Events, "NeverReady")
+			} else {
+				require.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
+
+func Test_convergingRequeueAfter(t *testing.T) {
+	const interval = 5 * time.Second
+
+	cases := map[string]struct {
+		desired    *int32
+		all, ready int32
+		exp        time.Duration
+	}{
+		"scaled to zero":   {},
+		"scaling up":       {desired: ptr.To[int32](2), all: 2, ready: 1, exp: interval},
+		"scaling down":     {desired: ptr.To[int32](1), all: 2, ready: 2, exp: interval},
+		"all ready":        {desired: ptr.To[int32](2), all: 2, ready: 2},
+		"scaling from one": {desired: ptr.To[int32](0), all: 1, ready: 1, exp: interval},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &ModelReconciler{ModelRollouts: config.ModelRollouts{ConvergingRequeueInterval: config.Duration{Duration: interval}}}
+			model := &v1.Model{Spec: v1.ModelSpec{Replicas: c.desired}}
+			model.Status.Replicas.All = c.all
+			model.Status.Replicas.Ready = c.ready
+			require.Equal(t, c.exp, r.convergingRequeueAfter(model))
+		})
+	}
+
+	require.Zero(t, (&ModelReconciler{}).convergingRequeueAfter(&v1.Model{Spec: v1.ModelSpec{Replicas: ptr.To[int32](1)}}))
+	require.Equal(t, time.Second, minRequeueAfter(0, 3*time.Second, time.Second))
+}
@@ -0,0 +1,47 @@
+package modelcontroller
+
+import (
+	"errors"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/modelclient"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reconcileScalable records whether the scale target of the Model implements
+// the scale subresource in the kubeaiv1.ModelConditionUnscalable condition,
+// based on the error of the most recent scale operation. The condition is only
+// added once the Model was unscalable.
+func (r *ModelReconciler) reconcileScalable(model *kubeaiv1.Model, scaleErr error) {
+	if !errors.Is(scaleErr, modelclient.ErrScaleUnscalable) {
+		if meta.FindStatusCondition(model.Status.Conditions, kubeaiv1.ModelConditionUnscalable) == nil {
+			return
+		}
+		meta.SetStatusCondition(&model.Status.Conditions, metav1.Condition{
+			Type:               kubeaiv1.ModelConditionUnscalable,
+			Status:             metav1.ConditionFalse,
+			Reason:             "ScaleSubresourceFound",
+			Message:            "The scale target implements the scale subresource.",
+			ObservedGeneration: model.Generation,
+		})
+		return
+	}
+
+	msg := scaleErr.Error()
+	if cond := meta.FindStatusCondition(model.Status.Conditions, kubeaiv1.ModelConditionUnscalable); cond != nil &&
+		cond.Status == metav1.ConditionTrue && cond.Message == msg {
+		return
+	}
+	meta.SetStatusCondition(&model.Status.Conditions, metav1.Condition{
+		Type:               kubeaiv1.ModelConditionUnscalable,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ScaleSubresourceNotFound",
+		Message:            msg,
+		ObservedGeneration: model.Generation,
+	})
+	if r.Recorder != nil {
+		r.Recorder.Event(model, corev1.EventTypeWarning, "Unscalable", msg)
+	}
+}
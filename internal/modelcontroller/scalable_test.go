@@ -0,0 +1,64 @@
+package modelcontroller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/modelclient"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func Test_reconcileScalable(t *testing.T) {
+	unscalableErr := &modelclient.ScaleError{Kind: modelclient.ScaleErrorUnscalable, Op: "get", Err: errors.New("no scale subresource")}
+
+	cases := map[string]struct {
+		err        error
+		conditions []metav1.Condition
+		expStatus  metav1.ConditionStatus
+		expEvent   bool
+	}{
+		"scalable": {},
+		"unscalable": {
+			err:       unscalableErr,
+			expStatus: metav1.ConditionTrue,
+			expEvent:  true,
+		},
+		"already reported": {
+			err:        unscalableErr,
+			conditions: []metav1.Condition{{Type: v1.ModelConditionUnscalable, Status: metav1.ConditionTrue, Message: unscalableErr.Error()}},
+			expStatus:  metav1.ConditionTrue,
+		},
+		"scalable again": {
+			conditions: []metav1.Condition{{Type: v1.ModelConditionUnscalable, Status: metav1.ConditionTrue, Message: unscalableErr.Error()}},
+			expStatus:  metav1.ConditionFalse,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &ModelReconciler{Recorder: recorder}
+			model := &v1.Model{}
+			model.Status.Conditions = c.conditions
+
+			r.reconcileScalable(model, c.err)
+
+			cond := meta.FindStatusCondition(model.Status.Conditions, v1.ModelConditionUnscalable)
+			if c.expStatus == "" {
+				require.Nil(t, cond)
+			} else {
+				require.NotNil(t, cond)
+				require.Equal(t, c.expStatus, cond.Status)
+			}
+			if c.expEvent {
+				require.Len(t, recorder.Events, 1)
+				require.Contains(t, <-recorder.Events, "Unscalable")
+			} else {
+				require.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
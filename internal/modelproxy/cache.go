@@ -0,0 +1,223 @@
+package modelproxy
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/substratusai/kubeai/internal/modelclient"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// cacheHeader is the response header that is set to "hit" for responses that
+// were served from the response cache.
+const cacheHeader = "X-KubeAI-Cache"
+
+// maxCachedResponseBytes is the size limit of cached response bodies.
+// Larger responses are not cached.
+const maxCachedResponseBytes = 1 << 20
+
+// maxResponseCacheBytes is the size limit of the cached response bodies of all
+// Models. The least recently used responses (of any Model) are evicted beyond
+// it, so that the memory of the cache is bounded even when many Models cache
+// responses or MaxEntries is large.
+const maxResponseCacheBytes = 256 << 20
+
+// responseCache caches the responses to deterministic requests by Model
+// (see kubeaiv1.ModelResponseCacheTTLAnnotation).
+type responseCache struct {
+	mtx    sync.Mutex
+	models map[string]*modelResponseCache
+	// lru holds the *cachedResponse entries of all Models, most recently
+	// used first.
+	lru *list.List
+	// bytes is the total size of the cached response bodies.
+	bytes int
+}
+
+// modelResponseCache holds the cached responses of one Model.
+type modelResponseCache struct {
+	// uid, generation and podHash identify the Model and the Pods that the
+	// responses were served by (see modelclient.ResponseCache).
+	uid        types.UID
+	generation int64
+	podHash    string
+	// lru holds the *cachedResponse entries of the Model, most recently used
+	// first.
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cachedResponse struct {
+	model   string
+	key     string
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+	// elem is the element of the response in the lru of the responseCache.
+	elem *list.Element
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{models: map[string]*modelResponseCache{}, lru: list.New()}
+}
+
+// responseCacheKey returns the cache key of a request: the path and the
+// (normalized) body. The body of JSON requests is re-marshalled when the
+// request is parsed, which sorts its fields.
+func responseCacheKey(path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// forModel returns the cache of the given Model. The cache is reset if the
+// Model was recreated, its spec changed or its Pods were replaced.
+// The caller must hold the mtx.
+func (c *responseCache) forModel(model string, cfg modelclient.ResponseCache) *modelResponseCache {
+	mc, ok := c.models[model]
+	if ok && mc.uid == cfg.UID && mc.generation == cfg.Generation && mc.podHash == cfg.PodHash {
+		return mc
+	}
+	c.forget(model)
+	mc = &modelResponseCache{
+		uid:        cfg.UID,
+		generation: cfg.Generation,
+		podHash:    cfg.PodHash,
+		lru:        list.New(),
+		entries:    map[string]*list.Element{},
+	}
+	c.models[model] = mc
+	return mc
+}
+
+// forgetModel removes the cached responses of the Model (i.e. once it was deleted).
+func (c *responseCache) forgetModel(model string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.forget(model)
+}
+
+// forget removes the cached responses of the Model. The caller must hold the mtx.
+func (c *responseCache) forget(model string) {
+	mc, ok := c.models[model]
+	if !ok {
+		return
+	}
+	for mc.lru.Len() > 0 {
+		c.remove(mc.lru.Back().Value.(*cachedResponse))
+	}
+	delete(c.models, model)
+}
+
+// get returns the cached response for the key, unless it expired.
+func (c *responseCache) get(model string, cfg modelclient.ResponseCache, key string, now time.Time) (*cachedResponse, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	mc := c.forModel(model, cfg)
+	e, ok := mc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	resp := e.Value.(*cachedResponse)
+	if !now.Before(resp.expires) {
+		c.remove(resp)
+		return nil, false
+	}
+	mc.lru.MoveToFront(e)
+	c.lru.MoveToFront(resp.elem)
+	return resp, true
+}
+
+// put caches the response and evicts the least recently used responses of the
+// Model beyond its max entries, and of all Models beyond maxResponseCacheBytes.
+func (c *responseCache) put(model string, cfg modelclient.ResponseCache, resp *cachedResponse) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	mc := c.forModel(model, cfg)
+	if e, ok := mc.entries[resp.key]; ok {
+		c.remove(e.Value.(*cachedResponse))
+	}
+	resp.model = model
+	mc.entries[resp.key] = mc.lru.PushFront(resp)
+	resp.elem = c.lru.PushFront(resp)
+	c.bytes += len(resp.body)
+	for mc.lru.Len() > cfg.MaxEntries {
+		c.remove(mc.lru.Back().Value.(*cachedResponse))
+	}
+	for c.bytes > maxResponseCacheBytes {
+		c.remove(c.lru.Back().Value.(*cachedResponse))
+	}
+}
+
+// remove removes the response from the cache. The caller must hold the mtx.
+func (c *responseCache) remove(resp *cachedResponse) {
+	mc := c.models[resp.model]
+	mc.lru.Remove(mc.entries[resp.key])
+	delete(mc.entries, resp.key)
+	c.lru.Remove(resp.elem)
+	c.bytes -= len(resp.body)
+}
+
+// ForgetModel drops the cached responses of the Model (i.e. once it was deleted).
+func (h *Handler) ForgetModel(model string) {
+	h.cache.forgetModel(model)
+}
+
+// serveCached writes the cached response of the request (if any).
+// Returns false if no response is cached.
+func (h *Handler) serveCached(w http.ResponseWriter, pr *proxyRequest) bool {
+	resp, ok := h.cache.get(pr.Model, pr.cache, pr.cacheKey, time.Now())
+	if !ok {
+		return false
+	}
+	for k, v := range resp.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(cacheHeader, "hit")
+	w.Header().Set(servedModelHeader, pr.Model)
+	pr.setStatus(w, resp.status)
+	_, _ = w.Write(resp.body)
+	return true
+}
+
+// cacheResponse caches the backend response to the request. Only complete
+// responses (not streamed and within maxCachedResponseBytes) with a 200 status
+// are cached. The body of the response is left intact for the client.
+func (h *Handler) cacheResponse(pr *proxyRequest, r *http.Response) {
+	if r.StatusCode != http.StatusOK || isStreamingResponse(r) {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCachedResponseBytes+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		log.Printf("not caching response, reading body: %v: %v", err, pr.ID)
+		return
+	}
+	if len(body) > maxCachedResponseBytes {
+		return
+	}
+
+	h.cache.put(pr.Model, pr.cache, &cachedResponse{
+		key:     pr.cacheKey,
+		status:  r.StatusCode,
+		header:  r.Header.Clone(),
+		body:    body,
+		expires: time.Now().Add(pr.cache.TTL),
+	})
+}
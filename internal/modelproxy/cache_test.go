@@ -0,0 +1,180 @@
+package modelproxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient"
+)
+
+func TestResponseCacheEntries(t *testing.T) {
+	c := newResponseCache()
+	cfg := modelclient.ResponseCache{UID: "uid-1", TTL: time.Minute, MaxEntries: 2}
+	now := time.Now()
+	put := func(key string) {
+		c.put("m", cfg, &cachedResponse{key: key, status: http.StatusOK, expires: now.Add(cfg.TTL)})
+	}
+
+	put("a")
+	put("b")
+	_, ok := c.get("m", cfg, "a", now)
+	require.True(t, ok)
+
+	// "b" is the least recently used entry.
+	put("c")
+	_, ok = c.get("m", cfg, "b", now)
+	require.False(t, ok)
+	_, ok = c.get("m", cfg, "a", now)
+	require.True(t, ok)
+
+	// Entries expire after the TTL.
+	_, ok = c.get("m", cfg, "a", now.Add(cfg.TTL))
+	require.False(t, ok)
+
+	// The cache is reset when the Model is recreated.
+	_, ok = c.get("m", modelclient.ResponseCache{UID: "uid-2", TTL: time.Minute, MaxEntries: 2}, "c", now)
+	require.False(t, ok)
+}
+
+func TestResponseCacheBytes(t *testing.T) {
+	c := newResponseCache()
+	cfg := modelclient.ResponseCache{UID: "uid", TTL: time.Minute, MaxEntries: 1000}
+	now := time.Now()
+	// The entries alternate between two Models, which share the budget.
+	model := func(i int) string { return "m" + strconv.Itoa(i%2) }
+	put := func(model, key string) {
+		c.put(model, cfg, &cachedResponse{key: key, status: http.StatusOK, body: make([]byte, maxCachedResponseBytes), expires: now.Add(cfg.TTL)})
+	}
+
+	const fit = maxResponseCacheBytes / maxCachedResponseBytes
+	for i := 0; i < fit; i++ {
+		put(model(i), strconv.Itoa(i))
+	}
+	_, ok := c.get(model(0), cfg, "0", now)
+	require.True(t, ok)
+
+	// "1" (of the other Model) is the least recently used entry.
+	put(model(0), "new")
+	_, ok = c.get(model(1), cfg, "1", now)
+	require.False(t, ok)
+	for _, i := range []int{0, 2, 3} {
+		_, ok = c.get(model(i), cfg, strconv.Itoa(i), now)
+		require.True(t, ok, i)
+	}
+
+	// Replacing and expiring entries frees their bytes.
+	put(model(0), "new")
+	_, ok = c.get(model(0), cfg, "0", now.Add(cfg.TTL))
+	require.False(t, ok)
+	require.Equal(t, (fit-1)*maxCachedResponseBytes, c.bytes)
+
+	// Forgetting a Model frees the bytes of its entries.
+	c.forgetModel(model(1))
+	require.Equal(t, fit/2*maxCachedResponseBytes, c.bytes)
+	require.Equal(t, fit/2, c.lru.Len())
+}
+
+func TestResponseCacheReset(t *testing.T) {
+	c := newResponseCache()
+	cfg := modelclient.ResponseCache{UID: "uid", Generation: 1, PodHash: "hash-1", TTL: time.Minute, MaxEntries: 10}
+	now := time.Now()
+	put := func(cfg modelclient.ResponseCache) {
+		c.put("m", cfg, &cachedResponse{key: "a", status: http.StatusOK, body: []byte("a"), expires: now.Add(cfg.TTL)})
+	}
+
+	put(cfg)
+	_, ok := c.get("m", cfg, "a", now)
+	require.True(t, ok)
+
+	// The cache is reset when the spec of the Model changes.
+	changed := cfg
+	changed.Generation = 2
+	_, ok = c.get("m", changed, "a", now)
+	require.False(t, ok)
+	require.Zero(t, c.bytes)
+
+	// The cache is reset when the Pods of the Model are replaced.
+	put(changed)
+	changed.PodHash = "hash-2"
+	_, ok = c.get("m", changed, "a", now)
+	require.False(t, ok)
+	require.Zero(t, c.bytes)
+}
+
+func TestHandlerResponseCache(t *testing.T) {
+	const cachedModel = "cached-model"
+
+	var backendRequestCount int
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backendRequestCount++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[]}`))
+	}))
+	defer backend.Close()
+
+	testInf := &testModelInterface{
+		models: map[string]testMockModel{
+			cachedModel: {responseCache: modelclient.ResponseCache{UID: "uid", TTL: time.Minute, MaxEntries: 10}},
+		},
+		address: backend.Listener.Addr().String(),
+	}
+	server := httptest.NewServer(NewHandler(testInf, testInf, 3, nil, true, ""))
+	defer server.Close()
+
+	cases := []struct {
+		name           string
+		reqBody        string
+		expCache       string
+		expBackendReqs int
+	}{
+		{
+			name:           "miss",
+			reqBody:        `{"model":"cached-model","prompt":"hi","temperature":0}`,
+			expBackendReqs: 1,
+		},
+		{
+			name:           "hit",
+			reqBody:        `{"temperature":0,"prompt":"hi","model":"cached-model"}`,
+			expCache:       "hit",
+			expBackendReqs: 1,
+		},
+		{
+			name:           "nonzero temperature",
+			reqBody:        `{"model":"cached-model","prompt":"hi","temperature":0.7}`,
+			expBackendReqs: 2,
+		},
+		{
+			name:           "default temperature",
+			reqBody:        `{"model":"cached-model","prompt":"hi"}`,
+			expBackendReqs: 3,
+		},
+		{
+			name:           "stream",
+			reqBody:        `{"model":"cached-model","prompt":"hi","temperature":0,"stream":true}`,
+			expBackendReqs: 4,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			metricstest.Init(t)
+
+			resp, err := http.Post(server.URL, "application/json", strings.NewReader(c.reqBody))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, `{"choices":[]}`, string(body))
+			require.Equal(t, c.expCache, resp.Header.Get(cacheHeader))
+			require.Equal(t, c.expBackendReqs, backendRequestCount)
+		})
+	}
+}
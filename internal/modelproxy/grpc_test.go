@@ -0,0 +1,120 @@
+package modelproxy
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestHandlerGRPC(t *testing.T) {
+	const (
+		grpcModel = "grpc-model"
+		httpModel = "http-model"
+	)
+
+	// The protobuf message is passed through as is.
+	const message = "\x00\x00\x00\x00\x02\x08\x01"
+	backend := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil || r.ProtoMajor != 2 || r.URL.Path != "/inference.GRPCInferenceService/ModelInfer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status")
+		_, _ = w.Write(body)
+		w.Header().Set("Grpc-Status", "0")
+	}), &http2.Server{}))
+	defer backend.Close()
+
+	testInf := &testModelInterface{
+		models: map[string]testMockModel{
+			grpcModel: {protocol: v1.ModelProtocolGRPC},
+			httpModel: {},
+		},
+		address: backend.Listener.Addr().String(),
+	}
+	h := NewHandler(testInf, testInf, 3, nil, true, "")
+	other := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	server := httptest.NewServer(h2c.NewHandler(h.WithGRPC(other), &http2.Server{}))
+	defer server.Close()
+
+	// A gRPC client over HTTP/2 without TLS.
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}}
+
+	cases := []struct {
+		name       string
+		model      string
+		grpc       bool
+		expCode    int
+		expTrailer string
+	}{
+		{
+			name:       "grpc model",
+			model:      grpcModel,
+			grpc:       true,
+			expCode:    http.StatusOK,
+			expTrailer: "0",
+		},
+		{
+			name:    "http model",
+			model:   httpModel,
+			grpc:    true,
+			expCode: http.StatusBadRequest,
+		},
+		{
+			name:    "missing model",
+			grpc:    true,
+			expCode: http.StatusBadRequest,
+		},
+		{
+			name:    "not grpc",
+			model:   grpcModel,
+			expCode: http.StatusTeapot,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			metricstest.Init(t)
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/inference.GRPCInferenceService/ModelInfer", strings.NewReader(message))
+			require.NoError(t, err)
+			if c.grpc {
+				req.Header.Set("Content-Type", "application/grpc")
+			}
+			if c.model != "" {
+				req.Header.Set("Kubeai-Model", c.model)
+			}
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			require.Equal(t, c.expCode, resp.StatusCode)
+			if c.expCode == http.StatusOK {
+				require.Equal(t, message, string(body))
+				require.Equal(t, c.expTrailer, resp.Trailer.Get("Grpc-Status"))
+			}
+		})
+	}
+}
@@ -2,22 +2,43 @@ package modelproxy
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"log"
+	"mime"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strings"
+	"time"
 
 	v1 "github.com/substratusai/kubeai/api/v1"
 	"github.com/substratusai/kubeai/internal/apiutils"
 	"github.com/substratusai/kubeai/internal/metrics"
+	"github.com/substratusai/kubeai/internal/modelclient"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/net/http2"
 )
 
 type ModelClient interface {
-	LookupModel(ctx context.Context, model, adapter string, selectors []string) (*v1.Model, error)
+	ResolveModel(ctx context.Context, model, adapter string, selectors []string) (*v1.Model, bool, error)
+	ResolveVersioned(ctx context.Context, model, version string) (string, bool, error)
+	ResolveCapable(ctx context.Context, capabilities, selectors []string) (string, bool, error)
 	ScaleAtLeastOneReplica(ctx context.Context, model string) error
+	IsSaturated(model *v1.Model) bool
+	ResolvedModelStatus(ctx context.Context, model *v1.Model) (modelclient.ModelStatus, error)
+	ReportBackendError(model, endpoint string)
+	CapacityUnavailable(ctx context.Context, model string) (bool, error)
+	ModelProtocol(model *v1.Model) (string, error)
+	MaxQueueWait(model *v1.Model) (time.Duration, error)
+	RequestTimeout(model *v1.Model) (time.Duration, error)
+	ResponseCache(model *v1.Model) (modelclient.ResponseCache, bool, error)
+	RecordLatency(model string, d time.Duration)
+	ModelCandidates(ctx context.Context, model, adapter string, selectors []string) ([]modelclient.ModelCandidate, error)
+	RegisterStream(ctx context.Context, model string) func()
+	IsModelCordoned(model *v1.Model) bool
 }
 
 type LoadBalancer interface {
@@ -31,6 +52,14 @@ type Handler struct {
 	loadBalancer LoadBalancer
 	maxRetries   int
 	retryCodes   map[int]struct{}
+	// rejectWhenSaturated causes requests for saturated models
+	// to be rejected instead of queued.
+	rejectWhenSaturated bool
+	// modelHeader is the name of the header that the model can be specified in
+	// instead of the request body. Disabled when empty.
+	modelHeader string
+	// cache holds the responses of Models that have the response cache enabled.
+	cache *responseCache
 }
 
 func NewHandler(
@@ -38,12 +67,17 @@ func NewHandler(
 	loadBalancer LoadBalancer,
 	maxRetries int,
 	retryCodes map[int]struct{},
+	rejectWhenSaturated bool,
+	modelHeader string,
 ) *Handler {
 	return &Handler{
-		modelClient:  modelClient,
-		loadBalancer: loadBalancer,
-		maxRetries:   maxRetries,
-		retryCodes:   retryCodes,
+		modelClient:         modelClient,
+		loadBalancer:        loadBalancer,
+		maxRetries:          maxRetries,
+		retryCodes:          retryCodes,
+		rejectWhenSaturated: rejectWhenSaturated,
+		modelHeader:         modelHeader,
+		cache:               newResponseCache(),
 	}
 }
 
@@ -54,6 +88,48 @@ var defaultRetryCodes = map[int]struct{}{
 	http.StatusGatewayTimeout:      {},
 }
 
+// scaledToZeroRetryAfter is the Retry-After value (in seconds) that is sent
+// when a Model is scaled to zero and will not be scaled up by the request.
+const scaledToZeroRetryAfter = "30"
+
+// capacityUnavailableRetryAfter is the Retry-After value (in seconds) that is sent
+// when a Model has no ready replicas and its Pods can not be scheduled.
+const capacityUnavailableRetryAfter = "60"
+
+// queueTimeoutRetryAfter is the Retry-After value (in seconds) that is sent
+// when a request waited for an available endpoint for longer than the max queue wait.
+const queueTimeoutRetryAfter = "10"
+
+// cordonedRetryAfter is the Retry-After value (in seconds) that is sent
+// when a Model is cordoned and no failover Model is healthy.
+const cordonedRetryAfter = "30"
+
+// servedModelHeader is the response header that contains the name of the Model
+// that the request was routed to (after fallback and failover).
+const servedModelHeader = "X-KubeAI-Model"
+
+// errMaxQueueWaitExceeded is the cause of the context cancellation when a request
+// waited for an available endpoint for longer than the max queue wait.
+var errMaxQueueWaitExceeded = errors.New("max queue wait exceeded")
+
+// errRequestTimeoutExceeded is the cause of the context cancellation when a request
+// was proxied to a backend for longer than the request timeout of the model.
+var errRequestTimeoutExceeded = errors.New("request timeout exceeded")
+
+// WithGRPC returns a handler that serves gRPC requests with h and all other
+// requests with next. gRPC requests are served on any path, as the path is the
+// method that is called, and are only proxied to Models that are served over
+// gRPC (see v1.ModelProtocolGRPC).
+func (h *Handler) WithGRPC(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			h.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log.Printf("url: %v", r.URL)
 
@@ -72,22 +148,181 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(pr.FailoverModels) > 0 {
+		if err := h.failover(pr); err != nil {
+			pr.sendErrorResponse(w, http.StatusInternalServerError, "selecting failover model: %v", err)
+			return
+		}
+	}
+
+	if h.modelClient.IsModelCordoned(pr.ResolvedModel) {
+		w.Header().Set("Retry-After", cordonedRetryAfter)
+		pr.sendErrorResponse(w, http.StatusServiceUnavailable, "model %q is cordoned and has no healthy backend", pr.RequestedModel)
+		return
+	}
+
 	log.Println("model:", pr.Model, "adapter:", pr.Adapter)
 
+	if pr.Deterministic && !pr.Stream {
+		cache, ok, err := h.modelClient.ResponseCache(pr.ResolvedModel)
+		if err != nil {
+			pr.sendErrorResponse(w, http.StatusInternalServerError, "resolving model response cache: %v", err)
+			return
+		}
+		if ok {
+			pr.cache, pr.cacheKey = cache, responseCacheKey(r.URL.Path, pr.Body)
+			// Cache hits are not counted as active requests, so they do not
+			// scale the Model up.
+			if h.serveCached(w, pr) {
+				metrics.InferenceRequestsCacheHits.Add(r.Context(), 1, metric.WithAttributeSet(attribute.NewSet(
+					metrics.AttrRequestModel.String(pr.Model),
+				)))
+				return
+			}
+		}
+	}
+
 	metricAttrs := metric.WithAttributeSet(attribute.NewSet(
 		metrics.AttrRequestModel.String(pr.RequestedModel),
 		metrics.AttrRequestType.String(metrics.AttrRequestTypeHTTP),
 	))
 	metrics.InferenceRequestsActive.Add(pr.http.Context(), 1, metricAttrs)
 	defer metrics.InferenceRequestsActive.Add(pr.http.Context(), -1, metricAttrs)
+	weight := pr.Weight
+	metrics.InferenceRequestsLoad.Add(pr.http.Context(), weight, metricAttrs)
+	defer metrics.InferenceRequestsLoad.Add(pr.http.Context(), -weight, metricAttrs)
+
+	start := time.Now()
+	defer func() {
+		recordRequestMetrics(pr, time.Since(start))
+	}()
+
+	status, err := h.modelClient.ResolvedModelStatus(r.Context(), pr.ResolvedModel)
+	if err != nil {
+		pr.sendErrorResponse(w, http.StatusInternalServerError, "getting model status: %v", err)
+		return
+	}
+	switch status {
+	case modelclient.ModelStatusScaledToZero:
+		if pr.AutoscalingDisabled {
+			// Nothing will scale the Model up, so waiting for an endpoint would only time out.
+			w.Header().Set("Retry-After", scaledToZeroRetryAfter)
+			pr.sendErrorResponse(w, http.StatusServiceUnavailable, "model %q is scaled to zero", pr.RequestedModel)
+			return
+		}
+	case modelclient.ModelStatusScalingUp:
+		unavailable, err := h.modelClient.CapacityUnavailable(r.Context(), pr.Model)
+		if err != nil {
+			pr.sendErrorResponse(w, http.StatusInternalServerError, "checking model capacity: %v", err)
+			return
+		}
+		if unavailable {
+			// Fail fast instead of waiting for replicas that can not be scheduled.
+			w.Header().Set("Retry-After", capacityUnavailableRetryAfter)
+			pr.sendErrorResponse(w, http.StatusServiceUnavailable, "model %q has no ready replicas and capacity is unavailable", pr.RequestedModel)
+			return
+		}
+	}
 
 	// Ensure the backend is scaled to at least one Pod.
 	if err := h.modelClient.ScaleAtLeastOneReplica(r.Context(), pr.Model); err != nil {
-		pr.sendErrorResponse(w, http.StatusInternalServerError, "unable to scale model: %v", err)
+		if errors.Is(err, modelclient.ErrScaleNotFound) {
+			// The Model was deleted after the request was parsed.
+			pr.sendErrorResponse(w, http.StatusNotFound, "%v: %q", apiutils.ErrModelNotFound, pr.RequestedModel)
+		} else {
+			pr.sendErrorResponse(w, http.StatusInternalServerError, "unable to scale model: %v", err)
+		}
+		return
+	}
+
+	if h.rejectWhenSaturated && h.modelClient.IsSaturated(pr.ResolvedModel) {
+		pr.sendErrorResponse(w, http.StatusTooManyRequests, "model %q is at max replicas", pr.RequestedModel)
 		return
 	}
 
+	protocol, err := h.modelClient.ModelProtocol(pr.ResolvedModel)
+	if err != nil {
+		pr.sendErrorResponse(w, http.StatusInternalServerError, "resolving model protocol: %v", err)
+		return
+	}
+	pr.protocol = protocol
+	if pr.GRPC && protocol != v1.ModelProtocolGRPC {
+		pr.sendErrorResponse(w, http.StatusBadRequest, "model %q is not served over gRPC", pr.RequestedModel)
+		return
+	}
+
+	maxQueueWait, err := h.modelClient.MaxQueueWait(pr.ResolvedModel)
+	if err != nil {
+		pr.sendErrorResponse(w, http.StatusInternalServerError, "resolving model max queue wait: %v", err)
+		return
+	}
+	pr.maxQueueWait = maxQueueWait
+
+	requestTimeout, err := h.modelClient.RequestTimeout(pr.ResolvedModel)
+	if err != nil {
+		pr.sendErrorResponse(w, http.StatusInternalServerError, "resolving model request timeout: %v", err)
+		return
+	}
+	pr.requestTimeout = requestTimeout
+
+	w.Header().Set(servedModelHeader, pr.Model)
 	h.proxyHTTP(w, pr)
+
+	// Requests that waited for a cold start would skew latency based autoscaling.
+	if status == modelclient.ModelStatusReady && pr.status > 0 && pr.status < http.StatusInternalServerError {
+		h.modelClient.RecordLatency(pr.Model, time.Since(start))
+	}
+}
+
+// failover routes the request to the first healthy candidate Model. The request
+// stays with the resolved Model when no candidate is healthy.
+func (h *Handler) failover(pr *proxyRequest) error {
+	candidates, err := h.modelClient.ModelCandidates(pr.http.Context(), pr.Model, pr.Adapter, pr.Selectors)
+	if err != nil {
+		return err
+	}
+	for _, c := range candidates {
+		if !c.Healthy() {
+			continue
+		}
+		if c.Model.Name == pr.Model {
+			return nil
+		}
+		log.Printf("model %q is unavailable, failing over to model %q: %v", pr.Model, c.Model.Name, pr.ID)
+		if err := pr.Failover(c.Model); err != nil {
+			return err
+		}
+		// The content length might have changed after the body was rewritten.
+		pr.http.ContentLength = pr.ContentLength
+		return nil
+	}
+	return nil
+}
+
+// recordRequestMetrics records the outcome of a request. The resolved model
+// name is used (rather than the requested name) so that the number of series
+// is bounded by the number of Models.
+func recordRequestMetrics(pr *proxyRequest, duration time.Duration) {
+	// Use a context that is not cancelled when the client disconnects.
+	ctx := context.WithoutCancel(pr.http.Context())
+	modelAttrs := []attribute.KeyValue{
+		metrics.AttrRequestModel.String(pr.Model),
+		metrics.AttrRequestType.String(metrics.AttrRequestTypeHTTP),
+	}
+	metrics.InferenceRequests.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		append(modelAttrs, metrics.AttrResponseStatusCode.Int(pr.status))...,
+	)))
+	metrics.InferenceRequestsDuration.Record(ctx, duration.Seconds(), metric.WithAttributeSet(attribute.NewSet(modelAttrs...)))
+}
+
+// h2cTransport is used to proxy requests to gRPC backends, which
+// require HTTP/2 without TLS.
+var h2cTransport = &http2.Transport{
+	AllowHTTP: true,
+	DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	},
 }
 
 // AdditionalProxyRewrite is an injection point for modifying proxy requests.
@@ -97,9 +332,21 @@ var AdditionalProxyRewrite = func(*httputil.ProxyRequest) {}
 func (h *Handler) proxyHTTP(w http.ResponseWriter, pr *proxyRequest) {
 	log.Printf("Waiting for host: %v", pr.ID)
 
-	addr, decrementInflight, err := h.loadBalancer.AwaitBestAddress(pr.http.Context(), pr.Request)
+	ctx, cancel := pr.http.Context(), context.CancelFunc(func() {})
+	if pr.maxQueueWait > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, pr.maxQueueWait, errMaxQueueWaitExceeded)
+	}
+	addr, decrementInflight, err := h.loadBalancer.AwaitBestAddress(ctx, pr.Request)
+	cancel()
 	if err != nil {
 		switch {
+		case errors.Is(context.Cause(ctx), errMaxQueueWaitExceeded) && pr.http.Context().Err() == nil:
+			metrics.InferenceRequestsQueueTimeouts.Add(context.WithoutCancel(ctx), 1, metric.WithAttributeSet(attribute.NewSet(
+				metrics.AttrRequestModel.String(pr.Model),
+			)))
+			w.Header().Set("Retry-After", queueTimeoutRetryAfter)
+			pr.sendErrorResponse(w, http.StatusServiceUnavailable, "model %q did not have an available endpoint within %s", pr.RequestedModel, pr.maxQueueWait)
+			return
 		case errors.Is(err, context.Canceled):
 			pr.sendErrorResponse(w, http.StatusInternalServerError, "request cancelled while finding host: %v", err)
 			return
@@ -114,6 +361,13 @@ func (h *Handler) proxyHTTP(w http.ResponseWriter, pr *proxyRequest) {
 	// NOTE: decrementInflight will be called after the request succeeds or fails after all retries.
 	defer decrementInflight()
 
+	req := pr.httpRequest()
+	if pr.requestTimeout > 0 {
+		ctx, cancel := context.WithTimeoutCause(req.Context(), pr.requestTimeout, errRequestTimeoutExceeded)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
 	proxy := &httputil.ReverseProxy{
 		Rewrite: func(r *httputil.ProxyRequest) {
 			r.SetURL(&url.URL{
@@ -124,24 +378,60 @@ func (h *Handler) proxyHTTP(w http.ResponseWriter, pr *proxyRequest) {
 			AdditionalProxyRewrite(r)
 		},
 	}
+	if pr.protocol == v1.ModelProtocolGRPC {
+		proxy.Transport = h2cTransport
+		// Stream responses (and trailers) as they are received.
+		proxy.FlushInterval = -1
+	}
 
+	var deregisterStream func()
+	defer func() {
+		// Also deregistered when the proxy aborts the response (i.e. after the
+		// request timeout was exceeded while streaming).
+		if deregisterStream != nil {
+			deregisterStream()
+		}
+	}()
 	proxy.ModifyResponse = func(r *http.Response) error {
 		// Record the response for metrics.
 		pr.status = r.StatusCode
 
+		if r.StatusCode >= http.StatusInternalServerError {
+			h.modelClient.ReportBackendError(pr.Model, addr)
+		}
+
 		// This point is reached if a response code is received.
 		if h.isRetryCode(r.StatusCode) && pr.attempt < h.maxRetries {
 			// Returning an error will trigger the ErrorHandler.
 			return ErrRetry
 		}
 
+		if pr.cacheKey != "" {
+			h.cacheResponse(pr, r)
+		}
+
+		if isStreamingResponse(r) {
+			// Deregistered once the response is fully proxied.
+			deregisterStream = h.modelClient.RegisterStream(pr.http.Context(), pr.Model)
+		}
+
 		return nil
 	}
 
 	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if errors.Is(context.Cause(r.Context()), errRequestTimeoutExceeded) && pr.http.Context().Err() == nil {
+			// The request is not retried. Timeouts are not reported as backend
+			// errors, as long requests (i.e. long completions) are not a sign
+			// of an unhealthy backend.
+			pr.sendErrorResponse(w, http.StatusGatewayTimeout, "model %q did not respond within %s", pr.RequestedModel, pr.requestTimeout)
+			return
+		}
 		// This point could be reached if a bad response code was sent by the backend
 		// or
 		// if there was an issue with the connection and no response was ever received.
+		if err != nil && !errors.Is(err, ErrRetry) && r.Context().Err() == nil {
+			h.modelClient.ReportBackendError(pr.Model, addr)
+		}
 		if err != nil && r.Context().Err() == nil && pr.attempt < h.maxRetries {
 			pr.attempt++
 
@@ -156,7 +446,17 @@ func (h *Handler) proxyHTTP(w http.ResponseWriter, pr *proxyRequest) {
 	}
 
 	log.Printf("Proxying request to ip %v: %v\n", addr, pr.ID)
-	proxy.ServeHTTP(w, pr.httpRequest())
+	proxy.ServeHTTP(w, req)
+}
+
+// isStreamingResponse returns true for responses that keep the connection open
+// for a long time: Server-Sent Events and upgrades (i.e. WebSockets).
+func isStreamingResponse(r *http.Response) bool {
+	if r.StatusCode == http.StatusSwitchingProtocols {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "text/event-stream"
 }
 
 var ErrRetry = errors.New("retry")
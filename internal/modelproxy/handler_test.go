@@ -16,6 +16,9 @@ import (
 	v1 "github.com/substratusai/kubeai/api/v1"
 	"github.com/substratusai/kubeai/internal/apiutils"
 	"github.com/substratusai/kubeai/internal/metrics/metricstest"
+	"github.com/substratusai/kubeai/internal/modelclient"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -27,7 +30,26 @@ func TestHandler(t *testing.T) {
 		model3   = "model3"
 		adapter3 = "adapter3"
 
+		saturatedModel = "saturated-model"
+
+		scaledToZeroModel = "scaled-to-zero-model"
+
+		capacityUnavailableModel = "capacity-unavailable-model"
+
+		grpcModel = "grpc-model"
+
+		failoverModel = "failover-model"
+
+		noEndpointsModel = "no-endpoints-model"
+
+		requestTimeoutModel = "request-timeout-model"
+
+		cordonedModel         = "cordoned-model"
+		cordonedFailoverModel = "cordoned-failover-model"
+
 		maxRetries = 3
+
+		testModelHeader = "X-Model"
 	)
 	models := map[string]testMockModel{
 		model1: {},
@@ -37,10 +59,45 @@ func TestHandler(t *testing.T) {
 				adapter3: true,
 			},
 		},
+		saturatedModel: {
+			saturated: true,
+		},
+		scaledToZeroModel: {
+			scaledToZero:        true,
+			autoscalingDisabled: true,
+		},
+		capacityUnavailableModel: {
+			capacityUnavailable: true,
+		},
+		grpcModel: {
+			protocol: v1.ModelProtocolGRPC,
+		},
+		failoverModel: {
+			saturated:      true,
+			failoverModels: []string{saturatedModel, model1},
+		},
+		noEndpointsModel: {
+			noEndpoints:  true,
+			maxQueueWait: 10 * time.Millisecond,
+		},
+		requestTimeoutModel: {
+			requestTimeout: 10 * time.Millisecond,
+		},
+		cordonedModel: {
+			cordoned: true,
+		},
+		cordonedFailoverModel: {
+			cordoned:       true,
+			failoverModels: []string{model1},
+		},
 	}
 
 	type metricsTestSpec struct {
+		// expModel is the requested model.
 		expModel string
+		// expResolvedModel is the model that the request was routed to.
+		// Defaults to expModel.
+		expResolvedModel string
 	}
 
 	specs := map[string]struct {
@@ -48,15 +105,93 @@ func TestHandler(t *testing.T) {
 		reqHeaders map[string]string
 
 		backendPanic bool
-		backendCode  int
-		backendBody  string
+		// backendDelay delays the response of the backend (unless the request
+		// is cancelled).
+		backendDelay   time.Duration
+		backendHeaders map[string]string
+		backendCode    int
+		backendBody    string
 
 		expRewrittenReqBody    string
 		expCode                int
+		expHeaders             map[string]string
 		expBody                string
 		expMetrics             *metricsTestSpec
 		expBackendRequestCount int
+		expBackendErrorCount   int
+		// expStreams is the number of streams that are registered.
+		expStreams int
+		// expBackendProtoMajor is the HTTP major version that the backend
+		// receives requests with. Defaults to 1.
+		expBackendProtoMajor int
 	}{
+		"429 saturated model": {
+			reqBody:                fmt.Sprintf(`{"model":%q}`, saturatedModel),
+			expCode:                http.StatusTooManyRequests,
+			expBody:                fmt.Sprintf(`{"error":%q}`, `model "`+saturatedModel+`" is at max replicas`) + "\n",
+			expBackendRequestCount: 0,
+		},
+		"503 max queue wait exceeded": {
+			reqBody:                fmt.Sprintf(`{"model":%q}`, noEndpointsModel),
+			expCode:                http.StatusServiceUnavailable,
+			expHeaders:             map[string]string{"Retry-After": queueTimeoutRetryAfter},
+			expBody:                `{"error":"Service Unavailable"}` + "\n",
+			expBackendRequestCount: 0,
+		},
+		"504 request timeout exceeded": {
+			reqBody:                fmt.Sprintf(`{"model":%q}`, requestTimeoutModel),
+			backendDelay:           time.Minute,
+			backendCode:            http.StatusOK,
+			expCode:                http.StatusGatewayTimeout,
+			expBody:                `{"error":"Gateway Timeout"}` + "\n",
+			expBackendRequestCount: 1,
+			expBackendErrorCount:   0,
+		},
+		"503 cordoned model": {
+			reqBody:                fmt.Sprintf(`{"model":%q}`, cordonedModel),
+			expCode:                http.StatusServiceUnavailable,
+			expHeaders:             map[string]string{"Retry-After": cordonedRetryAfter},
+			expBody:                `{"error":"Service Unavailable"}` + "\n",
+			expBackendRequestCount: 0,
+		},
+		"200 failover from cordoned model": {
+			reqBody:                fmt.Sprintf(`{"model":%q}`, cordonedFailoverModel),
+			expRewrittenReqBody:    fmt.Sprintf(`{"model":%q}`, model1),
+			backendCode:            http.StatusOK,
+			backendBody:            `{"result":"ok"}`,
+			expCode:                http.StatusOK,
+			expHeaders:             map[string]string{servedModelHeader: model1},
+			expBody:                `{"result":"ok"}`,
+			expBackendRequestCount: 1,
+		},
+		"200 failover from saturated model": {
+			reqBody:             fmt.Sprintf(`{"model":%q}`, failoverModel),
+			expRewrittenReqBody: fmt.Sprintf(`{"model":%q}`, model1),
+			backendCode:         http.StatusOK,
+			backendBody:         `{"result":"ok"}`,
+			expCode:             http.StatusOK,
+			expHeaders:          map[string]string{servedModelHeader: model1},
+			expBody:             `{"result":"ok"}`,
+			expMetrics: &metricsTestSpec{
+				expModel:         failoverModel,
+				expResolvedModel: model1,
+			},
+			expBackendRequestCount: 1,
+		},
+		"503 scaled to zero model": {
+			reqBody:                fmt.Sprintf(`{"model":%q}`, scaledToZeroModel),
+			expCode:                http.StatusServiceUnavailable,
+			expHeaders:             map[string]string{"Retry-After": scaledToZeroRetryAfter},
+			expBody:                `{"error":"Service Unavailable"}` + "\n",
+			expBackendRequestCount: 0,
+		},
+		"503 capacity unavailable model": {
+			reqBody:                fmt.Sprintf(`{"model":%q}`, capacityUnavailableModel),
+			expCode:                http.StatusServiceUnavailable,
+			expHeaders:             map[string]string{"Retry-After": capacityUnavailableRetryAfter},
+			expBody:                `{"error":"Service Unavailable"}` + "\n",
+			expBackendRequestCount: 0,
+		},
 		"no model": {
 			reqBody:                "{}",
 			expCode:                http.StatusBadRequest,
@@ -80,6 +215,41 @@ func TestHandler(t *testing.T) {
 			},
 			expBackendRequestCount: 1,
 		},
+		"200 server-sent events stream": {
+			reqBody:                fmt.Sprintf(`{"model":%q,"stream":true}`, model1),
+			backendHeaders:         map[string]string{"Content-Type": "text/event-stream; charset=utf-8"},
+			backendCode:            http.StatusOK,
+			backendBody:            "data: {}\n\n",
+			expCode:                http.StatusOK,
+			expBody:                "data: {}\n\n",
+			expBackendRequestCount: 1,
+			expStreams:             1,
+		},
+		"happy 200 model in header": {
+			reqBody:             `{"prompt":"test"}`,
+			reqHeaders:          map[string]string{testModelHeader: model1},
+			expRewrittenReqBody: `{"prompt":"test"}`,
+			backendCode:         http.StatusOK,
+			backendBody:         `{"result":"ok"}`,
+			expCode:             http.StatusOK,
+			expBody:             `{"result":"ok"}`,
+			expMetrics: &metricsTestSpec{
+				expModel: model1,
+			},
+			expBackendRequestCount: 1,
+		},
+		"happy 200 grpc model": {
+			reqBody:     fmt.Sprintf(`{"model":%q}`, grpcModel),
+			backendCode: http.StatusOK,
+			backendBody: `{"result":"ok"}`,
+			expCode:     http.StatusOK,
+			expBody:     `{"result":"ok"}`,
+			expMetrics: &metricsTestSpec{
+				expModel: grpcModel,
+			},
+			expBackendRequestCount: 1,
+			expBackendProtoMajor:   2,
+		},
 		"happy 200 model+adapter in body": {
 			reqBody:             fmt.Sprintf(`{"model":%q}`, apiutils.MergeModelAdapter(model3, adapter3)),
 			expRewrittenReqBody: fmt.Sprintf(`{"model":%q}`, adapter3),
@@ -88,7 +258,8 @@ func TestHandler(t *testing.T) {
 			expCode:             http.StatusOK,
 			expBody:             `{"result":"ok"}`,
 			expMetrics: &metricsTestSpec{
-				expModel: apiutils.MergeModelAdapter(model3, adapter3),
+				expModel:         apiutils.MergeModelAdapter(model3, adapter3),
+				expResolvedModel: model3,
 			},
 			expBackendRequestCount: 1,
 		},
@@ -144,6 +315,7 @@ func TestHandler(t *testing.T) {
 				expModel: model1,
 			},
 			expBackendRequestCount: 1 + maxRetries,
+			expBackendErrorCount:   1 + maxRetries,
 		},
 		"not retryable 400": {
 			reqBody:     fmt.Sprintf(`{"model":%q}`, model1),
@@ -165,6 +337,7 @@ func TestHandler(t *testing.T) {
 				expModel: model1,
 			},
 			expBackendRequestCount: 1 + maxRetries,
+			expBackendErrorCount:   1 + maxRetries,
 		},
 	}
 	for name, spec := range specs {
@@ -174,13 +347,18 @@ func TestHandler(t *testing.T) {
 			// Mock backend.
 			var backendRequestCount int
 			sendResponse := make(chan struct{})
-			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			expProtoMajor := spec.expBackendProtoMajor
+			if expProtoMajor == 0 {
+				expProtoMajor = 1
+			}
+			backend := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				defer func() {
 					t.Log("waiting for response to be allowed")
 					<-sendResponse
 					t.Log("sending response")
 				}()
 				backendRequestCount++
+				assert.Equal(t, expProtoMajor, r.ProtoMajor, "Unexpected protocol version of the backend request")
 
 				bdy, err := io.ReadAll(r.Body)
 				assert.NoError(t, err, "The request body should be readable")
@@ -191,26 +369,37 @@ func TestHandler(t *testing.T) {
 					assert.Equal(t, spec.reqBody, string(bdy), "The exact request body should reach the backend")
 				}
 
+				if spec.backendDelay > 0 {
+					select {
+					case <-time.After(spec.backendDelay):
+					case <-r.Context().Done():
+						return
+					}
+				}
+
 				if spec.backendPanic {
 					// Panic should close connection.
 					// https://pkg.go.dev/net/http#Handler
 					panic("panicing on purpose")
 				}
 
+				for k, v := range spec.backendHeaders {
+					w.Header().Set(k, v)
+				}
 				if spec.backendCode != 0 {
 					w.WriteHeader(spec.backendCode)
 				}
 				if spec.backendBody != "" {
 					_, _ = w.Write([]byte(spec.backendBody))
 				}
-			}))
+			}), &http2.Server{}))
 
 			// Setup handler.
 			testInf := &testModelInterface{
 				models:  models,
 				address: backend.Listener.Addr().String(),
 			}
-			h := NewHandler(testInf, testInf, maxRetries, nil)
+			h := NewHandler(testInf, testInf, maxRetries, nil, true, testModelHeader)
 			server := httptest.NewServer(h)
 
 			// Issue request.
@@ -250,21 +439,44 @@ func TestHandler(t *testing.T) {
 
 			// Assert on response.
 			assert.Equal(t, spec.expCode, resp.StatusCode, "Unexpected response code to client")
+			for k, v := range spec.expHeaders {
+				assert.Equal(t, v, resp.Header.Get(k), "Unexpected response header %q to client", k)
+			}
 			assert.Equal(t, spec.expBody, string(respBody), "Unexpected response body to client")
 			assert.Equal(t, spec.expBackendRequestCount, backendRequestCount, "Unexpected number of requests sent to backend")
 			assert.Equal(t, spec.expBackendRequestCount, testInf.hostRequestCount, "Unexpected number of requests for backend hosts")
+			assert.Equal(t, spec.expBackendErrorCount, testInf.backendErrorCount, "Unexpected number of reported backend errors")
+			assert.Equal(t, spec.expStreams, testInf.streamCount, "Unexpected number of registered streams")
 
 			// Assert on metrics after the request is responded to.
 			if spec.expMetrics != nil {
 				mets := metricstest.Collect(t)
 				metricstest.RequireActiveRequestsMetric(t, mets, spec.expMetrics.expModel, 0)
+				resolvedModel := spec.expMetrics.expResolvedModel
+				if resolvedModel == "" {
+					resolvedModel = spec.expMetrics.expModel
+				}
+				metricstest.RequireRequestsMetric(t, mets, resolvedModel, spec.expCode, 1)
 			}
 		})
 	}
 }
 
 type testMockModel struct {
-	adapters map[string]bool
+	adapters            map[string]bool
+	saturated           bool
+	scaledToZero        bool
+	autoscalingDisabled bool
+	capacityUnavailable bool
+	protocol            string
+	failoverModels      []string
+	cordoned            bool
+	// noEndpoints causes requests to wait for an endpoint until
+	// the context is done.
+	noEndpoints    bool
+	maxQueueWait   time.Duration
+	requestTimeout time.Duration
+	responseCache  modelclient.ResponseCache
 }
 
 type testModelInterface struct {
@@ -275,30 +487,126 @@ type testModelInterface struct {
 
 	hostRequestCount int
 
+	backendErrorCount int
+
+	streamCount int
+
 	models map[string]testMockModel
 }
 
-func (t *testModelInterface) LookupModel(ctx context.Context, model, adapter string, selector []string) (*v1.Model, error) {
+func (t *testModelInterface) ResolveModel(ctx context.Context, model, adapter string, selector []string) (*v1.Model, bool, error) {
 	m, ok := t.models[model]
 	if ok {
+		obj := &v1.Model{
+			ObjectMeta: metav1.ObjectMeta{Name: model},
+			Spec:       v1.ModelSpec{AutoscalingDisabled: m.autoscalingDisabled, FailoverModels: m.failoverModels},
+		}
 		if adapter == "" {
-			return &v1.Model{ObjectMeta: metav1.ObjectMeta{Name: model}}, nil
+			return obj, false, nil
 		}
 		if m.adapters == nil {
-			return nil, nil
+			return nil, false, nil
 		}
 		if m.adapters[adapter] {
-			return &v1.Model{ObjectMeta: metav1.ObjectMeta{Name: model}}, nil
+			return obj, false, nil
 		}
 	}
-	return nil, nil
+	return nil, false, nil
+}
+
+func (t *testModelInterface) ResolveVersioned(ctx context.Context, model, version string) (string, bool, error) {
+	return "", false, nil
+}
+
+func (t *testModelInterface) ResolveCapable(ctx context.Context, capabilities, selectors []string) (string, bool, error) {
+	return "", false, nil
+}
+
+func (t *testModelInterface) IsModelCordoned(model *v1.Model) bool {
+	return t.models[model.Name].cordoned
+}
+
+func (t *testModelInterface) RegisterStream(ctx context.Context, model string) func() {
+	t.streamCount++
+	return func() {}
 }
 
 func (t *testModelInterface) ScaleAtLeastOneReplica(ctx context.Context, model string) error {
 	return nil
 }
 
+func (t *testModelInterface) IsSaturated(model *v1.Model) bool {
+	return t.models[model.Name].saturated
+}
+
+func (t *testModelInterface) ReportBackendError(model, endpoint string) {
+	t.backendErrorCount++
+}
+
+func (t *testModelInterface) ResolvedModelStatus(ctx context.Context, model *v1.Model) (modelclient.ModelStatus, error) {
+	m, ok := t.models[model.Name]
+	if !ok {
+		return modelclient.ModelStatusUnknown, nil
+	}
+	if m.scaledToZero {
+		return modelclient.ModelStatusScaledToZero, nil
+	}
+	if m.capacityUnavailable {
+		return modelclient.ModelStatusScalingUp, nil
+	}
+	return modelclient.ModelStatusReady, nil
+}
+
+func (t *testModelInterface) CapacityUnavailable(ctx context.Context, model string) (bool, error) {
+	return t.models[model].capacityUnavailable, nil
+}
+
+func (t *testModelInterface) ModelProtocol(model *v1.Model) (string, error) {
+	if p := t.models[model.Name].protocol; p != "" {
+		return p, nil
+	}
+	return v1.ModelProtocolHTTP, nil
+}
+
+func (t *testModelInterface) RecordLatency(model string, d time.Duration) {}
+
+func (t *testModelInterface) MaxQueueWait(model *v1.Model) (time.Duration, error) {
+	return t.models[model.Name].maxQueueWait, nil
+}
+
+func (t *testModelInterface) RequestTimeout(model *v1.Model) (time.Duration, error) {
+	return t.models[model.Name].requestTimeout, nil
+}
+
+func (t *testModelInterface) ResponseCache(model *v1.Model) (modelclient.ResponseCache, bool, error) {
+	cache := t.models[model.Name].responseCache
+	return cache, cache.TTL > 0, nil
+}
+
+func (t *testModelInterface) ModelCandidates(ctx context.Context, model, adapter string, selectors []string) ([]modelclient.ModelCandidate, error) {
+	var candidates []modelclient.ModelCandidate
+	for _, name := range append([]string{model}, t.models[model].failoverModels...) {
+		obj, _, _ := t.ResolveModel(ctx, name, adapter, selectors)
+		if obj == nil {
+			continue
+		}
+		status, _ := t.ResolvedModelStatus(ctx, obj)
+		candidates = append(candidates, modelclient.ModelCandidate{
+			Model:               obj,
+			Status:              status,
+			Saturated:           t.models[name].saturated,
+			CapacityUnavailable: t.models[name].capacityUnavailable,
+			Cordoned:            t.models[name].cordoned,
+		})
+	}
+	return candidates, nil
+}
+
 func (t *testModelInterface) AwaitBestAddress(ctx context.Context, req *apiutils.Request) (string, func(), error) {
+	if t.models[req.Model].noEndpoints {
+		<-ctx.Done()
+		return "", nil, ctx.Err()
+	}
 	t.hostRequestCount++
 	t.requestedModel = req.Model
 	t.requestedAdapter = req.Adapter
@@ -7,8 +7,10 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/substratusai/kubeai/internal/apiutils"
+	"github.com/substratusai/kubeai/internal/modelclient"
 )
 
 // proxyRequest keeps track of the state of a request that is to be proxied.
@@ -20,6 +22,18 @@ type proxyRequest struct {
 	http    *http.Request
 	status  int
 	attempt int
+	// protocol is the protocol that the backends of the model are served over.
+	protocol string
+	// maxQueueWait is the maximum time that the request waits for an
+	// available endpoint. Disabled when 0.
+	maxQueueWait time.Duration
+	// requestTimeout is the maximum time that the request is proxied to a
+	// backend (per attempt). Disabled when 0.
+	requestTimeout time.Duration
+	// cache is the response cache of the model and cacheKey the key of the
+	// request in it. Responses are not cached when the cacheKey is empty.
+	cache    modelclient.ResponseCache
+	cacheKey string
 }
 
 func (h *Handler) parseProxyRequest(r *http.Request) (*proxyRequest, error) {
@@ -28,7 +42,7 @@ func (h *Handler) parseProxyRequest(r *http.Request) (*proxyRequest, error) {
 		status: http.StatusOK,
 	}
 
-	apiReq, err := apiutils.ParseRequest(r.Context(), h.modelClient, r.Body, r.URL.Path, r.Header)
+	apiReq, err := apiutils.ParseRequest(r.Context(), h.modelClient, r.Body, r.URL.Path, r.Header, h.modelHeader)
 	if err != nil {
 		return pr, err
 	}
@@ -0,0 +1,190 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// PodValidator returns the problems with the routing annotations of a Pod
+// (implemented by loadbalancer.LoadBalancer).
+type PodValidator interface {
+	ValidatePodAnnotations(pod corev1.Pod) []string
+}
+
+// ModelValidator returns the problems with the annotations of a Model
+// (implemented by modelclient.ModelClient).
+type ModelValidator interface {
+	ValidateAnnotations(model *kubeaiv1.Model) error
+}
+
+// Setup registers the validating admission webhooks with the webhook server of
+// the manager. They reject Models with invalid annotations or replica bounds
+// (/validate-kubeai-org-v1-model) and Deployments (/validate-apps-v1-deployment)
+// and bare Pods (/validate--v1-pod) with invalid routing annotations.
+// Pods that are owned by a controller (i.e. a ReplicaSet) are validated through
+// their Deployment, so that their creation is never rejected.
+func Setup(mgr ctrl.Manager, pods PodValidator, models ModelValidator) error {
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&kubeaiv1.Model{}).
+		WithValidator(&modelValidator{models: models}).
+		Complete(); err != nil {
+		return fmt.Errorf("model webhook: %w", err)
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&appsv1.Deployment{}).
+		WithValidator(&deploymentValidator{pods: pods}).
+		Complete(); err != nil {
+		return fmt.Errorf("deployment webhook: %w", err)
+	}
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&corev1.Pod{}).
+		WithValidator(&podValidator{pods: pods}).
+		Complete(); err != nil {
+		return fmt.Errorf("pod webhook: %w", err)
+	}
+	return nil
+}
+
+// annotationsChanged returns false for updates that do not change the
+// annotations, so that objects that were created before the webhook was enabled
+// can still be updated (i.e. to remove finalizers or scale).
+func annotationsChanged(oldObj, newObj metav1.Object) bool {
+	return !maps.Equal(oldObj.GetAnnotations(), newObj.GetAnnotations())
+}
+
+type modelValidator struct {
+	models ModelValidator
+}
+
+func (v *modelValidator) validate(model *kubeaiv1.Model, annotations bool) error {
+	var errs []error
+	if max := model.Spec.MaxReplicas; max != nil && model.Spec.MinReplicas > *max {
+		errs = append(errs, fmt.Errorf("minReplicas (%d) should be less than or equal to maxReplicas (%d)", model.Spec.MinReplicas, *max))
+	}
+	if annotations {
+		if err := v.models.ValidateAnnotations(model); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (v *modelValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	model, ok := obj.(*kubeaiv1.Model)
+	if !ok {
+		return nil, fmt.Errorf("expected a Model, got %T", obj)
+	}
+	return nil, v.validate(model, true)
+}
+
+func (v *modelValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldModel, ok := oldObj.(*kubeaiv1.Model)
+	if !ok {
+		return nil, fmt.Errorf("expected a Model, got %T", oldObj)
+	}
+	model, ok := newObj.(*kubeaiv1.Model)
+	if !ok {
+		return nil, fmt.Errorf("expected a Model, got %T", newObj)
+	}
+	return nil, v.validate(model, annotationsChanged(oldModel, model))
+}
+
+func (v *modelValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validatePod returns an error joining the problems with the routing
+// annotations of the Pod.
+func validatePod(pods PodValidator, pod corev1.Pod) error {
+	var errs []error
+	for _, problem := range pods.ValidatePodAnnotations(pod) {
+		errs = append(errs, errors.New(problem))
+	}
+	return errors.Join(errs...)
+}
+
+type deploymentValidator struct {
+	pods PodValidator
+}
+
+// validate validates the Pod template of the Deployment, which is where the
+// routing annotations of its Pods come from.
+func (v *deploymentValidator) validate(deploy *appsv1.Deployment) error {
+	tmpl := deploy.Spec.Template
+	return validatePod(v.pods, corev1.Pod{ObjectMeta: tmpl.ObjectMeta, Spec: tmpl.Spec})
+}
+
+func (v *deploymentValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	deploy, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return nil, fmt.Errorf("expected a Deployment, got %T", obj)
+	}
+	return nil, v.validate(deploy)
+}
+
+func (v *deploymentValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldDeploy, ok := oldObj.(*appsv1.Deployment)
+	if !ok {
+		return nil, fmt.Errorf("expected a Deployment, got %T", oldObj)
+	}
+	deploy, ok := newObj.(*appsv1.Deployment)
+	if !ok {
+		return nil, fmt.Errorf("expected a Deployment, got %T", newObj)
+	}
+	if !annotationsChanged(&oldDeploy.Spec.Template, &deploy.Spec.Template) {
+		return nil, nil
+	}
+	return nil, v.validate(deploy)
+}
+
+func (v *deploymentValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+type podValidator struct {
+	pods PodValidator
+}
+
+func (v *podValidator) validate(pod *corev1.Pod) error {
+	if metav1.GetControllerOf(pod) != nil {
+		return nil
+	}
+	return validatePod(v.pods, *pod)
+}
+
+func (v *podValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected a Pod, got %T", obj)
+	}
+	return nil, v.validate(pod)
+}
+
+func (v *podValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldPod, ok := oldObj.(*corev1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected a Pod, got %T", oldObj)
+	}
+	pod, ok := newObj.(*corev1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected a Pod, got %T", newObj)
+	}
+	if !annotationsChanged(oldPod, pod) {
+		return nil, nil
+	}
+	return nil, v.validate(pod)
+}
+
+func (v *podValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
@@ -0,0 +1,121 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kubeaiv1 "github.com/substratusai/kubeai/api/v1"
+	"github.com/substratusai/kubeai/internal/loadbalancer"
+	"github.com/substratusai/kubeai/internal/modelclient"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const testNamespace = "default"
+
+func TestModelValidator(t *testing.T) {
+	v := &modelValidator{models: modelclient.NewModelClient(nil, testNamespace, modelclient.Options{})}
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		minReplicas int32
+		maxReplicas *int32
+		annotations map[string]string
+		expErr      string
+	}{
+		"valid": {
+			minReplicas: 1,
+			maxReplicas: ptr.To[int32](3),
+			annotations: map[string]string{"kubeai.org/priority": "10"},
+		},
+		"min above max": {
+			minReplicas: 4,
+			maxReplicas: ptr.To[int32](3),
+			expErr:      "minReplicas (4) should be less than or equal to maxReplicas (3)",
+		},
+		"invalid annotation": {
+			annotations: map[string]string{"kubeai.org/priority": "high"},
+			expErr:      "kubeai.org/priority",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := &kubeaiv1.Model{
+				ObjectMeta: metav1.ObjectMeta{Name: "m", Annotations: c.annotations},
+				Spec:       kubeaiv1.ModelSpec{MinReplicas: c.minReplicas, MaxReplicas: c.maxReplicas},
+			}
+			_, err := v.ValidateCreate(ctx, m)
+			if c.expErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, c.expErr)
+		})
+	}
+
+	// Updates that do not change the annotations are not rejected because of
+	// annotations that were already invalid.
+	old := &kubeaiv1.Model{ObjectMeta: metav1.ObjectMeta{Name: "m", Annotations: map[string]string{"kubeai.org/priority": "high"}}}
+	updated := old.DeepCopy()
+	updated.Labels = map[string]string{"team": "ml"}
+	_, err := v.ValidateUpdate(ctx, old, updated)
+	require.NoError(t, err)
+	updated.Annotations["kubeai.org/replica-cost"] = "2"
+	_, err = v.ValidateUpdate(ctx, old, updated)
+	require.ErrorContains(t, err, "kubeai.org/priority")
+}
+
+func TestDeploymentValidator(t *testing.T) {
+	v := &deploymentValidator{pods: &loadbalancer.LoadBalancer{AnnotationDomains: kubeaiv1.AnnotationDomains(nil)}}
+	ctx := context.Background()
+
+	deploy := func(annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "d"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}},
+			},
+		}
+	}
+
+	_, err := v.ValidateCreate(ctx, deploy(nil))
+	require.NoError(t, err)
+	_, err = v.ValidateCreate(ctx, deploy(map[string]string{"lingo.substratus.ai/models": "a, b"}))
+	require.NoError(t, err)
+	_, err = v.ValidateCreate(ctx, deploy(map[string]string{"lingo.substratus.ai/models": " , "}))
+	require.ErrorContains(t, err, "lingo.substratus.ai/models annotation does not list any models")
+	_, err = v.ValidateCreate(ctx, deploy(map[string]string{"kubeai.org/models": "a,b,a"}))
+	require.ErrorContains(t, err, `kubeai.org/models annotation lists model "a" more than once`)
+
+	_, err = v.ValidateUpdate(ctx,
+		deploy(map[string]string{"kubeai.org/models": "a,a"}),
+		deploy(map[string]string{"kubeai.org/models": "a,a"}),
+	)
+	require.NoError(t, err, "unchanged annotations")
+}
+
+func TestPodValidator(t *testing.T) {
+	v := &podValidator{pods: &loadbalancer.LoadBalancer{AnnotationDomains: kubeaiv1.AnnotationDomains(nil)}}
+	ctx := context.Background()
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "p",
+		Annotations: map[string]string{"kubeai.org/model-ports": "a=0"},
+	}}
+	_, err := v.ValidateCreate(ctx, pod)
+	require.ErrorContains(t, err, "kubeai.org/model-ports")
+
+	// Pods of a controller are validated through their Deployment.
+	pod.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "apps/v1",
+		Kind:       "ReplicaSet",
+		Name:       "rs",
+		UID:        "uid",
+		Controller: ptr.To(true),
+	}}
+	_, err = v.ValidateCreate(ctx, pod)
+	require.NoError(t, err)
+}
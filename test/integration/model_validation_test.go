@@ -211,6 +211,48 @@ func TestModelValidation(t *testing.T) {
 			},
 			expErrContain: "minReplicas should be less than or equal to maxReplicas",
 		},
+		{
+			model: v1.Model{
+				ObjectMeta: metadata("idle-replicas-0-2-3-valid"),
+				Spec: v1.ModelSpec{
+					URL:             "hf://test-repo/test-model",
+					Engine:          "VLLM",
+					Features:        []v1.ModelFeature{},
+					MinReplicas:     0,
+					IdleMinReplicas: ptr.To[int32](2),
+					MaxReplicas:     ptr.To[int32](3),
+				},
+			},
+			expValid: true,
+		},
+		{
+			model: v1.Model{
+				ObjectMeta: metadata("idle-replicas-1-2-3-invalid"),
+				Spec: v1.ModelSpec{
+					URL:             "hf://test-repo/test-model",
+					Engine:          "VLLM",
+					Features:        []v1.ModelFeature{},
+					MinReplicas:     1,
+					IdleMinReplicas: ptr.To[int32](2),
+					MaxReplicas:     ptr.To[int32](3),
+				},
+			},
+			expErrContain: "idleMinReplicas should be less than or equal to minReplicas, unless minReplicas is 0",
+		},
+		{
+			model: v1.Model{
+				ObjectMeta: metadata("idle-replicas-0-4-3-invalid"),
+				Spec: v1.ModelSpec{
+					URL:             "hf://test-repo/test-model",
+					Engine:          "VLLM",
+					Features:        []v1.ModelFeature{},
+					MinReplicas:     0,
+					IdleMinReplicas: ptr.To[int32](4),
+					MaxReplicas:     ptr.To[int32](3),
+				},
+			},
+			expErrContain: "idleMinReplicas should be less than or equal to maxReplicas",
+		},
 		{
 			model: v1.Model{
 				ObjectMeta: metadata("cache-profile-with-hf-url-valid"),